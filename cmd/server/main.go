@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -35,6 +40,13 @@ import (
 	v "github.com/keithlinneman/linnemanlabs-web/internal/version"
 )
 
+// contentSource is the startup-load and watch surface shared by the S3 loader
+// and the local disk fetcher.
+type contentSource interface {
+	content.BundleFetcher
+	LoadIntoManager(ctx context.Context, mgr *content.Manager) error
+}
+
 func main() { //nolint:gocognit // main wires everything together, splitting it would obscure startup sequence
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -78,12 +90,15 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 		fmt.Fprintf(os.Stderr, "invalid log level %s: %v\n", conf.LogLevel, err)
 		os.Exit(1)
 	}
+	// level is held in a LevelVar so the ops admin API can change it at runtime
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(lvl)
 	lg, err := log.New(&log.Options{
 		App:               v.AppName,
 		Version:           v.Version,
 		Commit:            v.Commit,
 		BuildId:           v.BuildId,
-		Level:             lvl,
+		LevelVar:          logLevel,
 		JsonFormat:        conf.LogJSON,
 		MaxErrorLinks:     conf.MaxErrorLinks,
 		IncludeErrorLinks: conf.IncludeErrorLinks,
@@ -108,6 +123,7 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 		"http_port", conf.HTTPPort,
 		"admin_port", conf.AdminPort,
 		"enable_pprof", conf.EnablePprof,
+		"enable_admin_api", conf.EnableAdminAPI,
 		"enable_pyroscope", conf.EnablePyroscope,
 		"enable_tracing", conf.EnableTracing,
 		"enable_content_updates", conf.EnableContentUpdates,
@@ -120,6 +136,9 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 		"content_ssm_param", conf.ContentSSMParam,
 		"content_s3_bucket", conf.ContentS3Bucket,
 		"content_s3_prefix", conf.ContentS3Prefix,
+		"content_path", conf.ContentPath,
+		"content_tuf_url", conf.ContentTUFURL,
+		"content_oci_registry", conf.ContentOCIRegistry,
 		"content_signing_key_arn", conf.ContentSigningKeyARN,
 		"evidence_signing_key_arn", conf.EvidenceSigningKeyARN,
 		"trusted_proxy_hops", conf.TrustedProxyHops,
@@ -230,6 +249,17 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 		contentKeylessVerifier = kv
 	}
 
+	// ops listener TLS; with a client CA this also enables mTLS for the admin API.
+	// Loaded before evidence so the startup status listener uses it too.
+	var opsTLS *tls.Config
+	if conf.AdminTLSCert != "" {
+		opsTLS, err = opshttp.TLSConfig(conf.AdminTLSCert, conf.AdminTLSKey, conf.AdminClientCA)
+		if err != nil {
+			L.Error(ctx, err, "failed to load ops listener TLS config")
+			os.Exit(1)
+		}
+	}
+
 	// Startup content loading strategy:
 	//
 	// Content loads synchronously before HTTP servers bind their ports.
@@ -254,7 +284,19 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 	seedFS, haveSeed := webassets.SeedSiteFS()
 
 	// setup content manager that will manage what content we serve
-	contentMgr := content.NewManager()
+	// snapshots are immutable, so compressible files are encoded once on activation
+	var precompress *content.PrecompressOptions
+	if conf.ContentPrecompress {
+		precompress = &content.PrecompressOptions{
+			MinSize:  conf.ContentPrecompressMin,
+			MaxBytes: int64(conf.ContentPrecompressMB) << 20,
+		}
+	}
+	contentMgr := content.NewManagerWithOptions(&content.ManagerOptions{
+		HistorySize:     conf.ContentHistory,
+		HistoryMaxBytes: int64(conf.ContentHistoryMaxMB) << 20,
+		Precompress:     precompress,
+	})
 
 	// load initial seed content if available
 	if haveSeed {
@@ -271,10 +313,14 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 	}
 
 	// setup evidence loading (fetch build attestations from S3 at startup)
-	var evidenceStore *evidence.Store
+	var (
+		evidenceStore  *evidence.Store
+		evidenceLoader *evidence.Loader
+		selfExe        *evidence.Executable
+	)
 	if hasProvenance {
 		evidenceStore = evidence.NewStore()
-		evidenceLoader, err := evidence.NewLoader(ctx, &evidence.LoaderOptions{
+		evidenceLoader, err = evidence.NewLoader(ctx, &evidence.LoaderOptions{
 			Logger:           L,
 			Bucket:           vi.EvidenceBucket,
 			Prefix:           vi.EvidencePrefix,
@@ -289,12 +335,53 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 			L.Error(ctx, err, "failed to create evidence loader")
 			os.Exit(1)
 		} else {
-			bundle, err := evidenceLoader.Load(ctx)
+			// S3 errors (throttling during a scale-out, timeouts) are retried with
+			// jittered backoff until the deadline; verification failures fail
+			// closed at once. While retrying, a status-only ops listener reports
+			// progress on /readyz and systemd gets a STATUS= line.
+			var (
+				startupGate    health.ShutdownGate
+				startupOpsStop func(context.Context) error
+				startupOpsTry  bool
+			)
+			bundle, err := evidenceLoader.LoadRetry(ctx, evidence.RetryOptions{
+				Deadline: time.Duration(conf.EvidenceLoadSeconds) * time.Second,
+				OnRetry: func(attempt int, err error, wait time.Duration) {
+					status := fmt.Sprintf("loading build evidence: attempt %d failed, retrying in %s", attempt, wait.Truncate(time.Millisecond))
+					L.Warn(ctx, "evidence load failed, retrying",
+						"attempt", attempt,
+						"retry_in", wait.String(),
+						"error", err,
+					)
+					startupGate.Set(status)
+					_ = notifySystemd("STATUS=" + status)
+					if !startupOpsTry {
+						startupOpsTry = true
+						stop, serr := opshttp.Start(ctx, L, &opshttp.Options{
+							Port:         conf.AdminPort,
+							Metrics:      m.Handler(),
+							Health:       health.Fixed(true, ""),
+							Readiness:    startupGate.Probe(),
+							UseRecoverMW: true,
+							TLSConfig:    opsTLS,
+						})
+						if serr != nil {
+							L.Warn(ctx, "failed to start startup status listener", "error", serr)
+						} else {
+							startupOpsStop = stop
+						}
+					}
+				},
+			})
+			// the full ops listener binds the same port later
+			if startupOpsStop != nil {
+				_ = startupOpsStop(context.Background())
+			}
 			if err != nil {
 				// evidence is required for builds with provenance data, fail early
 				// systemd will restart, asg will terminate if we fail to start successfully
-				// will add retry logic in the future
 				L.Error(ctx, err, "failed to load evidence which is required when provenance data is present")
+				_ = notifySystemd("STATUS=failed to load build evidence")
 				os.Exit(1)
 			} else {
 				bundle = evidence.FilterBundleByPlatform(bundle, evidence.RuntimePlatform())
@@ -305,6 +392,10 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 					"categories", bundle.Summary(),
 					"inventory_hash", bundle.InventoryHash[:12],
 				)
+				// hash the running binary once; it is compared against whatever
+				// evidence is current, which the evidence watcher may refresh
+				selfExe = evidence.HashExecutable()
+				reportSelfAttestation(ctx, L, m, selfExe.Attest(bundle, evidence.RuntimePlatform()))
 			}
 		}
 	} else {
@@ -312,26 +403,114 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 	}
 	// setup provenance API
 	provenanceAPI := provenancehttp.NewAPI(contentMgr, evidenceStore, L)
-
-	// setup content bundle loader
-	contentLoader, err := content.NewLoader(ctx, &content.LoaderOptions{
-		Logger:          L,
-		SSMParam:        conf.ContentSSMParam,
-		S3Bucket:        conf.ContentS3Bucket,
-		S3Prefix:        conf.ContentS3Prefix,
-		S3Client:        s3Client,
-		SSMClient:       ssmClient,
-		Verifier:        contentBlobVerifier,
-		KeylessVerifier: contentKeylessVerifier,
-		Inliner:         provenanceAPI.Inliner(),
-	})
+	provenanceAPI.SetExecutable(selfExe)
+
+	// setup content bundle loader: a local directory/tarball when content-path
+	// is set (laptops, air-gapped CI), a TUF repository when content-tuf-url is
+	// set, an OCI registry when content-oci-registry is set, otherwise S3 + SSM
+	var contentLoader contentSource
+	var s3Loader *content.Loader
+	reloadOnWatcherStart := false
+	switch {
+	case conf.ContentTUFURL != "":
+		var trustedRoot []byte
+		trustedRoot, err = os.ReadFile(conf.ContentTUFRoot)
+		if err != nil {
+			break
+		}
+		contentLoader, err = content.NewTUFFetcher(&content.TUFFetcherOptions{
+			Logger:          L,
+			MetadataURL:     conf.ContentTUFURL,
+			TargetsURL:      conf.ContentTUFTargetsURL,
+			MetadataDir:     conf.ContentTUFMetadataDir,
+			TrustedRoot:     trustedRoot,
+			TargetName:      conf.ContentTUFTarget,
+			Verifier:        contentBlobVerifier,
+			KeylessVerifier: contentKeylessVerifier,
+			Inliner:         provenanceAPI.Inliner(),
+		})
+	case conf.ContentOCIRegistry != "":
+		var creds func(context.Context) (string, string, error)
+		if strings.Contains(conf.ContentOCIRegistry, ".dkr.ecr.") {
+			creds = content.ECRCredentials(awsCfg, nil)
+		}
+		contentLoader, err = content.NewOCIFetcher(&content.OCIFetcherOptions{
+			Logger:          L,
+			Registry:        conf.ContentOCIRegistry,
+			Repository:      conf.ContentOCIRepository,
+			Reference:       conf.ContentOCIReference,
+			PlainHTTP:       conf.ContentOCIPlainHTTP,
+			Credentials:     creds,
+			Verifier:        contentBlobVerifier,
+			KeylessVerifier: contentKeylessVerifier,
+			Inliner:         provenanceAPI.Inliner(),
+		})
+	case conf.ContentPath != "":
+		contentLoader, err = content.NewDiskFetcher(&content.DiskFetcherOptions{
+			Logger:            L,
+			Path:              conf.ContentPath,
+			PointerFile:       conf.ContentPointerFile,
+			Verifier:          contentBlobVerifier,
+			KeylessVerifier:   contentKeylessVerifier,
+			RequireSignatures: contentBlobVerifier != nil,
+			Inliner:           provenanceAPI.Inliner(),
+		})
+	default:
+		// delta loads reuse files from the active snapshot
+		var deltaBase content.DeltaBase
+		if conf.ContentDelta {
+			deltaBase = contentMgr
+		}
+		// the last verified bundles kept on disk let a restart serve content
+		// before S3 answers; an unusable directory only disables the cache
+		var bundleCache *content.BundleCache
+		if conf.ContentCacheDir != "" {
+			if bundleCache, err = content.NewBundleCache(conf.ContentCacheDir); err != nil {
+				L.Warn(ctx, "content cache unavailable, continuing without it", "error", err)
+			}
+		}
+		s3Loader, err = content.NewLoader(ctx, &content.LoaderOptions{
+			Logger:               L,
+			SSMParam:             conf.ContentSSMParam,
+			S3Bucket:             conf.ContentS3Bucket,
+			S3Prefix:             conf.ContentS3Prefix,
+			S3Client:             s3Client,
+			SSMClient:            ssmClient,
+			Verifier:             contentBlobVerifier,
+			KeylessVerifier:      contentKeylessVerifier,
+			RequireSignedPointer: conf.ContentSignedPointer,
+			Inliner:              provenanceAPI.Inliner(),
+			DeltaBase:            deltaBase,
+			Cache:                bundleCache,
+		})
+		contentLoader = s3Loader
+	}
 	if err != nil {
 		L.Error(ctx, err, "failed to create content loader, content updates will be disabled")
+		contentLoader = nil
 	} else {
-		if err := contentLoader.LoadIntoManager(ctx, contentMgr); err != nil {
-			L.Error(ctx, err, "failed to load content bundle, falling back to seed")
+		servedFromCache := false
+		if s3Loader != nil && conf.ContentCacheDir != "" {
+			if snap, err := s3Loader.LoadCached(ctx); err == nil {
+				contentMgr.Set(*snap)
+				servedFromCache = true
+			} else if !errors.Is(err, content.ErrCacheEmpty) {
+				L.Warn(ctx, "cached content bundle rejected, ignoring it", "error", err)
+			}
+		}
+		// with the watcher running, serve the cached bundle now and let its
+		// first poll (requested once it starts) pick up anything newer
+		if servedFromCache && conf.EnableContentUpdates {
+			reloadOnWatcherStart = true
+			L.Info(ctx, "serving cached content bundle until the watcher checks S3",
+				"content_version", contentMgr.ContentVersion(),
+				"content_hash", contentMgr.ContentHash(),
+			)
+		} else if err := contentLoader.LoadIntoManager(ctx, contentMgr); err != nil {
+			L.Error(ctx, err, "failed to load content bundle, keeping current content", "source", contentMgr.Source())
 		} else {
-			L.Info(ctx, "loaded content bundle from S3",
+			L.Info(ctx, "loaded content bundle",
+				"source", contentMgr.Source(),
 				"content_version", contentMgr.ContentVersion(),
 				"content_hash", contentMgr.ContentHash(),
 			)
@@ -342,30 +521,55 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 	if t := contentMgr.LoadedAt(); !t.IsZero() {
 		m.SetContentLoadedTimestamp(t)
 	}
+	m.ObserveContentBlobs(func() (int, int64, int64) {
+		s := contentMgr.BlobStats()
+		return s.Blobs, s.LogicalBytes, s.PhysicalBytes
+	})
 
-	if contentLoader != nil && conf.EnableContentUpdates {
-		// setup content watcher to poll for new bundles, validate and swap into manager
-		watcher := content.NewWatcher(&content.WatcherOptions{
-			Logger:       L,
-			Loader:       contentLoader,
-			Manager:      contentMgr,
-			PollInterval: 30 * time.Second,
-			Metrics:      m,
-			OnSwap: func(hash, version string) {
-				m.SetContentBundle(hash)
-				m.SetContentSource(string(content.SourceS3))
-				m.SetContentLoadedTimestamp(time.Now())
-			},
+	// bundles the watcher fails to load or validate are retried with backoff;
+	// shared with the ops server so an operator can list and clear them
+	contentQuarantine := content.NewQuarantine(content.QuarantineOptions{})
+
+	validation := content.DefaultValidationOptions()
+	validation.VerifyManifest = conf.ContentVerifyManifest
+
+	// operator-controlled maintenance mode, toggled from the ops admin API
+	maintenance := &sitehandler.Maintenance{}
+
+	// candidate bundles loaded from the admin API are served, marked as a
+	// preview, only on the ops listener until promoted
+	var (
+		contentPreview *content.Preview
+		previewHandler http.Handler
+	)
+	if conf.ContentPreview && contentLoader != nil {
+		contentPreview = content.NewPreview(&content.PreviewOptions{
+			Logger:      L,
+			Loader:      contentLoader,
+			Validation:  &validation,
+			Precompress: precompress,
+			Interner:    contentMgr,
 		})
-		// Run the watcher in a separate goroutine
-		go watcher.Run(ctx)
+		previewHandler, err = sitehandler.New(&sitehandler.Options{
+			Logger:     L,
+			Content:    contentPreview,
+			FallbackFS: fallbackFS,
+			Preview:    true,
+		})
+		if err != nil {
+			L.Error(ctx, err, "failed to create content preview handler")
+			os.Exit(1)
+		}
+	} else if conf.ContentPreview {
+		L.Warn(ctx, "content preview enabled without a content loader, preview disabled")
 	}
 
 	// setup site handler that serves site content
 	siteHandler, err := sitehandler.New(&sitehandler.Options{
-		Logger:     L,
-		Content:    contentMgr,
-		FallbackFS: fallbackFS,
+		Logger:      L,
+		Content:     contentMgr,
+		FallbackFS:  fallbackFS,
+		Maintenance: maintenance,
 	})
 	if err != nil {
 		L.Error(ctx, err, "failed to create site handler")
@@ -375,6 +579,19 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 	// setup toggle for server shutdown
 	var shutdownGate health.ShutdownGate
 
+	// optionally refuse readiness while the running binary doesn't match the
+	// release's artifact; unknown (no digest to compare) does not block
+	var selfAttestProbe health.Probe
+	if conf.RequireSelfAttestation && selfExe != nil {
+		selfAttestProbe = health.CheckFunc(func(ctx context.Context) error {
+			bundle, _ := evidenceStore.Get()
+			if sa := selfExe.Attest(bundle, evidence.RuntimePlatform()); sa.Status == evidence.SelfAttestMismatch {
+				return fmt.Errorf("running executable sha256 %s does not match release artifact %s", sa.SHA256, sa.Expected)
+			}
+			return nil
+		})
+	}
+
 	// setup readiness checks, both shutdown gate and content readiness must pass.
 	// checks that we have successfully loaded content to serve
 	readiness := health.All(
@@ -382,6 +599,7 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 		health.CheckFunc(func(ctx context.Context) error {
 			return contentMgr.ReadyErr()
 		}),
+		selfAttestProbe,
 	)
 
 	// Setup rate limiter middleware for site handler
@@ -400,24 +618,115 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 		}),
 	)
 
-	// start site http server
-	siteHTTPStop, err := httpserver.Start(
-		ctx,
-		&httpserver.Options{
-			Port:         conf.HTTPPort,
-			Health:       health.Fixed(true, ""),
-			Readiness:    readiness,
-			APIRoutes:    provenanceAPI.RegisterRoutes,
-			SiteHandler:  siteHandler,
-			UseRecoverMW: true,
-			OnPanic:      m.IncHttpPanic,
-			MetricsMW:    m.Middleware,
-			RateLimitMW:  limiter.Middleware,
+	siteHTTPOpts := &httpserver.Options{
+		Port:         conf.HTTPPort,
+		Health:       health.Fixed(true, ""),
+		Readiness:    readiness,
+		APIRoutes:    provenanceAPI.RegisterRoutes,
+		SiteHandler:  siteHandler,
+		UseRecoverMW: true,
+		OnPanic:      m.IncHttpPanic,
+		MetricsMW:    m.Middleware,
+		RateLimitMW:  limiter.Middleware,
+		Logger:       L,
+		ContentInfo:  contentMgr, // Pass content manager for headers
+		ClientIPOpts: httpmw.ClientIPOptions{TrustedHops: conf.TrustedProxyHops},
+	}
+
+	// post-swap self-check requests critical paths through the same handler
+	// chain the listener serves, minus rate limiting and request metrics so
+	// probes neither get throttled nor skew the SLIs
+	var selfCheck *content.SelfCheck
+	if conf.ContentSelfCheck != "" {
+		paths, err := content.ParseSelfCheckPaths(conf.ContentSelfCheck)
+		if err != nil {
+			L.Error(ctx, err, "invalid content self-check paths")
+			os.Exit(1)
+		}
+		probeOpts := *siteHTTPOpts
+		probeOpts.RateLimitMW = nil
+		probeOpts.MetricsMW = nil
+		selfCheck = content.NewSelfCheck(&content.SelfCheckOptions{
+			Handler: httpserver.NewHandler(&probeOpts),
+			Paths:   paths,
+		})
+	}
+
+	var watcher *content.Watcher
+	if contentLoader != nil && conf.EnableContentUpdates {
+		// setup content watcher to poll for new bundles, validate and swap into manager
+		// already checked by cfg.Validate
+		versionPolicy, _ := content.ParseVersionPolicy(conf.ContentVersionPolicy)
+		watcher = content.NewWatcher(&content.WatcherOptions{
+			Logger:        L,
+			Loader:        contentLoader,
+			Manager:       contentMgr,
+			PollInterval:  time.Duration(conf.ContentPollSeconds) * time.Second,
+			Validation:    &validation,
+			Quarantine:    contentQuarantine,
+			VersionPolicy: versionPolicy,
+			SelfCheck:     selfCheck,
+			Metrics:       m,
+			OnSwap: func(hash, version string) {
+				m.SetContentBundle(hash)
+				m.SetContentSource(string(contentMgr.Source()))
+				m.SetContentLoadedTimestamp(time.Now())
+				if s3Loader != nil {
+					var pointer *content.Pointer
+					if snap, ok := contentMgr.Get(); ok && snap.Meta.Hash == hash {
+						pointer = snap.Meta.Pointer
+					}
+					if err := s3Loader.MarkCached(hash, pointer); err != nil {
+						L.Warn(ctx, "content cache not updated for the swapped bundle", "hash", hash, "error", err)
+					}
+				}
+			},
+			// keep the cached pointer fresh so a cache boot doesn't find it expired
+			OnPointerRefresh: func(hash string, p *content.Pointer) {
+				if s3Loader != nil {
+					if err := s3Loader.MarkCached(hash, p); err != nil {
+						L.Warn(ctx, "content cache pointer not refreshed", "hash", hash, "error", err)
+					}
+				}
+			},
+		})
+		// Run the watcher in a separate goroutine
+		go watcher.Run(ctx)
+		// SIGHUP asks for a poll, e.g. from a deploy hook after publishing
+		go content.WatchSignals(ctx, watcher, syscall.SIGHUP)
+		if reloadOnWatcherStart {
+			watcher.RequestReload()
+		}
+	}
+
+	// re-scans republish evidence for the running release; pick them up and
+	// refresh the app provenance islands in the served pages
+	if evidenceLoader != nil && conf.EvidenceRefreshSeconds > 0 {
+		evidenceWatcher, err := evidence.NewWatcher(&evidence.WatcherOptions{
 			Logger:       L,
-			ContentInfo:  contentMgr, // Pass content manager for headers
-			ClientIPOpts: httpmw.ClientIPOptions{TrustedHops: conf.TrustedProxyHops},
-		},
-	)
+			Source:       evidenceLoader,
+			Store:        evidenceStore,
+			PollInterval: time.Duration(conf.EvidenceRefreshSeconds) * time.Second,
+			Filter: func(b *evidence.Bundle) *evidence.Bundle {
+				return evidence.FilterBundleByPlatform(b, evidence.RuntimePlatform())
+			},
+			OnSwap: func(b *evidence.Bundle) {
+				reportSelfAttestation(ctx, L, m, selfExe.Attest(b, evidence.RuntimePlatform()))
+				if contentMgr.RerenderIslands(ctx, L, provenanceAPI.Inliner()) {
+					L.Info(ctx, "re-rendered provenance islands with refreshed evidence")
+				}
+			},
+			Metrics: m,
+		})
+		if err != nil {
+			L.Warn(ctx, "evidence refresh disabled", "error", err)
+		} else {
+			go evidenceWatcher.Run(ctx)
+		}
+	}
+
+	// start site http server
+	siteHTTPStop, err := httpserver.Start(ctx, siteHTTPOpts)
 
 	if err != nil {
 		L.Error(ctx, err, "failed to start site http listener port")
@@ -425,10 +734,42 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 	}
 	defer func() { _ = siteHTTPStop(context.Background()) }()
 
-	// start admin/ops listener to serve metrics, health checks, pprof and any future admin APIs
+	// authenticated admin API for runtime actions (reload, rollback, pause,
+	// maintenance, log level, rate-limit bans); every request is audit logged
+	var adminOpts *opshttp.AdminOptions
+	if conf.EnableAdminAPI {
+		adminOpts = &opshttp.AdminOptions{
+			TokenSHA256:     conf.AdminTokenSHA256,
+			ClientCertAuth:  conf.AdminClientCA != "",
+			ClientCertNames: cfg.SplitList(conf.AdminClientNames),
+			History:         contentMgr,
+			Quarantine:      contentQuarantine,
+			Maintenance:     maintenance,
+			LogLevel:        logLevel,
+			RateLimit:       limiter,
+			OnAction:        m.IncAdminAction,
+		}
+		// leave the interface nil (routes unregistered) when updates are disabled
+		if watcher != nil {
+			adminOpts.Watcher = watcher
+		}
+		if contentPreview != nil {
+			adminOpts.Preview = contentPreview
+		}
+		if opsTLS == nil {
+			L.Warn(ctx, "admin API enabled without ops TLS, bearer tokens are sent in cleartext on the private network")
+		}
+	}
+
+	// start admin/ops listener to serve metrics, health checks, pprof and the admin API
 	// sg restricts inbound to internal monitoring infrastructure
 	// we reject connections from public ips and requests with x-forwarded set in middleware
 	// to prevent accidental exposure if sg is misconfigured or load balancer ever sends traffic there
+	var contentStaged opshttp.ContentStaged
+	if watcher != nil {
+		contentStaged = watcher
+	}
+
 	opsHTTPStop, err := opshttp.Start(ctx, L, &opshttp.Options{
 		Port:         conf.AdminPort,
 		Metrics:      m.Handler(),
@@ -437,6 +778,13 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 		Readiness:    readiness,
 		UseRecoverMW: true,
 		OnPanic:      m.IncHttpPanic,
+		TLSConfig:    opsTLS,
+
+		ContentQuarantine: contentQuarantine,
+		ContentStaged:     contentStaged,
+		Preview:           previewHandler,
+		PreviewHost:       conf.ContentPreviewHost,
+		Admin:             adminOpts,
 	})
 	if err != nil {
 		L.Error(ctx, err, "failed to start ops http listener")
@@ -445,7 +793,7 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 	defer func() { _ = opsHTTPStop(context.Background()) }()
 
 	// notify systemd that we started successfully if started under systemd
-	if err := notifySystemd(); err != nil {
+	if err := notifySystemd("READY=1\nSTATUS=serving"); err != nil {
 		// log and dont exit, worst case systemd will kill the process after timeout
 		L.Warn(ctx, "failed to notify systemd of readiness", "error", err)
 	}
@@ -504,7 +852,26 @@ func main() { //nolint:gocognit // main wires everything together, splitting it
 	os.Exit(0)
 }
 
-func notifySystemd() error {
+// reportSelfAttestation exports a self-attestation result and logs anything
+// other than a match.
+func reportSelfAttestation(ctx context.Context, L log.Logger, m *metrics.ServerMetrics, sa *evidence.SelfAttestation) {
+	m.SetSelfAttestation(sa.Status)
+	if sa.Status == evidence.SelfAttestMatch {
+		L.Info(ctx, "running executable matches release artifact", "artifact", sa.Artifact, "sha256", sa.SHA256)
+		return
+	}
+	L.Warn(ctx, "running executable not attested against release artifact",
+		"status", sa.Status,
+		"reason", sa.Reason,
+		"executable", sa.Executable,
+		"sha256", sa.SHA256,
+		"expected_sha256", sa.Expected,
+	)
+}
+
+// notifySystemd sends state (READY=1, STATUS=..., newline separated) to
+// systemd's notify socket.
+func notifySystemd(state string) error {
 	// systemd will set NOTIFY_SOCKET to a unix socket path if we were started under systemd with type=notify
 	addr := os.Getenv("NOTIFY_SOCKET")
 	if addr == "" {
@@ -515,7 +882,7 @@ func notifySystemd() error {
 		return fmt.Errorf("systemd notify failed: dial failed: %w", err)
 	}
 	defer conn.Close()
-	if _, err := conn.Write([]byte("READY=1")); err != nil {
+	if _, err := conn.Write([]byte(state)); err != nil {
 		return fmt.Errorf("systemd notify failed: write failed: %w", err)
 	}
 	return nil
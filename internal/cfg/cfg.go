@@ -18,6 +18,12 @@ type App struct {
 	HTTPPort              int
 	AdminPort             int
 	EnablePprof           bool
+	EnableAdminAPI        bool
+	AdminTokenSHA256      string
+	AdminTLSCert          string
+	AdminTLSKey           string
+	AdminClientCA         string
+	AdminClientNames      string
 	EnablePyroscope       bool
 	EnableTracing         bool
 	EnableContentUpdates  bool
@@ -31,11 +37,44 @@ type App struct {
 	ContentSSMParam       string
 	ContentS3Bucket       string
 	ContentS3Prefix       string
+	ContentPath           string
+	ContentPointerFile    string
+	ContentTUFURL         string
+	ContentTUFTargetsURL  string
+	ContentTUFRoot        string
+	ContentTUFMetadataDir string
+	ContentTUFTarget      string
+	ContentOCIRegistry    string
+	ContentOCIRepository  string
+	ContentOCIReference   string
+	ContentOCIPlainHTTP   bool
+	ContentVerifyManifest bool
+	ContentSignedPointer  bool
+	ContentDelta          bool
+	ContentCacheDir       string
+	ContentVersionPolicy  string
+	ContentPreview        bool
+	ContentPreviewHost    string
+	ContentSelfCheck      string
+	ContentHistory        int
+	ContentPollSeconds    int
+	ContentHistoryMaxMB   int
+	ContentPrecompress    bool
+	ContentPrecompressMin int
+	ContentPrecompressMB  int
 	EvidenceSigningKeyARN string
 	ContentSigningKeyARN  string
 	TrustedProxyHops      int
 	DrainSeconds          int
 	ShutdownBudgetSeconds int
+	EvidenceLoadSeconds   int
+
+	// EvidenceRefreshSeconds is the evidence watcher's poll interval; 0 disables it
+	EvidenceRefreshSeconds int
+
+	// RequireSelfAttestation fails readiness while the running binary doesn't
+	// match the release's artifact digest
+	RequireSelfAttestation bool
 }
 
 // Register binds all config fields to the given FlagSet with defaults inline
@@ -45,6 +84,12 @@ func Register(fs *flag.FlagSet, c *App) {
 	fs.IntVar(&c.HTTPPort, "http-port", 8080, "listen TCP port (1..65535)")
 	fs.IntVar(&c.AdminPort, "admin-port", 9000, "admin listen TCP port (1..65535)")
 	fs.BoolVar(&c.EnablePprof, "enable-pprof", true, "Enable pprof profiling (on admin port only)")
+	fs.BoolVar(&c.EnableAdminAPI, "enable-admin-api", false, "Enable the authenticated /admin/ API on the admin port (reload, rollback, maintenance, log level, rate-limit bans)")
+	fs.StringVar(&c.AdminTokenSHA256, "admin-token-sha256", "", "hex sha256 of the admin API bearer token (the token itself is never configured)")
+	fs.StringVar(&c.AdminTLSCert, "admin-tls-cert", "", "PEM certificate to serve the admin port over TLS")
+	fs.StringVar(&c.AdminTLSKey, "admin-tls-key", "", "PEM private key for admin-tls-cert")
+	fs.StringVar(&c.AdminClientCA, "admin-client-ca", "", "PEM CA bundle; client certificates it verifies may use the admin API (mTLS)")
+	fs.StringVar(&c.AdminClientNames, "admin-client-names", "", "comma-separated client certificate CNs/DNS SANs allowed to use the admin API (empty = any cert from admin-client-ca)")
 	fs.BoolVar(&c.EnableTracing, "enable-tracing", false, "Enable OTLP tracing and push to otlp-endpoint")
 	fs.BoolVar(&c.EnablePyroscope, "enable-pyroscope", false, "Enable pushing Pyroscope data to server set in -pyro-server")
 	fs.BoolVar(&c.EnableContentUpdates, "enable-content-updates", true, "Enable refreshing content bundles from S3/SSM")
@@ -58,10 +103,38 @@ func Register(fs *flag.FlagSet, c *App) {
 	fs.StringVar(&c.ContentSSMParam, "content-ssm-param", "/app/linnemanlabs-web/server/content/stable/release/id", "ssm parameter name to get content bundle hash from")
 	fs.StringVar(&c.ContentS3Bucket, "content-s3-bucket", "phxi-prod-use2-build-deployment-artifacts", "s3 bucket name to get content bundle from")
 	fs.StringVar(&c.ContentS3Prefix, "content-s3-prefix", "apps/linnemanlabs-web/server/content/bundles", "s3 prefix (key) to get content bundle from")
+	fs.StringVar(&c.ContentPath, "content-path", "", "local content directory or .tar.gz bundle to serve instead of S3/SSM (dev and air-gapped use)")
+	fs.StringVar(&c.ContentPointerFile, "content-pointer-file", "", "file holding the algo:hex hash of content-path (default content-path + \".hash\")")
+	fs.StringVar(&c.ContentTUFURL, "content-tuf-url", "", "TUF repository metadata base URL to resolve content bundles from instead of S3/SSM")
+	fs.StringVar(&c.ContentTUFTargetsURL, "content-tuf-targets-url", "", "TUF targets base URL (default content-tuf-url + \"/targets\")")
+	fs.StringVar(&c.ContentTUFRoot, "content-tuf-root", "", "path to the trusted TUF root.json used to bootstrap trust")
+	fs.StringVar(&c.ContentTUFMetadataDir, "content-tuf-metadata-dir", "/var/lib/linnemanlabs-web/tuf", "directory where trusted TUF metadata is persisted")
+	fs.StringVar(&c.ContentTUFTarget, "content-tuf-target", "content/site.tar.gz", "TUF target name of the content bundle")
+	fs.StringVar(&c.ContentOCIRegistry, "content-oci-registry", "", "OCI registry host to pull content bundles from instead of S3/SSM (e.g. 123456789012.dkr.ecr.us-east-2.amazonaws.com)")
+	fs.StringVar(&c.ContentOCIRepository, "content-oci-repository", "", "OCI repository holding the content artifact")
+	fs.StringVar(&c.ContentOCIReference, "content-oci-reference", "stable", "OCI tag or sha256 digest of the current content artifact")
+	fs.IntVar(&c.ContentPollSeconds, "content-poll-seconds", 30, "seconds between content pointer polls; SIGHUP and change notifications poll sooner (5..3600)")
+	fs.IntVar(&c.ContentHistory, "content-history", 5, "number of verified content snapshots kept in memory for rollback, including the active one (1..100)")
+	fs.IntVar(&c.ContentHistoryMaxMB, "content-history-max-mb", 256, "memory budget in MiB for retained content snapshots; the active snapshot is always kept")
+	fs.BoolVar(&c.ContentPrecompress, "content-precompress", true, "build gzip and zstd variants of compressible content files once at load instead of compressing per request")
+	fs.IntVar(&c.ContentPrecompressMin, "content-precompress-min-bytes", 1024, "smallest file in bytes that gets precompressed variants")
+	fs.IntVar(&c.ContentPrecompressMB, "content-precompress-max-mb", 64, "memory budget in MiB for precompressed variants per snapshot")
+	fs.BoolVar(&c.ContentVerifyManifest, "content-verify-manifest", false, "reject new content bundles whose files do not match the release.json file list")
+	fs.StringVar(&c.ContentVersionPolicy, "content-version-policy", "off", "reject content bundles older than the active one by release.json created_at, commit_date or semver version (off disables)")
+	fs.StringVar(&c.ContentSelfCheck, "content-selfcheck", "", "comma-separated post-swap checks, each path[|status[|content-type[|marker...]]]; a failure rolls the swap back (empty disables)")
+	fs.BoolVar(&c.ContentPreview, "content-preview", false, "serve a candidate bundle loaded through the admin API on the admin port, with noindex headers and a preview banner")
+	fs.StringVar(&c.ContentPreviewHost, "content-preview-host", "", "serve the content preview only for this Host header on the admin port (default: any path not used by an ops endpoint)")
+	fs.BoolVar(&c.ContentSignedPointer, "content-require-signed-pointer", false, "only accept a signed pointer document in content-ssm-param, not a bare algo:hex hash")
+	fs.BoolVar(&c.ContentDelta, "content-delta", false, "assemble bundles that publish a signed delta manifest from files already loaded plus the missing blobs, instead of downloading the whole tarball")
+	fs.StringVar(&c.ContentCacheDir, "content-cache-dir", "", "keep the last verified bundles in this directory and serve the active one, re-verified, at startup before S3 answers (default: no cache)")
+	fs.BoolVar(&c.ContentOCIPlainHTTP, "content-oci-plain-http", false, "talk to the OCI registry over plain http (local registries only)")
 	fs.StringVar(&c.ContentSigningKeyARN, "content-signing-key-arn", "", "KMS key ARN for content bundle signature verification")
 	fs.StringVar(&c.EvidenceSigningKeyARN, "evidence-signing-key-arn", "", "KMS key ARN for evidence signature verification")
 	fs.IntVar(&c.TrustedProxyHops, "trusted-proxy-hops", 1, "number of trusted reverse proxies (0=direct, 1=ALB, 2=CDN+ALB, etc.)")
 	fs.IntVar(&c.DrainSeconds, "drain-seconds", 60, "seconds to wait for in-flight requests to drain before shutdown (1..300)")
+	fs.IntVar(&c.EvidenceLoadSeconds, "evidence-load-seconds", 300, "seconds to keep retrying S3 errors while loading build evidence at startup before exiting (1..3600)")
+	fs.IntVar(&c.EvidenceRefreshSeconds, "evidence-refresh-seconds", 900, "seconds between checks for re-published build evidence such as new scan reports (0 disables, else 60..86400)")
+	fs.BoolVar(&c.RequireSelfAttestation, "require-self-attestation", false, "report not ready while the running executable's sha256 does not match this platform's artifact in release.json (release builds only)")
 	fs.IntVar(&c.ShutdownBudgetSeconds, "shutdown-budget-seconds", 30, "total seconds for component shutdown after drain (1..300)")
 }
 
@@ -110,6 +183,23 @@ func Validate(c *App, hasProvenance bool) error { //nolint:gocognit // config va
 		errs = append(errs, fmt.Errorf("ADMIN_PORT and HTTP_PORT must differ (both %d)", c.HTTPPort))
 	}
 
+	// Admin API and ops listener TLS
+	if (c.AdminTLSCert == "") != (c.AdminTLSKey == "") {
+		errs = append(errs, fmt.Errorf("ADMIN_TLS_CERT and ADMIN_TLS_KEY must be set together"))
+	}
+	if c.AdminClientCA != "" && c.AdminTLSCert == "" {
+		errs = append(errs, fmt.Errorf("ADMIN_CLIENT_CA requires ADMIN_TLS_CERT and ADMIN_TLS_KEY"))
+	}
+	if c.AdminClientNames != "" && c.AdminClientCA == "" {
+		errs = append(errs, fmt.Errorf("ADMIN_CLIENT_NAMES requires ADMIN_CLIENT_CA"))
+	}
+	if c.AdminTokenSHA256 != "" && !isSHA256Hex(c.AdminTokenSHA256) {
+		errs = append(errs, fmt.Errorf("ADMIN_TOKEN_SHA256 must be 64 hex characters"))
+	}
+	if c.EnableAdminAPI && c.AdminTokenSHA256 == "" && c.AdminClientCA == "" {
+		errs = append(errs, fmt.Errorf("ENABLE_ADMIN_API requires ADMIN_TOKEN_SHA256 or ADMIN_CLIENT_CA"))
+	}
+
 	// Log levels
 	if _, err := log.ParseLevel(c.LogLevel); err != nil {
 		errs = append(errs, fmt.Errorf("invalid LOG_LEVEL %q: %w", c.LogLevel, err))
@@ -155,7 +245,101 @@ func Validate(c *App, hasProvenance bool) error { //nolint:gocognit // config va
 		}
 	}
 
-	if c.EnableContentUpdates {
+	if c.ContentPollSeconds < 5 || c.ContentPollSeconds > 3600 {
+		errs = append(errs, fmt.Errorf("CONTENT_POLL_SECONDS must be 5..3600 (got %d)", c.ContentPollSeconds))
+	}
+	if c.ContentHistory < 1 || c.ContentHistory > 100 {
+		errs = append(errs, fmt.Errorf("CONTENT_HISTORY must be 1..100 (got %d)", c.ContentHistory))
+	}
+	if c.ContentHistoryMaxMB < 1 {
+		errs = append(errs, fmt.Errorf("CONTENT_HISTORY_MAX_MB must be >= 1 (got %d)", c.ContentHistoryMaxMB))
+	}
+	if c.ContentPrecompress {
+		if c.ContentPrecompressMin < 1 {
+			errs = append(errs, fmt.Errorf("CONTENT_PRECOMPRESS_MIN_BYTES must be >= 1 (got %d)", c.ContentPrecompressMin))
+		}
+		if c.ContentPrecompressMB < 1 {
+			errs = append(errs, fmt.Errorf("CONTENT_PRECOMPRESS_MAX_MB must be >= 1 (got %d)", c.ContentPrecompressMB))
+		}
+	}
+
+	if c.ContentPointerFile != "" && c.ContentPath == "" {
+		errs = append(errs, fmt.Errorf("CONTENT_POINTER_FILE requires CONTENT_PATH"))
+	}
+
+	if c.ContentTUFURL != "" {
+		if c.ContentPath != "" {
+			errs = append(errs, fmt.Errorf("CONTENT_PATH and CONTENT_TUF_URL are mutually exclusive"))
+		}
+		if u, err := url.Parse(c.ContentTUFURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("CONTENT_TUF_URL must be a URL (got %q)", c.ContentTUFURL))
+		}
+		if c.ContentTUFRoot == "" {
+			errs = append(errs, fmt.Errorf("CONTENT_TUF_ROOT is required when CONTENT_TUF_URL is set"))
+		}
+		if c.ContentTUFMetadataDir == "" {
+			errs = append(errs, fmt.Errorf("CONTENT_TUF_METADATA_DIR is required when CONTENT_TUF_URL is set"))
+		}
+		if c.ContentTUFTarget == "" {
+			errs = append(errs, fmt.Errorf("CONTENT_TUF_TARGET is required when CONTENT_TUF_URL is set"))
+		}
+	}
+
+	if c.ContentOCIRegistry != "" {
+		if c.ContentPath != "" || c.ContentTUFURL != "" {
+			errs = append(errs, fmt.Errorf("CONTENT_OCI_REGISTRY is mutually exclusive with CONTENT_PATH and CONTENT_TUF_URL"))
+		}
+		if strings.Contains(c.ContentOCIRegistry, "/") {
+			errs = append(errs, fmt.Errorf("CONTENT_OCI_REGISTRY must be a registry host without scheme or path (got %q)", c.ContentOCIRegistry))
+		}
+		if c.ContentOCIRepository == "" {
+			errs = append(errs, fmt.Errorf("CONTENT_OCI_REPOSITORY is required when CONTENT_OCI_REGISTRY is set"))
+		}
+		if c.ContentOCIReference == "" {
+			errs = append(errs, fmt.Errorf("CONTENT_OCI_REFERENCE is required when CONTENT_OCI_REGISTRY is set"))
+		}
+		// OCI artifacts are always dual-signed; the fetcher refuses to run without the KMS key
+		if c.ContentSigningKeyARN == "" {
+			errs = append(errs, fmt.Errorf("CONTENT_SIGNING_KEY_ARN is required when CONTENT_OCI_REGISTRY is set"))
+		}
+	}
+
+	switch strings.ToLower(c.ContentVersionPolicy) {
+	case "", "off", "created_at", "commit_date", "semver":
+	default:
+		errs = append(errs, fmt.Errorf("CONTENT_VERSION_POLICY must be off, created_at, commit_date or semver (got %q)", c.ContentVersionPolicy))
+	}
+
+	for _, entry := range strings.Split(c.ContentSelfCheck, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" && !strings.HasPrefix(entry, "/") {
+			errs = append(errs, fmt.Errorf("CONTENT_SELFCHECK entries must start with a path (got %q)", entry))
+		}
+	}
+
+	if c.ContentPreview && !c.EnableAdminAPI {
+		errs = append(errs, fmt.Errorf("CONTENT_PREVIEW requires ENABLE_ADMIN_API to load candidates"))
+	}
+	if c.ContentPreviewHost != "" {
+		if !c.ContentPreview {
+			errs = append(errs, fmt.Errorf("CONTENT_PREVIEW_HOST requires CONTENT_PREVIEW"))
+		}
+		if strings.ContainsAny(c.ContentPreviewHost, "/ ") {
+			errs = append(errs, fmt.Errorf("CONTENT_PREVIEW_HOST must be a bare host name (got %q)", c.ContentPreviewHost))
+		}
+	}
+
+	if c.ContentSignedPointer && (c.ContentPath != "" || c.ContentTUFURL != "" || c.ContentOCIRegistry != "") {
+		errs = append(errs, fmt.Errorf("CONTENT_REQUIRE_SIGNED_POINTER only applies to the S3/SSM content source"))
+	}
+	if c.ContentDelta && (c.ContentPath != "" || c.ContentTUFURL != "" || c.ContentOCIRegistry != "") {
+		errs = append(errs, fmt.Errorf("CONTENT_DELTA only applies to the S3/SSM content source"))
+	}
+	if c.ContentCacheDir != "" && (c.ContentPath != "" || c.ContentTUFURL != "" || c.ContentOCIRegistry != "") {
+		errs = append(errs, fmt.Errorf("CONTENT_CACHE_DIR only applies to the S3/SSM content source"))
+	}
+
+	// S3/SSM content settings are unused when content comes from a local path, TUF or OCI
+	if c.EnableContentUpdates && c.ContentPath == "" && c.ContentTUFURL == "" && c.ContentOCIRegistry == "" {
 		// Content config
 		if c.ContentSSMParam == "" {
 			errs = append(errs, fmt.Errorf("CONTENT_SSM_PARAM is required"))
@@ -181,6 +365,8 @@ func Validate(c *App, hasProvenance bool) error { //nolint:gocognit // config va
 		if c.ContentSigningKeyARN == "" {
 			errs = append(errs, fmt.Errorf("release build requires content-signing-key-arn"))
 		}
+	} else if c.RequireSelfAttestation {
+		errs = append(errs, fmt.Errorf("REQUIRE_SELF_ATTESTATION needs a release build with provenance to compare against"))
 	}
 
 	// Trusted proxy hops
@@ -195,9 +381,39 @@ func Validate(c *App, hasProvenance bool) error { //nolint:gocognit // config va
 	if c.ShutdownBudgetSeconds <= 0 {
 		errs = append(errs, fmt.Errorf("invalid SHUTDOWN_BUDGET_SECONDS %d (must be > 0)", c.ShutdownBudgetSeconds))
 	}
+	if c.EvidenceLoadSeconds < 1 || c.EvidenceLoadSeconds > 3600 {
+		errs = append(errs, fmt.Errorf("EVIDENCE_LOAD_SECONDS must be 1..3600 (got %d)", c.EvidenceLoadSeconds))
+	}
+	if c.EvidenceRefreshSeconds != 0 && (c.EvidenceRefreshSeconds < 60 || c.EvidenceRefreshSeconds > 86400) {
+		errs = append(errs, fmt.Errorf("EVIDENCE_REFRESH_SECONDS must be 0 (disabled) or 60..86400 (got %d)", c.EvidenceRefreshSeconds))
+	}
 
 	if len(errs) > 0 {
 		return errors.Join(errs...)
 	}
 	return nil
 }
+
+// isSHA256Hex reports whether s is a hex-encoded sha256 digest.
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitList splits a comma-separated flag value, dropping empty items.
+func SplitList(s string) []string {
+	var out []string
+	for p := range strings.SplitSeq(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
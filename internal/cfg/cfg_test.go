@@ -73,6 +73,51 @@ func TestRegister_Defaults(t *testing.T) {
 	if c.ShutdownBudgetSeconds != 30 {
 		t.Errorf("ShutdownBudgetSeconds: want 30, got %d", c.ShutdownBudgetSeconds)
 	}
+	if c.EvidenceLoadSeconds != 300 {
+		t.Errorf("EvidenceLoadSeconds: want 300, got %d", c.EvidenceLoadSeconds)
+	}
+	if c.EvidenceRefreshSeconds != 900 {
+		t.Errorf("EvidenceRefreshSeconds: want 900, got %d", c.EvidenceRefreshSeconds)
+	}
+	if c.RequireSelfAttestation {
+		t.Error("RequireSelfAttestation: want false")
+	}
+	if c.ContentPath != "" {
+		t.Errorf("ContentPath: want empty, got %q", c.ContentPath)
+	}
+	if c.ContentVerifyManifest {
+		t.Error("ContentVerifyManifest: want false")
+	}
+	if c.ContentHistory != 5 || c.ContentHistoryMaxMB != 256 {
+		t.Errorf("ContentHistory/MaxMB: want 5/256, got %d/%d", c.ContentHistory, c.ContentHistoryMaxMB)
+	}
+	if c.EnableAdminAPI {
+		t.Error("EnableAdminAPI: want false")
+	}
+	if c.ContentSignedPointer {
+		t.Error("ContentSignedPointer: want false")
+	}
+	if c.ContentDelta {
+		t.Error("ContentDelta: want false")
+	}
+	if c.ContentCacheDir != "" {
+		t.Errorf("ContentCacheDir: want empty, got %q", c.ContentCacheDir)
+	}
+	if c.ContentPollSeconds != 30 {
+		t.Errorf("ContentPollSeconds: want 30, got %d", c.ContentPollSeconds)
+	}
+	if c.ContentVersionPolicy != "off" {
+		t.Errorf("ContentVersionPolicy: want off, got %q", c.ContentVersionPolicy)
+	}
+	if c.ContentSelfCheck != "" {
+		t.Errorf("ContentSelfCheck: want empty, got %q", c.ContentSelfCheck)
+	}
+	if c.ContentPreview || c.ContentPreviewHost != "" {
+		t.Errorf("ContentPreview: want off, got %v %q", c.ContentPreview, c.ContentPreviewHost)
+	}
+	if !c.ContentPrecompress || c.ContentPrecompressMin != 1024 || c.ContentPrecompressMB != 64 {
+		t.Errorf("ContentPrecompress/Min/MB: want true/1024/64, got %v/%d/%d", c.ContentPrecompress, c.ContentPrecompressMin, c.ContentPrecompressMB)
+	}
 }
 
 func TestRegister_CLIOverrides(t *testing.T) {
@@ -366,6 +411,13 @@ func validConfig() App {
 		ContentSigningKeyARN:  "arn:aws:kms:us-east-2:000000000000:key/content-key",
 		DrainSeconds:          60,
 		ShutdownBudgetSeconds: 30,
+		EvidenceLoadSeconds:   300,
+		ContentHistory:        5,
+		ContentHistoryMaxMB:   256,
+		ContentPollSeconds:    30,
+		ContentPrecompress:    true,
+		ContentPrecompressMin: 1024,
+		ContentPrecompressMB:  64,
 	}
 }
 
@@ -386,6 +438,283 @@ func TestValidate_ContentUpdatesRequireSigningKey(t *testing.T) {
 	}
 }
 
+func TestValidate_ContentPathSkipsS3Settings(t *testing.T) {
+	c := validConfig()
+	c.EnableContentUpdates = true
+	c.ContentPath = "/srv/site.tar.gz"
+	c.ContentSigningKeyARN = ""
+	if err := Validate(&c, false); err != nil {
+		t.Fatalf("unexpected error with local content path: %v", err)
+	}
+}
+
+func TestValidate_ContentPointerFileRequiresPath(t *testing.T) {
+	c := validConfig()
+	c.ContentPointerFile = "/srv/site.hash"
+	wantErrContains(t, Validate(&c, false), "CONTENT_POINTER_FILE requires CONTENT_PATH")
+
+	c.ContentPath = "/srv/site.tar.gz"
+	if err := Validate(&c, false); err != nil {
+		t.Fatalf("unexpected error with content path set: %v", err)
+	}
+}
+
+func TestValidate_ContentTUF(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		c := validConfig()
+		c.EnableContentUpdates = true
+		c.ContentTUFURL = "https://tuf.example.com/metadata"
+		c.ContentTUFRoot = "/etc/linnemanlabs-web/root.json"
+		c.ContentTUFMetadataDir = "/var/lib/linnemanlabs-web/tuf"
+		c.ContentTUFTarget = "content/site.tar.gz"
+		if err := Validate(&c, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("missing root", func(t *testing.T) {
+		c := validConfig()
+		c.ContentTUFURL = "https://tuf.example.com/metadata"
+		c.ContentTUFMetadataDir = "/var/lib/linnemanlabs-web/tuf"
+		c.ContentTUFTarget = "content/site.tar.gz"
+		wantErrContains(t, Validate(&c, false), "CONTENT_TUF_ROOT is required")
+	})
+
+	t.Run("bad url", func(t *testing.T) {
+		c := validConfig()
+		c.ContentTUFURL = "tuf.example.com"
+		c.ContentTUFRoot = "/etc/root.json"
+		c.ContentTUFMetadataDir = "/tmp/tuf"
+		c.ContentTUFTarget = "site.tar.gz"
+		wantErrContains(t, Validate(&c, false), "CONTENT_TUF_URL must be a URL")
+	})
+
+	t.Run("exclusive with content path", func(t *testing.T) {
+		c := validConfig()
+		c.ContentPath = "/srv/site"
+		c.ContentTUFURL = "https://tuf.example.com/metadata"
+		c.ContentTUFRoot = "/etc/root.json"
+		c.ContentTUFMetadataDir = "/tmp/tuf"
+		c.ContentTUFTarget = "site.tar.gz"
+		wantErrContains(t, Validate(&c, false), "mutually exclusive")
+	})
+}
+
+func TestValidate_ContentOCI(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		c := validConfig()
+		c.EnableContentUpdates = true
+		c.ContentOCIRegistry = "123456789012.dkr.ecr.us-east-2.amazonaws.com"
+		c.ContentOCIRepository = "linnemanlabs/content"
+		c.ContentOCIReference = "stable"
+		if err := Validate(&c, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("missing repository", func(t *testing.T) {
+		c := validConfig()
+		c.ContentOCIRegistry = "registry.example.com"
+		c.ContentOCIReference = "stable"
+		wantErrContains(t, Validate(&c, false), "CONTENT_OCI_REPOSITORY is required")
+	})
+
+	t.Run("registry with scheme", func(t *testing.T) {
+		c := validConfig()
+		c.ContentOCIRegistry = "https://registry.example.com"
+		c.ContentOCIRepository = "content"
+		c.ContentOCIReference = "stable"
+		wantErrContains(t, Validate(&c, false), "without scheme or path")
+	})
+
+	t.Run("requires signing key", func(t *testing.T) {
+		c := validConfig()
+		c.ContentOCIRegistry = "registry.example.com"
+		c.ContentOCIRepository = "content"
+		c.ContentOCIReference = "stable"
+		c.ContentSigningKeyARN = ""
+		wantErrContains(t, Validate(&c, false), "CONTENT_SIGNING_KEY_ARN is required when CONTENT_OCI_REGISTRY")
+	})
+
+	t.Run("exclusive with content path", func(t *testing.T) {
+		c := validConfig()
+		c.ContentPath = "/srv/site"
+		c.ContentOCIRegistry = "registry.example.com"
+		c.ContentOCIRepository = "content"
+		c.ContentOCIReference = "stable"
+		wantErrContains(t, Validate(&c, false), "mutually exclusive")
+	})
+}
+
+func TestValidate_ContentHistory(t *testing.T) {
+	for _, n := range []int{0, 101} {
+		c := validConfig()
+		c.ContentHistory = n
+		wantErrContains(t, Validate(&c, false), "CONTENT_HISTORY must be 1..100")
+	}
+
+	c := validConfig()
+	c.ContentHistoryMaxMB = 0
+	wantErrContains(t, Validate(&c, false), "CONTENT_HISTORY_MAX_MB")
+}
+
+func TestValidate_ContentPollSeconds(t *testing.T) {
+	for _, n := range []int{0, 4, 3601} {
+		c := validConfig()
+		c.ContentPollSeconds = n
+		wantErrContains(t, Validate(&c, false), "CONTENT_POLL_SECONDS must be 5..3600")
+	}
+}
+
+func TestValidate_ContentPrecompress(t *testing.T) {
+	c := validConfig()
+	c.ContentPrecompressMin = 0
+	wantErrContains(t, Validate(&c, false), "CONTENT_PRECOMPRESS_MIN_BYTES")
+
+	c = validConfig()
+	c.ContentPrecompressMB = 0
+	wantErrContains(t, Validate(&c, false), "CONTENT_PRECOMPRESS_MAX_MB")
+
+	// limits are ignored when precompression is off
+	c.ContentPrecompress = false
+	if err := Validate(&c, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidate_ContentVersionPolicy(t *testing.T) {
+	for _, p := range []string{"off", "created_at", "commit_date", "semver", "SEMVER"} {
+		c := validConfig()
+		c.ContentVersionPolicy = p
+		if err := Validate(&c, false); err != nil {
+			t.Fatalf("%s: unexpected error: %v", p, err)
+		}
+	}
+	c := validConfig()
+	c.ContentVersionPolicy = "newest"
+	wantErrContains(t, Validate(&c, false), "CONTENT_VERSION_POLICY")
+}
+
+func TestValidate_ContentSignedPointer(t *testing.T) {
+	c := validConfig()
+	c.ContentSignedPointer = true
+	if err := Validate(&c, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.ContentPath = "/srv/site"
+	wantErrContains(t, Validate(&c, false), "CONTENT_REQUIRE_SIGNED_POINTER")
+}
+
+func TestValidate_ContentDelta(t *testing.T) {
+	c := validConfig()
+	c.ContentDelta = true
+	if err := Validate(&c, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.ContentOCIRegistry = "registry.example.com"
+	c.ContentOCIRepository = "site"
+	wantErrContains(t, Validate(&c, false), "CONTENT_DELTA")
+}
+
+func TestValidate_ContentCacheDir(t *testing.T) {
+	c := validConfig()
+	c.ContentCacheDir = "/var/cache/linnemanlabs-web"
+	if err := Validate(&c, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.ContentPath = "/srv/content"
+	wantErrContains(t, Validate(&c, false), "CONTENT_CACHE_DIR")
+}
+
+func TestValidate_ContentSelfCheck(t *testing.T) {
+	c := validConfig()
+	c.ContentSelfCheck = `/|200|text/html|id="provenance-content-data", /about/`
+	if err := Validate(&c, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.ContentSelfCheck = "/,about"
+	wantErrContains(t, Validate(&c, false), "CONTENT_SELFCHECK")
+}
+
+func TestValidate_ContentPreview(t *testing.T) {
+	c := validConfig()
+	c.ContentPreview = true
+	wantErrContains(t, Validate(&c, false), "CONTENT_PREVIEW requires ENABLE_ADMIN_API")
+
+	c.EnableAdminAPI = true
+	c.AdminTokenSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	c.ContentPreviewHost = "preview.internal"
+	if err := Validate(&c, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c.ContentPreviewHost = "preview.internal/x"
+	wantErrContains(t, Validate(&c, false), "CONTENT_PREVIEW_HOST must be")
+
+	c = validConfig()
+	c.ContentPreviewHost = "preview.internal"
+	wantErrContains(t, Validate(&c, false), "CONTENT_PREVIEW_HOST requires CONTENT_PREVIEW")
+}
+
+func TestValidate_AdminAPI(t *testing.T) {
+	const digest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+	t.Run("requires an auth method", func(t *testing.T) {
+		c := validConfig()
+		c.EnableAdminAPI = true
+		wantErrContains(t, Validate(&c, false), "ENABLE_ADMIN_API requires")
+	})
+
+	t.Run("token digest", func(t *testing.T) {
+		c := validConfig()
+		c.EnableAdminAPI = true
+		c.AdminTokenSHA256 = digest
+		if err := Validate(&c, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		c.AdminTokenSHA256 = "hunter2"
+		wantErrContains(t, Validate(&c, false), "ADMIN_TOKEN_SHA256 must be 64 hex")
+	})
+
+	t.Run("tls cert and key together", func(t *testing.T) {
+		c := validConfig()
+		c.AdminTLSCert = "/etc/ops/tls.crt"
+		wantErrContains(t, Validate(&c, false), "ADMIN_TLS_CERT and ADMIN_TLS_KEY")
+	})
+
+	t.Run("client CA needs TLS", func(t *testing.T) {
+		c := validConfig()
+		c.EnableAdminAPI = true
+		c.AdminClientCA = "/etc/ops/ca.pem"
+		wantErrContains(t, Validate(&c, false), "ADMIN_CLIENT_CA requires")
+
+		c.AdminTLSCert, c.AdminTLSKey = "/etc/ops/tls.crt", "/etc/ops/tls.key"
+		if err := Validate(&c, false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("client names need CA", func(t *testing.T) {
+		c := validConfig()
+		c.AdminClientNames = "ops-cli"
+		wantErrContains(t, Validate(&c, false), "ADMIN_CLIENT_NAMES requires")
+	})
+}
+
+func TestSplitList(t *testing.T) {
+	got := SplitList(" ops-cli, ,deploy.internal,")
+	if len(got) != 2 || got[0] != "ops-cli" || got[1] != "deploy.internal" {
+		t.Fatalf("SplitList = %q", got)
+	}
+	if SplitList("") != nil {
+		t.Fatal("SplitList(\"\") should be nil")
+	}
+}
+
 func TestValidate_ProvenanceRequiresBothKeys(t *testing.T) {
 	t.Run("both missing", func(t *testing.T) {
 		c := validConfig()
@@ -437,6 +766,38 @@ func TestValidate_DrainSeconds_Invalid(t *testing.T) {
 	wantErrContains(t, Validate(&c, false), "invalid DRAIN_SECONDS")
 }
 
+func TestValidate_EvidenceLoadSeconds(t *testing.T) {
+	for _, n := range []int{0, 3601} {
+		c := validConfig()
+		c.EvidenceLoadSeconds = n
+		wantErrContains(t, Validate(&c, false), "EVIDENCE_LOAD_SECONDS must be 1..3600")
+	}
+}
+
+func TestValidate_EvidenceRefreshSeconds(t *testing.T) {
+	for _, n := range []int{0, 60, 86400} {
+		c := validConfig()
+		c.EvidenceRefreshSeconds = n
+		if err := Validate(&c, false); err != nil {
+			t.Errorf("EvidenceRefreshSeconds %d: unexpected error %v", n, err)
+		}
+	}
+	for _, n := range []int{-1, 59, 86401} {
+		c := validConfig()
+		c.EvidenceRefreshSeconds = n
+		wantErrContains(t, Validate(&c, false), "EVIDENCE_REFRESH_SECONDS must be 0 (disabled) or 60..86400")
+	}
+}
+
+func TestValidate_RequireSelfAttestation(t *testing.T) {
+	c := validConfig()
+	c.RequireSelfAttestation = true
+	wantErrContains(t, Validate(&c, false), "REQUIRE_SELF_ATTESTATION needs a release build")
+	if err := Validate(&c, true); err != nil {
+		t.Fatalf("release build: unexpected error %v", err)
+	}
+}
+
 func TestValidate_ShutdownBudgetSeconds_Invalid(t *testing.T) {
 	c := validConfig()
 	c.ShutdownBudgetSeconds = 0
@@ -0,0 +1,116 @@
+// internal/content/blobstore.go
+//
+// Consecutive releases share most of their files: fonts, images and scripts
+// rarely change between content deploys. Every extraction allocates fresh
+// slices for them, so the active snapshot, its retained history and a staged
+// or previewed candidate would each hold their own copy. The blob store keys
+// file data by SHA-256 and hands every snapshot the same slice for identical
+// files, reference-counted by the snapshots that hold it.
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"sync"
+	"testing/fstest"
+)
+
+// BlobStats describes the blob store's memory use.
+type BlobStats struct {
+	// Blobs is the number of distinct file contents held.
+	Blobs int `json:"blobs"`
+
+	// LogicalBytes is what the referencing snapshots would hold without
+	// sharing; PhysicalBytes is what is actually held.
+	LogicalBytes  int64 `json:"logical_bytes"`
+	PhysicalBytes int64 `json:"physical_bytes"`
+}
+
+type blob struct {
+	data []byte
+	refs int
+}
+
+// blobStore interns snapshot file data by content hash. Safe for concurrent
+// use.
+type blobStore struct {
+	mu       sync.Mutex
+	blobs    map[string]*blob
+	logical  int64
+	physical int64
+}
+
+func newBlobStore() *blobStore {
+	return &blobStore{blobs: make(map[string]*blob)}
+}
+
+// intern returns a copy of fsys whose file data is shared with identical
+// files already held, and the digests it took a reference on; pass those to
+// release when the snapshot is dropped. fsys itself is not modified, since
+// its files may be being served. Filesystems other than MapFS are returned
+// unchanged with no references.
+func (b *blobStore) intern(fsys fs.FS, digests Digests) (fs.FS, []string) {
+	mfs, ok := fsys.(fstest.MapFS)
+	if !ok {
+		return fsys, nil
+	}
+	out := make(fstest.MapFS, len(mfs))
+	refs := make([]string, 0, len(mfs))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for name, f := range mfs {
+		if f == nil || f.Mode.IsDir() {
+			out[name] = f
+			continue
+		}
+		sum := digests[name].SHA256
+		if sum == "" {
+			h := sha256.Sum256(f.Data)
+			sum = hex.EncodeToString(h[:])
+		}
+		bl, held := b.blobs[sum]
+		if !held {
+			bl = &blob{data: f.Data}
+			b.blobs[sum] = bl
+			b.physical += int64(len(f.Data))
+		}
+		bl.refs++
+		b.logical += int64(len(bl.data))
+		refs = append(refs, sum)
+
+		cp := *f
+		cp.Data = bl.data
+		out[name] = &cp
+	}
+	return out, refs
+}
+
+// release drops one reference per digest; blobs nobody references are
+// forgotten and collected once no snapshot slice points at them.
+func (b *blobStore) release(refs []string) {
+	if len(refs) == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, sum := range refs {
+		bl, ok := b.blobs[sum]
+		if !ok {
+			continue
+		}
+		bl.refs--
+		b.logical -= int64(len(bl.data))
+		if bl.refs <= 0 {
+			delete(b.blobs, sum)
+			b.physical -= int64(len(bl.data))
+		}
+	}
+}
+
+func (b *blobStore) stats() BlobStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BlobStats{Blobs: len(b.blobs), LogicalBytes: b.logical, PhysicalBytes: b.physical}
+}
@@ -0,0 +1,205 @@
+package content
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func blobFS(files map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for name, body := range files {
+		fsys[name] = &fstest.MapFile{Data: []byte(body), Mode: 0o644}
+	}
+	return fsys
+}
+
+func TestBlobStore_InternSharesIdenticalFiles(t *testing.T) {
+	b := newBlobStore()
+	a := blobFS(map[string]string{"font.woff2": "FONTDATA", "index.html": "<html>a</html>"})
+	c := blobFS(map[string]string{"font.woff2": "FONTDATA", "index.html": "<html>bb</html>"})
+
+	fa, refsA := b.intern(a, nil)
+	fc, refsC := b.intern(c, nil)
+
+	ma, mc := fa.(fstest.MapFS), fc.(fstest.MapFS)
+	if &ma["font.woff2"].Data[0] != &mc["font.woff2"].Data[0] {
+		t.Fatal("identical files should share one slice")
+	}
+	if &ma["index.html"].Data[0] == &mc["index.html"].Data[0] {
+		t.Fatal("different files must not share")
+	}
+	if len(refsA) != 2 || len(refsC) != 2 {
+		t.Fatalf("refs = %d, %d, want 2 each", len(refsA), len(refsC))
+	}
+
+	want := BlobStats{Blobs: 3, LogicalBytes: 8 + 14 + 8 + 15, PhysicalBytes: 8 + 14 + 15}
+	if got := b.stats(); got != want {
+		t.Fatalf("stats = %+v, want %+v", got, want)
+	}
+
+	b.release(refsA)
+	want = BlobStats{Blobs: 2, LogicalBytes: 8 + 15, PhysicalBytes: 8 + 15}
+	if got := b.stats(); got != want {
+		t.Fatalf("after release = %+v, want %+v", got, want)
+	}
+	b.release(refsC)
+	if got := b.stats(); got != (BlobStats{}) {
+		t.Fatalf("after releasing all = %+v, want empty", got)
+	}
+}
+
+func TestBlobStore_InternDoesNotModifySource(t *testing.T) {
+	b := newBlobStore()
+	first := blobFS(map[string]string{"a.css": "body{}"})
+	_, _ = b.intern(first, nil)
+
+	src := blobFS(map[string]string{"a.css": "body{}"})
+	orig := src["a.css"]
+	origData := orig.Data
+	out, _ := b.intern(src, nil)
+
+	if src["a.css"] != orig || &src["a.css"].Data[0] != &origData[0] {
+		t.Fatal("intern must not modify the source filesystem")
+	}
+	if &out.(fstest.MapFS)["a.css"].Data[0] != &first["a.css"].Data[0] {
+		t.Fatal("interned copy should reference the first holder's data")
+	}
+}
+
+func TestBlobStore_UsesDigests(t *testing.T) {
+	b := newBlobStore()
+	fsys := blobFS(map[string]string{"x.txt": "x"})
+	_, refs := b.intern(fsys, Digests{"x.txt": {SHA256: "precomputed"}})
+	if len(refs) != 1 || refs[0] != "precomputed" {
+		t.Fatalf("refs = %v, want the supplied digest", refs)
+	}
+}
+
+func TestBlobStore_SkipsDirsAndNonMapFS(t *testing.T) {
+	b := newBlobStore()
+	fsys := blobFS(map[string]string{"a/b.txt": "b"})
+	fsys["a"] = &fstest.MapFile{Mode: 0o755 | os.ModeDir}
+	out, refs := b.intern(fsys, nil)
+	if len(refs) != 1 || out.(fstest.MapFS)["a"] != fsys["a"] {
+		t.Fatalf("directories should be copied as-is, refs = %v", refs)
+	}
+
+	dir := os.DirFS(t.TempDir())
+	got, refs := b.intern(dir, nil)
+	if got != dir || refs != nil {
+		t.Fatal("non-MapFS filesystems should be returned unchanged")
+	}
+}
+
+func TestBlobStore_ReleaseUnknownIgnored(t *testing.T) {
+	b := newBlobStore()
+	b.release([]string{"missing"})
+	if got := b.stats(); got != (BlobStats{}) {
+		t.Fatalf("stats = %+v, want empty", got)
+	}
+}
+
+func TestManager_Blobs_SharedAcrossHistory(t *testing.T) {
+	m := NewManager()
+	m.Set(Snapshot{FS: blobFS(map[string]string{"font.woff2": "FONTDATA", "index.html": "v1"}), Meta: Meta{Hash: "h1"}})
+	m.Set(Snapshot{FS: blobFS(map[string]string{"font.woff2": "FONTDATA", "index.html": "v2"}), Meta: Meta{Hash: "h2"}})
+
+	got := m.BlobStats()
+	if got.Blobs != 3 || got.LogicalBytes != 20 || got.PhysicalBytes != 12 {
+		t.Fatalf("BlobStats = %+v, want 3 blobs, 20 logical, 12 physical", got)
+	}
+	// history accounting stays logical so budgets don't shift with sharing
+	if m.HistoryBytes() != 20 {
+		t.Fatalf("HistoryBytes = %d, want 20", m.HistoryBytes())
+	}
+	snap, _ := m.Get()
+	if data, err := fs.ReadFile(snap.FS, "font.woff2"); err != nil || string(data) != "FONTDATA" {
+		t.Fatalf("active font = %q, %v", data, err)
+	}
+}
+
+func TestManager_Blobs_ReleasedOnEviction(t *testing.T) {
+	m := NewManagerWithOptions(&ManagerOptions{HistorySize: 1})
+	m.Set(Snapshot{FS: blobFS(map[string]string{"old.js": "oldoldold"}), Meta: Meta{Hash: "h1"}})
+	m.Set(Snapshot{FS: blobFS(map[string]string{"new.js": "new"}), Meta: Meta{Hash: "h2"}})
+
+	want := BlobStats{Blobs: 1, LogicalBytes: 3, PhysicalBytes: 3}
+	if got := m.BlobStats(); got != want {
+		t.Fatalf("BlobStats = %+v, want %+v", got, want)
+	}
+
+	// re-setting a retained hash replaces the older copy's references
+	m.Set(Snapshot{FS: blobFS(map[string]string{"new.js": "new"}), Meta: Meta{Hash: "h2"}})
+	if got := m.BlobStats(); got != want {
+		t.Fatalf("after re-set = %+v, want %+v", got, want)
+	}
+}
+
+func TestManager_Intern(t *testing.T) {
+	m := NewManager()
+	m.Set(Snapshot{FS: blobFS(map[string]string{"font.woff2": "FONTDATA"}), Meta: Meta{Hash: "h1"}})
+
+	staged := &Snapshot{FS: blobFS(map[string]string{"font.woff2": "FONTDATA", "index.html": "v2"}), Meta: Meta{Hash: "h2"}}
+	release := m.Intern(staged)
+	if staged.Digests == nil {
+		t.Fatal("Intern should compute digests")
+	}
+	active, _ := m.Get()
+	if &staged.FS.(fstest.MapFS)["font.woff2"].Data[0] != &active.FS.(fstest.MapFS)["font.woff2"].Data[0] {
+		t.Fatal("interned snapshot should share the active snapshot's font")
+	}
+	if got := m.BlobStats(); got.LogicalBytes != 18 || got.PhysicalBytes != 10 {
+		t.Fatalf("BlobStats = %+v, want 18 logical, 10 physical", got)
+	}
+
+	release()
+	release()
+	want := BlobStats{Blobs: 1, LogicalBytes: 8, PhysicalBytes: 8}
+	if got := m.BlobStats(); got != want {
+		t.Fatalf("after release = %+v, want %+v", got, want)
+	}
+
+	if release := m.Intern(nil); release == nil {
+		t.Fatal("Intern(nil) should return a no-op release")
+	}
+}
+
+func TestCheckOnce_Staged_ReleasesBlobs(t *testing.T) {
+	t.Parallel()
+	f, w, _, hashOld, _ := stagedFixture(t, time.Now().Add(time.Hour))
+	base := f.mgr.BlobStats()
+
+	_ = w.checkOnce(t.Context())
+	if got := f.mgr.BlobStats(); got.LogicalBytes <= base.LogicalBytes {
+		t.Fatalf("staging should reference its files: %+v, base %+v", got, base)
+	}
+
+	f.ssm.setValue(ssmValue(hashOld))
+	_ = w.checkOnce(t.Context())
+	if got := f.mgr.BlobStats(); got != base {
+		t.Fatalf("dropping the staged bundle should release it: %+v, want %+v", got, base)
+	}
+}
+
+func TestPreview_InternReleases(t *testing.T) {
+	t.Parallel()
+	f, _, _, hashNew := previewFixture(t)
+	p := NewPreview(&PreviewOptions{Loader: f.loader, Validation: &ValidationOptions{MinFiles: 1}, Interner: f.mgr})
+	base := f.mgr.BlobStats()
+
+	if _, err := p.Load(t.Context(), "sha384", hashNew); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.mgr.BlobStats(); got.LogicalBytes <= base.LogicalBytes {
+		t.Fatalf("preview should reference its files: %+v, base %+v", got, base)
+	}
+	if !p.Clear() {
+		t.Fatal("Clear should report a loaded candidate")
+	}
+	if got := f.mgr.BlobStats(); got != base {
+		t.Fatalf("Clear should release the candidate: %+v, want %+v", got, base)
+	}
+}
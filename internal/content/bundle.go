@@ -3,6 +3,7 @@ package content
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
 	"crypto/sha256"
@@ -17,6 +18,8 @@ import (
 	"strings"
 	"testing/fstest"
 
+	"github.com/klauspost/compress/zstd"
+
 	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
 	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
 )
@@ -73,6 +76,47 @@ func copyWithHash(dst io.Writer, src io.Reader) (written int64, contentHash stri
 	return written, hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// BundleFormat is the archive format of a content bundle. It is chosen by
+// the signed pointer document, never guessed from the object key or bytes.
+type BundleFormat string
+
+const (
+	FormatTarGz  BundleFormat = "tar.gz"
+	FormatTarZst BundleFormat = "tar.zst"
+	FormatZip    BundleFormat = "zip"
+)
+
+// zstdMaxWindow caps the zstd decoder window so a bundle can't demand an
+// arbitrarily large allocation from its frame header.
+const zstdMaxWindow = 32 << 20
+
+// ParseBundleFormat validates a format name; empty means FormatTarGz.
+func ParseBundleFormat(s string) (BundleFormat, error) {
+	switch f := BundleFormat(s); f {
+	case "":
+		return FormatTarGz, nil
+	case FormatTarGz, FormatTarZst, FormatZip:
+		return f, nil
+	default:
+		return "", xerrors.Newf("unsupported bundle format %q", s)
+	}
+}
+
+// extractBundleToMem extracts an archive in the given format to an in-memory
+// filesystem. Every format enforces the same path, size and file-type rules.
+func extractBundleToMem(data []byte, format BundleFormat) (fs.FS, error) {
+	switch format {
+	case "", FormatTarGz:
+		return extractTarGzToMem(data)
+	case FormatTarZst:
+		return extractTarZstToMem(data)
+	case FormatZip:
+		return extractZipToMem(data)
+	default:
+		return nil, fmt.Errorf("unsupported bundle format %q", format)
+	}
+}
+
 // extractTarGzToMem extracts a .tar.gz file to an in-memory filesystem
 func extractTarGzToMem(data []byte) (fs.FS, error) {
 	gr, err := gzip.NewReader(bytes.NewReader(data))
@@ -80,9 +124,70 @@ func extractTarGzToMem(data []byte) (fs.FS, error) {
 		return nil, fmt.Errorf("open gzip: %w", err)
 	}
 	defer gr.Close()
+	return extractTarToMem(gr)
+}
+
+// extractTarZstToMem extracts a .tar.zst file to an in-memory filesystem
+func extractTarZstToMem(data []byte) (fs.FS, error) {
+	zr, err := zstd.NewReader(bytes.NewReader(data),
+		zstd.WithDecoderConcurrency(1),
+		zstd.WithDecoderMaxWindow(zstdMaxWindow),
+		zstd.WithDecoderMaxMemory(uint64(maxTotalExtract)+zstdMaxWindow),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("open zstd: %w", err)
+	}
+	defer zr.Close()
+	return extractTarToMem(zr)
+}
 
+// cleanArchivePath cleans and validates an archive entry name - same rules
+// as disk extraction. skip is true for entries naming the archive root.
+func cleanArchivePath(name string) (clean string, skip bool, err error) {
+	clean = path.Clean(name)
+	if clean == "." || clean == "" {
+		return "", true, nil
+	}
+	if path.IsAbs(clean) {
+		return "", false, fmt.Errorf("absolute path in archive: %s", name)
+	}
+	if strings.Contains(clean, "..") {
+		return "", false, fmt.Errorf("path traversal in archive: %s", name)
+	}
+	return clean, false, nil
+}
+
+// readArchiveFile reads one entry under the per-file limit and adds it to the
+// running total, failing once either limit is exceeded. declared is the size
+// the archive claims, checked before anything is read.
+func readArchiveFile(r io.Reader, name string, declared int64, totalBytes *int64) ([]byte, error) {
+	if declared > maxSingleFile {
+		return nil, fmt.Errorf("file %s exceeds max size (%d > %d)",
+			name, declared, maxSingleFile)
+	}
+
+	lr := io.LimitReader(r, maxSingleFile+1)
+	content, err := io.ReadAll(lr)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	if int64(len(content)) > maxSingleFile {
+		return nil, fmt.Errorf("file %s exceeds max size after read", name)
+	}
+
+	*totalBytes += int64(len(content))
+	if *totalBytes > maxTotalExtract {
+		return nil, fmt.Errorf("total extracted size exceeds limit (%d bytes, max %d)",
+			*totalBytes, maxTotalExtract)
+	}
+	return content, nil
+}
+
+// extractTarToMem extracts an uncompressed tar stream to an in-memory
+// filesystem
+func extractTarToMem(r io.Reader) (fs.FS, error) {
 	mfs := make(fstest.MapFS)
-	tr := tar.NewReader(gr)
+	tr := tar.NewReader(r)
 
 	var totalBytes int64
 
@@ -95,16 +200,12 @@ func extractTarGzToMem(data []byte) (fs.FS, error) {
 			return nil, fmt.Errorf("read tar header: %w", err)
 		}
 
-		// clean and validate the path - same rules as disk extraction
-		cleanName := path.Clean(hdr.Name)
-		if cleanName == "." || cleanName == "" {
-			continue
-		}
-		if path.IsAbs(cleanName) {
-			return nil, fmt.Errorf("absolute path in archive: %s", hdr.Name)
+		cleanName, skip, err := cleanArchivePath(hdr.Name)
+		if err != nil {
+			return nil, err
 		}
-		if strings.Contains(cleanName, "..") {
-			return nil, fmt.Errorf("path traversal in archive: %s", hdr.Name)
+		if skip {
+			continue
 		}
 
 		switch hdr.Typeflag {
@@ -117,24 +218,9 @@ func extractTarGzToMem(data []byte) (fs.FS, error) {
 			continue
 
 		case tar.TypeReg:
-			if hdr.Size > maxSingleFile {
-				return nil, fmt.Errorf("file %s exceeds max size (%d > %d)",
-					cleanName, hdr.Size, maxSingleFile)
-			}
-
-			lr := io.LimitReader(tr, maxSingleFile+1)
-			content, err := io.ReadAll(lr)
+			content, err := readArchiveFile(tr, cleanName, hdr.Size, &totalBytes)
 			if err != nil {
-				return nil, fmt.Errorf("read %s: %w", cleanName, err)
-			}
-			if int64(len(content)) > maxSingleFile {
-				return nil, fmt.Errorf("file %s exceeds max size after read", cleanName)
-			}
-
-			totalBytes += int64(len(content))
-			if totalBytes > maxTotalExtract {
-				return nil, fmt.Errorf("total extracted size exceeds limit (%d bytes, max %d)",
-					totalBytes, maxTotalExtract)
+				return nil, err
 			}
 
 			mfs[cleanName] = &fstest.MapFile{
@@ -152,6 +238,62 @@ func extractTarGzToMem(data []byte) (fs.FS, error) {
 	return mfs, nil
 }
 
+// extractZipToMem extracts a .zip file to an in-memory filesystem. Entry
+// checksums are verified as each file is read.
+func extractZipToMem(data []byte) (fs.FS, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open zip: %w", err)
+	}
+
+	mfs := make(fstest.MapFS)
+	var totalBytes int64
+
+	for _, zf := range zr.File {
+		cleanName, skip, err := cleanArchivePath(zf.Name)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			continue
+		}
+
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			// directories are implicit in MapFS - skip
+			continue
+
+		case mode.IsRegular():
+			declared := int64(maxSingleFile + 1)
+			if zf.UncompressedSize64 <= uint64(maxSingleFile) {
+				declared = int64(zf.UncompressedSize64)
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				return nil, fmt.Errorf("open %s: %w", cleanName, err)
+			}
+			content, err := readArchiveFile(rc, cleanName, declared, &totalBytes)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+
+			mfs[cleanName] = &fstest.MapFile{
+				Data: content,
+				// all files are read-only in-mem fs, same as tar extraction
+				Mode: 0o600,
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported file type in archive: %s (mode=%s)",
+				cleanName, mode.Type())
+		}
+	}
+
+	return mfs, nil
+}
+
 // ComputeFileHash computes SHA256 of a file. Only called by ValidateBundle()
 // which is used for validating local files, never accepts user input.
 func ComputeFileHash(filepath string) (string, error) {
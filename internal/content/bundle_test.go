@@ -2,16 +2,20 @@ package content
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"compress/gzip"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"strings"
 	"testing"
 	"testing/fstest"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 // helpers
@@ -699,6 +703,234 @@ func buildSeedArchiveWithLabel() []byte {
 	return buf.Bytes()
 }
 
+// zstd and zip bundles
+
+// makeTarZst builds a .tar.zst archive in memory from the given entries.
+func makeTarZst(t *testing.T, entries map[string]string) []byte {
+	t.Helper()
+	return recompressZstd(t, makeTarGz(t, entries))
+}
+
+// recompressZstd converts a .tar.gz archive to .tar.zst.
+func recompressZstd(t testing.TB, tarGz []byte) []byte {
+	t.Helper()
+	gr, err := gzip.NewReader(bytes.NewReader(tarGz))
+	if err != nil {
+		t.Fatalf("open gzip: %v", err)
+	}
+	raw, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("read gzip: %v", err)
+	}
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd writer: %v", err)
+	}
+	defer zw.Close()
+	return zw.EncodeAll(raw, nil)
+}
+
+// makeZip builds a .zip archive in memory; each header's content is written
+// after it. A nil content writes the header alone.
+func makeZip(t testing.TB, headers []*zip.FileHeader, contents [][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i, h := range headers {
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatalf("zip header %q: %v", h.Name, err)
+		}
+		if contents[i] != nil {
+			if _, err := w.Write(contents[i]); err != nil {
+				t.Fatalf("zip write %q: %v", h.Name, err)
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zipFiles(t testing.TB, entries map[string]string) []byte {
+	t.Helper()
+	var headers []*zip.FileHeader
+	var contents [][]byte
+	for name, body := range entries {
+		headers = append(headers, &zip.FileHeader{Name: name, Method: zip.Deflate})
+		contents = append(contents, []byte(body))
+	}
+	return makeZip(t, headers, contents)
+}
+
+func TestParseBundleFormat(t *testing.T) {
+	for in, want := range map[string]BundleFormat{"": FormatTarGz, "tar.gz": FormatTarGz, "tar.zst": FormatTarZst, "zip": FormatZip} {
+		if got, err := ParseBundleFormat(in); err != nil || got != want {
+			t.Errorf("ParseBundleFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"tar", "tgz", "TAR.GZ", "rar"} {
+		if _, err := ParseBundleFormat(bad); err == nil {
+			t.Errorf("ParseBundleFormat(%q): expected error", bad)
+		}
+	}
+}
+
+func TestExtractBundleToMem_Formats(t *testing.T) {
+	entries := map[string]string{"index.html": "<html>hi</html>", "assets/app.css": "body{}"}
+	for _, tt := range []struct {
+		format BundleFormat
+		data   []byte
+	}{
+		{FormatTarGz, makeTarGz(t, entries)},
+		{"", makeTarGz(t, entries)},
+		{FormatTarZst, makeTarZst(t, entries)},
+		{FormatZip, zipFiles(t, entries)},
+	} {
+		t.Run(string(tt.format), func(t *testing.T) {
+			fsys, err := extractBundleToMem(tt.data, tt.format)
+			if err != nil {
+				t.Fatalf("extract: %v", err)
+			}
+			for name, want := range entries {
+				if got := readFileFromFS(t, fsys, name); got != want {
+					t.Fatalf("%s = %q, want %q", name, got, want)
+				}
+			}
+			if m := fsys.(fstest.MapFS)["index.html"].Mode; m != 0o600 {
+				t.Fatalf("mode = %v, want 0600", m)
+			}
+		})
+	}
+
+	if _, err := extractBundleToMem(makeTarGz(t, entries), "rar"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+	// the format is never sniffed: a zip named as tar.zst fails
+	if _, err := extractBundleToMem(zipFiles(t, entries), FormatTarZst); err == nil {
+		t.Fatal("expected error for mismatched format")
+	}
+}
+
+func TestExtractTarZstToMem_RejectsPathTraversal(t *testing.T) {
+	_, err := extractTarZstToMem(makeTarZst(t, map[string]string{"../../etc/passwd": "x"}))
+	if err == nil || !strings.Contains(err.Error(), "path traversal") {
+		t.Fatalf("expected path traversal error, got %v", err)
+	}
+}
+
+func TestExtractTarZstToMem_RejectsSymlink(t *testing.T) {
+	_, err := extractTarZstToMem(recompressZstd(t, makeTarGzWithType(t, "link", tar.TypeSymlink)))
+	if err == nil || !strings.Contains(err.Error(), "unsupported file type") {
+		t.Fatalf("expected unsupported file type error, got %v", err)
+	}
+}
+
+func TestExtractTarZstToMem_Invalid(t *testing.T) {
+	if _, err := extractTarZstToMem([]byte("not zstd")); err == nil {
+		t.Fatal("expected error for invalid zstd")
+	}
+}
+
+func TestExtractTarZstToMem_OversizedFile(t *testing.T) {
+	big := strings.Repeat("\x00", int(maxSingleFile)+1)
+	_, err := extractTarZstToMem(makeTarZst(t, map[string]string{"bomb.bin": big}))
+	if err == nil || !strings.Contains(err.Error(), "exceeds max size") {
+		t.Fatalf("expected 'exceeds max size' error, got %v", err)
+	}
+}
+
+func TestExtractZipToMem_DirectorySkipped(t *testing.T) {
+	data := makeZip(t,
+		[]*zip.FileHeader{{Name: "mydir/"}, {Name: "mydir/file.txt"}},
+		[][]byte{nil, []byte("inside dir")},
+	)
+	fsys, err := extractZipToMem(data)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if got := readFileFromFS(t, fsys, "mydir/file.txt"); got != "inside dir" {
+		t.Fatalf("file = %q", got)
+	}
+	if _, ok := fsys.(fstest.MapFS)["mydir"]; ok {
+		t.Fatal("directory entries should be implicit")
+	}
+}
+
+func TestExtractZipToMem_RejectsSymlink(t *testing.T) {
+	h := &zip.FileHeader{Name: "link"}
+	h.SetMode(fs.ModeSymlink | 0o777)
+	_, err := extractZipToMem(makeZip(t, []*zip.FileHeader{h}, [][]byte{[]byte("/etc/passwd")}))
+	if err == nil || !strings.Contains(err.Error(), "unsupported file type") {
+		t.Fatalf("expected unsupported file type error, got %v", err)
+	}
+}
+
+func TestExtractZipToMem_RejectsPaths(t *testing.T) {
+	for _, name := range []string{"../escape.txt", "a/../../escape.txt", "/etc/passwd"} {
+		if _, err := extractZipToMem(zipFiles(t, map[string]string{name: "x"})); err == nil {
+			t.Errorf("%q: expected error", name)
+		}
+	}
+}
+
+func TestExtractZipToMem_Invalid(t *testing.T) {
+	if _, err := extractZipToMem([]byte("not a zip")); err == nil {
+		t.Fatal("expected error for invalid zip")
+	}
+}
+
+func TestExtractZipToMem_OversizedFile(t *testing.T) {
+	big := strings.Repeat("\x00", int(maxSingleFile)+1)
+	_, err := extractZipToMem(zipFiles(t, map[string]string{"bomb.bin": big}))
+	if err == nil || !strings.Contains(err.Error(), "exceeds max size") {
+		t.Fatalf("expected 'exceeds max size' error, got %v", err)
+	}
+}
+
+func TestExtractZipToMem_TotalSizeLimit(t *testing.T) {
+	fileSize := int64(1 * 1024 * 1024)
+	numFiles := int(maxTotalExtract/fileSize) + 1
+	content := bytes.Repeat([]byte("x"), int(fileSize))
+
+	headers := make([]*zip.FileHeader, numFiles)
+	contents := make([][]byte, numFiles)
+	for i := range numFiles {
+		headers[i] = &zip.FileHeader{Name: fmt.Sprintf("file_%d.bin", i), Method: zip.Deflate}
+		contents[i] = content
+	}
+	_, err := extractZipToMem(makeZip(t, headers, contents))
+	if err == nil || !strings.Contains(err.Error(), "total extracted size exceeds limit") {
+		t.Fatalf("expected total size error, got %v", err)
+	}
+}
+
+func FuzzExtractTarZstToMem(f *testing.F) {
+	f.Add(recompressZstd(f, buildSeedArchive()))
+	f.Add(recompressZstd(f, buildSeedArchiveWithDir()))
+	f.Add(recompressZstd(f, buildSeedArchiveWithLabel()))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// We don't care if it errors - we care that it doesn't panic or hang.
+		_, _ = extractTarZstToMem(data)
+	})
+}
+
+func FuzzExtractZipToMem(f *testing.F) {
+	f.Add(zipFiles(f, map[string]string{
+		"index.html":       "<html>hello</html>",
+		"assets/style.css": "body { color: red; }",
+		"a/b/c/deep.txt":   "deep content",
+	}))
+	f.Add(makeZip(f, []*zip.FileHeader{{Name: "mydir/"}, {Name: "mydir/file.txt"}}, [][]byte{nil, []byte("inside dir")}))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// We don't care if it errors - we care that it doesn't panic or hang.
+		_, _ = extractZipToMem(data)
+	})
+}
+
 // ComputeFileHash
 
 func TestComputeFileHash_Basic(t *testing.T) {
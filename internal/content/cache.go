@@ -0,0 +1,280 @@
+// internal/content/cache.go
+//
+// Without a cache, an instance that boots while S3 or SSM is unreachable can
+// only serve the embedded seed. The bundle cache keeps the raw bytes of the
+// last verified bundles and both of their sigstore bundles on local disk.
+// Nothing on disk is trusted: at startup the active entry is re-verified
+// (hash, KMS and keyless signatures) exactly as a download would be before it
+// is served.
+package content
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// ErrCacheEmpty is returned by BundleCache.Active when no bundle has been
+// marked active yet.
+var ErrCacheEmpty = errors.New("content cache is empty")
+
+const (
+	// cacheActiveFile names the entry to serve at startup.
+	cacheActiveFile = "active.json"
+
+	// cacheKeepInactive is how many verified but not yet active entries are
+	// kept, so a staged or previewed bundle is still cached when it goes live.
+	cacheKeepInactive = 2
+
+	cacheKMSFile     = "kms.bundle.sigstore.json"
+	cacheKeylessFile = "keyless.bundle.sigstore.json"
+)
+
+// CachedBundle is a bundle read back from the cache, not yet verified.
+type CachedBundle struct {
+	Algorithm string
+	Hash      string
+	Format    BundleFormat
+
+	Bundle      []byte
+	KMSJSON     []byte
+	KeylessJSON []byte
+
+	// Pointer is the signed pointer the bundle went live under; nil when it
+	// was published as a bare hash.
+	Pointer *PointerDocument
+}
+
+// cacheEntry is the content of active.json.
+type cacheEntry struct {
+	Algorithm string           `json:"algorithm"`
+	Hash      string           `json:"hash"`
+	Format    BundleFormat     `json:"format"`
+	CachedAt  time.Time        `json:"cached_at"`
+	Pointer   *PointerDocument `json:"pointer,omitempty"`
+}
+
+// BundleCache stores verified bundles under a local directory, one entry
+// directory per bundle. Writes go through a temporary name and a rename, so
+// a crash never leaves a half-written entry behind the active marker.
+type BundleCache struct {
+	dir string
+
+	// mu serializes writers: the watcher, staging and preview all Put, and
+	// prune must not mistake an in-flight temporary entry for a leftover
+	mu sync.Mutex
+}
+
+// NewBundleCache creates dir if needed and returns a cache rooted there.
+func NewBundleCache(dir string) (*BundleCache, error) {
+	if dir == "" {
+		return nil, xerrors.New("content cache directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, xerrors.Wrapf(err, "create content cache directory %s", dir)
+	}
+	return &BundleCache{dir: dir}, nil
+}
+
+// entryName returns the directory name for a bundle, rejecting anything that
+// isn't a supported algorithm and a hex digest so it can't escape the cache.
+func entryName(algorithm, hash string) (string, error) {
+	if algorithm != "sha256" && algorithm != "sha384" {
+		return "", xerrors.Newf("content cache: unsupported hash algorithm %q", algorithm)
+	}
+	if hash == "" || strings.Trim(strings.ToLower(hash), "0123456789abcdef") != "" {
+		return "", xerrors.Newf("content cache: invalid hash %q", hash)
+	}
+	return algorithm + "-" + strings.ToLower(hash), nil
+}
+
+// Put stores a verified bundle and its signatures. An entry that already
+// exists is left as is.
+func (c *BundleCache) Put(algorithm, hash string, format BundleFormat, bundle, kmsJSON, keylessJSON []byte) error {
+	name, err := entryName(algorithm, hash)
+	if err != nil {
+		return err
+	}
+	if format, err = ParseBundleFormat(string(format)); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	final := filepath.Join(c.dir, name)
+	if _, err := os.Stat(final); err == nil {
+		return nil
+	}
+
+	tmp, err := os.MkdirTemp(c.dir, ".tmp-"+name+"-")
+	if err != nil {
+		return xerrors.Wrap(err, "content cache: create entry")
+	}
+	defer os.RemoveAll(tmp)
+
+	for file, data := range map[string][]byte{
+		"bundle." + string(format): bundle,
+		cacheKMSFile:               kmsJSON,
+		cacheKeylessFile:           keylessJSON,
+	} {
+		if err := os.WriteFile(filepath.Join(tmp, file), data, 0o600); err != nil {
+			return xerrors.Wrapf(err, "content cache: write %s", file)
+		}
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		return xerrors.Wrap(err, "content cache: publish entry")
+	}
+	return nil
+}
+
+// MarkActive records the cached bundle with the given hash as the one to
+// serve at startup, with the signed pointer it went live under (nil for a
+// bare hash), and prunes entries no longer worth keeping. It fails if the
+// bundle was never Put.
+func (c *BundleCache) MarkActive(hash string, pointer *PointerDocument) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return xerrors.Wrap(err, "content cache: list entries")
+	}
+	var active *cacheEntry
+	for _, e := range entries {
+		algorithm, h, ok := strings.Cut(e.Name(), "-")
+		if !ok || !e.IsDir() || h != strings.ToLower(hash) {
+			continue
+		}
+		format, err := entryFormat(filepath.Join(c.dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		active = &cacheEntry{Algorithm: algorithm, Hash: h, Format: format, CachedAt: time.Now().UTC(), Pointer: pointer}
+		break
+	}
+	if active == nil {
+		return xerrors.Newf("content cache: bundle %s is not cached", truncHash(hash))
+	}
+
+	data, err := json.Marshal(active)
+	if err != nil {
+		return xerrors.Wrap(err, "content cache: encode active entry")
+	}
+	if err := writeFileAtomic(filepath.Join(c.dir, cacheActiveFile), data); err != nil {
+		return err
+	}
+	c.prune(active.Algorithm + "-" + active.Hash)
+	return nil
+}
+
+// Active reads the active entry back. The caller must verify it.
+func (c *BundleCache) Active() (*CachedBundle, error) {
+	raw, err := os.ReadFile(filepath.Join(c.dir, cacheActiveFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCacheEmpty
+	}
+	if err != nil {
+		return nil, xerrors.Wrap(err, "content cache: read active entry")
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, xerrors.Wrap(err, "content cache: decode active entry")
+	}
+	name, err := entryName(e.Algorithm, e.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ParseBundleFormat(string(e.Format)); err != nil {
+		return nil, xerrors.Wrap(err, "content cache")
+	}
+
+	dir := filepath.Join(c.dir, name)
+	cb := &CachedBundle{Algorithm: e.Algorithm, Hash: e.Hash, Format: e.Format, Pointer: e.Pointer}
+	for file, dst := range map[string]*[]byte{
+		"bundle." + string(e.Format): &cb.Bundle,
+		cacheKMSFile:                 &cb.KMSJSON,
+		cacheKeylessFile:             &cb.KeylessJSON,
+	} {
+		limit := maxSigBundleSize
+		if file != cacheKMSFile && file != cacheKeylessFile {
+			limit = maxBundleSize
+		}
+		data, err := readFileLimit(filepath.Join(dir, file), limit)
+		if err != nil {
+			return nil, xerrors.Wrapf(err, "content cache: read %s", file)
+		}
+		*dst = data
+	}
+	return cb, nil
+}
+
+// entryFormat returns the format of the bundle file stored in an entry.
+func entryFormat(dir string) (BundleFormat, error) {
+	for _, f := range []BundleFormat{FormatTarGz, FormatTarZst, FormatZip} {
+		if _, err := os.Stat(filepath.Join(dir, "bundle."+string(f))); err == nil {
+			return f, nil
+		}
+	}
+	return "", xerrors.Newf("content cache: no bundle in %s", filepath.Base(dir))
+}
+
+// prune removes temporary entries and files left by a crashed write, and all
+// but the newest cacheKeepInactive entries besides active. It runs under mu,
+// so no temporary name belongs to a write in progress. Failures are ignored;
+// the next prune tries again.
+func (c *BundleCache) prune(active string) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	type aged struct {
+		name string
+		mod  time.Time
+	}
+	var inactive []aged
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".tmp-") {
+			_ = os.RemoveAll(filepath.Join(c.dir, e.Name()))
+			continue
+		}
+		if !e.IsDir() || e.Name() == active {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		inactive = append(inactive, aged{e.Name(), info.ModTime()})
+	}
+	sort.Slice(inactive, func(i, j int) bool { return inactive[i].mod.After(inactive[j].mod) })
+	for _, e := range inactive[min(len(inactive), cacheKeepInactive):] {
+		_ = os.RemoveAll(filepath.Join(c.dir, e.name))
+	}
+}
+
+// writeFileAtomic replaces path with data via a temporary file and rename.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-")
+	if err != nil {
+		return xerrors.Wrap(err, "content cache: create temp file")
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return xerrors.Wrap(err, "content cache: write temp file")
+	}
+	if err := f.Close(); err != nil {
+		return xerrors.Wrap(err, "content cache: close temp file")
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return xerrors.Wrap(err, "content cache: replace "+filepath.Base(path))
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+)
+
+func newTestCache(t *testing.T) *BundleCache {
+	t.Helper()
+	c, err := NewBundleCache(filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatalf("NewBundleCache: %v", err)
+	}
+	return c
+}
+
+// cachedLoader returns a test loader with a cache and a bundle it has
+// downloaded and marked active.
+func cachedLoader(t *testing.T) (l *Loader, fake *fakeS3, hash string) {
+	t.Helper()
+	fake = newFakeS3()
+	data, hash := buildContentBundle(t)
+	putBundle(fake, hash, data)
+	putSigBundle(fake, hash, []byte(`{"mock":"sig"}`))
+
+	l = newTestLoader(t, fake, ssmWithValue(ssmValue(hash)))
+	l.opts.Cache = newTestCache(t)
+	if err := l.LoadIntoManager(t.Context(), NewManager()); err != nil {
+		t.Fatalf("LoadIntoManager: %v", err)
+	}
+	return l, fake, hash
+}
+
+func TestNewBundleCache_RequiresDir(t *testing.T) {
+	if _, err := NewBundleCache(""); err == nil {
+		t.Fatal("expected error for empty dir")
+	}
+}
+
+func TestBundleCache_RoundTrip(t *testing.T) {
+	c := newTestCache(t)
+	if _, err := c.Active(); !errors.Is(err, ErrCacheEmpty) {
+		t.Fatalf("Active on empty cache = %v, want ErrCacheEmpty", err)
+	}
+
+	if err := c.Put("sha384", "ABC123", FormatTarZst, []byte("bundle"), []byte("kms"), []byte("keyless")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := c.MarkActive("abc123", nil); err != nil {
+		t.Fatalf("MarkActive: %v", err)
+	}
+	cb, err := c.Active()
+	if err != nil {
+		t.Fatalf("Active: %v", err)
+	}
+	if cb.Algorithm != "sha384" || cb.Hash != "abc123" || cb.Format != FormatTarZst {
+		t.Fatalf("entry = %s:%s %s", cb.Algorithm, cb.Hash, cb.Format)
+	}
+	if string(cb.Bundle) != "bundle" || string(cb.KMSJSON) != "kms" || string(cb.KeylessJSON) != "keyless" {
+		t.Fatalf("files = %q %q %q", cb.Bundle, cb.KMSJSON, cb.KeylessJSON)
+	}
+}
+
+func TestBundleCache_PutExistingIsNoop(t *testing.T) {
+	c := newTestCache(t)
+	if err := c.Put("sha256", "aa", FormatTarGz, []byte("first"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Put("sha256", "aa", FormatTarGz, []byte("second"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MarkActive("aa", nil); err != nil {
+		t.Fatal(err)
+	}
+	cb, err := c.Active()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cb.Bundle) != "first" {
+		t.Fatalf("bundle = %q, want the first copy kept", cb.Bundle)
+	}
+}
+
+func TestBundleCache_RejectsBadNames(t *testing.T) {
+	c := newTestCache(t)
+	for _, tc := range []struct{ algorithm, hash string }{
+		{"md5", "abcd"},
+		{"sha384", ""},
+		{"sha384", "../../etc"},
+		{"sha384", "abc/def"},
+	} {
+		if err := c.Put(tc.algorithm, tc.hash, FormatTarGz, []byte("x"), nil, nil); err == nil {
+			t.Errorf("Put(%q, %q) should fail", tc.algorithm, tc.hash)
+		}
+	}
+	if err := c.Put("sha384", "abcd", "rar", []byte("x"), nil, nil); err == nil {
+		t.Error("Put with an unknown format should fail")
+	}
+}
+
+func TestBundleCache_MarkActiveUnknown(t *testing.T) {
+	c := newTestCache(t)
+	err := c.MarkActive("deadbeef", nil)
+	if err == nil || !strings.Contains(err.Error(), "not cached") {
+		t.Fatalf("MarkActive = %v, want not cached", err)
+	}
+	if _, err := c.Active(); !errors.Is(err, ErrCacheEmpty) {
+		t.Fatalf("Active = %v, want ErrCacheEmpty", err)
+	}
+}
+
+func TestBundleCache_Prune(t *testing.T) {
+	c := newTestCache(t)
+	base := time.Now().Add(-time.Hour)
+	for i, h := range []string{"a1", "a2", "a3", "a4", "a5"} {
+		if err := c.Put("sha256", h, FormatTarGz, []byte(h), nil, nil); err != nil {
+			t.Fatal(err)
+		}
+		mod := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(filepath.Join(c.dir, "sha256-"+h), mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(c.dir, ".tmp-sha256-a6-123"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	// the oldest entry is active; the two newest others are kept
+	if err := c.MarkActive("a1", nil); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		if e.IsDir() {
+			got = append(got, e.Name())
+		}
+	}
+	want := "sha256-a1,sha256-a4,sha256-a5"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("entries = %v, want %s", got, want)
+	}
+}
+
+func TestBundleCache_PruneRemovesStaleTempFiles(t *testing.T) {
+	c := newTestCache(t)
+	if err := c.Put("sha256", "a1", FormatTarGz, []byte("a1"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	// left behind by a writeFileAtomic that crashed before its rename
+	stale := filepath.Join(c.dir, ".tmp-active.json-123")
+	if err := os.WriteFile(stale, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MarkActive("a1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(stale); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("stale temp file not removed: %v", err)
+	}
+}
+
+func TestBundleCache_ConcurrentPutAndMarkActive(t *testing.T) {
+	c := newTestCache(t)
+	if err := c.Put("sha256", "aa", FormatTarGz, []byte("aa"), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// staging and preview Put while the watcher marks its bundle active;
+	// prune must never take a temp entry out from under a Put
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			h := fmt.Sprintf("b%02x", i)
+			errs <- c.Put("sha256", h, FormatTarGz, []byte(h), nil, nil)
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- c.MarkActive("aa", nil)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent write: %v", err)
+		}
+	}
+}
+
+func TestLoadHash_PutsIntoCache(t *testing.T) {
+	l, _, hash := cachedLoader(t)
+	cb, err := l.opts.Cache.Active()
+	if err != nil {
+		t.Fatalf("Active: %v", err)
+	}
+	if cb.Hash != hash || cb.Format != FormatTarGz || cryptoutil.SHA384Hex(cb.Bundle) != hash {
+		t.Fatalf("cached %s %s, want %s tar.gz", cb.Hash, cb.Format, hash)
+	}
+	if string(cb.KMSJSON) != `{"mock":"sig"}` || string(cb.KeylessJSON) != `{"mock":"sig"}` {
+		t.Fatal("both sigstore bundles should be cached")
+	}
+}
+
+func TestLoadHash_FailedVerificationNotCached(t *testing.T) {
+	fake := newFakeS3()
+	data, hash := buildContentBundle(t)
+	putBundle(fake, hash, data)
+	putSigBundle(fake, hash, []byte(`{"mock":"sig"}`))
+
+	l := newTestLoaderWithVerifier(t, fake, ssmWithValue(ssmValue(hash)), failVerifier("bad sig"))
+	l.opts.Cache = newTestCache(t)
+	if _, err := l.LoadHash(t.Context(), "sha384", hash); err == nil {
+		t.Fatal("expected verification error")
+	}
+	if err := l.opts.Cache.MarkActive(hash, nil); err == nil {
+		t.Fatal("an unverified bundle must not be cached")
+	}
+}
+
+func TestLoadCached_ServesWithoutS3(t *testing.T) {
+	l, fake, hash := cachedLoader(t)
+	fake.objects = map[string][]byte{}
+
+	snap, err := l.LoadCached(t.Context())
+	if err != nil {
+		t.Fatalf("LoadCached: %v", err)
+	}
+	if snap.Meta.Source != SourceCache {
+		t.Fatalf("Source = %q, want %q", snap.Meta.Source, SourceCache)
+	}
+	if snap.Meta.Hash != hash || snap.Meta.HashAlgorithm != "sha384" {
+		t.Fatalf("Meta = %s:%s, want sha384:%s", snap.Meta.HashAlgorithm, snap.Meta.Hash, hash)
+	}
+	if snap.Meta.VerifiedAt.IsZero() || snap.Meta.Signatures == nil {
+		t.Fatal("cached load should record verification")
+	}
+	data, err := fs.ReadFile(snap.FS, "index.html")
+	if err != nil || !strings.Contains(string(data), "hello") {
+		t.Fatalf("index.html = %q, %v", data, err)
+	}
+}
+
+func TestLoadCached_RejectsTamperedBundle(t *testing.T) {
+	l, _, hash := cachedLoader(t)
+	path := filepath.Join(l.opts.Cache.dir, "sha384-"+hash, "bundle.tar.gz")
+	if err := os.WriteFile(path, makeTarGz(t, map[string]string{"index.html": "evil"}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := l.LoadCached(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("LoadCached = %v, want checksum mismatch", err)
+	}
+}
+
+func TestLoadCached_ReverifiesSignatures(t *testing.T) {
+	l, _, _ := cachedLoader(t)
+	l.opts.KeylessVerifier = failVerifier("certificate identity mismatch")
+	_, err := l.LoadCached(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "keyless signature verification failed") {
+		t.Fatalf("LoadCached = %v, want keyless verification failure", err)
+	}
+
+	l.opts.Verifier = failVerifier("kms key mismatch")
+	_, err = l.LoadCached(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "kms signature verification failed") {
+		t.Fatalf("LoadCached = %v, want kms verification failure", err)
+	}
+}
+
+func TestLoadCached_NoCache(t *testing.T) {
+	l := newTestLoader(t, newFakeS3(), ssmWithValue("sha384:abc"))
+	if _, err := l.LoadCached(t.Context()); err == nil {
+		t.Fatal("expected error without a cache")
+	}
+	if err := l.MarkCached("abc", nil); err != nil {
+		t.Fatalf("MarkCached without a cache = %v, want nil", err)
+	}
+
+	l.opts.Cache = newTestCache(t)
+	if _, err := l.LoadCached(t.Context()); !errors.Is(err, ErrCacheEmpty) {
+		t.Fatalf("LoadCached = %v, want ErrCacheEmpty", err)
+	}
+}
+
+// signedCachedLoader is cachedLoader for a bundle published through a signed
+// pointer created at created.
+func signedCachedLoader(t *testing.T, created time.Time) (l *Loader, fake *fakeS3, hash string) {
+	t.Helper()
+	fake = newFakeS3()
+	data, hash := buildContentBundle(t)
+	putBundle(fake, hash, data)
+	putSigBundle(fake, hash, []byte(`{"mock":"sig"}`))
+
+	l = newTestLoader(t, fake, ssmWithValue(signedPointer(fake, hash, created)))
+	l.opts.Cache = newTestCache(t)
+	if err := l.LoadIntoManager(t.Context(), NewManager()); err != nil {
+		t.Fatalf("LoadIntoManager: %v", err)
+	}
+	return l, fake, hash
+}
+
+func TestLoadCached_RestoresSignedPointer(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	l, fake, _ := signedCachedLoader(t, created)
+	fake.objects = map[string][]byte{}
+
+	snap, err := l.LoadCached(t.Context())
+	if err != nil {
+		t.Fatalf("LoadCached: %v", err)
+	}
+	if snap.Meta.Pointer == nil || !snap.Meta.Pointer.CreatedAt.Equal(created) {
+		t.Fatalf("Meta.Pointer = %+v, want the pointer the bundle went live under", snap.Meta.Pointer)
+	}
+
+	// a watcher started on the cached snapshot keeps the downgrade floor
+	mgr := NewManager()
+	mgr.Set(*snap)
+	w := NewWatcher(&WatcherOptions{Manager: mgr})
+	if !w.pointerFloor.Equal(created) || !w.signedPointerSeen() {
+		t.Fatalf("pointerFloor = %v, want %v", w.pointerFloor, created)
+	}
+}
+
+func TestLoadCached_ReverifiesPointer(t *testing.T) {
+	l, _, _ := signedCachedLoader(t, time.Now().Add(-time.Hour))
+	cb, _ := l.opts.Cache.Active()
+	l.opts.Verifier = &pointerOnlyFailVerifier{doc: cb.Pointer.Document}
+
+	_, err := l.LoadCached(t.Context())
+	if !errors.Is(err, ErrPointerRejected) {
+		t.Fatalf("LoadCached = %v, want ErrPointerRejected", err)
+	}
+}
+
+func TestLoadCached_RejectsExpiredPointer(t *testing.T) {
+	l, _, hash := cachedLoader(t)
+	created := time.Now().Add(-48 * time.Hour)
+	doc := pointerDoc(hash, created, created.Add(time.Hour))
+	pd := &PointerDocument{Document: []byte(doc), KMSJSON: []byte(`{"mock":"sig"}`), KeylessJSON: []byte(`{"mock":"sig"}`)}
+	if err := l.opts.Cache.MarkActive(hash, pd); err != nil {
+		t.Fatalf("MarkActive: %v", err)
+	}
+
+	_, err := l.LoadCached(t.Context())
+	if !errors.Is(err, ErrPointerRejected) || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("LoadCached = %v, want an expired pointer rejection", err)
+	}
+}
+
+func TestLoadCached_RejectsPointerForOtherBundle(t *testing.T) {
+	l, _, hash := cachedLoader(t)
+	created := time.Now().Add(-time.Minute)
+	doc := pointerDoc(strings.Repeat("0", 96), created, created.Add(time.Hour))
+	pd := &PointerDocument{Document: []byte(doc), KMSJSON: []byte(`{"mock":"sig"}`), KeylessJSON: []byte(`{"mock":"sig"}`)}
+	if err := l.opts.Cache.MarkActive(hash, pd); err != nil {
+		t.Fatalf("MarkActive: %v", err)
+	}
+
+	if _, err := l.LoadCached(t.Context()); !errors.Is(err, ErrPointerRejected) {
+		t.Fatalf("LoadCached = %v, want ErrPointerRejected", err)
+	}
+}
+
+func TestLoadCached_RequireSignedPointer(t *testing.T) {
+	l, _, _ := cachedLoader(t)
+	l.opts.RequireSignedPointer = true
+
+	if _, err := l.LoadCached(t.Context()); !errors.Is(err, ErrPointerRejected) {
+		t.Fatalf("LoadCached = %v, want a bare-hash entry rejected", err)
+	}
+}
+
+// pointerOnlyFailVerifier rejects signatures over doc and accepts the rest.
+type pointerOnlyFailVerifier struct{ doc []byte }
+
+func (v *pointerOnlyFailVerifier) VerifyBlob(_ context.Context, _, artifact []byte) error {
+	if bytes.Equal(artifact, v.doc) {
+		return errors.New("pointer signature mismatch")
+	}
+	return nil
+}
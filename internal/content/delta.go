@@ -0,0 +1,309 @@
+// internal/content/delta.go
+//
+// A content update that changes one post still downloads the whole tarball.
+// The delta protocol publishes, beside each bundle, a signed manifest listing
+// every file by SHA-256 and stores the files content-addressed in S3. The
+// loader reuses files the active snapshot already holds and fetches only the
+// rest, verifying every file against the signed manifest before the new
+// snapshot is assembled. Any failure falls back to the full tarball.
+package content
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// DeltaSchema identifies version 1 of the delta manifest.
+const DeltaSchema = "linnemanlabs.content-delta/v1"
+
+const (
+	// maxDeltaManifestSize caps the delta manifest download.
+	maxDeltaManifestSize int64 = 4 * 1024 * 1024
+
+	// deltaFetchConcurrency bounds parallel blob downloads.
+	deltaFetchConcurrency = 8
+)
+
+// DeltaManifest lists the files of one content bundle. It is signed with the
+// same KMS and keyless identities as the bundle itself.
+type DeltaManifest struct {
+	Schema string `json:"schema"`
+
+	// Bundle names the tarball this manifest describes as algo:hex; it must
+	// equal the hash being loaded.
+	Bundle string `json:"bundle"`
+
+	Files []DeltaFile `json:"files"`
+}
+
+// DeltaFile is one file of a delta manifest. Its content is stored at
+// blobs/sha256/{SHA256}.
+type DeltaFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// DeltaBase supplies the snapshot whose files a delta load may reuse;
+// *Manager implements it.
+type DeltaBase interface {
+	Get() (*Snapshot, bool)
+}
+
+// deltaStats summarizes how a delta load was assembled.
+type deltaStats struct {
+	reused, fetched           int
+	reusedBytes, fetchedBytes int64
+}
+
+// ParseDeltaManifest decodes and sanity-checks a delta manifest: schema, the
+// bundle it names, file paths, digests and the extraction limits that apply
+// to tarballs. It does not verify signatures.
+func ParseDeltaManifest(data []byte, algorithm, hash string) (*DeltaManifest, error) {
+	var m DeltaManifest
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, xerrors.Wrap(err, "decode delta manifest")
+	}
+	if m.Schema != DeltaSchema {
+		return nil, xerrors.Newf("delta manifest schema %q not supported", m.Schema)
+	}
+	if m.Bundle != algorithm+":"+hash {
+		return nil, xerrors.Newf("delta manifest describes %s, not %s:%s", m.Bundle, algorithm, hash)
+	}
+	if len(m.Files) == 0 {
+		return nil, xerrors.New("delta manifest lists no files")
+	}
+
+	seen := make(map[string]bool, len(m.Files))
+	var total int64
+	for i := range m.Files {
+		f := &m.Files[i]
+		// same path rules as tarball extraction
+		clean := path.Clean(f.Path)
+		if clean == "." || clean == "" || path.IsAbs(clean) || strings.Contains(clean, "..") {
+			return nil, xerrors.Newf("delta manifest: invalid path %q", f.Path)
+		}
+		if seen[clean] {
+			return nil, xerrors.Newf("delta manifest lists %q more than once", f.Path)
+		}
+		seen[clean] = true
+		f.Path = clean
+
+		f.SHA256 = strings.ToLower(f.SHA256)
+		if len(f.SHA256) != 64 || strings.Trim(f.SHA256, "0123456789abcdef") != "" {
+			return nil, xerrors.Newf("delta manifest: %s has invalid sha256", f.Path)
+		}
+		if f.Size < 0 || f.Size > maxSingleFile {
+			return nil, xerrors.Newf("delta manifest: %s size %d outside 0..%d", f.Path, f.Size, maxSingleFile)
+		}
+		total += f.Size
+		if total > maxTotalExtract {
+			return nil, xerrors.Newf("delta manifest: total size exceeds limit (max %d)", maxTotalExtract)
+		}
+	}
+	return &m, nil
+}
+
+// deltaKey returns the S3 key of a bundle's delta manifest.
+func (l *Loader) deltaKey(algorithm, hash string) string {
+	return strings.TrimSuffix(l.s3Key(algorithm, hash), ".tar.gz") + ".delta.json"
+}
+
+// blobKey returns the S3 key of a content-addressed file.
+func (l *Loader) blobKey(sha256 string) string {
+	if l.opts.S3Prefix != "" {
+		return fmt.Sprintf("%s/blobs/sha256/%s", l.opts.S3Prefix, sha256)
+	}
+	return "blobs/sha256/" + sha256
+}
+
+// loadDelta assembles the bundle from its signed delta manifest, reusing
+// files from base and fetching the rest.
+func (l *Loader) loadDelta(ctx context.Context, algorithm, hash string, base *Snapshot) (*Snapshot, error) {
+	loadedAt := time.Now().UTC()
+
+	key := l.deltaKey(algorithm, hash)
+	doc, err := l.fetchS3(ctx, key, maxDeltaManifestSize)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "fetch delta manifest")
+	}
+	kmsBundleJSON, err := l.fetchS3(ctx, key+kmsBundleSuffix, maxSigBundleSize)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "fetch delta manifest kms sigstore bundle")
+	}
+	if err := l.opts.Verifier.VerifyBlob(ctx, kmsBundleJSON, doc); err != nil {
+		return nil, xerrors.Wrap(err, "delta manifest kms signature verification failed")
+	}
+	keylessBundleJSON, err := l.fetchS3(ctx, key+keylessBundleSuffix, maxSigBundleSize)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "fetch delta manifest keyless sigstore bundle")
+	}
+	if err := l.opts.KeylessVerifier.VerifyBlob(ctx, keylessBundleJSON, doc); err != nil {
+		return nil, xerrors.Wrap(err, "delta manifest keyless signature verification failed")
+	}
+
+	manifest, err := ParseDeltaManifest(doc, algorithm, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	contentFS, stats, err := l.assembleDelta(ctx, manifest, base)
+	if err != nil {
+		return nil, err
+	}
+
+	l.logger.Info(ctx, "assembled content bundle from delta",
+		"hash", hash,
+		"files_reused", stats.reused,
+		"files_fetched", stats.fetched,
+		"bytes_reused", stats.reusedBytes,
+		"bytes_fetched", stats.fetchedBytes,
+	)
+
+	snap := newSnapshot(ctx, l.logger, contentFS, Meta{
+		Hash:          hash,
+		HashAlgorithm: algorithm,
+		Source:        SourceS3,
+		VerifiedAt:    time.Now().UTC(),
+		Signatures:    signaturesInfo(ctx, l.logger, hash, kmsBundleJSON, keylessBundleJSON),
+	}, loadedAt)
+	l.inlineProvenance(ctx, snap)
+	return snap, nil
+}
+
+// assembleDelta builds the MapFS for manifest. Every file, reused or fetched,
+// is hashed and checked against the manifest before it is added.
+func (l *Loader) assembleDelta(ctx context.Context, manifest *DeltaManifest, base *Snapshot) (fstest.MapFS, deltaStats, error) {
+	have := reusableFiles(base)
+
+	var (
+		stats deltaStats
+		mfs   = make(fstest.MapFS, len(manifest.Files))
+		fetch []DeltaFile
+	)
+	for _, f := range manifest.Files {
+		data, ok := have[f.SHA256]
+		if !ok || !deltaFileMatches(f, data) {
+			fetch = append(fetch, f)
+			continue
+		}
+		mfs[f.Path] = &fstest.MapFile{Data: data, Mode: 0o600}
+		stats.reused++
+		stats.reusedBytes += f.Size
+	}
+
+	fetched, err := l.fetchBlobs(ctx, fetch)
+	if err != nil {
+		return nil, stats, err
+	}
+	for i, f := range fetch {
+		mfs[f.Path] = &fstest.MapFile{Data: fetched[i], Mode: 0o600}
+		stats.fetched++
+		stats.fetchedBytes += f.Size
+	}
+	return mfs, stats, nil
+}
+
+// fetchBlobs downloads and verifies files concurrently, returning their
+// contents in the order given. The first failure cancels the rest.
+func (l *Loader) fetchBlobs(ctx context.Context, files []DeltaFile) ([][]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	out := make([][]byte, len(files))
+	sem := make(chan struct{}, deltaFetchConcurrency)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i, f := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			data, err := l.fetchS3(ctx, l.blobKey(f.SHA256), f.Size)
+			if err == nil && !deltaFileMatches(f, data) {
+				err = xerrors.Newf("blob for %s does not match the delta manifest", f.Path)
+			}
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = xerrors.Wrapf(err, "fetch %s", f.Path)
+					cancel()
+				})
+				return
+			}
+			out[i] = data
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Wrap(err, "fetch delta blobs")
+	}
+	return out, nil
+}
+
+// deltaFileMatches reports whether data is exactly the file f describes.
+func deltaFileMatches(f DeltaFile, data []byte) bool {
+	return int64(len(data)) == f.Size && cryptoutil.HashEqual(cryptoutil.SHA256Hex(data), f.SHA256)
+}
+
+// reusableFiles indexes base's files by SHA-256. Files rewritten by island
+// injection are left out: their served bytes no longer match any manifest.
+func reusableFiles(base *Snapshot) map[string][]byte {
+	if base == nil || base.FS == nil {
+		return nil
+	}
+	out := make(map[string][]byte)
+	add := func(name string, data []byte) {
+		if _, rewritten := base.Augmented[name]; rewritten {
+			return
+		}
+		sum := base.Digests[name].SHA256
+		if sum == "" {
+			sum = cryptoutil.SHA256Hex(data)
+		}
+		out[sum] = data
+	}
+
+	// share the MapFS slices directly rather than copying them out
+	if mfs, ok := base.FS.(fstest.MapFS); ok {
+		for name, f := range mfs {
+			if f != nil && !f.Mode.IsDir() {
+				add(name, f.Data)
+			}
+		}
+		return out
+	}
+	_ = fs.WalkDir(base.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil //nolint:nilerr // best-effort reuse
+		}
+		if data, err := fs.ReadFile(base.FS, name); err == nil {
+			add(name, data)
+		}
+		return nil
+	})
+	return out
+}
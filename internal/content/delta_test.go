@@ -0,0 +1,295 @@
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+)
+
+// recordingS3 records every key fetched through it.
+type recordingS3 struct {
+	*fakeS3
+	mu   sync.Mutex
+	keys []string
+}
+
+func (r *recordingS3) GetObject(ctx context.Context, in *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	r.mu.Lock()
+	r.keys = append(r.keys, aws.ToString(in.Key))
+	r.mu.Unlock()
+	return r.fakeS3.GetObject(ctx, in, opts...)
+}
+
+func (r *recordingS3) fetched(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *recordingS3) reset() {
+	r.mu.Lock()
+	r.keys = nil
+	r.mu.Unlock()
+}
+
+const deltaFont = "FONT-BYTES-THAT-NEVER-CHANGE"
+
+// deltaFixture seeds the manager with a bundle and enables delta loads
+// against it, recording S3 fetches from then on.
+func deltaFixture(t *testing.T) (f *watcherFixture, rec *recordingS3) {
+	t.Helper()
+	f = newWatcherFixture(t, "")
+	hashOld := publishDelta(t, f, map[string]string{
+		"index.html":       "<html>v1</html>",
+		"fonts/site.woff2": deltaFont,
+	})
+	snap, err := f.loader.LoadHash(t.Context(), "sha384", hashOld)
+	if err != nil {
+		t.Fatalf("seed LoadHash: %v", err)
+	}
+	f.mgr.Set(*snap)
+
+	rec = &recordingS3{fakeS3: f.s3}
+	f.loader.s3Client = rec
+	f.loader.opts.DeltaBase = f.mgr
+	return f, rec
+}
+
+// publishDelta stores a bundle as both a tarball and a signed delta manifest
+// with its content-addressed blobs.
+func publishDelta(t *testing.T, f *watcherFixture, files map[string]string) string {
+	t.Helper()
+	hash := storeBundle(t, f, files)
+	m := DeltaManifest{Schema: DeltaSchema, Bundle: "sha384:" + hash}
+	for name, body := range files {
+		sum := cryptoutil.SHA256Hex([]byte(body))
+		m.Files = append(m.Files, DeltaFile{Path: name, SHA256: sum, Size: int64(len(body))})
+		f.s3.put(f.loader.blobKey(sum), []byte(body))
+	}
+	putDeltaManifest(t, f, hash, m)
+	return hash
+}
+
+func putDeltaManifest(t *testing.T, f *watcherFixture, hash string, m DeltaManifest) {
+	t.Helper()
+	doc, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := f.loader.deltaKey("sha384", hash)
+	f.s3.put(key, doc)
+	f.s3.put(key+kmsBundleSuffix, []byte(`{"mock":"sig"}`))
+	f.s3.put(key+keylessBundleSuffix, []byte(`{"mock":"sig"}`))
+}
+
+func readSnapFile(t *testing.T, snap *Snapshot, name string) string {
+	t.Helper()
+	data, err := fs.ReadFile(snap.FS, name)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func TestLoadHash_Delta_ReusesUnchangedFiles(t *testing.T) {
+	t.Parallel()
+	f, rec := deltaFixture(t)
+	hashNew := publishDelta(t, f, map[string]string{
+		"index.html":       "<html>v2</html>",
+		"fonts/site.woff2": deltaFont,
+	})
+
+	snap, err := f.loader.LoadHash(t.Context(), "sha384", hashNew)
+	if err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if got := readSnapFile(t, snap, "index.html"); got != "<html>v2</html>" {
+		t.Fatalf("index.html = %q", got)
+	}
+	if got := readSnapFile(t, snap, "fonts/site.woff2"); got != deltaFont {
+		t.Fatalf("font = %q", got)
+	}
+	if snap.Meta.Hash != hashNew || snap.Meta.Source != SourceS3 || snap.Meta.Signatures == nil {
+		t.Fatalf("Meta = %+v", snap.Meta)
+	}
+
+	if rec.fetched(f.loader.s3Key("sha384", hashNew)) {
+		t.Fatal("delta load should not download the tarball")
+	}
+	if rec.fetched(f.loader.blobKey(cryptoutil.SHA256Hex([]byte(deltaFont)))) {
+		t.Fatal("unchanged font should be reused, not fetched")
+	}
+	if !rec.fetched(f.loader.blobKey(cryptoutil.SHA256Hex([]byte("<html>v2</html>")))) {
+		t.Fatal("changed index.html should be fetched")
+	}
+
+	// reused files share the active snapshot's bytes
+	active, _ := f.mgr.Get()
+	got := snap.FS.(fstest.MapFS)["fonts/site.woff2"].Data
+	if &got[0] != &active.FS.(fstest.MapFS)["fonts/site.woff2"].Data[0] {
+		t.Fatal("reused file should share the active snapshot's data")
+	}
+}
+
+func TestLoadHash_Delta_FallsBackWithoutManifest(t *testing.T) {
+	t.Parallel()
+	f, rec := deltaFixture(t)
+	hashNew := storeBundle(t, f, map[string]string{"index.html": "<html>tarball only</html>"})
+
+	snap, err := f.loader.LoadHash(t.Context(), "sha384", hashNew)
+	if err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if got := readSnapFile(t, snap, "index.html"); got != "<html>tarball only</html>" {
+		t.Fatalf("index.html = %q", got)
+	}
+	if !rec.fetched(f.loader.s3Key("sha384", hashNew)) {
+		t.Fatal("missing manifest should fall back to the tarball")
+	}
+}
+
+func TestLoadHash_Delta_TamperedBlobFallsBack(t *testing.T) {
+	t.Parallel()
+	f, rec := deltaFixture(t)
+	body := "<html>v2</html>"
+	hashNew := publishDelta(t, f, map[string]string{"index.html": body, "fonts/site.woff2": deltaFont})
+	f.s3.put(f.loader.blobKey(cryptoutil.SHA256Hex([]byte(body))), []byte("<html>EVIL</html>"))
+
+	snap, err := f.loader.LoadHash(t.Context(), "sha384", hashNew)
+	if err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if got := readSnapFile(t, snap, "index.html"); got != body {
+		t.Fatalf("index.html = %q, want the tarball's content", got)
+	}
+	if !rec.fetched(f.loader.s3Key("sha384", hashNew)) {
+		t.Fatal("tampered blob should fall back to the tarball")
+	}
+
+	// without a tarball to fall back to, the load fails rather than serving it
+	hashGone := publishDelta(t, f, map[string]string{"index.html": "<html>v3</html>"})
+	f.s3.put(f.loader.blobKey(cryptoutil.SHA256Hex([]byte("<html>v3</html>"))), []byte("<html>v4</html>"))
+	f.s3.failOn(f.loader.s3Key("sha384", hashGone), fmt.Errorf("NoSuchKey"))
+	if _, err := f.loader.LoadHash(t.Context(), "sha384", hashGone); err == nil {
+		t.Fatal("expected error when the delta is tampered and no tarball exists")
+	}
+}
+
+func TestLoadHash_Delta_ManifestForOtherBundleFallsBack(t *testing.T) {
+	t.Parallel()
+	f, rec := deltaFixture(t)
+	hashNew := storeBundle(t, f, map[string]string{"index.html": "<html>real</html>"})
+	other := publishDelta(t, f, map[string]string{"index.html": "<html>other</html>"})
+	// a validly signed manifest copied to another bundle's key
+	doc := f.s3.objects[f.loader.deltaKey("sha384", other)]
+	f.s3.put(f.loader.deltaKey("sha384", hashNew), doc)
+	f.s3.put(f.loader.deltaKey("sha384", hashNew)+kmsBundleSuffix, []byte(`{"mock":"sig"}`))
+	f.s3.put(f.loader.deltaKey("sha384", hashNew)+keylessBundleSuffix, []byte(`{"mock":"sig"}`))
+	rec.reset()
+
+	snap, err := f.loader.LoadHash(t.Context(), "sha384", hashNew)
+	if err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if got := readSnapFile(t, snap, "index.html"); got != "<html>real</html>" {
+		t.Fatalf("index.html = %q", got)
+	}
+	if !rec.fetched(f.loader.s3Key("sha384", hashNew)) {
+		t.Fatal("mismatched manifest should fall back to the tarball")
+	}
+}
+
+func TestLoadHash_Delta_SignatureFailureFallsBack(t *testing.T) {
+	t.Parallel()
+	f, rec := deltaFixture(t)
+	hashNew := publishDelta(t, f, map[string]string{"index.html": "<html>v2</html>"})
+	delete(f.s3.objects, f.loader.deltaKey("sha384", hashNew)+keylessBundleSuffix)
+
+	if _, err := f.loader.LoadHash(t.Context(), "sha384", hashNew); err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if !rec.fetched(f.loader.s3Key("sha384", hashNew)) {
+		t.Fatal("unsigned manifest should fall back to the tarball")
+	}
+}
+
+func TestParseDeltaManifest(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	valid := func() DeltaManifest {
+		return DeltaManifest{Schema: DeltaSchema, Bundle: "sha384:h", Files: []DeltaFile{{Path: "./a/index.html", SHA256: strings.ToUpper(sum), Size: 3}}}
+	}
+	encode := func(m DeltaManifest) []byte {
+		b, _ := json.Marshal(m)
+		return b
+	}
+
+	m, err := ParseDeltaManifest(encode(valid()), "sha384", "h")
+	if err != nil {
+		t.Fatalf("valid manifest: %v", err)
+	}
+	if m.Files[0].Path != "a/index.html" || m.Files[0].SHA256 != sum {
+		t.Fatalf("normalized file = %+v", m.Files[0])
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*DeltaManifest)
+		raw    string
+	}{
+		{name: "schema", mutate: func(m *DeltaManifest) { m.Schema = "v0" }},
+		{name: "other bundle", mutate: func(m *DeltaManifest) { m.Bundle = "sha384:x" }},
+		{name: "no files", mutate: func(m *DeltaManifest) { m.Files = nil }},
+		{name: "traversal", mutate: func(m *DeltaManifest) { m.Files[0].Path = "../etc/passwd" }},
+		{name: "absolute", mutate: func(m *DeltaManifest) { m.Files[0].Path = "/index.html" }},
+		{name: "duplicate", mutate: func(m *DeltaManifest) { m.Files = append(m.Files, DeltaFile{Path: "a/index.html", SHA256: sum}) }},
+		{name: "bad digest", mutate: func(m *DeltaManifest) { m.Files[0].SHA256 = "zz" }},
+		{name: "oversized file", mutate: func(m *DeltaManifest) { m.Files[0].Size = maxSingleFile + 1 }},
+		{name: "negative size", mutate: func(m *DeltaManifest) { m.Files[0].Size = -1 }},
+		{name: "unknown field", raw: `{"schema":"` + DeltaSchema + `","bundle":"sha384:h","files":[],"extra":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(tt.raw)
+			if tt.mutate != nil {
+				m := valid()
+				tt.mutate(&m)
+				data = encode(m)
+			}
+			if _, err := ParseDeltaManifest(data, "sha384", "h"); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestReusableFiles_SkipsAugmented(t *testing.T) {
+	base := &Snapshot{
+		FS: fstest.MapFS{
+			"index.html": {Data: []byte("<html>islands</html>")},
+			"app.js":     {Data: []byte("js")},
+		},
+		Augmented: map[string]AugmentedFile{"index.html": {}},
+	}
+	got := reusableFiles(base)
+	if len(got) != 1 || string(got[cryptoutil.SHA256Hex([]byte("js"))]) != "js" {
+		t.Fatalf("reusableFiles = %v, want only app.js", got)
+	}
+	if reusableFiles(nil) != nil {
+		t.Fatal("nil base should have nothing to reuse")
+	}
+}
@@ -0,0 +1,68 @@
+// internal/content/digest.go
+//
+// Bundle files are held in memory with no usable modification time, so the
+// site handler validates cached copies by content hash instead. The index is
+// built from the bytes actually served, which differ from release.json for
+// files rewritten by island injection.
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"time"
+)
+
+// FileDigest identifies the served bytes of one snapshot file.
+type FileDigest struct {
+	// SHA256 is the hex digest of the served (identity) bytes.
+	SHA256 string
+
+	// Modified is the file's modification time from release.json. Zero when
+	// the manifest doesn't list the file or the server rewrote it.
+	Modified time.Time
+}
+
+// Digests maps snapshot file paths to their digests.
+type Digests map[string]FileDigest
+
+// Get returns the digest for name. Safe on a nil map.
+func (d Digests) Get(name string) (FileDigest, bool) {
+	fd, ok := d[name]
+	return fd, ok
+}
+
+// DigestFiles hashes every file in fsys and takes modification times from
+// prov. Files listed in augmented were rewritten after the manifest was
+// produced, so their manifest timestamp no longer describes the served bytes
+// and is left zero. Unreadable files are omitted.
+func DigestFiles(fsys fs.FS, prov *Provenance, augmented map[string]AugmentedFile) Digests {
+	if fsys == nil {
+		return nil
+	}
+	modified := make(map[string]time.Time)
+	if prov != nil {
+		for _, f := range prov.Files {
+			modified[manifestPath(f.Path)] = f.Modified
+		}
+	}
+
+	out := make(Digests)
+	_ = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil //nolint:nilerr // best-effort
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil //nolint:nilerr // best-effort
+		}
+		sum := sha256.Sum256(data)
+		fd := FileDigest{SHA256: hex.EncodeToString(sum[:])}
+		if _, rewritten := augmented[name]; !rewritten {
+			fd.Modified = modified[name]
+		}
+		out[name] = fd
+		return nil
+	})
+	return out
+}
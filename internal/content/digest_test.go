@@ -0,0 +1,66 @@
+package content
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestDigestFiles(t *testing.T) {
+	mod := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"index.html":       &fstest.MapFile{Data: []byte("<html>injected</html>")},
+		"css/site.css":     &fstest.MapFile{Data: []byte("body{}")},
+		"img/unlisted.png": &fstest.MapFile{Data: []byte("png")},
+	}
+	prov := &Provenance{Files: []ProvenanceFile{
+		{Path: "./index.html", SHA256: "original", Modified: mod},
+		{Path: "css/site.css", SHA256: "ignored", Modified: mod},
+	}}
+	augmented := map[string]AugmentedFile{"index.html": {SHA256: "x"}}
+
+	d := DigestFiles(fsys, prov, augmented)
+	if len(d) != 3 {
+		t.Fatalf("len = %d, want 3", len(d))
+	}
+
+	css, _ := d.Get("css/site.css")
+	if css.SHA256 != sha256hex([]byte("body{}")) {
+		t.Fatalf("css sha = %s, want hash of served bytes", css.SHA256)
+	}
+	if !css.Modified.Equal(mod) {
+		t.Fatalf("css modified = %v, want %v", css.Modified, mod)
+	}
+
+	idx, _ := d.Get("index.html")
+	if idx.SHA256 != sha256hex([]byte("<html>injected</html>")) {
+		t.Fatalf("index sha = %s, want hash of rewritten bytes", idx.SHA256)
+	}
+	if !idx.Modified.IsZero() {
+		t.Fatalf("rewritten file kept manifest mtime %v", idx.Modified)
+	}
+
+	png, ok := d.Get("img/unlisted.png")
+	if !ok || !png.Modified.IsZero() {
+		t.Fatalf("unlisted = %+v, %v", png, ok)
+	}
+}
+
+func TestDigests_NilSafe(t *testing.T) {
+	if DigestFiles(nil, nil, nil) != nil {
+		t.Fatal("nil FS should give nil digests")
+	}
+	var d Digests
+	if _, ok := d.Get("index.html"); ok {
+		t.Fatal("nil Digests Get should miss")
+	}
+}
+
+func TestManager_Set_BuildsDigests(t *testing.T) {
+	m := NewManager()
+	m.Set(Snapshot{FS: fstest.MapFS{"index.html": &fstest.MapFile{Data: []byte("hi")}}})
+	snap, _ := m.Get()
+	if fd, ok := snap.Digests.Get("index.html"); !ok || fd.SHA256 != sha256hex([]byte("hi")) {
+		t.Fatalf("digest = %+v, %v", fd, ok)
+	}
+}
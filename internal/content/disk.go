@@ -0,0 +1,401 @@
+// internal/content/disk.go
+//
+// DiskFetcher is a BundleFetcher backed by the local filesystem. It serves
+// either an unpacked site directory or a .tar.gz content bundle, with a sidecar
+// pointer file standing in for the SSM parameter. It lets the full Watcher ->
+// Manager -> sitehandler pipeline run on laptops and in air-gapped CI without
+// AWS credentials.
+package content
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing/fstest"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+const (
+	// DefaultPointerSuffix is appended to DiskFetcherOptions.Path to locate the
+	// pointer file when DiskFetcherOptions.PointerFile is empty.
+	DefaultPointerSuffix = ".hash"
+
+	// diskHashAlgorithm is used when the fetcher derives the hash itself
+	// because no pointer file exists.
+	diskHashAlgorithm = "sha256"
+
+	// sigstore sidecar suffixes, matching the S3 layout used by Loader.
+	kmsBundleSuffix     = ".kms.bundle.sigstore.json"
+	keylessBundleSuffix = ".keyless.bundle.sigstore.json"
+)
+
+// DiskFetcherOptions configures a DiskFetcher.
+type DiskFetcherOptions struct {
+	Logger log.Logger
+
+	// Path is either a directory containing an unpacked site tree or a
+	// .tar.gz content bundle in the same format published to S3.
+	Path string
+
+	// PointerFile holds the current bundle hash as algo:hex, the same format
+	// as the SSM parameter. Empty defaults to Path + DefaultPointerSuffix, and
+	// a missing default pointer makes the fetcher hash Path itself so edits
+	// are picked up by the Watcher without maintaining a pointer. An
+	// explicitly configured pointer file must exist.
+	PointerFile string
+
+	// Verifier verifies the .kms.bundle.sigstore.json sidecar next to a
+	// tarball when it exists. Optional: without it a present KMS sidecar is
+	// skipped with a warning.
+	Verifier BlobVerifier
+
+	// KeylessVerifier verifies the .keyless.bundle.sigstore.json sidecar next
+	// to a tarball when it exists. Optional, as for Verifier.
+	KeylessVerifier BlobVerifier
+
+	// RequireSignatures makes both sidecars mandatory, mirroring the S3
+	// loader's dual-signature policy. Directories cannot carry signatures and
+	// fail to load when this is set. Requires both verifiers.
+	RequireSignatures bool
+
+	// Inliner fills provenance data islands, as LoaderOptions.Inliner.
+	Inliner ProvenanceInliner
+}
+
+// DiskFetcher loads content bundles from the local filesystem.
+type DiskFetcher struct {
+	opts    DiskFetcherOptions
+	logger  log.Logger
+	pointer string
+}
+
+// NewDiskFetcher creates a DiskFetcher with the given options.
+func NewDiskFetcher(opts *DiskFetcherOptions) (*DiskFetcher, error) {
+	if opts.Path == "" {
+		return nil, xerrors.New("content: Path is required")
+	}
+	if opts.RequireSignatures && (opts.Verifier == nil || opts.KeylessVerifier == nil) {
+		return nil, xerrors.New("content: RequireSignatures needs both Verifier and KeylessVerifier")
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.Nop()
+	}
+
+	path := filepath.Clean(opts.Path)
+	pointer := opts.PointerFile
+	if pointer == "" {
+		pointer = path + DefaultPointerSuffix
+	}
+
+	o := *opts
+	o.Path = path
+	return &DiskFetcher{
+		opts:    o,
+		logger:  opts.Logger,
+		pointer: pointer,
+	}, nil
+}
+
+// FetchCurrentBundleHash reads the expected hash from the pointer file, or
+// derives it from Path when the default pointer file does not exist.
+func (f *DiskFetcher) FetchCurrentBundleHash(ctx context.Context) (hashAlgorithm, contentHash string, err error) {
+	raw, err := readFileLimit(f.pointer, maxSigBundleSize)
+	switch {
+	case err == nil:
+		return parsePointer(f.pointer, raw)
+	case errors.Is(err, fs.ErrNotExist) && f.opts.PointerFile == "":
+		hash, err := f.digest(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		return diskHashAlgorithm, hash, nil
+	default:
+		return "", "", xerrors.Wrapf(err, "read pointer file %s", f.pointer)
+	}
+}
+
+// parsePointer parses an algo:hex pointer file body.
+func parsePointer(name string, raw []byte) (algorithm, hash string, err error) {
+	s := strings.TrimSpace(string(raw))
+	if s == "" {
+		return "", "", xerrors.Newf("pointer file %s is empty", name)
+	}
+	algorithm, hash, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", "", xerrors.Newf("pointer file %s missing algorithm prefix (expected algo:hex)", name)
+	}
+	return algorithm, hash, nil
+}
+
+// digest computes the hash of Path without a pointer file: the file SHA-256
+// for a tarball, or the tree digest for a directory.
+func (f *DiskFetcher) digest(ctx context.Context) (string, error) {
+	isDir, err := f.isDir()
+	if err != nil {
+		return "", err
+	}
+	if !isDir {
+		hash, err := ComputeFileHash(f.opts.Path)
+		if err != nil {
+			return "", xerrors.Wrapf(err, "hash %s", f.opts.Path)
+		}
+		return hash, nil
+	}
+
+	mfs, err := readDirToMem(f.opts.Path)
+	if err != nil {
+		return "", xerrors.Wrapf(err, "read content directory %s", f.opts.Path)
+	}
+	f.logger.Debug(ctx, "computed content directory digest", "path", f.opts.Path, "files", len(mfs))
+	return treeDigest(mfs), nil
+}
+
+// isDir reports whether Path is a directory.
+func (f *DiskFetcher) isDir() (bool, error) {
+	info, err := os.Stat(f.opts.Path)
+	if err != nil {
+		return false, xerrors.Wrapf(err, "stat content path %s", f.opts.Path)
+	}
+	return info.IsDir(), nil
+}
+
+// Load reads the current bundle and returns a Snapshot.
+func (f *DiskFetcher) Load(ctx context.Context) (*Snapshot, error) {
+	algorithm, hash, err := f.FetchCurrentBundleHash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f.LoadHash(ctx, algorithm, hash)
+}
+
+// LoadHash reads Path, verifies it against the expected hash and any sigstore
+// sidecars, and returns a Snapshot. The content is copied into memory, so
+// later edits on disk never affect a published snapshot.
+func (f *DiskFetcher) LoadHash(ctx context.Context, algorithm, hash string) (*Snapshot, error) {
+	loadedAt := time.Now().UTC()
+
+	isDir, err := f.isDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		contentFS  fs.FS
+		signatures *cryptoutil.SignaturesInfo
+		verifiedAt time.Time
+	)
+	if isDir {
+		contentFS, err = f.loadDir(ctx, algorithm, hash)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		contentFS, signatures, err = f.loadTarball(ctx, algorithm, hash)
+		if err != nil {
+			return nil, err
+		}
+		if signatures != nil {
+			verifiedAt = time.Now().UTC()
+		}
+	}
+
+	snap := newSnapshot(ctx, f.logger, contentFS, Meta{
+		Hash:          hash,
+		HashAlgorithm: algorithm,
+		Source:        SourceDisk,
+		VerifiedAt:    verifiedAt,
+		Signatures:    signatures,
+	}, loadedAt)
+
+	inlineProvenance(ctx, f.logger, f.opts.Inliner, snap)
+
+	return snap, nil
+}
+
+// loadTarball reads and verifies a .tar.gz bundle and extracts it to memory.
+// signatures is nil when no sidecar was verified.
+func (f *DiskFetcher) loadTarball(ctx context.Context, algorithm, hash string) (fs.FS, *cryptoutil.SignaturesInfo, error) {
+	fh, err := os.Open(f.opts.Path)
+	if err != nil {
+		return nil, nil, xerrors.Wrapf(err, "open content bundle %s", f.opts.Path)
+	}
+	defer fh.Close()
+
+	data, actualHash, err := readWithHash(fh, maxBundleSize, algorithm)
+	if err != nil {
+		return nil, nil, xerrors.Wrap(err, "read content bundle")
+	}
+	if !cryptoutil.HashEqual(actualHash, hash) {
+		return nil, nil, xerrors.Newf("checksum mismatch: expected %s, got %s", hash, actualHash)
+	}
+
+	kmsBundleJSON, err := f.verifySidecar(ctx, kmsBundleSuffix, "kms", f.opts.Verifier, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	keylessBundleJSON, err := f.verifySidecar(ctx, keylessBundleSuffix, "keyless", f.opts.KeylessVerifier, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var signatures *cryptoutil.SignaturesInfo
+	if kmsBundleJSON != nil || keylessBundleJSON != nil {
+		signatures = signaturesInfo(ctx, f.logger, hash, kmsBundleJSON, keylessBundleJSON)
+	}
+
+	contentFS, err := extractTarGzToMem(data)
+	if err != nil {
+		return nil, nil, xerrors.Wrap(err, "extract bundle")
+	}
+
+	f.logger.Info(ctx, "loaded content bundle from disk",
+		"path", f.opts.Path,
+		"bytes", len(data),
+		"hash", hash,
+		"signed", signatures != nil,
+	)
+	return contentFS, signatures, nil
+}
+
+// verifySidecar verifies the sigstore bundle at Path+suffix against data and
+// returns its JSON, or nil when the sidecar is absent or cannot be checked and
+// signatures are not required.
+func (f *DiskFetcher) verifySidecar(ctx context.Context, suffix, kind string, v BlobVerifier, data []byte) ([]byte, error) {
+	name := f.opts.Path + suffix
+	bundleJSON, err := readFileLimit(name, maxSigBundleSize)
+	if errors.Is(err, fs.ErrNotExist) {
+		if f.opts.RequireSignatures {
+			return nil, xerrors.Newf("%s sigstore bundle %s is required", kind, name)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Wrapf(err, "read %s sigstore bundle", kind)
+	}
+	if v == nil {
+		f.logger.Warn(ctx, "sigstore sidecar present but no verifier configured, skipping", "kind", kind, "path", name)
+		return nil, nil
+	}
+	if err := v.VerifyBlob(ctx, bundleJSON, data); err != nil {
+		return nil, xerrors.Wrapf(err, "content bundle %s signature verification failed", kind)
+	}
+	return bundleJSON, nil
+}
+
+// loadDir copies a site directory into memory and checks its tree digest.
+func (f *DiskFetcher) loadDir(ctx context.Context, algorithm, hash string) (fs.FS, error) {
+	if f.opts.RequireSignatures {
+		return nil, xerrors.Newf("content directory %s cannot be signature-verified; use a signed .tar.gz", f.opts.Path)
+	}
+	if algorithm != diskHashAlgorithm {
+		return nil, xerrors.Newf("unsupported hash algorithm for content directory: %s", algorithm)
+	}
+
+	mfs, err := readDirToMem(f.opts.Path)
+	if err != nil {
+		return nil, xerrors.Wrapf(err, "read content directory %s", f.opts.Path)
+	}
+	if actual := treeDigest(mfs); !cryptoutil.HashEqual(actual, hash) {
+		return nil, xerrors.Newf("checksum mismatch: expected %s, got %s", hash, actual)
+	}
+
+	f.logger.Info(ctx, "loaded content directory from disk",
+		"path", f.opts.Path,
+		"files", len(mfs),
+		"hash", hash,
+	)
+	return mfs, nil
+}
+
+// LoadIntoManager reads the current bundle and updates the content manager.
+func (f *DiskFetcher) LoadIntoManager(ctx context.Context, mgr *Manager) error {
+	snap, err := f.Load(ctx)
+	if err != nil {
+		return err
+	}
+	mgr.Set(*snap)
+	return nil
+}
+
+// readFileLimit reads a whole file, failing if it exceeds maxSize.
+func readFileLimit(name string, maxSize int64) ([]byte, error) {
+	fh, err := os.Open(name) //nolint:gosec // G304: operator-configured content path
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	data, err := io.ReadAll(io.LimitReader(fh, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%s exceeds max size (%d bytes)", name, maxSize)
+	}
+	return data, nil
+}
+
+// readDirToMem copies a directory tree into an in-memory filesystem under the
+// same limits as extractTarGzToMem. Only regular files are accepted; symlinks
+// and other special files are rejected rather than followed.
+func readDirToMem(root string) (fstest.MapFS, error) {
+	mfs := make(fstest.MapFS)
+	var totalBytes int64
+
+	err := fs.WalkDir(os.DirFS(root), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("unsupported file type in content directory: %s (mode=%s)", name, d.Type())
+		}
+
+		data, err := readFileLimit(filepath.Join(root, filepath.FromSlash(name)), maxSingleFile)
+		if err != nil {
+			return err
+		}
+		totalBytes += int64(len(data))
+		if totalBytes > maxTotalExtract {
+			return fmt.Errorf("total content size exceeds limit (%d bytes, max %d)", totalBytes, maxTotalExtract)
+		}
+
+		mfs[name] = &fstest.MapFile{Data: data, Mode: 0o600}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mfs, nil
+}
+
+// treeDigest returns a SHA-256 over the sorted path and per-file SHA-256 of
+// every file in mfs, so any added, removed, renamed or edited file changes it.
+func treeDigest(mfs fstest.MapFS) string {
+	names := make([]string, 0, len(mfs))
+	for name := range mfs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		sum := sha256.Sum256(mfs[name].Data)
+		fmt.Fprintf(h, "%s\x00%s\n", name, hex.EncodeToString(sum[:]))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,380 @@
+package content
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+)
+
+// writeSiteDir writes files into a fresh temp directory and returns its path.
+func writeSiteDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// writeTarball writes a tar.gz bundle (and optional sidecars) into a temp dir
+// and returns the bundle path and its SHA-384.
+func writeTarball(t *testing.T, files map[string]string, sidecars bool) (bundlePath, hash string) {
+	t.Helper()
+	data := makeTarGz(t, files)
+	bundlePath = filepath.Join(t.TempDir(), "site.tar.gz")
+	if err := os.WriteFile(bundlePath, data, 0o644); err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+	if sidecars {
+		for _, suffix := range []string{kmsBundleSuffix, keylessBundleSuffix} {
+			if err := os.WriteFile(bundlePath+suffix, []byte(`{"mock":"sig"}`), 0o644); err != nil {
+				t.Fatalf("write sidecar: %v", err)
+			}
+		}
+	}
+	return bundlePath, cryptoutil.SHA384Hex(data)
+}
+
+func writePointer(t *testing.T, path, value string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(value+"\n"), 0o644); err != nil {
+		t.Fatalf("write pointer: %v", err)
+	}
+}
+
+func newTestDiskFetcher(t *testing.T, opts DiskFetcherOptions) *DiskFetcher {
+	t.Helper()
+	opts.Logger = log.Nop()
+	f, err := NewDiskFetcher(&opts)
+	if err != nil {
+		t.Fatalf("NewDiskFetcher: %v", err)
+	}
+	return f
+}
+
+// NewDiskFetcher
+
+func TestNewDiskFetcher_RequiresPath(t *testing.T) {
+	if _, err := NewDiskFetcher(&DiskFetcherOptions{}); err == nil {
+		t.Fatal("expected error for empty Path")
+	}
+}
+
+func TestNewDiskFetcher_RequireSignaturesNeedsVerifiers(t *testing.T) {
+	_, err := NewDiskFetcher(&DiskFetcherOptions{
+		Path:              "site.tar.gz",
+		Verifier:          passVerifier(),
+		RequireSignatures: true,
+	})
+	if err == nil {
+		t.Fatal("expected error when KeylessVerifier is missing")
+	}
+}
+
+func TestNewDiskFetcher_DefaultPointer(t *testing.T) {
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: "/srv/site/"})
+	if want := "/srv/site" + DefaultPointerSuffix; f.pointer != want {
+		t.Fatalf("pointer = %q, want %q", f.pointer, want)
+	}
+}
+
+// FetchCurrentBundleHash
+
+func TestDiskFetcher_FetchCurrentBundleHash_Pointer(t *testing.T) {
+	bundlePath, hash := writeTarball(t, map[string]string{"index.html": "hi"}, false)
+	writePointer(t, bundlePath+DefaultPointerSuffix, "sha384:"+hash)
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: bundlePath})
+	algo, got, err := f.FetchCurrentBundleHash(t.Context())
+	if err != nil {
+		t.Fatalf("FetchCurrentBundleHash: %v", err)
+	}
+	if algo != "sha384" || got != hash {
+		t.Fatalf("got %s:%s, want sha384:%s", algo, got, hash)
+	}
+}
+
+func TestDiskFetcher_FetchCurrentBundleHash_PointerMissingPrefix(t *testing.T) {
+	bundlePath, hash := writeTarball(t, map[string]string{"index.html": "hi"}, false)
+	writePointer(t, bundlePath+DefaultPointerSuffix, hash)
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: bundlePath})
+	if _, _, err := f.FetchCurrentBundleHash(t.Context()); err == nil {
+		t.Fatal("expected error for pointer without algorithm prefix")
+	}
+}
+
+func TestDiskFetcher_FetchCurrentBundleHash_ExplicitPointerMissing(t *testing.T) {
+	bundlePath, _ := writeTarball(t, map[string]string{"index.html": "hi"}, false)
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{
+		Path:        bundlePath,
+		PointerFile: filepath.Join(t.TempDir(), "missing"),
+	})
+	if _, _, err := f.FetchCurrentBundleHash(t.Context()); err == nil {
+		t.Fatal("expected error for missing explicit pointer file")
+	}
+}
+
+func TestDiskFetcher_FetchCurrentBundleHash_DerivesTarballHash(t *testing.T) {
+	bundlePath, _ := writeTarball(t, map[string]string{"index.html": "hi"}, false)
+	data, err := os.ReadFile(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: bundlePath})
+	algo, got, err := f.FetchCurrentBundleHash(t.Context())
+	if err != nil {
+		t.Fatalf("FetchCurrentBundleHash: %v", err)
+	}
+	if algo != "sha256" || got != sha256hex(data) {
+		t.Fatalf("got %s:%s, want sha256:%s", algo, got, sha256hex(data))
+	}
+}
+
+func TestDiskFetcher_FetchCurrentBundleHash_DirectoryChangesOnEdit(t *testing.T) {
+	dir := writeSiteDir(t, map[string]string{"index.html": "v1"})
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: dir})
+
+	_, h1, err := f.FetchCurrentBundleHash(t.Context())
+	if err != nil {
+		t.Fatalf("FetchCurrentBundleHash: %v", err)
+	}
+	_, again, _ := f.FetchCurrentBundleHash(t.Context())
+	if h1 != again {
+		t.Fatal("directory digest is not stable")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, h2, _ := f.FetchCurrentBundleHash(t.Context())
+	if h1 == h2 {
+		t.Fatal("directory digest did not change after edit")
+	}
+}
+
+// LoadHash - tarball
+
+func TestDiskFetcher_LoadHash_Tarball(t *testing.T) {
+	bundlePath, hash := writeTarball(t, map[string]string{
+		"index.html":   "<html>hello</html>",
+		"release.json": `{"version":"2.0.0"}`,
+	}, false)
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: bundlePath})
+	snap, err := f.LoadHash(t.Context(), "sha384", hash)
+	if err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if snap.Meta.Source != SourceDisk {
+		t.Fatalf("Source = %q, want %q", snap.Meta.Source, SourceDisk)
+	}
+	if snap.Meta.Version != "2.0.0" {
+		t.Fatalf("Version = %q, want 2.0.0", snap.Meta.Version)
+	}
+	if snap.Meta.Signatures != nil || !snap.Meta.VerifiedAt.IsZero() {
+		t.Fatal("unsigned tarball should not report signatures")
+	}
+	if got := readFileFromFS(t, snap.FS, "index.html"); got != "<html>hello</html>" {
+		t.Fatalf("index.html = %q", got)
+	}
+}
+
+func TestDiskFetcher_LoadHash_ChecksumMismatch(t *testing.T) {
+	bundlePath, _ := writeTarball(t, map[string]string{"index.html": "hi"}, false)
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: bundlePath})
+	_, err := f.LoadHash(t.Context(), "sha384", strings.Repeat("0", 96))
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestDiskFetcher_LoadHash_VerifiesSidecars(t *testing.T) {
+	bundlePath, hash := writeTarball(t, map[string]string{"index.html": "hi"}, true)
+	kms, keyless := passVerifier(), passVerifier()
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: bundlePath, Verifier: kms, KeylessVerifier: keyless})
+	snap, err := f.LoadHash(t.Context(), "sha384", hash)
+	if err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if kms.gotBundle == nil || keyless.gotBundle == nil {
+		t.Fatal("expected both sidecars to be verified")
+	}
+	if snap.Meta.Signatures == nil || snap.Meta.VerifiedAt.IsZero() {
+		t.Fatal("expected signatures and VerifiedAt on a signed tarball")
+	}
+}
+
+func TestDiskFetcher_LoadHash_SidecarVerificationFails(t *testing.T) {
+	bundlePath, hash := writeTarball(t, map[string]string{"index.html": "hi"}, true)
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{
+		Path:            bundlePath,
+		Verifier:        passVerifier(),
+		KeylessVerifier: failVerifier("bad keyless signature"),
+	})
+	_, err := f.LoadHash(t.Context(), "sha384", hash)
+	if err == nil || !strings.Contains(err.Error(), "keyless signature verification failed") {
+		t.Fatalf("expected keyless verification failure, got %v", err)
+	}
+}
+
+func TestDiskFetcher_LoadHash_SidecarWithoutVerifierSkipped(t *testing.T) {
+	bundlePath, hash := writeTarball(t, map[string]string{"index.html": "hi"}, true)
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: bundlePath})
+	snap, err := f.LoadHash(t.Context(), "sha384", hash)
+	if err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if snap.Meta.Signatures != nil {
+		t.Fatal("unverified sidecars must not be reported as signatures")
+	}
+}
+
+func TestDiskFetcher_LoadHash_RequireSignaturesMissingSidecar(t *testing.T) {
+	bundlePath, hash := writeTarball(t, map[string]string{"index.html": "hi"}, false)
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{
+		Path:              bundlePath,
+		Verifier:          passVerifier(),
+		KeylessVerifier:   passVerifier(),
+		RequireSignatures: true,
+	})
+	if _, err := f.LoadHash(t.Context(), "sha384", hash); err == nil {
+		t.Fatal("expected error for missing required sidecar")
+	}
+}
+
+// LoadHash - directory
+
+func TestDiskFetcher_LoadHash_Directory(t *testing.T) {
+	dir := writeSiteDir(t, map[string]string{
+		"index.html":       "<html>home</html>",
+		"posts/first.html": "<html>first</html>",
+	})
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: dir})
+
+	algo, hash, err := f.FetchCurrentBundleHash(t.Context())
+	if err != nil {
+		t.Fatalf("FetchCurrentBundleHash: %v", err)
+	}
+	snap, err := f.LoadHash(t.Context(), algo, hash)
+	if err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if snap.Meta.Source != SourceDisk || snap.Meta.Hash != hash {
+		t.Fatalf("meta = %+v", snap.Meta)
+	}
+	if got := readFileFromFS(t, snap.FS, "posts/first.html"); got != "<html>first</html>" {
+		t.Fatalf("posts/first.html = %q", got)
+	}
+
+	// the snapshot is a copy: later edits on disk must not leak into it
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFileFromFS(t, snap.FS, "index.html"); got != "<html>home</html>" {
+		t.Fatalf("snapshot changed after disk edit: %q", got)
+	}
+}
+
+func TestDiskFetcher_LoadHash_DirectoryChecksumMismatch(t *testing.T) {
+	dir := writeSiteDir(t, map[string]string{"index.html": "hi"})
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: dir})
+
+	if _, err := f.LoadHash(t.Context(), "sha256", strings.Repeat("0", 64)); err == nil {
+		t.Fatal("expected checksum mismatch")
+	}
+}
+
+func TestDiskFetcher_LoadHash_DirectoryRejectsSymlink(t *testing.T) {
+	dir := writeSiteDir(t, map[string]string{"index.html": "hi"})
+	if err := os.Symlink("/etc/passwd", filepath.Join(dir, "passwd")); err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+
+	_, err := readDirToMem(dir)
+	if err == nil || !strings.Contains(err.Error(), "unsupported file type") {
+		t.Fatalf("expected symlink rejection, got %v", err)
+	}
+}
+
+func TestDiskFetcher_LoadHash_DirectoryRequireSignatures(t *testing.T) {
+	dir := writeSiteDir(t, map[string]string{"index.html": "hi"})
+	f := newTestDiskFetcher(t, DiskFetcherOptions{
+		Path:              dir,
+		Verifier:          passVerifier(),
+		KeylessVerifier:   passVerifier(),
+		RequireSignatures: true,
+	})
+	mfs, _ := readDirToMem(dir)
+	if _, err := f.LoadHash(t.Context(), "sha256", treeDigest(mfs)); err == nil {
+		t.Fatal("expected error: directories cannot be signed")
+	}
+}
+
+func TestDiskFetcher_LoadHash_MissingPath(t *testing.T) {
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: filepath.Join(t.TempDir(), "nope")})
+	_, err := f.LoadHash(t.Context(), "sha256", "abc")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+// Watcher integration
+
+func TestDiskFetcher_WatcherSwapsOnPointerChange(t *testing.T) {
+	bundleV1, hashV1 := writeTarball(t, map[string]string{"index.html": "v1"}, false)
+	pointer := filepath.Join(t.TempDir(), "current")
+	writePointer(t, pointer, "sha384:"+hashV1)
+
+	f := newTestDiskFetcher(t, DiskFetcherOptions{Path: bundleV1, PointerFile: pointer})
+	mgr := NewManager()
+	if err := f.LoadIntoManager(t.Context(), mgr); err != nil {
+		t.Fatalf("LoadIntoManager: %v", err)
+	}
+
+	w := NewWatcher(&WatcherOptions{
+		Loader:     f,
+		Manager:    mgr,
+		Validation: &ValidationOptions{MinFiles: 1},
+	})
+	if got := w.checkOnce(t.Context()); got != pollNoChange {
+		t.Fatalf("result = %d, want pollNoChange", got)
+	}
+
+	// replace the bundle in place and repoint
+	data := makeTarGz(t, map[string]string{"index.html": "v2"})
+	if err := os.WriteFile(bundleV1, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	writePointer(t, pointer, "sha384:"+cryptoutil.SHA384Hex(data))
+
+	if got := w.checkOnce(t.Context()); got != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", got)
+	}
+	snap, _ := mgr.Get()
+	if got := readFileFromFS(t, snap.FS, "index.html"); got != "v2" {
+		t.Fatalf("index.html = %q, want v2", got)
+	}
+	if mgr.Source() != SourceDisk {
+		t.Fatalf("Source = %q, want %q", mgr.Source(), SourceDisk)
+	}
+}
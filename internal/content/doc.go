@@ -5,6 +5,9 @@
 //
 // The core components are:
 //   - [Loader]: downloads and verifies content bundles from S3/SSM
+//   - [DiskFetcher]: loads a local directory or tarball for dev and air-gapped use
+//   - [TUFFetcher]: resolves bundles through signed TUF metadata with rollback and freeze protection
+//   - [OCIFetcher]: pulls bundles and their sigstore signatures from an OCI registry by tag or digest
 //   - [Manager]: stores the active content snapshot using atomic.Pointer for lock-free reads
 //   - [Watcher]: polls SSM for hash changes and hot-swaps bundles into the Manager
 //   - [Snapshot]: an immutable in-memory filesystem with metadata and provenance
@@ -18,6 +18,9 @@ import (
 	"sort"
 	"strings"
 	"testing/fstest"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
 )
 
 // ProvenanceInliner builds the JSON payloads embedded into a freshly loaded
@@ -25,11 +28,12 @@ import (
 // the provenancehttp layer, which already imports content) and injected via
 // LoaderOptions.Inliner; defining the port here keeps content free of an import
 // cycle. Both methods are called at most once per bundle load, on the load
-// goroutine, before the snapshot is published to the Manager.
+// goroutine, before the snapshot is published to the Manager, and again by
+// Manager.RerenderIslands when the build evidence behind them is refreshed.
 type ProvenanceInliner interface {
 	// AppDataIsland returns the JSON to embed in the app provenance island. It
-	// mirrors GET /api/provenance/app and is stable for the life of the process
-	// (derived from build evidence, not the bundle).
+	// mirrors GET /api/provenance/app and is derived from build evidence, not
+	// the bundle, so it only changes when that evidence is refreshed.
 	AppDataIsland(ctx context.Context) ([]byte, error)
 
 	// ContentDataIsland returns the JSON to embed in the content provenance
@@ -63,45 +67,69 @@ type islandInjectionCounts struct {
 // until Manager.Set stores it in the atomic pointer, so mutating it here is
 // race-free; after publish the serve path treats it as read-only.
 //
-// Provenance note: the two augmented pages intentionally no longer match the
-// per-file SHA-256 recorded in the bundle's release.json. The bundle-level
-// tarball signature is verified before extraction and is unaffected.
+// Provenance note: the augmented pages intentionally no longer match the
+// per-file SHA-256 recorded in the bundle's release.json. Their original and
+// served digests are kept in Snapshot.Augmented so manifest verification and
+// /api/provenance/content can reconcile them. The bundle-level tarball
+// signature is verified before extraction and is unaffected.
 func (l *Loader) inlineProvenance(ctx context.Context, snap *Snapshot) {
-	if l.inliner == nil {
+	inlineProvenance(ctx, l.logger, l.inliner, snap)
+}
+
+// inlineProvenance is the fetcher-agnostic body of Loader.inlineProvenance,
+// shared by every BundleFetcher that produces MapFS snapshots.
+func inlineProvenance(ctx context.Context, logger log.Logger, inliner ProvenanceInliner, snap *Snapshot) {
+	if inliner == nil {
 		return
 	}
 
 	mfs, ok := snap.FS.(fstest.MapFS)
 	if !ok {
-		l.logger.Warn(ctx, "content FS is not a mutable MapFS; skipping provenance island injection")
+		logger.Warn(ctx, "content FS is not a mutable MapFS; skipping provenance island injection")
 		return
 	}
 
-	contentJSON, err := l.inliner.ContentDataIsland(ctx, snap)
+	contentJSON, err := inliner.ContentDataIsland(ctx, snap)
 	if err != nil {
-		l.logger.Warn(ctx, "build content provenance island failed; leaving sentinel unreplaced", "error", err)
+		logger.Warn(ctx, "build content provenance island failed; leaving sentinel unreplaced", "error", err)
 		contentJSON = nil
 	}
-	appJSON, err := l.inliner.AppDataIsland(ctx)
+	appJSON, err := inliner.AppDataIsland(ctx)
 	if err != nil {
-		l.logger.Warn(ctx, "build app provenance island failed; leaving sentinel unreplaced", "error", err)
+		logger.Warn(ctx, "build app provenance island failed; leaving sentinel unreplaced", "error", err)
 		appJSON = nil
 	}
 	if contentJSON == nil && appJSON == nil {
 		return
 	}
 
-	counts, modified := injectDataIslands(mfs, contentJSON, appJSON)
+	// injection replaces file data rather than editing it, so these slices
+	// stay the unmodified pages
+	before := make(map[string][]byte)
+	for name, f := range mfs {
+		if f != nil && isHTMLFile(name) {
+			before[name] = f.Data
+		}
+	}
+
+	counts, modified, augmented := injectDataIslands(mfs, contentJSON, appJSON)
+	if len(augmented) > 0 {
+		snap.Augmented = augmented
+		snap.islandSources = make(map[string][]byte, len(augmented))
+		for name := range augmented {
+			snap.islandSources[name] = before[name]
+		}
+	}
 
 	// A configured island whose sentinel appears in no page
 	if contentJSON != nil && counts.content == 0 {
-		l.logger.Warn(ctx, "content provenance island sentinel not found in any page", "island_id", contentDataIslandID)
+		logger.Warn(ctx, "content provenance island sentinel not found in any page", "island_id", contentDataIslandID)
 	}
 	if appJSON != nil && counts.app == 0 {
-		l.logger.Warn(ctx, "app provenance island sentinel not found in any page", "island_id", appDataIslandID)
+		logger.Warn(ctx, "app provenance island sentinel not found in any page", "island_id", appDataIslandID)
 	}
 	if len(modified) > 0 {
-		l.logger.Info(ctx, "inlined provenance data islands",
+		logger.Info(ctx, "inlined provenance data islands",
 			"content_injections", counts.content,
 			"app_injections", counts.app,
 			"augmented_files", modified,
@@ -111,8 +139,10 @@ func (l *Loader) inlineProvenance(ctx context.Context, snap *Snapshot) {
 
 // injectDataIslands replaces each provenance sentinel with its JSON payload in
 // every HTML file of the in-memory bundle. A nil payload skips that island.
-func injectDataIslands(mfs fstest.MapFS, contentJSON, appJSON []byte) (counts islandInjectionCounts, modified []string) {
+// augmented holds the pre- and post-injection digests of every modified file.
+func injectDataIslands(mfs fstest.MapFS, contentJSON, appJSON []byte) (counts islandInjectionCounts, modified []string, augmented map[string]AugmentedFile) {
 	modified = make([]string, 0, len(mfs))
+	augmented = make(map[string]AugmentedFile)
 	contentTok := []byte(contentDataSentinel)
 	appTok := []byte(appDataSentinel)
 
@@ -122,31 +152,55 @@ func injectDataIslands(mfs fstest.MapFS, contentJSON, appJSON []byte) (counts is
 		}
 
 		data := file.Data
-		changed := false
+		var islands []string
 
 		if contentJSON != nil {
 			if n := bytes.Count(data, contentTok); n > 0 {
 				data = bytes.ReplaceAll(data, contentTok, contentJSON)
 				counts.content += n
-				changed = true
+				islands = append(islands, contentDataIslandID)
 			}
 		}
 		if appJSON != nil {
 			if n := bytes.Count(data, appTok); n > 0 {
 				data = bytes.ReplaceAll(data, appTok, appJSON)
 				counts.app += n
-				changed = true
+				islands = append(islands, appDataIslandID)
 			}
 		}
 
-		if changed {
+		if len(islands) > 0 {
+			augmented[name] = AugmentedFile{
+				OriginalSHA256: cryptoutil.SHA256Hex(file.Data),
+				OriginalSize:   int64(len(file.Data)),
+				SHA256:         cryptoutil.SHA256Hex(data),
+				Size:           int64(len(data)),
+				Islands:        islands,
+			}
 			file.Data = data
 			modified = append(modified, name)
 		}
 	}
 
 	sort.Strings(modified)
-	return counts, modified
+	return counts, modified, augmented
+}
+
+// hasIslandSentinels reports whether any HTML page still carries an
+// unreplaced provenance sentinel, e.g. because its island failed to build
+// when the bundle was loaded.
+func hasIslandSentinels(mfs fstest.MapFS) bool {
+	contentTok := []byte(contentDataSentinel)
+	appTok := []byte(appDataSentinel)
+	for name, f := range mfs {
+		if f == nil || !isHTMLFile(name) {
+			continue
+		}
+		if bytes.Contains(f.Data, contentTok) || bytes.Contains(f.Data, appTok) {
+			return true
+		}
+	}
+	return false
 }
 
 // isHTMLFile reports whether name has an HTML extension. Injection is limited to
@@ -159,3 +213,53 @@ func isHTMLFile(name string) bool {
 		return false
 	}
 }
+
+// RerenderIslands injects freshly built provenance islands into a copy of
+// the active snapshot and swaps it in, for when the data they embed changed
+// without a new bundle (e.g. refreshed build evidence, or evidence that had
+// not loaded when the bundle did). The copy starts from the pages as
+// shipped, so both islands are rebuilt. It reports whether a re-rendered
+// snapshot was activated: false without an inliner, when the active
+// snapshot has neither rendered islands nor unreplaced sentinels, or when
+// another swap won the race. Retained history is left as rendered at load
+// time.
+func (m *Manager) RerenderIslands(ctx context.Context, logger log.Logger, inliner ProvenanceInliner) bool {
+	if inliner == nil {
+		return false
+	}
+	if logger == nil {
+		logger = log.Nop()
+	}
+	cur, ok := m.Get()
+	if !ok {
+		return false
+	}
+	mfs, ok := cur.FS.(fstest.MapFS)
+	if !ok || (len(cur.islandSources) == 0 && !hasIslandSentinels(mfs)) {
+		return false
+	}
+
+	// every file is copied: injection rewrites MapFile.Data in place, and the
+	// live snapshot's files are being served concurrently
+	next := *cur
+	fresh := make(fstest.MapFS, len(mfs))
+	for name, f := range mfs {
+		if f == nil {
+			fresh[name] = f
+			continue
+		}
+		cp := *f
+		if src, ok := cur.islandSources[name]; ok {
+			cp.Data = src
+		}
+		fresh[name] = &cp
+	}
+	next.FS = fresh
+	next.Augmented = nil
+	next.islandSources = nil
+	next.Digests = nil
+	next.Variants = nil
+
+	inlineProvenance(ctx, logger, inliner, &next)
+	return m.set(next, cur)
+}
@@ -3,6 +3,7 @@ package content
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"io/fs"
 	"net/http"
@@ -62,7 +63,8 @@ func TestInjectDataIslands(t *testing.T) {
 	contentJSON := []byte(`{"c":true}`)
 	appJSON := []byte(`{"a":true}`)
 
-	counts, modified := injectDataIslands(mfs, contentJSON, appJSON)
+	origPage := append([]byte(nil), mfs["page.html"].Data...)
+	counts, modified, augmented := injectDataIslands(mfs, contentJSON, appJSON)
 
 	if counts.content != 1 {
 		t.Fatalf("content replacements = %d, want 1", counts.content)
@@ -87,19 +89,37 @@ func TestInjectDataIslands(t *testing.T) {
 	if !bytes.Equal(mfs["feed.json"].Data, notHTML) {
 		t.Fatalf("feed.json (non-HTML) was modified: %q", mfs["feed.json"].Data)
 	}
+
+	// pre- and post-injection digests are recorded per augmented file
+	if len(augmented) != 2 {
+		t.Fatalf("augmented = %v, want 2 entries", augmented)
+	}
+	pa := augmented["page.html"]
+	if pa.OriginalSHA256 != cryptoutil.SHA256Hex(origPage) || pa.OriginalSize != int64(len(origPage)) {
+		t.Fatalf("page.html original = %+v", pa)
+	}
+	if pa.SHA256 != cryptoutil.SHA256Hex(gotPage) || pa.Size != int64(len(gotPage)) {
+		t.Fatalf("page.html served = %+v", pa)
+	}
+	if len(pa.Islands) != 2 || pa.Islands[0] != contentDataIslandID || pa.Islands[1] != appDataIslandID {
+		t.Fatalf("page.html islands = %v", pa.Islands)
+	}
+	if fa := augmented["footer.html"]; len(fa.Islands) != 1 || fa.Islands[0] != appDataIslandID {
+		t.Fatalf("footer.html islands = %v", fa.Islands)
+	}
 }
 
 func TestInjectDataIslands_Idempotent(t *testing.T) {
 	mfs := fstest.MapFS{"index.html": &fstest.MapFile{Data: []byte(contentIsland())}}
 	contentJSON := []byte(`{"v":1}`)
 
-	c1, m1 := injectDataIslands(mfs, contentJSON, nil)
+	c1, m1, _ := injectDataIslands(mfs, contentJSON, nil)
 	if c1.content != 1 || len(m1) != 1 {
 		t.Fatalf("first run: counts=%+v modified=%v", c1, m1)
 	}
 	first := append([]byte(nil), mfs["index.html"].Data...)
 
-	c2, m2 := injectDataIslands(mfs, contentJSON, nil)
+	c2, m2, _ := injectDataIslands(mfs, contentJSON, nil)
 	if c2.content != 0 || len(m2) != 0 {
 		t.Fatalf("second run should be a no-op: counts=%+v modified=%v", c2, m2)
 	}
@@ -112,7 +132,7 @@ func TestInjectDataIslands_NilPayloadSkipsIsland(t *testing.T) {
 	mfs := fstest.MapFS{"page.html": &fstest.MapFile{Data: []byte(contentIsland() + appIsland())}}
 
 	appJSON := []byte(`{"a":1}`)
-	counts, _ := injectDataIslands(mfs, nil, appJSON)
+	counts, _, _ := injectDataIslands(mfs, nil, appJSON)
 
 	if counts.content != 0 {
 		t.Fatalf("content replacements = %d, want 0 (nil payload)", counts.content)
@@ -200,7 +220,7 @@ func TestInjectedIslandServedWithCorrectLength(t *testing.T) {
 	mfs := fstest.MapFS{name: &fstest.MapFile{Data: []byte(`<!doctype html>` + contentIsland())}}
 	payload := []byte(`{"served":true}`)
 
-	counts, _ := injectDataIslands(mfs, payload, nil)
+	counts, _, _ := injectDataIslands(mfs, payload, nil)
 	if counts.content != 1 {
 		t.Fatalf("content replacements = %d, want 1", counts.content)
 	}
@@ -299,3 +319,152 @@ func TestLoadHash_NoInlinerLeavesSentinels(t *testing.T) {
 		t.Fatalf("sentinel should remain with no inliner: %q", out)
 	}
 }
+
+func TestRerenderIslands(t *testing.T) {
+	page := `<html><body>` + contentIsland() + appIsland() + `</body></html>`
+	snap := &Snapshot{
+		FS: fstest.MapFS{
+			"index.html": &fstest.MapFile{Data: []byte(page)},
+			"plain.html": &fstest.MapFile{Data: []byte(`<p>no islands</p>`)},
+		},
+		Meta: Meta{Hash: "abc"},
+	}
+	inlineProvenance(t.Context(), log.Nop(), &stubInliner{
+		contentJSON: []byte(`{"c":1}`),
+		appJSON:     []byte(`{"scan":"old"}`),
+	}, snap)
+
+	m := NewManager()
+	m.Set(*snap)
+	before, _ := m.Get()
+
+	stub := &stubInliner{contentJSON: []byte(`{"c":1}`), appJSON: []byte(`{"scan":"new"}`)}
+	if !m.RerenderIslands(t.Context(), log.Nop(), stub) {
+		t.Fatal("RerenderIslands = false, want a swap")
+	}
+	after, _ := m.Get()
+	if after == before {
+		t.Fatal("active snapshot was not replaced")
+	}
+	if after.Meta.Hash != "abc" {
+		t.Fatalf("hash = %q, want the same bundle", after.Meta.Hash)
+	}
+
+	out, err := fs.ReadFile(after.FS, "index.html")
+	if err != nil {
+		t.Fatalf("read index.html: %v", err)
+	}
+	if !bytes.Contains(out, []byte(`{"scan":"new"}`)) || bytes.Contains(out, []byte(`{"scan":"old"}`)) {
+		t.Fatalf("app island not re-rendered: %q", out)
+	}
+	if !bytes.Contains(out, []byte(`{"c":1}`)) {
+		t.Fatalf("content island lost: %q", out)
+	}
+	if d, ok := after.Digests["index.html"]; !ok || d == before.Digests["index.html"] {
+		t.Fatal("digest not recomputed for the re-rendered page")
+	}
+
+	// the previous snapshot keeps what it served
+	old, _ := fs.ReadFile(before.FS, "index.html")
+	if !bytes.Contains(old, []byte(`{"scan":"old"}`)) {
+		t.Fatalf("previous snapshot was modified: %q", old)
+	}
+
+	// and it can be rendered again from the shipped pages
+	stub.appJSON = []byte(`{"scan":"newer"}`)
+	if !m.RerenderIslands(t.Context(), log.Nop(), stub) {
+		t.Fatal("second RerenderIslands = false")
+	}
+	latest, _ := m.Get()
+	out, _ = fs.ReadFile(latest.FS, "index.html")
+	if !bytes.Contains(out, []byte(`{"scan":"newer"}`)) {
+		t.Fatalf("second re-render missing: %q", out)
+	}
+	if n := len(m.List()); n != 1 {
+		t.Fatalf("history = %d entries, want re-renders to replace the same hash", n)
+	}
+}
+
+func TestRerenderIslands_NothingToRender(t *testing.T) {
+	stub := &stubInliner{appJSON: []byte(`{}`)}
+
+	m := NewManager()
+	if m.RerenderIslands(t.Context(), log.Nop(), stub) {
+		t.Fatal("re-rendered with no active snapshot")
+	}
+
+	m.Set(Snapshot{FS: fstest.MapFS{"index.html": &fstest.MapFile{Data: []byte(`<p>x</p>`)}}})
+	if m.RerenderIslands(t.Context(), log.Nop(), stub) {
+		t.Fatal("re-rendered a snapshot without islands")
+	}
+	if m.RerenderIslands(t.Context(), log.Nop(), nil) {
+		t.Fatal("re-rendered without an inliner")
+	}
+}
+
+func TestRerenderIslands_AfterInitialError(t *testing.T) {
+	page := `<html><body>` + appIsland() + `</body></html>`
+	snap := &Snapshot{
+		FS:   fstest.MapFS{"index.html": &fstest.MapFile{Data: []byte(page)}},
+		Meta: Meta{Hash: "abc"},
+	}
+	// evidence had not loaded: the app island fails and no page is augmented
+	inlineProvenance(t.Context(), log.Nop(), &stubInliner{appErr: errors.New("no evidence")}, snap)
+	if len(snap.islandSources) != 0 {
+		t.Fatal("failed island should leave nothing rendered")
+	}
+
+	m := NewManager()
+	m.Set(*snap)
+	before, _ := m.Get()
+	served := httptest.NewServer(http.FileServerFS(before.FS))
+	defer served.Close()
+
+	// serve the active snapshot while it is re-rendered
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 50 {
+			resp, err := http.Get(served.URL + "/index.html")
+			if err != nil {
+				t.Errorf("GET: %v", err)
+				return
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}()
+	ok := m.RerenderIslands(t.Context(), log.Nop(), &stubInliner{appJSON: []byte(`{"scan":"late"}`)})
+	<-done
+	if !ok {
+		t.Fatal("RerenderIslands = false, want the unreplaced sentinel rendered")
+	}
+
+	after, _ := m.Get()
+	out, _ := fs.ReadFile(after.FS, "index.html")
+	if !bytes.Contains(out, []byte(`{"scan":"late"}`)) {
+		t.Fatalf("app island not rendered: %q", out)
+	}
+	// the snapshot that was live keeps its bytes
+	old, _ := fs.ReadFile(before.FS, "index.html")
+	if !bytes.Contains(old, []byte(appDataSentinel)) {
+		t.Fatalf("previously active snapshot was modified: %q", old)
+	}
+	if before.Digests["index.html"] != DigestFiles(before.FS, nil, nil)["index.html"] {
+		t.Fatal("previously active snapshot's digests no longer match its files")
+	}
+}
+
+func TestManagerSet_IfActive(t *testing.T) {
+	m := NewManager()
+	m.Set(Snapshot{FS: fstest.MapFS{}, Meta: Meta{Hash: "a"}})
+	stale, _ := m.Get()
+	m.Set(Snapshot{FS: fstest.MapFS{}, Meta: Meta{Hash: "b"}})
+
+	if m.set(Snapshot{FS: fstest.MapFS{}, Meta: Meta{Hash: "a"}}, stale) {
+		t.Fatal("set succeeded against a stale active snapshot")
+	}
+	if got := m.ContentHash(); got != "b" {
+		t.Fatalf("active = %q, want the newer swap to stand", got)
+	}
+}
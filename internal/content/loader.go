@@ -2,9 +2,11 @@
 package content
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"strings"
 	"time"
 
@@ -41,11 +43,26 @@ type LoaderOptions struct {
 	// verified on every load.
 	KeylessVerifier BlobVerifier
 
+	// RequireSignedPointer rejects bare algo:hex values in SSMParam; only a
+	// signed pointer document is accepted. When false both forms are read.
+	RequireSignedPointer bool
+
 	// Inliner, when non-nil, fills the bundle's provenance data islands with the
 	// JSON served by /api/provenance/{content,app} at load time. nil disables
 	// injection (local/dev builds without a provenance API, and tests).
 	Inliner ProvenanceInliner
 
+	// DeltaBase, when non-nil, enables delta loads: a bundle published with a
+	// signed delta manifest is assembled from files already in DeltaBase's
+	// active snapshot plus the content-addressed blobs it lacks. Bundles
+	// without a manifest, and failed delta loads, use the full tarball.
+	DeltaBase DeltaBase
+
+	// Cache, when non-nil, keeps every fully verified tarball download and
+	// its sigstore bundles on local disk so LoadCached can serve the last
+	// known-good bundle at startup. Delta loads are not cached.
+	Cache *BundleCache
+
 	// S3Client allows injecting a custom S3 implementation for testing.
 	// If nil, a real client is created from AWSConfig.
 	S3Client s3Getter
@@ -118,48 +135,131 @@ func NewLoader(ctx context.Context, opts *LoaderOptions) (*Loader, error) {
 // FetchCurrentBundleHash gets the current bundle hash from SSM
 // returns the hash algorithm, hash value, and error if any
 func (l *Loader) FetchCurrentBundleHash(ctx context.Context) (hashAlgorithm, contentHash string, err error) {
+	algorithm, hash, _, err := l.FetchCurrentPointer(ctx)
+	return algorithm, hash, err
+}
+
+// FetchCurrentPointer reads SSMParam, which holds either a bare algo:hex hash
+// or a signed pointer document. Documents are verified against their KMS and
+// keyless sigstore bundles in S3 before the hash is returned; expiry and
+// rollback are left to the caller via Pointer.Check.
+func (l *Loader) FetchCurrentPointer(ctx context.Context) (hashAlgorithm, contentHash string, p *Pointer, err error) {
 	out, err := l.ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
 		Name:           aws.String(l.opts.SSMParam),
 		WithDecryption: aws.Bool(true),
 	})
 	if err != nil {
-		return "", "", xerrors.Wrapf(err, "get SSM parameter %s", l.opts.SSMParam)
+		return "", "", nil, xerrors.Wrapf(err, "get SSM parameter %s", l.opts.SSMParam)
 	}
 	if out.Parameter == nil || out.Parameter.Value == nil {
-		return "", "", xerrors.Newf("SSM parameter %s has no value", l.opts.SSMParam)
+		return "", "", nil, xerrors.Newf("SSM parameter %s has no value", l.opts.SSMParam)
 	}
 
 	raw := strings.TrimSpace(*out.Parameter.Value)
 	if raw == "" {
-		return "", "", xerrors.Newf("SSM parameter %s is empty", l.opts.SSMParam)
+		return "", "", nil, xerrors.Newf("SSM parameter %s is empty", l.opts.SSMParam)
+	}
+
+	if isPointerDocument([]byte(raw)) {
+		p, err := l.verifyPointer(ctx, []byte(raw))
+		if err != nil {
+			return "", "", nil, err
+		}
+		algorithm, hash := p.Split()
+		return algorithm, hash, p, nil
+	}
+
+	if l.opts.RequireSignedPointer {
+		return "", "", nil, xerrors.Wrapf(ErrPointerRejected, "SSM parameter %s holds a bare hash, signed pointer required", l.opts.SSMParam)
 	}
 
 	algorithm, hash, ok := strings.Cut(raw, ":")
 	if !ok {
-		return "", "", xerrors.Newf("SSM parameter %s missing algorithm prefix (expected algo:hex)", l.opts.SSMParam)
+		return "", "", nil, xerrors.Newf("SSM parameter %s missing algorithm prefix (expected algo:hex)", l.opts.SSMParam)
+	}
+
+	return algorithm, hash, nil, nil
+}
+
+// verifyPointer parses a pointer document and verifies both of its sigstore
+// bundles against the exact document bytes.
+func (l *Loader) verifyPointer(ctx context.Context, doc []byte) (*Pointer, error) {
+	p, err := ParsePointer(doc)
+	if err != nil {
+		return nil, xerrors.Wrapf(ErrPointerRejected, "SSM parameter %s: %v", l.opts.SSMParam, err)
+	}
+
+	kmsBundleJSON, err := l.fetchS3(ctx, l.pointerKey(p.Digest)+kmsBundleSuffix, maxSigBundleSize)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "fetch pointer kms sigstore bundle")
+	}
+	if err := l.opts.Verifier.VerifyBlob(ctx, kmsBundleJSON, doc); err != nil {
+		return nil, xerrors.Wrapf(ErrPointerRejected, "pointer kms signature verification failed: %v", err)
+	}
+	keylessBundleJSON, err := l.fetchS3(ctx, l.pointerKey(p.Digest)+keylessBundleSuffix, maxSigBundleSize)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "fetch pointer keyless sigstore bundle")
+	}
+	if err := l.opts.KeylessVerifier.VerifyBlob(ctx, keylessBundleJSON, doc); err != nil {
+		return nil, xerrors.Wrapf(ErrPointerRejected, "pointer keyless signature verification failed: %v", err)
+	}
+	p.signed = &PointerDocument{Document: doc, KMSJSON: kmsBundleJSON, KeylessJSON: keylessBundleJSON}
+	return p, nil
+}
+
+// verifyCachedPointer re-verifies a pointer document stored in the bundle
+// cache against its stored sigstore bundles.
+func (l *Loader) verifyCachedPointer(ctx context.Context, pd *PointerDocument) (*Pointer, error) {
+	p, err := ParsePointer(pd.Document)
+	if err != nil {
+		return nil, xerrors.Wrapf(ErrPointerRejected, "cached pointer: %v", err)
+	}
+	if err := l.opts.Verifier.VerifyBlob(ctx, pd.KMSJSON, pd.Document); err != nil {
+		return nil, xerrors.Wrapf(ErrPointerRejected, "cached pointer kms signature verification failed: %v", err)
 	}
+	if err := l.opts.KeylessVerifier.VerifyBlob(ctx, pd.KeylessJSON, pd.Document); err != nil {
+		return nil, xerrors.Wrapf(ErrPointerRejected, "cached pointer keyless signature verification failed: %v", err)
+	}
+	p.signed = pd
+	return p, nil
+}
 
-	return algorithm, hash, nil
+// pointerKey returns the S3 key prefix for a pointer document's sigstore
+// bundles, addressed by the sha256 of the document bytes.
+func (l *Loader) pointerKey(digest string) string {
+	if l.opts.S3Prefix != "" {
+		return fmt.Sprintf("%s/pointers/sha256/%s.json", l.opts.S3Prefix, digest)
+	}
+	return fmt.Sprintf("pointers/sha256/%s.json", digest)
 }
 
 // s3Key returns the S3 object key for a given hash
 func (l *Loader) s3Key(algorithm, hash string) string {
+	return l.bundleKey(algorithm, hash, FormatTarGz)
+}
+
+// bundleKey returns the S3 object key for a bundle archive in format; the
+// extension follows the format so each has its own signatures.
+func (l *Loader) bundleKey(algorithm, hash string, format BundleFormat) string {
+	if format == "" {
+		format = FormatTarGz
+	}
 	if l.opts.S3Prefix != "" {
-		return fmt.Sprintf("%s/%s/%s.tar.gz", l.opts.S3Prefix, algorithm, hash)
+		return fmt.Sprintf("%s/%s/%s.%s", l.opts.S3Prefix, algorithm, hash, format)
 	}
-	return fmt.Sprintf("%s/%s.tar.gz", algorithm, hash)
+	return fmt.Sprintf("%s/%s.%s", algorithm, hash, format)
 }
 
 // kmsBundleKey returns the S3 object key for the KMS sigstore bundle
 // for a given content bundle hash.
 func (l *Loader) kmsBundleKey(algorithm, hash string) string {
-	return l.s3Key(algorithm, hash) + ".kms.bundle.sigstore.json"
+	return l.s3Key(algorithm, hash) + kmsBundleSuffix
 }
 
 // keylessBundleKey returns the S3 object key for the keyless (Fulcio) sigstore
 // bundle for a given content bundle hash, stored alongside the KMS bundle.
 func (l *Loader) keylessBundleKey(algorithm, hash string) string {
-	return l.s3Key(algorithm, hash) + ".keyless.bundle.sigstore.json"
+	return l.s3Key(algorithm, hash) + keylessBundleSuffix
 }
 
 // fetchS3 fetches an S3 object and returns its contents, limited to maxSize.
@@ -189,27 +289,68 @@ func (l *Loader) fetchS3(ctx context.Context, key string, maxSize int64) ([]byte
 
 // Load fetches the current release and returns a Snapshot
 func (l *Loader) Load(ctx context.Context) (*Snapshot, error) {
-	algorithm, hash, err := l.FetchCurrentBundleHash(ctx)
+	algorithm, hash, p, err := l.FetchCurrentPointer(ctx)
 	if err != nil {
 		return nil, err
 	}
+	// nothing is served yet, so only expiry applies
+	if p != nil {
+		if err := p.Check(time.Now(), time.Time{}); err != nil {
+			return nil, err
+		}
+	}
 
-	return l.LoadHash(ctx, algorithm, hash)
+	var format BundleFormat
+	if p != nil {
+		format = p.Format
+	}
+	snap, err := l.LoadHashFormat(ctx, algorithm, hash, format)
+	if err != nil {
+		return nil, err
+	}
+	snap.Meta.Pointer = p
+	return snap, nil
 }
 
 // LoadHash fetches a specific bundle by hash, verifies the hash signature
 // against a trusted key, downloads the bundle, verifies its SHA-384 integrity,
 // extracts to an in-memory filesystem, and returns a Snapshot. No files are
 // written to disk - the bundle is served directly from memory, eliminating
-// disk tampering threat surface entirely and improving performance.
+// disk tampering threat surface entirely and improving performance. With
+// DeltaBase set, a signed delta manifest is tried first (see loadDelta).
 func (l *Loader) LoadHash(ctx context.Context, algorithm, hash string) (*Snapshot, error) {
+	return l.LoadHashFormat(ctx, algorithm, hash, FormatTarGz)
+}
+
+// LoadHashFormat is LoadHash for a bundle archived in format, as named by a
+// signed pointer document. Empty means FormatTarGz.
+func (l *Loader) LoadHashFormat(ctx context.Context, algorithm, hash string, format BundleFormat) (*Snapshot, error) {
+	format, err := ParseBundleFormat(string(format))
+	if err != nil {
+		return nil, err
+	}
+
+	if l.opts.DeltaBase != nil {
+		if base, ok := l.opts.DeltaBase.Get(); ok {
+			snap, err := l.loadDelta(ctx, algorithm, hash, base)
+			if err == nil {
+				return snap, nil
+			}
+			l.logger.Warn(ctx, "delta content load failed, fetching full bundle",
+				"hash", hash,
+				"error", err,
+			)
+		}
+	}
+
 	loadedAt := time.Now().UTC()
 
 	// download the bundle
-	key := l.s3Key(algorithm, hash)
+	key := l.bundleKey(algorithm, hash, format)
 	l.logger.Info(ctx, "fetching content bundle",
 		"bucket", l.opts.S3Bucket,
 		"key", key,
+		"format", format,
 		"expected_hash", hash,
 	)
 
@@ -242,7 +383,7 @@ func (l *Loader) LoadHash(ctx context.Context, algorithm, hash string) (*Snapsho
 	}
 
 	// fetch and verify the KMS sigstore bundle for this content bundle
-	kmsKey := l.kmsBundleKey(algorithm, hash)
+	kmsKey := key + kmsBundleSuffix
 	kmsBundleJSON, err := l.fetchS3(ctx, kmsKey, maxSigBundleSize)
 	if err != nil {
 		return nil, xerrors.Wrap(err, "fetch kms sigstore bundle")
@@ -253,7 +394,7 @@ func (l *Loader) LoadHash(ctx context.Context, algorithm, hash string) (*Snapsho
 
 	// fetch and verify the keyless (Fulcio) sigstore bundle. Content bundles
 	// are dual-signed, both signatures are required.
-	keylessKey := l.keylessBundleKey(algorithm, hash)
+	keylessKey := key + keylessBundleSuffix
 	keylessBundleJSON, err := l.fetchS3(ctx, keylessKey, maxSigBundleSize)
 	if err != nil {
 		return nil, xerrors.Wrap(err, "fetch keyless sigstore bundle")
@@ -262,21 +403,27 @@ func (l *Loader) LoadHash(ctx context.Context, algorithm, hash string) (*Snapsho
 		return nil, xerrors.Wrap(err, "content bundle keyless signature verification failed")
 	}
 
-	// extract per-signature display data for the provenance API.
-	signatures := &cryptoutil.SignaturesInfo{}
-	if keyless, err := cryptoutil.KeylessSignatureFromBundle(keylessBundleJSON); err != nil {
-		l.logger.Warn(ctx, "failed to extract keyless signature info", "hash", hash, "error", err)
-	} else {
-		signatures.Keyless = keyless
+	snap, err := l.snapshotFromBundle(ctx, algorithm, hash, format, data, kmsBundleJSON, keylessBundleJSON, SourceS3, loadedAt)
+	if err != nil {
+		return nil, err
 	}
-	if kms, err := cryptoutil.KMSSignatureFromBundle(kmsBundleJSON); err != nil {
-		l.logger.Warn(ctx, "failed to extract kms signature info", "hash", hash, "error", err)
-	} else {
-		signatures.KMS = kms
+
+	if l.opts.Cache != nil {
+		if err := l.opts.Cache.Put(algorithm, hash, format, data, kmsBundleJSON, keylessBundleJSON); err != nil {
+			l.logger.Warn(ctx, "failed to cache content bundle", "hash", hash, "error", err)
+		}
 	}
+	return snap, nil
+}
+
+// snapshotFromBundle extracts a bundle whose hash and both signatures have
+// already been verified and wraps it in a Snapshot.
+func (l *Loader) snapshotFromBundle(ctx context.Context, algorithm, hash string, format BundleFormat, data, kmsBundleJSON, keylessBundleJSON []byte, source Source, loadedAt time.Time) (*Snapshot, error) {
+	// extract per-signature display data for the provenance API.
+	signatures := signaturesInfo(ctx, l.logger, hash, kmsBundleJSON, keylessBundleJSON)
 
 	// extract to in-memory filesystem
-	contentFS, err := extractTarGzToMem(data)
+	contentFS, err := extractBundleToMem(data, format)
 	if err != nil {
 		return nil, xerrors.Wrap(err, "extract bundle")
 	}
@@ -285,43 +432,72 @@ func (l *Loader) LoadHash(ctx context.Context, algorithm, hash string) (*Snapsho
 		"hash", hash,
 	)
 
-	// load provenance from the in-memory FS
+	snap := newSnapshot(ctx, l.logger, contentFS, Meta{
+		Hash:          hash,
+		HashAlgorithm: algorithm,
+		Source:        source,
+		VerifiedAt:    time.Now().UTC(),
+		Signatures:    signatures,
+	}, loadedAt)
+
+	// fill the bundle's provenance data islands in-memory before the snapshot is
+	// published (no-op when no inliner is configured). Safe to mutate here: the
+	// extracted MapFS is single-owner until the swap.
+	l.inlineProvenance(ctx, snap)
+
+	return snap, nil
+}
+
+// signaturesInfo extracts per-signature display data for the provenance API
+// from the sigstore bundles that verified a content bundle. A nil bundle leaves
+// its half of the result unset; extraction failures are logged, not fatal,
+// since the signatures themselves have already been verified.
+func signaturesInfo(ctx context.Context, logger log.Logger, hash string, kmsBundleJSON, keylessBundleJSON []byte) *cryptoutil.SignaturesInfo {
+	signatures := &cryptoutil.SignaturesInfo{}
+	if keylessBundleJSON != nil {
+		if keyless, err := cryptoutil.KeylessSignatureFromBundle(keylessBundleJSON); err != nil {
+			logger.Warn(ctx, "failed to extract keyless signature info", "hash", hash, "error", err)
+		} else {
+			signatures.Keyless = keyless
+		}
+	}
+	if kmsBundleJSON != nil {
+		if kms, err := cryptoutil.KMSSignatureFromBundle(kmsBundleJSON); err != nil {
+			logger.Warn(ctx, "failed to extract kms signature info", "hash", hash, "error", err)
+		} else {
+			signatures.KMS = kms
+		}
+	}
+	return signatures
+}
+
+// newSnapshot wraps an extracted content FS in a Snapshot, loading release.json
+// provenance from it when present. A missing or malformed release.json is
+// logged and the snapshot is returned without provenance.
+func newSnapshot(ctx context.Context, logger log.Logger, contentFS fs.FS, meta Meta, loadedAt time.Time) *Snapshot {
 	var provenance *Provenance
 	prov, err := LoadProvenance(contentFS)
 	if err != nil {
-		l.logger.Warn(ctx, "failed to load release.json, continuing without provenance data",
-			"hash", hash,
+		logger.Warn(ctx, "failed to load release.json, continuing without provenance data",
+			"hash", meta.Hash,
 			"error", err,
 		)
 	} else {
 		provenance = prov
-		l.logger.Info(ctx, "loaded content provenance",
+		logger.Info(ctx, "loaded content provenance",
 			"version", provenance.Version,
 			"total_files", provenance.Summary.TotalFiles,
 			"commit", provenance.Source.CommitShort,
 		)
 	}
 
-	snap := &Snapshot{
-		FS: contentFS,
-		Meta: Meta{
-			Hash:          hash,
-			HashAlgorithm: algorithm,
-			Source:        SourceS3,
-			VerifiedAt:    time.Now().UTC(),
-			Version:       provenanceVersion(provenance),
-			Signatures:    signatures,
-		},
+	meta.Version = provenanceVersion(provenance)
+	return &Snapshot{
+		FS:         contentFS,
+		Meta:       meta,
 		Provenance: provenance,
 		LoadedAt:   loadedAt,
 	}
-
-	// fill the bundle's provenance data islands in-memory before the snapshot is
-	// published (no-op when no inliner is configured). Safe to mutate here: the
-	// extracted MapFS is single-owner until the swap.
-	l.inlineProvenance(ctx, snap)
-
-	return snap, nil
 }
 
 // provenanceVersion extracts version from provenance or returns empty string
@@ -332,6 +508,76 @@ func provenanceVersion(p *Provenance) string {
 	return p.Version
 }
 
+// LoadCached re-verifies the cache's active bundle as if it had just been
+// downloaded (hash, KMS and keyless signatures) and returns it with
+// SourceCache. The signed pointer the bundle went live under is re-verified
+// too and restored as Meta.Pointer, so the watcher's downgrade floor
+// survives a cache boot; an expired pointer, or a missing one when signed
+// pointers are required, rejects the entry. It returns ErrCacheEmpty when
+// nothing has been cached and an error when no cache is configured.
+func (l *Loader) LoadCached(ctx context.Context) (*Snapshot, error) {
+	if l.opts.Cache == nil {
+		return nil, xerrors.New("content cache is not configured")
+	}
+	loadedAt := time.Now().UTC()
+
+	cb, err := l.opts.Cache.Active()
+	if err != nil {
+		return nil, err
+	}
+
+	var pointer *Pointer
+	if cb.Pointer != nil {
+		pointer, err = l.verifyCachedPointer(ctx, cb.Pointer)
+		if err != nil {
+			return nil, err
+		}
+		if algorithm, hash := pointer.Split(); algorithm != cb.Algorithm || !cryptoutil.HashEqual(hash, cb.Hash) {
+			return nil, xerrors.Wrapf(ErrPointerRejected, "cached pointer names %s, not the cached bundle", pointer.Hash)
+		}
+		if err := pointer.Check(loadedAt, time.Time{}); err != nil {
+			return nil, xerrors.Wrap(err, "cached pointer")
+		}
+	} else if l.opts.RequireSignedPointer {
+		return nil, xerrors.Wrap(ErrPointerRejected, "cached bundle has no signed pointer, signed pointer required")
+	}
+	_, actualHash, err := readWithHash(bytes.NewReader(cb.Bundle), maxBundleSize, cb.Algorithm)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "hash cached content bundle")
+	}
+	if !cryptoutil.HashEqual(actualHash, cb.Hash) {
+		return nil, xerrors.Newf("cached bundle checksum mismatch: expected %s, got %s", cb.Hash, actualHash)
+	}
+	if err := l.opts.Verifier.VerifyBlob(ctx, cb.KMSJSON, cb.Bundle); err != nil {
+		return nil, xerrors.Wrap(err, "cached content bundle kms signature verification failed")
+	}
+	if err := l.opts.KeylessVerifier.VerifyBlob(ctx, cb.KeylessJSON, cb.Bundle); err != nil {
+		return nil, xerrors.Wrap(err, "cached content bundle keyless signature verification failed")
+	}
+
+	snap, err := l.snapshotFromBundle(ctx, cb.Algorithm, cb.Hash, cb.Format, cb.Bundle, cb.KMSJSON, cb.KeylessJSON, SourceCache, loadedAt)
+	if err != nil {
+		return nil, err
+	}
+	snap.Meta.Pointer = pointer
+	l.logger.Info(ctx, "loaded verified content bundle from cache",
+		"hash", cb.Hash,
+		"format", cb.Format,
+	)
+	return snap, nil
+}
+
+// MarkCached records hash as the bundle LoadCached serves at startup, with
+// the signed pointer it went live under (nil for a bare hash). It is a no-op
+// without a cache; a hash that was never cached (seed, delta loads) is an
+// error for the caller to log.
+func (l *Loader) MarkCached(hash string, p *Pointer) error {
+	if l.opts.Cache == nil {
+		return nil
+	}
+	return l.opts.Cache.MarkActive(hash, p.Signed())
+}
+
 // LoadIntoManager fetches the current release and updates the content manager
 func (l *Loader) LoadIntoManager(ctx context.Context, mgr *Manager) error {
 	snap, err := l.Load(ctx)
@@ -339,5 +585,8 @@ func (l *Loader) LoadIntoManager(ctx context.Context, mgr *Manager) error {
 		return err
 	}
 	mgr.Set(*snap)
+	if err := l.MarkCached(snap.Meta.Hash, snap.Meta.Pointer); err != nil {
+		l.logger.Warn(ctx, "failed to mark content bundle cached", "hash", snap.Meta.Hash, "error", err)
+	}
 	return nil
 }
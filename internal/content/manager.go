@@ -1,40 +1,328 @@
 package content
 
 import (
+	"io/fs"
+	"sync"
 	"sync/atomic"
+	"testing/fstest"
 	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
 )
 
+const (
+	// DefaultHistorySize is how many snapshots (including the active one) the
+	// Manager retains for rollback.
+	DefaultHistorySize = 5
+
+	// DefaultHistoryMaxBytes is the memory budget for retained snapshots. The
+	// active snapshot is always kept even if it alone exceeds the budget.
+	DefaultHistoryMaxBytes int64 = 256 << 20
+)
+
+// ManagerOptions configures snapshot history retention. Zero values use the
+// defaults.
+type ManagerOptions struct {
+	// HistorySize is the maximum number of snapshots retained, including the
+	// active one. 1 disables rollback.
+	HistorySize int
+
+	// HistoryMaxBytes caps the total file bytes of retained snapshots,
+	// including precompressed variants. Oldest inactive snapshots are evicted
+	// first.
+	HistoryMaxBytes int64
+
+	// Precompress, when set, builds precompressed variants for each snapshot
+	// passed to Set that doesn't already carry them.
+	Precompress *PrecompressOptions
+}
+
+// HistoryEntry describes a retained snapshot for listing.
+type HistoryEntry struct {
+	Hash     string    `json:"hash"`
+	Version  string    `json:"version,omitempty"`
+	Source   Source    `json:"source"`
+	LoadedAt time.Time `json:"loaded_at"`
+	Files    int       `json:"files"`
+	Bytes    int64     `json:"bytes"`
+	Active   bool      `json:"active"`
+
+	// PrecompressedFiles and PrecompressedBytes describe the snapshot's
+	// variants; Bytes already includes PrecompressedBytes.
+	PrecompressedFiles int   `json:"precompressed_files"`
+	PrecompressedBytes int64 `json:"precompressed_bytes"`
+}
+
+// historyItem is a retained snapshot with its accounted size and the blob
+// references it holds.
+type historyItem struct {
+	snap  *Snapshot
+	files int
+	bytes int64
+	blobs []string
+}
+
 type Manager struct {
-	active   atomic.Pointer[Snapshot]
-	previous atomic.Pointer[Snapshot]
+	active atomic.Pointer[Snapshot]
+
+	// history holds retained snapshots, most recently activated first;
+	// history[0] is the active snapshot. Guarded by mu; reads of the active
+	// snapshot stay lock-free via the atomic pointer.
+	mu       sync.Mutex
+	history  []historyItem
+	maxItems int
+	maxBytes int64
+
+	precompress *PrecompressOptions
+
+	// blobs shares file data between retained snapshots and any staged or
+	// previewed snapshot interned through Intern.
+	blobs *blobStore
 }
 
-func NewManager() *Manager { return &Manager{} }
+func NewManager() *Manager { return NewManagerWithOptions(nil) }
+
+// NewManagerWithOptions creates a Manager with the given history limits.
+func NewManagerWithOptions(opts *ManagerOptions) *Manager {
+	m := &Manager{maxItems: DefaultHistorySize, maxBytes: DefaultHistoryMaxBytes, blobs: newBlobStore()}
+	if opts != nil {
+		if opts.HistorySize > 0 {
+			m.maxItems = opts.HistorySize
+		}
+		if opts.HistoryMaxBytes > 0 {
+			m.maxBytes = opts.HistoryMaxBytes
+		}
+		m.precompress = opts.Precompress
+	}
+	return m
+}
 
 // Set sets the active snapshot safely
 func (m *Manager) Set(s Snapshot) { //nolint:gocritic // hugeParam: value param is intentional defensive copy for atomic store
+	m.set(s, nil)
+}
+
+// Prepare computes s's file digests and, when precompression is enabled, its
+// encoded variants, unless already present. Set does the same for a snapshot
+// that wasn't prepared, but encoding a whole site takes seconds, so callers
+// that publish while holding their own lock prepare first.
+func (m *Manager) Prepare(s *Snapshot) {
+	if s == nil || s.FS == nil {
+		return
+	}
+	if m.precompress != nil && s.Variants == nil {
+		s.Variants = Precompress(s.FS, m.precompress)
+	}
+	if s.Digests == nil {
+		s.Digests = DigestFiles(s.FS, s.Provenance, s.Augmented)
+	}
+}
+
+// set activates s. With ifActive non-nil it only does so while ifActive is
+// still the active snapshot, reporting whether s was activated.
+func (m *Manager) set(s Snapshot, ifActive *Snapshot) bool { //nolint:gocritic // hugeParam: see Set
 	cp := new(Snapshot)
 	*cp = s
 	if cp.LoadedAt.IsZero() {
 		cp.LoadedAt = time.Now().UTC()
 	}
-	// current becomes previous before we overwrite
-	if old := m.active.Load(); old != nil {
-		m.previous.Store(old)
+	// encode outside m.mu; snapshots are immutable so this is done once
+	m.Prepare(cp)
+	var blobs []string
+	cp.FS, blobs = m.blobs.intern(cp.FS, cp.Digests)
+	files, size := snapshotSize(cp.FS)
+	size += cp.Variants.Bytes()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ifActive != nil && m.active.Load() != ifActive {
+		m.blobs.release(blobs)
+		return false
+	}
+	// re-setting a retained hash replaces the older copy
+	if cp.Meta.Hash != "" {
+		m.removeLocked(cp.Meta.Hash)
 	}
+	m.history = append([]historyItem{{snap: cp, files: files, bytes: size, blobs: blobs}}, m.history...)
+	m.trimLocked()
 	m.active.Store(cp)
+	return true
 }
 
+// Rollback reactivates the most recently active previous snapshot.
 func (m *Manager) Rollback() bool {
-	prev := m.previous.Load()
-	if prev == nil {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.history) < 2 {
 		return false
 	}
-	m.active.Store(prev)
+	m.activateLocked(1)
 	return true
 }
 
+// RollbackTo reactivates the retained snapshot with the given hash.
+func (m *Manager) RollbackTo(hash string) (*Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, it := range m.history {
+		if it.snap.Meta.Hash == hash {
+			m.activateLocked(i)
+			return it.snap, nil
+		}
+	}
+	return nil, xerrors.Newf("content: snapshot %s is not in history", truncHash(hash))
+}
+
+// Discard drops the retained snapshot with the given hash and releases its
+// file data, for a snapshot that must never be reactivated. Discarding the
+// active snapshot first reactivates the most recently active previous one;
+// the only retained snapshot is kept. Reports whether it was dropped.
+func (m *Manager) Discard(hash string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, it := range m.history {
+		if it.snap.Meta.Hash != hash {
+			continue
+		}
+		if i == 0 {
+			if len(m.history) < 2 {
+				return false
+			}
+			m.activateLocked(1)
+		}
+		m.removeLocked(hash)
+		return true
+	}
+	return false
+}
+
+// List returns the retained snapshots, most recently activated first.
+func (m *Manager) List() []HistoryEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]HistoryEntry, 0, len(m.history))
+	for i, it := range m.history {
+		version := it.snap.Meta.Version
+		if it.snap.Provenance != nil && it.snap.Provenance.Version != "" {
+			version = it.snap.Provenance.Version
+		}
+		out = append(out, HistoryEntry{
+			Hash:     it.snap.Meta.Hash,
+			Version:  version,
+			Source:   it.snap.Meta.Source,
+			LoadedAt: it.snap.LoadedAt,
+			Files:    it.files,
+			Bytes:    it.bytes,
+			Active:   i == 0,
+
+			PrecompressedFiles: it.snap.Variants.Len(),
+			PrecompressedBytes: it.snap.Variants.Bytes(),
+		})
+	}
+	return out
+}
+
+// Intern shares s's file data with identical files already held by the
+// Manager, for a snapshot kept outside the history such as a staged or
+// previewed bundle. Digests are computed if s has none. The returned func
+// drops the references; it is safe to call more than once.
+func (m *Manager) Intern(s *Snapshot) (release func()) {
+	if s == nil || s.FS == nil {
+		return func() {}
+	}
+	if s.Digests == nil {
+		s.Digests = DigestFiles(s.FS, s.Provenance, s.Augmented)
+	}
+	var blobs []string
+	s.FS, blobs = m.blobs.intern(s.FS, s.Digests)
+	var once sync.Once
+	return func() { once.Do(func() { m.blobs.release(blobs) }) }
+}
+
+// BlobStats reports how much file data is shared between the snapshots the
+// Manager and its interned holders reference.
+func (m *Manager) BlobStats() BlobStats {
+	return m.blobs.stats()
+}
+
+// HistoryBytes returns the total file bytes of retained snapshots.
+func (m *Manager) HistoryBytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var total int64
+	for _, it := range m.history {
+		total += it.bytes
+	}
+	return total
+}
+
+// activateLocked moves history[i] to the front and publishes it.
+func (m *Manager) activateLocked(i int) {
+	it := m.history[i]
+	copy(m.history[1:i+1], m.history[:i])
+	m.history[0] = it
+	m.active.Store(it.snap)
+}
+
+func (m *Manager) removeLocked(hash string) {
+	for i, it := range m.history {
+		if it.snap.Meta.Hash == hash {
+			m.blobs.release(it.blobs)
+			m.history = append(m.history[:i], m.history[i+1:]...)
+			return
+		}
+	}
+}
+
+// trimLocked evicts the oldest inactive snapshots until both the count and
+// byte budgets are met. The active snapshot is never evicted.
+func (m *Manager) trimLocked() {
+	var total int64
+	for _, it := range m.history {
+		total += it.bytes
+	}
+	for len(m.history) > 1 && (len(m.history) > m.maxItems || total > m.maxBytes) {
+		last := len(m.history) - 1
+		total -= m.history[last].bytes
+		m.blobs.release(m.history[last].blobs)
+		m.history[last] = historyItem{}
+		m.history = m.history[:last]
+	}
+}
+
+// snapshotSize counts files and bytes held by a snapshot's filesystem.
+func snapshotSize(fsys fs.FS) (files int, size int64) {
+	switch t := fsys.(type) {
+	case nil:
+		return 0, 0
+	case fstest.MapFS:
+		for _, f := range t {
+			if f != nil && !f.Mode.IsDir() {
+				files++
+				size += int64(len(f.Data))
+			}
+		}
+		return files, size
+	}
+	_ = fs.WalkDir(fsys, ".", func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil //nolint:nilerr // best-effort accounting
+		}
+		if info, err := d.Info(); err == nil {
+			files++
+			size += info.Size()
+		}
+		return nil
+	})
+	return files, size
+}
+
 // Get retrieves the active snapshot value
 func (m *Manager) Get() (*Snapshot, bool) {
 	s := m.active.Load()
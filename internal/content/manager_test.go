@@ -1,6 +1,7 @@
 package content
 
 import (
+	"bytes"
 	"fmt"
 	"sync"
 	"testing"
@@ -175,6 +176,201 @@ func TestManager_Rollback_RestoresPrevious(t *testing.T) {
 	}
 }
 
+// History
+
+// histSnap builds a snapshot with a single file of size bytes.
+func histSnap(hash string, size int) Snapshot {
+	return Snapshot{
+		FS:   fstest.MapFS{"index.html": &fstest.MapFile{Data: make([]byte, size)}},
+		Meta: Meta{Hash: hash, Version: "v-" + hash, Source: SourceS3},
+	}
+}
+
+func historyHashes(m *Manager) []string {
+	var out []string
+	for _, e := range m.List() {
+		out = append(out, e.Hash)
+	}
+	return out
+}
+
+func TestManager_History_BoundedByCount(t *testing.T) {
+	m := NewManagerWithOptions(&ManagerOptions{HistorySize: 3})
+	for _, h := range []string{"a", "b", "c", "d"} {
+		m.Set(histSnap(h, 10))
+	}
+	if got := historyHashes(m); fmt.Sprint(got) != "[d c b]" {
+		t.Fatalf("history = %v, want [d c b]", got)
+	}
+}
+
+func TestManager_History_BoundedByBytes(t *testing.T) {
+	m := NewManagerWithOptions(&ManagerOptions{HistorySize: 10, HistoryMaxBytes: 250})
+	m.Set(histSnap("a", 100))
+	m.Set(histSnap("b", 100))
+	m.Set(histSnap("c", 100))
+
+	if got := historyHashes(m); fmt.Sprint(got) != "[c b]" {
+		t.Fatalf("history = %v, want [c b]", got)
+	}
+	if m.HistoryBytes() != 200 {
+		t.Fatalf("HistoryBytes = %d, want 200", m.HistoryBytes())
+	}
+}
+
+func TestManager_History_ActiveKeptOverBudget(t *testing.T) {
+	m := NewManagerWithOptions(&ManagerOptions{HistoryMaxBytes: 50})
+	m.Set(histSnap("a", 10))
+	m.Set(histSnap("big", 100))
+
+	list := m.List()
+	if len(list) != 1 || list[0].Hash != "big" || !list[0].Active {
+		t.Fatalf("history = %+v, want only the active oversized snapshot", list)
+	}
+}
+
+func TestManager_History_ResetSameHashDedupes(t *testing.T) {
+	m := NewManager()
+	m.Set(histSnap("a", 1))
+	m.Set(histSnap("b", 1))
+	m.Set(histSnap("a", 1))
+
+	if got := historyHashes(m); fmt.Sprint(got) != "[a b]" {
+		t.Fatalf("history = %v, want [a b]", got)
+	}
+}
+
+func TestManager_List_Details(t *testing.T) {
+	m := NewManager()
+	m.Set(histSnap("a", 7))
+	s := histSnap("b", 3)
+	s.Provenance = &Provenance{Version: "2.0.0"}
+	m.Set(s)
+
+	list := m.List()
+	if len(list) != 2 {
+		t.Fatalf("len = %d", len(list))
+	}
+	if !list[0].Active || list[1].Active {
+		t.Fatalf("only the first entry should be active: %+v", list)
+	}
+	if list[0].Version != "2.0.0" || list[1].Version != "v-a" {
+		t.Fatalf("versions = %q, %q", list[0].Version, list[1].Version)
+	}
+	if list[1].Files != 1 || list[1].Bytes != 7 {
+		t.Fatalf("a: files=%d bytes=%d", list[1].Files, list[1].Bytes)
+	}
+}
+
+func TestManager_RollbackTo(t *testing.T) {
+	m := NewManager()
+	for _, h := range []string{"a", "b", "c"} {
+		m.Set(histSnap(h, 1))
+	}
+
+	snap, err := m.RollbackTo("a")
+	if err != nil {
+		t.Fatalf("RollbackTo: %v", err)
+	}
+	if snap.Meta.Hash != "a" || m.ContentHash() != "a" {
+		t.Fatalf("active = %q, want a", m.ContentHash())
+	}
+	// rolled-back-to snapshot moves to the front; nothing is dropped
+	if got := historyHashes(m); fmt.Sprint(got) != "[a c b]" {
+		t.Fatalf("history = %v, want [a c b]", got)
+	}
+
+	if _, err := m.RollbackTo("zzz"); err == nil {
+		t.Fatal("expected error for unknown hash")
+	}
+	if m.ContentHash() != "a" {
+		t.Fatal("failed RollbackTo must not change the active snapshot")
+	}
+}
+
+func TestManager_Rollback_HistorySizeOne(t *testing.T) {
+	m := NewManagerWithOptions(&ManagerOptions{HistorySize: 1})
+	m.Set(histSnap("a", 1))
+	m.Set(histSnap("b", 1))
+	if m.Rollback() {
+		t.Fatal("Rollback should fail with history disabled")
+	}
+}
+
+func TestManager_Discard(t *testing.T) {
+	m := NewManager()
+	for _, h := range []string{"a", "b", "c"} {
+		m.Set(histSnap(h, 1))
+	}
+
+	// discarding the active snapshot falls back to the previous one
+	if !m.Discard("c") {
+		t.Fatal("Discard(c) = false")
+	}
+	if m.ContentHash() != "b" {
+		t.Fatalf("active = %q, want b", m.ContentHash())
+	}
+	if got := historyHashes(m); fmt.Sprint(got) != "[b a]" {
+		t.Fatalf("history = %v, want [b a]", got)
+	}
+	if _, err := m.RollbackTo("c"); err == nil {
+		t.Fatal("discarded snapshot can still be rolled back to")
+	}
+
+	// an inactive snapshot is dropped without touching the active one
+	if !m.Discard("a") || m.ContentHash() != "b" {
+		t.Fatalf("Discard(a): active = %q", m.ContentHash())
+	}
+	if got := historyHashes(m); fmt.Sprint(got) != "[b]" {
+		t.Fatalf("history = %v, want [b]", got)
+	}
+
+	// the only snapshot is kept, unknown hashes are a no-op
+	if m.Discard("b") || m.ContentHash() != "b" {
+		t.Fatal("Discard dropped the only snapshot")
+	}
+	if m.Discard("zzz") {
+		t.Fatal("Discard(zzz) = true")
+	}
+}
+
+func TestManager_Discard_ReleasesBlobs(t *testing.T) {
+	m := NewManager()
+	m.Set(Snapshot{FS: fstest.MapFS{"a.html": &fstest.MapFile{Data: []byte("aaaa")}}, Meta: Meta{Hash: "a"}})
+	before := m.BlobStats()
+	m.Set(Snapshot{FS: fstest.MapFS{"b.html": &fstest.MapFile{Data: []byte("bbbbbbbb")}}, Meta: Meta{Hash: "b"}})
+
+	if !m.Discard("b") {
+		t.Fatal("Discard(b) = false")
+	}
+	if after := m.BlobStats(); after != before {
+		t.Fatalf("BlobStats = %+v after discard, want %+v", after, before)
+	}
+}
+
+func TestManager_Prepare(t *testing.T) {
+	m := NewManagerWithOptions(&ManagerOptions{Precompress: &PrecompressOptions{MinSize: 1}})
+	s := Snapshot{
+		FS:   fstest.MapFS{"index.html": &fstest.MapFile{Data: bytes.Repeat([]byte("<p>hello</p>"), 200)}},
+		Meta: Meta{Hash: "a"},
+	}
+	m.Prepare(&s)
+	if s.Digests["index.html"].SHA256 == "" {
+		t.Fatal("Prepare did not compute digests")
+	}
+	if s.Variants.Len() != 1 {
+		t.Fatalf("variants = %d, want 1", s.Variants.Len())
+	}
+
+	// Set publishes the prepared work rather than redoing it
+	variants := s.Variants
+	m.Set(s)
+	got, _ := m.Get()
+	if got.Variants != variants {
+		t.Fatal("Set re-encoded a prepared snapshot")
+	}
+}
+
 // ContentVersion
 
 func TestManager_ContentVersion_Empty(t *testing.T) {
@@ -14,6 +14,8 @@ const (
 	SourceDisk    Source = "disk"
 	SourceTUF     Source = "tuf"
 	SourceS3      Source = "s3"
+	SourceOCI     Source = "oci"
+	SourceCache   Source = "cache"
 )
 
 type Meta struct {
@@ -29,4 +31,8 @@ type Meta struct {
 	// be nil when its bundle was not loaded; both populated for verified
 	// dual-signed releases.
 	Signatures *cryptoutil.SignaturesInfo `json:"signatures,omitempty"`
+
+	// Pointer is the signed pointer document that selected this bundle. Nil
+	// for sources without one (seed, disk, bare SSM hashes).
+	Pointer *Pointer `json:"pointer,omitempty"`
 }
@@ -0,0 +1,194 @@
+// internal/content/monotonic.go
+//
+// A pointer naming a bundle older than the one being served is usually an
+// accidental republish rather than an intended revert. With a version policy
+// set, the watcher compares each new bundle's provenance against the active
+// snapshot and refuses regressions unless the revert is explicitly allowed
+// (a signed rollback pointer or an operator override).
+package content
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// VersionPolicy selects the provenance field used to order bundles.
+type VersionPolicy string
+
+const (
+	// VersionPolicyOff swaps any bundle whose hash differs (the default).
+	VersionPolicyOff VersionPolicy = ""
+
+	// VersionPolicyCreatedAt orders bundles by release.json created_at.
+	VersionPolicyCreatedAt VersionPolicy = "created_at"
+
+	// VersionPolicyCommitDate orders bundles by release.json source.commit_date.
+	VersionPolicyCommitDate VersionPolicy = "commit_date"
+
+	// VersionPolicySemver orders bundles by release.json version as semver.
+	VersionPolicySemver VersionPolicy = "semver"
+)
+
+// ErrDowngrade marks a bundle rejected by the version policy.
+var ErrDowngrade = errors.New("content downgrade rejected")
+
+// ParseVersionPolicy validates a policy name; "" and "off" disable the check.
+func ParseVersionPolicy(s string) (VersionPolicy, error) {
+	switch p := VersionPolicy(strings.ToLower(strings.TrimSpace(s))); p {
+	case VersionPolicyOff, "off":
+		return VersionPolicyOff, nil
+	case VersionPolicyCreatedAt, VersionPolicyCommitDate, VersionPolicySemver:
+		return p, nil
+	default:
+		return "", xerrors.Newf("unknown content version policy %q (want created_at, commit_date, semver or off)", s)
+	}
+}
+
+// CheckMonotonic returns an ErrDowngrade error when incoming is older than
+// active under policy. Equal versions pass, so a rebuild of the same release
+// can still be published. When active has no value to compare (seed content,
+// no release.json) anything passes; when only incoming lacks one it is
+// rejected, since it can't be shown not to be a regression.
+func CheckMonotonic(policy VersionPolicy, incoming, active *Snapshot) error {
+	if policy == VersionPolicyOff || active == nil {
+		return nil
+	}
+	switch policy {
+	case VersionPolicyCreatedAt, VersionPolicyCommitDate:
+		cur, next := policyTime(policy, active.Provenance), policyTime(policy, incoming.Provenance)
+		if cur.IsZero() {
+			return nil
+		}
+		if next.IsZero() {
+			return xerrors.Wrapf(ErrDowngrade, "new bundle has no %s to compare", policy)
+		}
+		if next.Before(cur) {
+			return xerrors.Wrapf(ErrDowngrade, "%s %s is older than active %s",
+				policy, next.Format(time.RFC3339), cur.Format(time.RFC3339))
+		}
+		return nil
+	case VersionPolicySemver:
+		cur, next := provenanceVersion(active.Provenance), provenanceVersion(incoming.Provenance)
+		if _, err := parseSemver(cur); err != nil {
+			return nil //nolint:nilerr // nothing trustworthy to compare against
+		}
+		c, err := compareSemver(next, cur)
+		if err != nil {
+			return xerrors.Wrapf(ErrDowngrade, "new bundle version: %v", err)
+		}
+		if c < 0 {
+			return xerrors.Wrapf(ErrDowngrade, "version %s is older than active %s", next, cur)
+		}
+		return nil
+	default:
+		return xerrors.Newf("unknown content version policy %q", policy)
+	}
+}
+
+func policyTime(policy VersionPolicy, p *Provenance) time.Time {
+	if p == nil {
+		return time.Time{}
+	}
+	if policy == VersionPolicyCommitDate {
+		return p.Source.CommitDate
+	}
+	return p.CreatedAt
+}
+
+// semver is a parsed MAJOR.MINOR.PATCH[-PRERELEASE]; build metadata is dropped.
+type semver struct {
+	core [3]uint64
+	pre  []string
+}
+
+func parseSemver(s string) (semver, error) {
+	var v semver
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(s, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, xerrors.Newf("%q is not semver", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.ParseUint(p, 10, 64)
+		if err != nil {
+			return v, xerrors.Newf("%q is not semver", s)
+		}
+		v.core[i] = n
+	}
+	if hasPre {
+		if pre == "" {
+			return v, xerrors.Newf("%q has an empty pre-release", s)
+		}
+		v.pre = strings.Split(pre, ".")
+	}
+	return v, nil
+}
+
+// compareSemver orders a and b by semver precedence: -1, 0 or 1.
+func compareSemver(a, b string) (int, error) {
+	va, err := parseSemver(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseSemver(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range va.core {
+		if va.core[i] != vb.core[i] {
+			if va.core[i] < vb.core[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	// a release outranks any of its pre-releases
+	switch {
+	case len(va.pre) == 0 && len(vb.pre) == 0:
+		return 0, nil
+	case len(va.pre) == 0:
+		return 1, nil
+	case len(vb.pre) == 0:
+		return -1, nil
+	}
+	for i := 0; i < len(va.pre) && i < len(vb.pre); i++ {
+		if c := comparePreIdent(va.pre[i], vb.pre[i]); c != 0 {
+			return c, nil
+		}
+	}
+	switch {
+	case len(va.pre) < len(vb.pre):
+		return -1, nil
+	case len(va.pre) > len(vb.pre):
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// comparePreIdent compares pre-release identifiers: numeric ones numerically
+// and below alphanumeric ones, which compare lexically.
+func comparePreIdent(a, b string) int {
+	na, errA := strconv.ParseUint(a, 10, 64)
+	nb, errB := strconv.ParseUint(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+		return 0
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
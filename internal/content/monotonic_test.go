@@ -0,0 +1,205 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseVersionPolicy(t *testing.T) {
+	for in, want := range map[string]VersionPolicy{
+		"":            VersionPolicyOff,
+		"off":         VersionPolicyOff,
+		"created_at":  VersionPolicyCreatedAt,
+		"COMMIT_DATE": VersionPolicyCommitDate,
+		" semver ":    VersionPolicySemver,
+	} {
+		got, err := ParseVersionPolicy(in)
+		if err != nil || got != want {
+			t.Errorf("ParseVersionPolicy(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseVersionPolicy("newest"); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+}
+
+func TestCompareSemver(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3+build.5", "1.2.3+build.9", 0},
+		{"1.2.4", "1.2.3", 1},
+		{"1.10.0", "1.9.9", 1},
+		{"2.0.0", "10.0.0", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.11", 1},
+	}
+	for _, tc := range cases {
+		got, err := compareSemver(tc.a, tc.b)
+		if err != nil || got != tc.want {
+			t.Errorf("compareSemver(%q, %q) = %d, %v; want %d", tc.a, tc.b, got, err, tc.want)
+		}
+	}
+	for _, bad := range []string{"", "1.2", "1.2.x", "1.2.3-", "latest"} {
+		if _, err := compareSemver(bad, "1.0.0"); err == nil {
+			t.Errorf("compareSemver(%q) expected error", bad)
+		}
+	}
+}
+
+func provSnap(version string, created, commit time.Time) *Snapshot {
+	return &Snapshot{Provenance: &Provenance{
+		Version:   version,
+		CreatedAt: created,
+		Source:    ProvenanceSource{CommitDate: commit},
+	}}
+}
+
+func TestCheckMonotonic(t *testing.T) {
+	t0 := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	active := provSnap("1.4.0", t1, t1)
+
+	cases := []struct {
+		name      string
+		policy    VersionPolicy
+		incoming  *Snapshot
+		active    *Snapshot
+		downgrade bool
+	}{
+		{"off ignores regressions", VersionPolicyOff, provSnap("1.0.0", t0, t0), active, false},
+		{"created_at newer", VersionPolicyCreatedAt, provSnap("", t1.Add(time.Hour), time.Time{}), active, false},
+		{"created_at equal", VersionPolicyCreatedAt, provSnap("", t1, time.Time{}), active, false},
+		{"created_at older", VersionPolicyCreatedAt, provSnap("", t0, t1.Add(time.Hour)), active, true},
+		{"created_at missing", VersionPolicyCreatedAt, &Snapshot{}, active, true},
+		{"commit_date older", VersionPolicyCommitDate, provSnap("", t1.Add(time.Hour), t0), active, true},
+		{"commit_date newer", VersionPolicyCommitDate, provSnap("", t0, t1.Add(time.Hour)), active, false},
+		{"semver newer", VersionPolicySemver, provSnap("1.5.0", t0, t0), active, false},
+		{"semver older", VersionPolicySemver, provSnap("1.3.9", t1, t1), active, true},
+		{"semver unparseable", VersionPolicySemver, provSnap("main-abc123", t1, t1), active, true},
+		{"active without provenance", VersionPolicySemver, provSnap("0.0.1", t0, t0), &Snapshot{}, false},
+		{"no active", VersionPolicyCreatedAt, provSnap("", t0, t0), nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckMonotonic(tc.policy, tc.incoming, tc.active)
+			if got := errors.Is(err, ErrDowngrade); got != tc.downgrade {
+				t.Fatalf("downgrade = %v (err %v), want %v", got, err, tc.downgrade)
+			}
+		})
+	}
+}
+
+// watcher
+
+// storeVersionedBundle stores a bundle whose release.json carries version.
+func storeVersionedBundle(t *testing.T, f *watcherFixture, version string) string {
+	t.Helper()
+	return storeBundle(t, f, map[string]string{
+		"index.html":   "<html>" + version + "</html>",
+		"release.json": fmt.Sprintf(`{"version":%q}`, version),
+	})
+}
+
+// semverFixture serves version 2.0.0 with the semver policy enabled and
+// returns a stored 1.0.0 bundle ready to be pointed at.
+func semverFixture(t *testing.T) (f *watcherFixture, w *Watcher, m *fakeWatcherMetrics, hashNew, hashOld string) {
+	t.Helper()
+	f = newWatcherFixture(t, "")
+	hashNew = storeVersionedBundle(t, f, "2.0.0")
+	hashOld = storeVersionedBundle(t, f, "1.0.0")
+	snap, err := f.loader.LoadHash(t.Context(), "sha384", hashNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.mgr.Set(*snap)
+
+	m = newFakeWatcherMetrics()
+	w = f.newWatcher(func(o *WatcherOptions) {
+		o.Metrics = m
+		o.VersionPolicy = VersionPolicySemver
+	})
+	f.ssm.setValue(ssmValue(hashOld))
+	return f, w, m, hashNew, hashOld
+}
+
+func TestCheckOnce_VersionPolicy_RejectsDowngrade(t *testing.T) {
+	t.Parallel()
+	f, w, m, hashNew, hashOld := semverFixture(t)
+
+	if r := w.checkOnce(t.Context()); r != pollDowngradeRejected {
+		t.Fatalf("result = %d, want pollDowngradeRejected", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashNew {
+		t.Fatal("downgrade was swapped in")
+	}
+	if m.getErrors("downgrade") != 1 {
+		t.Fatalf("downgrade errors = %d, want 1", m.getErrors("downgrade"))
+	}
+	e, blocked := w.Quarantine().Blocked(QuarantineKey("sha384", hashOld))
+	if !blocked || e.Reason != QuarantineReasonDowngrade {
+		t.Fatalf("quarantine entry = %+v, %v", e, blocked)
+	}
+}
+
+func TestCheckOnce_VersionPolicy_AllowDowngrade(t *testing.T) {
+	t.Parallel()
+	f, w, _, _, hashOld := semverFixture(t)
+	key := QuarantineKey("sha384", hashOld)
+
+	_ = w.checkOnce(t.Context())
+	w.AllowDowngrade(key)
+	if w.DowngradeAllowed() != key {
+		t.Fatalf("DowngradeAllowed = %q", w.DowngradeAllowed())
+	}
+	if _, blocked := w.Quarantine().Blocked(key); blocked {
+		t.Fatal("AllowDowngrade should clear the quarantine entry")
+	}
+
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashOld {
+		t.Fatal("allowed downgrade not swapped in")
+	}
+	if w.DowngradeAllowed() != "" {
+		t.Fatal("override should be consumed by the swap")
+	}
+}
+
+func TestCheckOnce_VersionPolicy_SignedRollbackPointer(t *testing.T) {
+	t.Parallel()
+	f, w, _, _, hashOld := semverFixture(t)
+
+	created := time.Now().Add(-time.Minute)
+	doc := fmt.Sprintf(`{"schema":%q,"hash":"sha384:%s","created_at":%q,"expires_at":%q,"rollback":true}`,
+		PointerSchema, hashOld, created.Format(time.RFC3339), created.Add(time.Hour).Format(time.RFC3339))
+	putPointerSigs(f.s3, doc)
+	f.ssm.setValue(doc)
+
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashOld {
+		t.Fatal("signed rollback not swapped in")
+	}
+}
+
+func TestCheckOnce_VersionPolicy_AllowsUpgrade(t *testing.T) {
+	t.Parallel()
+	f, w, _, _, _ := semverFixture(t)
+	hashNewer := storeVersionedBundle(t, f, "2.1.0")
+	f.ssm.setValue(ssmValue(hashNewer))
+
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", r)
+	}
+}
@@ -0,0 +1,163 @@
+// internal/content/notify.go
+//
+// Change notification sources for the watcher. Polling SSM on an interval
+// bounds how quickly a publish goes live; these sources tell the watcher as
+// soon as something may have changed, and the watcher debounces them into a
+// single poll. Every notification only triggers a poll: the pointer it finds
+// is verified exactly as on a timed poll, so a forged event costs at most one
+// SSM read.
+package content
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// Notification sources, used as the metric label.
+const (
+	NotifySourceSignal = "signal"
+	NotifySourceAdmin  = "admin"
+	NotifySourceQueue  = "queue"
+)
+
+const (
+	// defaultQueueRetryDelay is the first wait after a failed receive.
+	defaultQueueRetryDelay = time.Second
+
+	// maxQueueRetryDelay caps the receive backoff.
+	maxQueueRetryDelay = time.Minute
+)
+
+// Notifiable receives change notifications; *Watcher implements it.
+type Notifiable interface {
+	Notify(source string)
+}
+
+// WatchSignals notifies target each time one of sigs arrives until ctx is
+// done. Intended to be launched as: go content.WatchSignals(ctx, w, syscall.SIGHUP)
+func WatchSignals(ctx context.Context, target Notifiable, sigs ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			target.Notify(NotifySourceSignal)
+		}
+	}
+}
+
+// QueueMessage is one change event received from a MessageQueue.
+type QueueMessage struct {
+	ID   string
+	Body []byte
+
+	// Receipt is the queue's handle for deleting the message.
+	Receipt string
+}
+
+// MessageQueue is a queue of change events, such as an SQS queue subscribed
+// to S3 object-created or EventBridge parameter-change events.
+type MessageQueue interface {
+	// Receive waits for messages, returning an empty batch if none arrive
+	// within the queue's long-poll window.
+	Receive(ctx context.Context) ([]QueueMessage, error)
+
+	// Delete acknowledges messages so they are not delivered again.
+	Delete(ctx context.Context, msgs []QueueMessage) error
+}
+
+// QueueConsumerOptions configures a QueueConsumer.
+type QueueConsumerOptions struct {
+	Logger log.Logger
+	Queue  MessageQueue
+	Target Notifiable
+
+	// Match, when set, selects the messages that signal a content change;
+	// the rest are acknowledged without notifying. Nil matches every message.
+	Match func(QueueMessage) bool
+
+	// RetryDelay is the first wait after a failed receive, doubled on each
+	// consecutive failure up to a minute. Zero uses one second.
+	RetryDelay time.Duration
+}
+
+// QueueConsumer turns messages from a MessageQueue into watcher
+// notifications, one per received batch.
+type QueueConsumer struct {
+	opts   QueueConsumerOptions
+	logger log.Logger
+}
+
+// NewQueueConsumer validates opts and returns a consumer. Call Run to start it.
+func NewQueueConsumer(opts *QueueConsumerOptions) (*QueueConsumer, error) {
+	if opts.Queue == nil {
+		return nil, xerrors.New("content: Queue is required")
+	}
+	if opts.Target == nil {
+		return nil, xerrors.New("content: Target is required")
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.Nop()
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultQueueRetryDelay
+	}
+	return &QueueConsumer{opts: *opts, logger: opts.Logger}, nil
+}
+
+// Run receives and acknowledges messages until ctx is done. Receive errors
+// are logged and retried with backoff.
+func (c *QueueConsumer) Run(ctx context.Context) error {
+	delay := c.opts.RetryDelay
+	for {
+		msgs, err := c.opts.Queue.Receive(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			c.logger.Warn(ctx, "content change queue receive failed",
+				"error", err,
+				"retry_in", delay.String(),
+			)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, maxQueueRetryDelay)
+			continue
+		}
+		delay = c.opts.RetryDelay
+		if len(msgs) == 0 {
+			continue
+		}
+
+		matched := 0
+		for _, m := range msgs {
+			if c.opts.Match == nil || c.opts.Match(m) {
+				matched++
+			}
+		}
+		if matched > 0 {
+			c.opts.Target.Notify(NotifySourceQueue)
+		}
+		c.logger.Debug(ctx, "content change queue batch",
+			"messages", len(msgs),
+			"matched", matched,
+		)
+
+		// the watcher polls regardless of delivery, so acknowledging before
+		// the poll loses nothing
+		if err := c.opts.Queue.Delete(ctx, msgs); err != nil {
+			c.logger.Warn(ctx, "content change queue delete failed", "error", err)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package content
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or the deadline passes.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for !cond() {
+		select {
+		case <-deadline:
+			t.Fatalf("timed out waiting for %s", what)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func TestRun_Notify_DebouncesBurst(t *testing.T) {
+	bundleDataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, bundleDataA)
+	hashB := storeBundle(t, f, map[string]string{"index.html": "<html>notified</html>"})
+
+	metrics := newFakeWatcherMetrics()
+	w := f.newWatcher(func(o *WatcherOptions) {
+		o.PollInterval = time.Hour
+		o.NotifyDebounce = 50 * time.Millisecond
+		o.Metrics = metrics
+		o.OnSwap = nil
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go w.Run(ctx)
+
+	f.ssm.setValue(ssmValue(hashB))
+	for range 5 {
+		w.Notify(NotifySourceQueue)
+	}
+	waitFor(t, "notified poll", func() bool { return metrics.getPolls() == 1 })
+	if got := f.mgr.ContentHash(); got != hashB {
+		t.Fatalf("hash = %q, want %q", got, hashB)
+	}
+
+	// the burst was answered by one poll
+	time.Sleep(150 * time.Millisecond)
+	if got := metrics.getPolls(); got != 1 {
+		t.Fatalf("polls = %d, want 1 for a burst", got)
+	}
+	metrics.mu.Lock()
+	notified := metrics.notifications[NotifySourceQueue]
+	metrics.mu.Unlock()
+	if notified != 5 {
+		t.Fatalf("notifications = %d, want 5", notified)
+	}
+
+	// a later notification polls again
+	w.Notify(NotifySourceSignal)
+	waitFor(t, "second notified poll", func() bool { return metrics.getPolls() == 2 })
+}
+
+func TestRun_Notify_WaitsForDebounce(t *testing.T) {
+	_, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	metrics := newFakeWatcherMetrics()
+	w := f.newWatcher(func(o *WatcherOptions) {
+		o.PollInterval = time.Hour
+		o.NotifyDebounce = time.Hour
+		o.Metrics = metrics
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go w.Run(ctx)
+
+	w.Notify(NotifySourceAdmin)
+	time.Sleep(50 * time.Millisecond)
+	if got := metrics.getPolls(); got != 0 {
+		t.Fatalf("polls = %d, want none before the debounce elapses", got)
+	}
+
+	// an explicit reload answers the pending notification too
+	w.RequestReload()
+	waitFor(t, "reload poll", func() bool { return metrics.getPolls() == 1 })
+	if got := w.notified.Load(); got != 0 {
+		t.Fatalf("pending notifications = %d after a poll, want 0", got)
+	}
+}
+
+func TestNewWatcher_DefaultNotifyDebounce(t *testing.T) {
+	_, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	if w := f.newWatcher(); w.debounce != DefaultNotifyDebounce {
+		t.Fatalf("debounce = %v, want %v", w.debounce, DefaultNotifyDebounce)
+	}
+}
+
+// recordingTarget is a Notifiable that records sources.
+type recordingTarget struct {
+	mu      sync.Mutex
+	sources []string
+}
+
+func (r *recordingTarget) Notify(source string) {
+	r.mu.Lock()
+	r.sources = append(r.sources, source)
+	r.mu.Unlock()
+}
+
+func (r *recordingTarget) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sources)
+}
+
+func TestWatchSignals(t *testing.T) {
+	target := &recordingTarget{}
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		WatchSignals(ctx, target, syscall.SIGUSR1)
+		close(done)
+	}()
+
+	// the handler is installed asynchronously; resend until it is seen
+	waitFor(t, "signal notification", func() bool {
+		_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+		return target.count() > 0
+	})
+	if target.sources[0] != NotifySourceSignal {
+		t.Fatalf("source = %q, want %q", target.sources[0], NotifySourceSignal)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WatchSignals did not return after cancel")
+	}
+}
+
+// fakeQueue is a local stand-in for an SQS-style queue: batches are handed
+// out in order, then Receive blocks until ctx is done.
+type fakeQueue struct {
+	mu      sync.Mutex
+	batches [][]QueueMessage
+	errs    []error
+	deleted []string
+	delErr  error
+}
+
+func (q *fakeQueue) Receive(ctx context.Context) ([]QueueMessage, error) {
+	q.mu.Lock()
+	if len(q.errs) > 0 {
+		err := q.errs[0]
+		q.errs = q.errs[1:]
+		q.mu.Unlock()
+		return nil, err
+	}
+	if len(q.batches) > 0 {
+		b := q.batches[0]
+		q.batches = q.batches[1:]
+		q.mu.Unlock()
+		return b, nil
+	}
+	q.mu.Unlock()
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (q *fakeQueue) Delete(_ context.Context, msgs []QueueMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, m := range msgs {
+		q.deleted = append(q.deleted, m.ID)
+	}
+	return q.delErr
+}
+
+func (q *fakeQueue) deletedCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.deleted)
+}
+
+func runConsumer(t *testing.T, opts *QueueConsumerOptions) (cancel func(), done <-chan error) {
+	t.Helper()
+	c, err := NewQueueConsumer(opts)
+	if err != nil {
+		t.Fatalf("NewQueueConsumer: %v", err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	ch := make(chan error, 1)
+	go func() { ch <- c.Run(ctx) }()
+	return cancel, ch
+}
+
+func TestQueueConsumer_NotifiesOncePerBatch(t *testing.T) {
+	q := &fakeQueue{batches: [][]QueueMessage{
+		{{ID: "1"}, {ID: "2"}, {ID: "3"}},
+		{},
+		{{ID: "4"}},
+	}}
+	target := &recordingTarget{}
+	cancel, done := runConsumer(t, &QueueConsumerOptions{Queue: q, Target: target})
+
+	waitFor(t, "all messages deleted", func() bool { return q.deletedCount() == 4 })
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run = %v, want context.Canceled", err)
+	}
+	if got := target.count(); got != 2 {
+		t.Fatalf("notifications = %d, want one per non-empty batch", got)
+	}
+	if target.sources[0] != NotifySourceQueue {
+		t.Fatalf("source = %q, want %q", target.sources[0], NotifySourceQueue)
+	}
+}
+
+func TestQueueConsumer_Match(t *testing.T) {
+	q := &fakeQueue{batches: [][]QueueMessage{
+		{{ID: "noise", Body: []byte(`{"detail-type":"Other"}`)}},
+		{{ID: "hit", Body: []byte(`{"detail-type":"Parameter Store Change"}`)}},
+	}}
+	target := &recordingTarget{}
+	cancel, done := runConsumer(t, &QueueConsumerOptions{
+		Queue:  q,
+		Target: target,
+		Match:  func(m QueueMessage) bool { return m.ID == "hit" },
+	})
+	defer func() { cancel(); <-done }()
+
+	waitFor(t, "both batches deleted", func() bool { return q.deletedCount() == 2 })
+	if got := target.count(); got != 1 {
+		t.Fatalf("notifications = %d, want only the matching batch", got)
+	}
+}
+
+func TestQueueConsumer_RetriesReceiveErrors(t *testing.T) {
+	q := &fakeQueue{
+		errs:    []error{errors.New("throttled"), errors.New("throttled")},
+		batches: [][]QueueMessage{{{ID: "1"}}},
+	}
+	target := &recordingTarget{}
+	cancel, done := runConsumer(t, &QueueConsumerOptions{Queue: q, Target: target, RetryDelay: time.Millisecond})
+	defer func() { cancel(); <-done }()
+
+	waitFor(t, "notification after errors", func() bool { return target.count() == 1 })
+}
+
+func TestQueueConsumer_DeleteErrorIsNotFatal(t *testing.T) {
+	q := &fakeQueue{
+		batches: [][]QueueMessage{{{ID: "1"}}, {{ID: "2"}}},
+		delErr:  errors.New("access denied"),
+	}
+	target := &recordingTarget{}
+	cancel, done := runConsumer(t, &QueueConsumerOptions{Queue: q, Target: target})
+	defer func() { cancel(); <-done }()
+
+	waitFor(t, "both batches", func() bool { return target.count() == 2 })
+}
+
+func TestNewQueueConsumer_Requires(t *testing.T) {
+	if _, err := NewQueueConsumer(&QueueConsumerOptions{Target: &recordingTarget{}}); err == nil {
+		t.Fatal("expected error without Queue")
+	}
+	if _, err := NewQueueConsumer(&QueueConsumerOptions{Queue: &fakeQueue{}}); err == nil {
+		t.Fatal("expected error without Target")
+	}
+}
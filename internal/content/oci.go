@@ -0,0 +1,661 @@
+// internal/content/oci.go
+//
+// OCIFetcher is a BundleFetcher that pulls content bundles from any OCI
+// distribution-spec registry (ECR, GHCR, a local registry:2, ...). The bundle
+// is published as an OCI artifact whose manifest carries the content tarball
+// as a layer; the KMS and keyless sigstore bundles are either additional
+// layers of the same manifest or referrers attached to it. The pointer is a
+// tag (or pinned manifest digest) instead of an SSM parameter, and the
+// tarball's layer digest is the bundle hash fed to the usual hash +
+// dual-signature verification.
+package content
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+const (
+	// OCIBundleMediaType is the layer media type of a content tarball.
+	OCIBundleMediaType = "application/vnd.linnemanlabs.content.bundle.v1.tar+gzip"
+
+	// OCISigstoreBundleMediaType is the media type of sigstore bundle layers
+	// and the artifactType of sigstore referrers.
+	OCISigstoreBundleMediaType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociIndexMediaType    = "application/vnd.oci.image.index.v1+json"
+	ociTitleAnnotation   = "org.opencontainers.image.title"
+
+	// maxOCIManifestSize caps manifest and referrers index downloads.
+	maxOCIManifestSize int64 = 4 * 1024 * 1024 // 4MB
+
+	// ociOpTimeout bounds individual registry requests.
+	ociOpTimeout = 60 * time.Second
+)
+
+// OCIFetcherOptions configures an OCIFetcher.
+type OCIFetcherOptions struct {
+	Logger log.Logger
+
+	// Registry is the registry host[:port], e.g.
+	// "123456789012.dkr.ecr.us-east-2.amazonaws.com".
+	Registry string
+
+	// Repository is the repository name within the registry.
+	Repository string
+
+	// Reference is the tag (e.g. "stable") or manifest digest
+	// ("sha256:...") that points at the current content artifact.
+	Reference string
+
+	// PlainHTTP talks to the registry over http instead of https. Local
+	// registries and tests only.
+	PlainHTTP bool
+
+	// Credentials returns registry credentials, used for basic auth and for
+	// bearer token exchange. ECR callers return "AWS" and the decoded
+	// authorization token. Nil means anonymous access.
+	Credentials func(ctx context.Context) (username, password string, err error)
+
+	// Verifier verifies the KMS sigstore bundle for the content bundle.
+	Verifier BlobVerifier
+
+	// KeylessVerifier verifies the keyless (Fulcio) sigstore bundle. Content
+	// bundles are dual-signed: both signatures are verified on every load.
+	KeylessVerifier BlobVerifier
+
+	// Inliner fills provenance data islands, as LoaderOptions.Inliner.
+	Inliner ProvenanceInliner
+
+	// HTTPClient allows injecting a custom client. Nil uses a default client.
+	HTTPClient *http.Client
+}
+
+// ociDescriptor is an OCI content descriptor.
+type ociDescriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest is an OCI image manifest.
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType"`
+	ArtifactType  string          `json:"artifactType,omitempty"`
+	Config        ociDescriptor   `json:"config"`
+	Layers        []ociDescriptor `json:"layers"`
+	Subject       *ociDescriptor  `json:"subject,omitempty"`
+}
+
+// ociIndex is an OCI image index, as returned by the referrers API.
+type ociIndex struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType"`
+	Manifests     []ociDescriptor `json:"manifests"`
+}
+
+// ociResolved is a resolved content artifact: the manifest digest and the
+// descriptors of the tarball and its two sigstore bundles.
+type ociResolved struct {
+	manifestDigest string
+	bundle         ociDescriptor
+	kms            ociDescriptor
+	keyless        ociDescriptor
+}
+
+// OCIFetcher pulls content bundles from an OCI registry.
+type OCIFetcher struct {
+	opts    OCIFetcherOptions
+	logger  log.Logger
+	client  *http.Client
+	baseURL string
+
+	mu       sync.Mutex
+	resolved *ociResolved // last resolved artifact, reused while the manifest digest is unchanged
+	token    string       // bearer token from the last successful token exchange
+}
+
+// NewOCIFetcher creates an OCIFetcher with the given options.
+func NewOCIFetcher(opts *OCIFetcherOptions) (*OCIFetcher, error) {
+	if opts.Registry == "" {
+		return nil, xerrors.New("content: Registry is required")
+	}
+	if opts.Repository == "" {
+		return nil, xerrors.New("content: Repository is required")
+	}
+	if opts.Reference == "" {
+		return nil, xerrors.New("content: Reference is required")
+	}
+	if opts.Verifier == nil {
+		return nil, xerrors.New("Verifier is required")
+	}
+	if opts.KeylessVerifier == nil {
+		return nil, xerrors.New("KeylessVerifier is required")
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.Nop()
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	scheme := "https"
+	if opts.PlainHTTP {
+		scheme = "http"
+	}
+	return &OCIFetcher{
+		opts:    *opts,
+		logger:  opts.Logger,
+		client:  client,
+		baseURL: fmt.Sprintf("%s://%s/v2/%s", scheme, opts.Registry, opts.Repository),
+	}, nil
+}
+
+// FetchCurrentBundleHash resolves Reference and returns the content tarball's
+// layer digest.
+func (f *OCIFetcher) FetchCurrentBundleHash(ctx context.Context) (hashAlgorithm, contentHash string, err error) {
+	res, err := f.resolve(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	algorithm, hash, ok := strings.Cut(res.bundle.Digest, ":")
+	if !ok {
+		return "", "", xerrors.Newf("bundle layer digest %q missing algorithm prefix", res.bundle.Digest)
+	}
+	return algorithm, hash, nil
+}
+
+// resolve returns the artifact Reference currently points at. A HEAD request
+// detects an unchanged manifest so steady-state polls cost one round trip.
+func (f *OCIFetcher) resolve(ctx context.Context) (*ociResolved, error) {
+	f.mu.Lock()
+	cached := f.resolved
+	f.mu.Unlock()
+
+	if cached != nil {
+		digest, err := f.headManifest(ctx, f.opts.Reference)
+		if err != nil {
+			return nil, err
+		}
+		if digest == cached.manifestDigest {
+			return cached, nil
+		}
+	}
+
+	raw, digest, err := f.getManifest(ctx, f.opts.Reference)
+	if err != nil {
+		return nil, err
+	}
+	var m ociManifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, xerrors.Wrap(err, "parse content manifest")
+	}
+	if m.MediaType != "" && m.MediaType != ociManifestMediaType {
+		return nil, xerrors.Newf("unsupported manifest media type %q", m.MediaType)
+	}
+
+	res := &ociResolved{manifestDigest: digest}
+	var haveBundle, haveKMS, haveKeyless bool
+	for _, l := range m.Layers {
+		switch {
+		case l.MediaType == OCIBundleMediaType:
+			if haveBundle {
+				return nil, xerrors.New("content manifest has more than one bundle layer")
+			}
+			res.bundle, haveBundle = l, true
+		case strings.HasSuffix(l.Annotations[ociTitleAnnotation], kmsBundleSuffix):
+			res.kms, haveKMS = l, true
+		case strings.HasSuffix(l.Annotations[ociTitleAnnotation], keylessBundleSuffix):
+			res.keyless, haveKeyless = l, true
+		}
+	}
+	if !haveBundle {
+		return nil, xerrors.Newf("content manifest %s has no %s layer", digest, OCIBundleMediaType)
+	}
+
+	// signatures not embedded in the artifact are looked up as referrers
+	if !haveKMS || !haveKeyless {
+		kms, keyless, err := f.referrerSignatures(ctx, digest)
+		if err != nil {
+			return nil, err
+		}
+		if !haveKMS && kms != nil {
+			res.kms, haveKMS = *kms, true
+		}
+		if !haveKeyless && keyless != nil {
+			res.keyless, haveKeyless = *keyless, true
+		}
+	}
+	if !haveKMS {
+		return nil, xerrors.Newf("no kms sigstore bundle for content manifest %s", digest)
+	}
+	if !haveKeyless {
+		return nil, xerrors.Newf("no keyless sigstore bundle for content manifest %s", digest)
+	}
+
+	f.mu.Lock()
+	f.resolved = res
+	f.mu.Unlock()
+
+	f.logger.Info(ctx, "resolved OCI content artifact",
+		"reference", f.opts.Reference,
+		"manifest_digest", digest,
+		"bundle_digest", res.bundle.Digest,
+	)
+	return res, nil
+}
+
+// referrerSignatures finds the sigstore bundles attached to subject via the
+// referrers API, falling back to the sha256-<hex> referrers tag schema for
+// registries without it. Either result may be nil.
+func (f *OCIFetcher) referrerSignatures(ctx context.Context, subject string) (kms, keyless *ociDescriptor, err error) {
+	idx, err := f.referrers(ctx, subject)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, d := range idx.Manifests {
+		if d.ArtifactType != "" && d.ArtifactType != OCISigstoreBundleMediaType {
+			continue
+		}
+		raw, _, err := f.getManifest(ctx, d.Digest)
+		if err != nil {
+			return nil, nil, xerrors.Wrap(err, "fetch referrer manifest")
+		}
+		var m ociManifest
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return nil, nil, xerrors.Wrap(err, "parse referrer manifest")
+		}
+		if m.Subject == nil || m.Subject.Digest != subject {
+			continue
+		}
+		for _, l := range m.Layers {
+			title := l.Annotations[ociTitleAnnotation]
+			switch {
+			case kms == nil && strings.HasSuffix(title, kmsBundleSuffix):
+				kms = &l
+			case keyless == nil && strings.HasSuffix(title, keylessBundleSuffix):
+				keyless = &l
+			}
+		}
+	}
+	return kms, keyless, nil
+}
+
+func (f *OCIFetcher) referrers(ctx context.Context, subject string) (*ociIndex, error) {
+	u := f.baseURL + "/referrers/" + subject + "?artifactType=" + url.QueryEscape(OCISigstoreBundleMediaType)
+	raw, status, err := f.get(ctx, http.MethodGet, u, ociIndexMediaType, maxOCIManifestSize)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "list referrers")
+	}
+	if status == http.StatusNotFound {
+		// referrers tag schema fallback
+		tag := strings.Replace(subject, ":", "-", 1)
+		raw, status, err = f.get(ctx, http.MethodGet, f.baseURL+"/manifests/"+tag, ociIndexMediaType, maxOCIManifestSize)
+		if err != nil {
+			return nil, xerrors.Wrap(err, "fetch referrers tag")
+		}
+		if status == http.StatusNotFound {
+			return &ociIndex{}, nil
+		}
+	}
+	if status != http.StatusOK {
+		return nil, xerrors.Newf("list referrers: unexpected status %d", status)
+	}
+
+	var idx ociIndex
+	if err := json.Unmarshal(raw, &idx); err != nil {
+		return nil, xerrors.Wrap(err, "parse referrers index")
+	}
+	return &idx, nil
+}
+
+// headManifest returns the digest the registry reports for ref.
+func (f *OCIFetcher) headManifest(ctx context.Context, ref string) (string, error) {
+	if strings.HasPrefix(ref, "sha256:") {
+		return ref, nil
+	}
+	resp, err := f.do(ctx, http.MethodHead, f.baseURL+"/manifests/"+ref, ociManifestMediaType)
+	if err != nil {
+		return "", xerrors.Wrapf(err, "head manifest %s", ref)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", xerrors.Newf("head manifest %s: unexpected status %d", ref, resp.StatusCode)
+	}
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// getManifest downloads the manifest for ref and verifies its digest. The
+// digest is computed locally, never taken from the registry on trust.
+func (f *OCIFetcher) getManifest(ctx context.Context, ref string) (raw []byte, digest string, err error) {
+	raw, status, err := f.get(ctx, http.MethodGet, f.baseURL+"/manifests/"+ref, ociManifestMediaType, maxOCIManifestSize)
+	if err != nil {
+		return nil, "", xerrors.Wrapf(err, "get manifest %s", ref)
+	}
+	if status != http.StatusOK {
+		return nil, "", xerrors.Newf("get manifest %s: unexpected status %d", ref, status)
+	}
+	digest = "sha256:" + cryptoutil.SHA256Hex(raw)
+	if strings.HasPrefix(ref, "sha256:") && !cryptoutil.HashEqual(digest, ref) {
+		return nil, "", xerrors.Newf("manifest digest mismatch: expected %s, got %s", ref, digest)
+	}
+	return raw, digest, nil
+}
+
+// getBlob downloads a blob and checks its size and digest.
+func (f *OCIFetcher) getBlob(ctx context.Context, d ociDescriptor, maxSize int64) ([]byte, error) {
+	if d.Size <= 0 || d.Size > maxSize {
+		return nil, xerrors.Newf("blob %s size %d out of range (max %d)", d.Digest, d.Size, maxSize)
+	}
+	algorithm, want, ok := strings.Cut(d.Digest, ":")
+	if !ok {
+		return nil, xerrors.Newf("blob digest %q missing algorithm prefix", d.Digest)
+	}
+
+	resp, err := f.do(ctx, http.MethodGet, f.baseURL+"/blobs/"+d.Digest, "")
+	if err != nil {
+		return nil, xerrors.Wrapf(err, "get blob %s", d.Digest)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, xerrors.Newf("get blob %s: unexpected status %d", d.Digest, resp.StatusCode)
+	}
+
+	data, got, err := readWithHash(resp.Body, d.Size, algorithm)
+	if err != nil {
+		return nil, xerrors.Wrapf(err, "read blob %s", d.Digest)
+	}
+	if int64(len(data)) != d.Size {
+		return nil, xerrors.Newf("blob %s size %d, expected %d", d.Digest, len(data), d.Size)
+	}
+	if !cryptoutil.HashEqual(got, want) {
+		return nil, xerrors.Newf("checksum mismatch: expected %s, got %s", want, got)
+	}
+	return data, nil
+}
+
+// get performs a request and returns the body (limited to maxSize) and status.
+func (f *OCIFetcher) get(ctx context.Context, method, u, accept string, maxSize int64) ([]byte, int, error) {
+	resp, err := f.do(ctx, method, u, accept)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, 0, xerrors.Wrapf(err, "read %s", u)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, 0, xerrors.Newf("%s exceeds max size (%d bytes)", u, maxSize)
+	}
+	return data, resp.StatusCode, nil
+}
+
+// do sends a registry request, answering a 401 challenge once with basic
+// credentials or a bearer token obtained from the challenge's realm.
+func (f *OCIFetcher) do(ctx context.Context, method, u, accept string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, ociOpTimeout)
+
+	send := func(authz string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u, http.NoBody)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if authz != "" {
+			req.Header.Set("Authorization", authz)
+		}
+		return f.client.Do(req)
+	}
+
+	f.mu.Lock()
+	token := f.token
+	f.mu.Unlock()
+	authz := ""
+	if token != "" {
+		authz = "Bearer " + token
+	}
+
+	resp, err := send(authz)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		authz, err = f.authorize(ctx, challenge)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		resp, err = send(authz)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// authorize answers a WWW-Authenticate challenge with an Authorization value.
+func (f *OCIFetcher) authorize(ctx context.Context, challenge string) (string, error) {
+	scheme, params := parseAuthChallenge(challenge)
+	var user, pass string
+	if f.opts.Credentials != nil {
+		var err error
+		user, pass, err = f.opts.Credentials(ctx)
+		if err != nil {
+			return "", xerrors.Wrap(err, "registry credentials")
+		}
+	}
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if f.opts.Credentials == nil {
+			return "", xerrors.New("registry requires basic auth but no credentials are configured")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass)), nil
+
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return "", xerrors.New("bearer challenge has no realm")
+		}
+		q := url.Values{}
+		if s := params["service"]; s != "" {
+			q.Set("service", s)
+		}
+		scope := params["scope"]
+		if scope == "" {
+			scope = "repository:" + f.opts.Repository + ":pull"
+		}
+		q.Set("scope", scope)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+"?"+q.Encode(), http.NoBody)
+		if err != nil {
+			return "", xerrors.Wrap(err, "build token request")
+		}
+		if f.opts.Credentials != nil {
+			req.SetBasicAuth(user, pass)
+		}
+		resp, err := f.client.Do(req)
+		if err != nil {
+			return "", xerrors.Wrap(err, "token request")
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", xerrors.Newf("token request: unexpected status %d", resp.StatusCode)
+		}
+		var tok struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, maxSigBundleSize)).Decode(&tok); err != nil {
+			return "", xerrors.Wrap(err, "parse token response")
+		}
+		token := tok.Token
+		if token == "" {
+			token = tok.AccessToken
+		}
+		if token == "" {
+			return "", xerrors.New("token response has no token")
+		}
+		f.mu.Lock()
+		f.token = token
+		f.mu.Unlock()
+		return "Bearer " + token, nil
+
+	default:
+		return "", xerrors.Newf("unsupported registry auth challenge %q", challenge)
+	}
+}
+
+// parseAuthChallenge splits `Bearer realm="...",service="..."` into its
+// scheme and parameters.
+func parseAuthChallenge(h string) (scheme string, params map[string]string) {
+	params = make(map[string]string)
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(h), " ")
+	for rest != "" {
+		var kv string
+		rest = strings.TrimLeft(rest, " ,")
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		if strings.HasPrefix(after, `"`) {
+			end := strings.Index(after[1:], `"`)
+			if end < 0 {
+				break
+			}
+			kv = after[1 : end+1]
+			rest = after[end+2:]
+		} else {
+			kv, rest, _ = strings.Cut(after, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = kv
+	}
+	return scheme, params
+}
+
+// cancelOnClose releases a request's timeout context when its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// Load resolves Reference and returns a Snapshot of the current bundle.
+func (f *OCIFetcher) Load(ctx context.Context) (*Snapshot, error) {
+	algorithm, hash, err := f.FetchCurrentBundleHash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f.LoadHash(ctx, algorithm, hash)
+}
+
+// LoadHash downloads the bundle layer with the given digest from the
+// artifact Reference currently points at, verifies its digest and both
+// sigstore bundles, and returns a Snapshot.
+func (f *OCIFetcher) LoadHash(ctx context.Context, algorithm, hash string) (*Snapshot, error) {
+	loadedAt := time.Now().UTC()
+	digest := algorithm + ":" + hash
+
+	f.mu.Lock()
+	res := f.resolved
+	f.mu.Unlock()
+	if res == nil || !cryptoutil.HashEqual(res.bundle.Digest, digest) {
+		var err error
+		if res, err = f.resolve(ctx); err != nil {
+			return nil, err
+		}
+		if !cryptoutil.HashEqual(res.bundle.Digest, digest) {
+			return nil, xerrors.Newf("bundle %s is not referenced by %s", truncHash(hash), f.opts.Reference)
+		}
+	}
+
+	f.logger.Info(ctx, "fetching content bundle",
+		"registry", f.opts.Registry,
+		"repository", f.opts.Repository,
+		"digest", digest,
+	)
+
+	data, err := f.getBlob(ctx, res.bundle, maxBundleSize)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "fetch content bundle")
+	}
+
+	kmsBundleJSON, err := f.getBlob(ctx, res.kms, maxSigBundleSize)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "fetch kms sigstore bundle")
+	}
+	if err := f.opts.Verifier.VerifyBlob(ctx, kmsBundleJSON, data); err != nil {
+		return nil, xerrors.Wrap(err, "content bundle kms signature verification failed")
+	}
+
+	keylessBundleJSON, err := f.getBlob(ctx, res.keyless, maxSigBundleSize)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "fetch keyless sigstore bundle")
+	}
+	if err := f.opts.KeylessVerifier.VerifyBlob(ctx, keylessBundleJSON, data); err != nil {
+		return nil, xerrors.Wrap(err, "content bundle keyless signature verification failed")
+	}
+
+	signatures := signaturesInfo(ctx, f.logger, hash, kmsBundleJSON, keylessBundleJSON)
+
+	contentFS, err := extractTarGzToMem(data)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "extract bundle")
+	}
+
+	snap := newSnapshot(ctx, f.logger, contentFS, Meta{
+		Hash:          hash,
+		HashAlgorithm: algorithm,
+		Source:        SourceOCI,
+		VerifiedAt:    time.Now().UTC(),
+		Signatures:    signatures,
+	}, loadedAt)
+
+	inlineProvenance(ctx, f.logger, f.opts.Inliner, snap)
+
+	return snap, nil
+}
+
+// LoadIntoManager resolves Reference and updates the content manager.
+func (f *OCIFetcher) LoadIntoManager(ctx context.Context, mgr *Manager) error {
+	snap, err := f.Load(ctx)
+	if err != nil {
+		return err
+	}
+	mgr.Set(*snap)
+	return nil
+}
@@ -0,0 +1,126 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// ECR authorization tokens are valid for 12 hours; refresh well before expiry
+// so a poll never races an expiring token.
+const ecrTokenRefreshMargin = 30 * time.Minute
+
+// ECRCredentials returns an OCIFetcherOptions.Credentials func that exchanges
+// the AWS credentials in cfg for an ECR registry password via
+// GetAuthorizationToken. The token is cached until shortly before it expires.
+//
+// The call is signed directly rather than through the ECR SDK client; it is
+// the only ECR API the server needs.
+func ECRCredentials(cfg aws.Config, httpClient *http.Client) func(ctx context.Context) (string, string, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	endpoint := "https://api.ecr." + cfg.Region + ".amazonaws.com"
+	if cfg.BaseEndpoint != nil {
+		endpoint = strings.TrimSuffix(*cfg.BaseEndpoint, "/")
+	}
+
+	var (
+		mu      sync.Mutex
+		user    string
+		pass    string
+		expires time.Time
+	)
+
+	return func(ctx context.Context) (string, string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if pass != "" && time.Until(expires) > ecrTokenRefreshMargin {
+			return user, pass, nil
+		}
+
+		u, p, exp, err := ecrGetAuthorizationToken(ctx, cfg, httpClient, endpoint)
+		if err != nil {
+			return "", "", err
+		}
+		user, pass, expires = u, p, exp
+		return user, pass, nil
+	}
+}
+
+func ecrGetAuthorizationToken(ctx context.Context, cfg aws.Config, client *http.Client, endpoint string) (user, pass string, expires time.Time, err error) {
+	if cfg.Credentials == nil {
+		return "", "", time.Time{}, xerrors.New("ecr: no AWS credentials configured")
+	}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return "", "", time.Time{}, xerrors.Wrap(err, "ecr: retrieve AWS credentials")
+	}
+
+	body := []byte("{}")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", "", time.Time{}, xerrors.Wrap(err, "ecr: build request")
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken")
+
+	sum := sha256.Sum256(body)
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "ecr", cfg.Region, time.Now()); err != nil {
+		return "", "", time.Time{}, xerrors.Wrap(err, "ecr: sign request")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", "", time.Time{}, xerrors.Wrap(err, "ecr: GetAuthorizationToken")
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxSigBundleSize))
+	if err != nil {
+		return "", "", time.Time{}, xerrors.Wrap(err, "ecr: read response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", "", time.Time{}, xerrors.Newf("ecr: GetAuthorizationToken status %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	var out struct {
+		AuthorizationData []struct {
+			AuthorizationToken string  `json:"authorizationToken"`
+			ExpiresAt          float64 `json:"expiresAt"`
+		} `json:"authorizationData"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return "", "", time.Time{}, xerrors.Wrap(err, "ecr: parse response")
+	}
+	if len(out.AuthorizationData) == 0 {
+		return "", "", time.Time{}, xerrors.New("ecr: response has no authorization data")
+	}
+
+	data := out.AuthorizationData[0]
+	decoded, err := base64.StdEncoding.DecodeString(data.AuthorizationToken)
+	if err != nil {
+		return "", "", time.Time{}, xerrors.Wrap(err, "ecr: decode authorization token")
+	}
+	user, pass, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return "", "", time.Time{}, xerrors.New("ecr: malformed authorization token")
+	}
+	// expiresAt is epoch seconds (with fraction) in the JSON protocol
+	expires = time.Unix(0, int64(data.ExpiresAt*float64(time.Second)))
+	return user, pass, expires, nil
+}
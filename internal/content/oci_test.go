@@ -0,0 +1,482 @@
+package content
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+)
+
+// In-process OCI registry stand-in
+
+const (
+	testOCIRepo = "linnemanlabs/content"
+	testOCITag  = "stable"
+)
+
+// fakeRegistry implements the subset of the distribution spec the fetcher
+// uses: manifests (GET/HEAD by tag or digest), blobs, and referrers.
+type fakeRegistry struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	manifests map[string][]byte // by digest
+	tags      map[string]string // tag -> digest
+	blobs     map[string][]byte // by digest
+	referrers map[string][]ociDescriptor
+	noAPI     bool // 404 the referrers API to exercise the tag-schema fallback
+
+	// bearer auth; empty disables
+	token     string
+	heads     int
+	manifestN int
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	t.Helper()
+	r := &fakeRegistry{
+		t:         t,
+		manifests: make(map[string][]byte),
+		tags:      make(map[string]string),
+		blobs:     make(map[string][]byte),
+		referrers: make(map[string][]ociDescriptor),
+	}
+	r.srv = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.srv.Close)
+	return r
+}
+
+func (r *fakeRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.URL.Path == "/token" {
+		user, pass, ok := req.BasicAuth()
+		if !ok || user != "AWS" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": r.token})
+		return
+	}
+	if r.token != "" && req.Header.Get("Authorization") != "Bearer "+r.token {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+r.srv.URL+`/token",service="fake",scope="repository:`+testOCIRepo+`:pull"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	prefix := "/v2/" + testOCIRepo + "/"
+	if !strings.HasPrefix(req.URL.Path, prefix) {
+		http.NotFound(w, req)
+		return
+	}
+	kind, ref, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, prefix), "/")
+
+	switch kind {
+	case "manifests":
+		digest := ref
+		if d, ok := r.tags[ref]; ok {
+			digest = d
+		}
+		data, ok := r.manifests[digest]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Header().Set("Content-Type", ociManifestMediaType)
+		if req.Method == http.MethodHead {
+			r.heads++
+			return
+		}
+		r.manifestN++
+		_, _ = w.Write(data)
+	case "blobs":
+		data, ok := r.blobs[ref]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(data)
+	case "referrers":
+		if r.noAPI {
+			http.NotFound(w, req)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(ociIndex{SchemaVersion: 2, MediaType: ociIndexMediaType, Manifests: r.referrers[ref]})
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (r *fakeRegistry) putBlob(data []byte, mediaType, title string) ociDescriptor {
+	d := ociDescriptor{MediaType: mediaType, Digest: "sha256:" + cryptoutil.SHA256Hex(data), Size: int64(len(data))}
+	if title != "" {
+		d.Annotations = map[string]string{ociTitleAnnotation: title}
+	}
+	r.mu.Lock()
+	r.blobs[d.Digest] = data
+	r.mu.Unlock()
+	return d
+}
+
+func (r *fakeRegistry) putManifest(m ociManifest, tag string) string {
+	m.SchemaVersion = 2
+	m.MediaType = ociManifestMediaType
+	raw, err := json.Marshal(m)
+	if err != nil {
+		r.t.Fatalf("marshal manifest: %v", err)
+	}
+	digest := "sha256:" + cryptoutil.SHA256Hex(raw)
+	r.mu.Lock()
+	r.manifests[digest] = raw
+	if tag != "" {
+		r.tags[tag] = digest
+	}
+	r.mu.Unlock()
+	return digest
+}
+
+// pushBundle publishes a content artifact with the sigstore bundles embedded
+// as layers and returns the bundle layer descriptor.
+func (r *fakeRegistry) pushBundle(t *testing.T, body string) ociDescriptor {
+	t.Helper()
+	bundle := r.putBlob(makeTarGz(t, map[string]string{"index.html": body}), OCIBundleMediaType, "site.tar.gz")
+	kms := r.putBlob([]byte(`{"mock":"kms"}`), OCISigstoreBundleMediaType, "site.tar.gz"+kmsBundleSuffix)
+	keyless := r.putBlob([]byte(`{"mock":"keyless"}`), OCISigstoreBundleMediaType, "site.tar.gz"+keylessBundleSuffix)
+	r.putManifest(ociManifest{Layers: []ociDescriptor{bundle, kms, keyless}}, testOCITag)
+	return bundle
+}
+
+// pushBundleWithReferrers publishes the tarball alone and attaches each
+// sigstore bundle as a referrer manifest.
+func (r *fakeRegistry) pushBundleWithReferrers(t *testing.T, body string) ociDescriptor {
+	t.Helper()
+	bundle := r.putBlob(makeTarGz(t, map[string]string{"index.html": body}), OCIBundleMediaType, "site.tar.gz")
+	subject := r.putManifest(ociManifest{Layers: []ociDescriptor{bundle}}, testOCITag)
+	subjectDesc := &ociDescriptor{MediaType: ociManifestMediaType, Digest: subject}
+
+	var refs []ociDescriptor
+	for _, suffix := range []string{kmsBundleSuffix, keylessBundleSuffix} {
+		layer := r.putBlob([]byte(`{"mock":"`+suffix+`"}`), OCISigstoreBundleMediaType, "site.tar.gz"+suffix)
+		d := r.putManifest(ociManifest{ArtifactType: OCISigstoreBundleMediaType, Layers: []ociDescriptor{layer}, Subject: subjectDesc}, "")
+		refs = append(refs, ociDescriptor{MediaType: ociManifestMediaType, Digest: d, ArtifactType: OCISigstoreBundleMediaType})
+	}
+
+	r.mu.Lock()
+	r.referrers[subject] = refs
+	// referrers tag schema, for registries without the referrers API
+	idx, _ := json.Marshal(ociIndex{SchemaVersion: 2, MediaType: ociIndexMediaType, Manifests: refs})
+	tagDigest := "sha256:" + cryptoutil.SHA256Hex(idx)
+	r.manifests[tagDigest] = idx
+	r.tags[strings.Replace(subject, ":", "-", 1)] = tagDigest
+	r.mu.Unlock()
+	return bundle
+}
+
+func (r *fakeRegistry) fetcher(t *testing.T, mutate ...func(*OCIFetcherOptions)) *OCIFetcher {
+	t.Helper()
+	opts := &OCIFetcherOptions{
+		Logger:          log.Nop(),
+		Registry:        strings.TrimPrefix(r.srv.URL, "http://"),
+		Repository:      testOCIRepo,
+		Reference:       testOCITag,
+		PlainHTTP:       true,
+		Verifier:        passVerifier(),
+		KeylessVerifier: passVerifier(),
+	}
+	for _, m := range mutate {
+		m(opts)
+	}
+	f, err := NewOCIFetcher(opts)
+	if err != nil {
+		t.Fatalf("NewOCIFetcher: %v", err)
+	}
+	return f
+}
+
+// NewOCIFetcher
+
+func TestNewOCIFetcher_RequiredOptions(t *testing.T) {
+	base := OCIFetcherOptions{
+		Registry: "r", Repository: "repo", Reference: "tag",
+		Verifier: passVerifier(), KeylessVerifier: passVerifier(),
+	}
+	for name, mutate := range map[string]func(*OCIFetcherOptions){
+		"Registry":        func(o *OCIFetcherOptions) { o.Registry = "" },
+		"Repository":      func(o *OCIFetcherOptions) { o.Repository = "" },
+		"Reference":       func(o *OCIFetcherOptions) { o.Reference = "" },
+		"Verifier":        func(o *OCIFetcherOptions) { o.Verifier = nil },
+		"KeylessVerifier": func(o *OCIFetcherOptions) { o.KeylessVerifier = nil },
+	} {
+		o := base
+		mutate(&o)
+		if _, err := NewOCIFetcher(&o); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// Fetch + load
+
+func TestOCIFetcher_LoadEmbeddedSignatures(t *testing.T) {
+	r := newFakeRegistry(t)
+	bundle := r.pushBundle(t, "v1")
+	kms, keyless := passVerifier(), passVerifier()
+	f := r.fetcher(t, func(o *OCIFetcherOptions) { o.Verifier, o.KeylessVerifier = kms, keyless })
+
+	algo, hash, err := f.FetchCurrentBundleHash(t.Context())
+	if err != nil {
+		t.Fatalf("FetchCurrentBundleHash: %v", err)
+	}
+	if algo+":"+hash != bundle.Digest {
+		t.Fatalf("got %s:%s, want %s", algo, hash, bundle.Digest)
+	}
+
+	snap, err := f.LoadHash(t.Context(), algo, hash)
+	if err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if snap.Meta.Source != SourceOCI {
+		t.Fatalf("Source = %q, want %q", snap.Meta.Source, SourceOCI)
+	}
+	if got := readFileFromFS(t, snap.FS, "index.html"); got != "v1" {
+		t.Fatalf("index.html = %q", got)
+	}
+	if string(kms.gotBundle) != `{"mock":"kms"}` || string(keyless.gotBundle) != `{"mock":"keyless"}` {
+		t.Fatalf("verifiers got kms=%q keyless=%q", kms.gotBundle, keyless.gotBundle)
+	}
+}
+
+func TestOCIFetcher_LoadReferrerSignatures(t *testing.T) {
+	r := newFakeRegistry(t)
+	r.pushBundleWithReferrers(t, "v1")
+	kms := passVerifier()
+	f := r.fetcher(t, func(o *OCIFetcherOptions) { o.Verifier = kms })
+
+	if _, err := f.Load(t.Context()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !strings.Contains(string(kms.gotBundle), kmsBundleSuffix) {
+		t.Fatalf("kms verifier got %q", kms.gotBundle)
+	}
+}
+
+func TestOCIFetcher_ReferrersTagSchemaFallback(t *testing.T) {
+	r := newFakeRegistry(t)
+	r.pushBundleWithReferrers(t, "v1")
+	r.noAPI = true
+
+	if _, err := r.fetcher(t).Load(t.Context()); err != nil {
+		t.Fatalf("Load with referrers tag fallback: %v", err)
+	}
+}
+
+func TestOCIFetcher_MissingSignature(t *testing.T) {
+	r := newFakeRegistry(t)
+	bundle := r.putBlob(makeTarGz(t, map[string]string{"index.html": "x"}), OCIBundleMediaType, "site.tar.gz")
+	r.putManifest(ociManifest{Layers: []ociDescriptor{bundle}}, testOCITag)
+
+	_, _, err := r.fetcher(t).FetchCurrentBundleHash(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "no kms sigstore bundle") {
+		t.Fatalf("expected missing signature error, got %v", err)
+	}
+}
+
+func TestOCIFetcher_SignatureFails(t *testing.T) {
+	r := newFakeRegistry(t)
+	r.pushBundle(t, "v1")
+	f := r.fetcher(t, func(o *OCIFetcherOptions) { o.KeylessVerifier = failVerifier("bad cert") })
+
+	_, err := f.Load(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "keyless signature verification failed") {
+		t.Fatalf("expected keyless failure, got %v", err)
+	}
+}
+
+func TestOCIFetcher_TamperedBlob(t *testing.T) {
+	r := newFakeRegistry(t)
+	bundle := r.pushBundle(t, "v1")
+	r.mu.Lock()
+	data := append([]byte{}, r.blobs[bundle.Digest]...)
+	data[len(data)-1] ^= 0xff
+	r.blobs[bundle.Digest] = data
+	r.mu.Unlock()
+
+	_, err := r.fetcher(t).Load(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestOCIFetcher_DigestReference(t *testing.T) {
+	r := newFakeRegistry(t)
+	r.pushBundle(t, "v1")
+	r.mu.Lock()
+	digest := r.tags[testOCITag]
+	r.mu.Unlock()
+
+	f := r.fetcher(t, func(o *OCIFetcherOptions) { o.Reference = digest })
+	if _, err := f.Load(t.Context()); err != nil {
+		t.Fatalf("Load by digest: %v", err)
+	}
+
+	// a registry serving different bytes for a pinned digest is rejected
+	r.mu.Lock()
+	r.manifests[digest] = []byte(`{"schemaVersion":2,"layers":[]}`)
+	r.mu.Unlock()
+	f = r.fetcher(t, func(o *OCIFetcherOptions) { o.Reference = digest })
+	if _, _, err := f.FetchCurrentBundleHash(t.Context()); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected manifest digest mismatch, got %v", err)
+	}
+}
+
+func TestOCIFetcher_LoadHashRejectsUnreferencedDigest(t *testing.T) {
+	r := newFakeRegistry(t)
+	r.pushBundle(t, "v1")
+	f := r.fetcher(t)
+
+	_, err := f.LoadHash(t.Context(), "sha256", strings.Repeat("a", 64))
+	if err == nil || !strings.Contains(err.Error(), "not referenced") {
+		t.Fatalf("expected unreferenced digest error, got %v", err)
+	}
+}
+
+func TestOCIFetcher_UnchangedTagUsesHead(t *testing.T) {
+	r := newFakeRegistry(t)
+	r.pushBundle(t, "v1")
+	f := r.fetcher(t)
+
+	for range 3 {
+		if _, _, err := f.FetchCurrentBundleHash(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.manifestN != 1 || r.heads != 2 {
+		t.Fatalf("manifest GETs = %d, HEADs = %d; want 1 and 2", r.manifestN, r.heads)
+	}
+}
+
+func TestOCIFetcher_BearerTokenAuth(t *testing.T) {
+	r := newFakeRegistry(t)
+	r.pushBundle(t, "v1")
+	r.token = "tok-123"
+
+	f := r.fetcher(t, func(o *OCIFetcherOptions) {
+		o.Credentials = func(_ context.Context) (string, string, error) { return "AWS", "secret", nil }
+	})
+	if _, err := f.Load(t.Context()); err != nil {
+		t.Fatalf("Load with bearer auth: %v", err)
+	}
+
+	anon := r.fetcher(t)
+	if _, err := anon.Load(t.Context()); err == nil {
+		t.Fatal("expected anonymous pull to fail")
+	}
+}
+
+func TestOCIFetcher_WatcherSwapOnRetag(t *testing.T) {
+	r := newFakeRegistry(t)
+	r.pushBundle(t, "v1")
+	f := r.fetcher(t)
+	mgr := NewManager()
+	if err := f.LoadIntoManager(t.Context(), mgr); err != nil {
+		t.Fatalf("LoadIntoManager: %v", err)
+	}
+
+	w := NewWatcher(&WatcherOptions{Loader: f, Manager: mgr, Validation: &ValidationOptions{MinFiles: 1}})
+	if got := w.checkOnce(t.Context()); got != pollNoChange {
+		t.Fatalf("result = %d, want pollNoChange", got)
+	}
+	r.pushBundle(t, "v2")
+	if got := w.checkOnce(t.Context()); got != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", got)
+	}
+	if mgr.Source() != SourceOCI {
+		t.Fatalf("Source = %q, want %q", mgr.Source(), SourceOCI)
+	}
+}
+
+func TestParseAuthChallenge(t *testing.T) {
+	scheme, params := parseAuthChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:a/b:pull"`)
+	if scheme != "Bearer" {
+		t.Fatalf("scheme = %q", scheme)
+	}
+	if params["realm"] != "https://auth.example.com/token" || params["service"] != "registry.example.com" || params["scope"] != "repository:a/b:pull" {
+		t.Fatalf("params = %v", params)
+	}
+}
+
+// ECR credentials
+
+func TestECRCredentials_ExchangesAndCaches(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if got := r.Header.Get("X-Amz-Target"); got != "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken" {
+			t.Errorf("X-Amz-Target = %q", got)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+			t.Errorf("request not SigV4 signed: %q", r.Header.Get("Authorization"))
+		}
+		exp := time.Now().Add(12 * time.Hour).Unix()
+		_, _ = fmt.Fprintf(w, `{"authorizationData":[{"authorizationToken":%q,"expiresAt":%d}]}`,
+			base64.StdEncoding.EncodeToString([]byte("AWS:ecr-password")), exp)
+	}))
+	defer srv.Close()
+
+	cfg := aws.Config{
+		Region:       "us-east-2",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+		}),
+	}
+	creds := ECRCredentials(cfg, srv.Client())
+
+	for range 2 {
+		user, pass, err := creds(t.Context())
+		if err != nil {
+			t.Fatalf("ECRCredentials: %v", err)
+		}
+		if user != "AWS" || pass != "ecr-password" {
+			t.Fatalf("got %q/%q", user, pass)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("GetAuthorizationToken calls = %d, want 1 (cached)", calls)
+	}
+}
+
+func TestECRCredentials_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"__type":"AccessDeniedException"}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	cfg := aws.Config{
+		Region:       "us-east-2",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+		}),
+	}
+	_, _, err := ECRCredentials(cfg, srv.Client())(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "AccessDeniedException") {
+		t.Fatalf("expected access denied error, got %v", err)
+	}
+}
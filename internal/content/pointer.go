@@ -0,0 +1,149 @@
+// internal/content/pointer.go
+//
+// A bare algo:hex pointer lets anyone who can write the SSM parameter
+// reactivate any bundle that was ever signed. A signed pointer document binds
+// the hash to a creation and expiry time under the same KMS + keyless
+// signatures as the bundles, so the watcher can refuse downgrades to older
+// pointers and stop trusting a pointer that is no longer being refreshed.
+package content
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// PointerSchema identifies version 1 of the signed pointer document.
+const PointerSchema = "linnemanlabs.content-pointer/v1"
+
+// maxPointerSize caps the pointer document; SSM values are at most 8KB.
+const maxPointerSize = 8 * 1024
+
+// ErrPointerRejected marks a pointer that was fetched but must not be acted
+// on: bad signature, expired, or older than content already served.
+var ErrPointerRejected = errors.New("content pointer rejected")
+
+// Pointer is the signed document naming the current content bundle.
+type Pointer struct {
+	Schema string `json:"schema"`
+
+	// Hash names the bundle as algo:hex, the same form as the bare pointer.
+	Hash string `json:"hash"`
+
+	// Version is the release version, informational.
+	Version string `json:"version,omitempty"`
+
+	// Format is the bundle's archive format; empty means tar.gz. Carrying
+	// it in the signed document keeps the loader from trusting the object
+	// key or sniffing the bytes.
+	Format BundleFormat `json:"format,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// ActivateAt, when set, holds the bundle staged until this time. It
+	// takes precedence over release.json activate_at.
+	ActivateAt time.Time `json:"activate_at,omitzero"`
+
+	// Rollback marks an intentional revert to an older release; the
+	// watcher's version policy lets it through.
+	Rollback bool `json:"rollback,omitempty"`
+
+	// Digest is the sha256 of the signed document bytes. It locates the
+	// document's signatures and is not part of the document itself.
+	Digest string `json:"digest,omitempty"`
+
+	// signed is the document and both sigstore bundles as verified, kept so
+	// the bundle cache can re-verify the pointer offline.
+	signed *PointerDocument
+}
+
+// PointerDocument is a signed pointer document with its KMS and keyless
+// sigstore bundles, exactly as verified.
+type PointerDocument struct {
+	Document    []byte `json:"document"`
+	KMSJSON     []byte `json:"kms_bundle"`
+	KeylessJSON []byte `json:"keyless_bundle"`
+}
+
+// Signed returns the verified document and signatures p was parsed from, or
+// nil for a pointer that wasn't verified.
+func (p *Pointer) Signed() *PointerDocument {
+	if p == nil {
+		return nil
+	}
+	return p.signed
+}
+
+// PointerFetcher is implemented by fetchers that can resolve the current
+// bundle through a signed pointer document. p is nil when the source holds
+// a bare algo:hex value and signed pointers are not required.
+type PointerFetcher interface {
+	FetchCurrentPointer(ctx context.Context) (algorithm, hash string, p *Pointer, err error)
+}
+
+// isPointerDocument reports whether a pointer value is a JSON document rather
+// than a bare algo:hex string.
+func isPointerDocument(raw []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{"))
+}
+
+// ParsePointer decodes and sanity-checks a pointer document. It does not
+// verify signatures or check expiry.
+func ParsePointer(data []byte) (*Pointer, error) {
+	if len(data) > maxPointerSize {
+		return nil, xerrors.Newf("content pointer exceeds %d bytes", maxPointerSize)
+	}
+	var p Pointer
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, xerrors.Wrap(err, "decode content pointer")
+	}
+	if p.Schema != PointerSchema {
+		return nil, xerrors.Newf("content pointer schema %q not supported", p.Schema)
+	}
+	algorithm, hash, ok := strings.Cut(p.Hash, ":")
+	if !ok || algorithm == "" || hash == "" {
+		return nil, xerrors.Newf("content pointer hash %q is not algo:hex", p.Hash)
+	}
+	if _, err := ParseBundleFormat(string(p.Format)); err != nil {
+		return nil, xerrors.Wrap(err, "content pointer")
+	}
+	if p.CreatedAt.IsZero() || p.ExpiresAt.IsZero() {
+		return nil, xerrors.New("content pointer requires created_at and expires_at")
+	}
+	if !p.ExpiresAt.After(p.CreatedAt) {
+		return nil, xerrors.New("content pointer expires_at must be after created_at")
+	}
+	sum := sha256.Sum256(data)
+	p.Digest = hex.EncodeToString(sum[:])
+	return &p, nil
+}
+
+// Split returns the pointer's hash algorithm and hex digest.
+func (p *Pointer) Split() (algorithm, hash string) {
+	algorithm, hash, _ = strings.Cut(p.Hash, ":")
+	return algorithm, hash
+}
+
+// Check rejects a pointer that has expired at now or was created before
+// floor, the creation time of the newest pointer already acted on. A zero
+// floor skips the rollback check.
+func (p *Pointer) Check(now, floor time.Time) error {
+	if !now.Before(p.ExpiresAt) {
+		return xerrors.Wrapf(ErrPointerRejected, "pointer expired at %s", p.ExpiresAt.Format(time.RFC3339))
+	}
+	if !floor.IsZero() && p.CreatedAt.Before(floor) {
+		return xerrors.Wrapf(ErrPointerRejected, "pointer created %s is older than served content (%s)",
+			p.CreatedAt.Format(time.RFC3339), floor.Format(time.RFC3339))
+	}
+	return nil
+}
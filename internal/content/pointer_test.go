@@ -0,0 +1,488 @@
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+)
+
+// pointerDoc renders a v1 pointer document for hash.
+func pointerDoc(hash string, created, expires time.Time) string {
+	return fmt.Sprintf(`{"schema":%q,"hash":"sha384:%s","version":"1.2.3","created_at":%q,"expires_at":%q}`,
+		PointerSchema, hash, created.Format(time.RFC3339), expires.Format(time.RFC3339))
+}
+
+// putPointerSigs stores both sigstore bundles for doc at the loader's
+// pointer signature keys.
+func putPointerSigs(fake *fakeS3, doc string) {
+	sum := sha256.Sum256([]byte(doc))
+	base := fmt.Sprintf("%s/pointers/sha256/%s.json", testS3Prefix, hex.EncodeToString(sum[:]))
+	fake.put(base+kmsBundleSuffix, []byte(`{"mock":"sig"}`))
+	fake.put(base+keylessBundleSuffix, []byte(`{"mock":"sig"}`))
+}
+
+// signedPointer stores the signatures for a fresh pointer to hash and returns
+// the document to place in SSM.
+func signedPointer(fake *fakeS3, hash string, created time.Time) string {
+	doc := pointerDoc(hash, created, created.Add(24*time.Hour))
+	putPointerSigs(fake, doc)
+	return doc
+}
+
+// ParsePointer
+
+func TestParsePointer(t *testing.T) {
+	created := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	valid := pointerDoc("abc", created, created.Add(time.Hour))
+
+	p, err := ParsePointer([]byte(valid))
+	if err != nil {
+		t.Fatalf("ParsePointer: %v", err)
+	}
+	if algo, hash := p.Split(); algo != "sha384" || hash != "abc" {
+		t.Fatalf("Split = %s, %s", algo, hash)
+	}
+	if p.Version != "1.2.3" || !p.CreatedAt.Equal(created) {
+		t.Fatalf("pointer = %+v", p)
+	}
+	sum := sha256.Sum256([]byte(valid))
+	if p.Digest != hex.EncodeToString(sum[:]) {
+		t.Fatalf("Digest = %s", p.Digest)
+	}
+
+	bad := map[string]string{
+		"schema":          strings.Replace(valid, PointerSchema, "other/v9", 1),
+		"no algo":         strings.Replace(valid, "sha384:abc", "abc", 1),
+		"unknown field":   strings.Replace(valid, `"version"`, `"extra":1,"version"`, 1),
+		"missing expiry":  fmt.Sprintf(`{"schema":%q,"hash":"sha384:abc","created_at":"2026-05-01T00:00:00Z"}`, PointerSchema),
+		"expiry reversed": pointerDoc("abc", created, created.Add(-time.Hour)),
+		"not json":        "{nope",
+		"too large":       "{" + strings.Repeat(" ", maxPointerSize) + "}",
+		"unknown format":  strings.Replace(valid, `"version"`, `"format":"rar","version"`, 1),
+	}
+	for name, doc := range bad {
+		if _, err := ParsePointer([]byte(doc)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParsePointer_Format(t *testing.T) {
+	created := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	doc := strings.Replace(pointerDoc("abc", created, created.Add(time.Hour)), `"version"`, `"format":"tar.zst","version"`, 1)
+	p, err := ParsePointer([]byte(doc))
+	if err != nil {
+		t.Fatalf("ParsePointer: %v", err)
+	}
+	if p.Format != FormatTarZst {
+		t.Fatalf("Format = %q, want tar.zst", p.Format)
+	}
+}
+
+// formatPointer stores signatures for a fresh pointer naming format.
+func formatPointer(fake *fakeS3, hash string, format BundleFormat) string {
+	created := time.Now().Add(-time.Minute)
+	doc := strings.Replace(pointerDoc(hash, created, created.Add(24*time.Hour)), `"version"`, fmt.Sprintf(`"format":%q,"version"`, format), 1)
+	putPointerSigs(fake, doc)
+	return doc
+}
+
+func TestPointer_Check(t *testing.T) {
+	created := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	p := &Pointer{CreatedAt: created, ExpiresAt: created.Add(time.Hour)}
+
+	if err := p.Check(created.Add(time.Minute), time.Time{}); err != nil {
+		t.Fatalf("fresh pointer: %v", err)
+	}
+	if err := p.Check(created.Add(time.Minute), created); err != nil {
+		t.Fatalf("same floor: %v", err)
+	}
+	if err := p.Check(created.Add(time.Hour), time.Time{}); !errors.Is(err, ErrPointerRejected) {
+		t.Fatalf("expired: err = %v", err)
+	}
+	if err := p.Check(created.Add(time.Minute), created.Add(time.Second)); !errors.Is(err, ErrPointerRejected) {
+		t.Fatalf("downgrade: err = %v", err)
+	}
+}
+
+// Loader.FetchCurrentPointer
+
+func TestFetchCurrentPointer_Signed(t *testing.T) {
+	s3fake := newFakeS3()
+	doc := signedPointer(s3fake, "abc", time.Now().Add(-time.Minute))
+	v := passVerifier()
+	l := newTestLoaderWithVerifier(t, s3fake, ssmWithValue(doc), v)
+
+	algo, hash, p, err := l.FetchCurrentPointer(t.Context())
+	if err != nil {
+		t.Fatalf("FetchCurrentPointer: %v", err)
+	}
+	if algo != "sha384" || hash != "abc" || p == nil {
+		t.Fatalf("got %s:%s pointer=%v", algo, hash, p)
+	}
+	if string(v.gotArtifact) != doc {
+		t.Fatalf("verified artifact = %q, want the pointer document", v.gotArtifact)
+	}
+
+	// the legacy accessor resolves through the document too
+	if _, h, err := l.FetchCurrentBundleHash(t.Context()); err != nil || h != "abc" {
+		t.Fatalf("FetchCurrentBundleHash = %s, %v", h, err)
+	}
+}
+
+func TestFetchCurrentPointer_BadSignature(t *testing.T) {
+	s3fake := newFakeS3()
+	doc := signedPointer(s3fake, "abc", time.Now())
+	l := newTestLoaderWithVerifier(t, s3fake, ssmWithValue(doc), failVerifier("bad sig"))
+
+	_, _, _, err := l.FetchCurrentPointer(t.Context())
+	if !errors.Is(err, ErrPointerRejected) {
+		t.Fatalf("err = %v, want ErrPointerRejected", err)
+	}
+}
+
+func TestFetchCurrentPointer_MissingSignature(t *testing.T) {
+	doc := pointerDoc("abc", time.Now(), time.Now().Add(time.Hour))
+	l := newTestLoader(t, newFakeS3(), ssmWithValue(doc))
+
+	_, _, _, err := l.FetchCurrentPointer(t.Context())
+	if err == nil || errors.Is(err, ErrPointerRejected) {
+		t.Fatalf("err = %v, want a fetch error", err)
+	}
+}
+
+func TestFetchCurrentPointer_Malformed(t *testing.T) {
+	l := newTestLoader(t, newFakeS3(), ssmWithValue(`{"schema":"x"}`))
+	_, _, _, err := l.FetchCurrentPointer(t.Context())
+	if !errors.Is(err, ErrPointerRejected) {
+		t.Fatalf("err = %v, want ErrPointerRejected", err)
+	}
+}
+
+func TestFetchCurrentPointer_BareHash(t *testing.T) {
+	l := newTestLoader(t, newFakeS3(), ssmWithValue("sha384:abc"))
+	algo, hash, p, err := l.FetchCurrentPointer(t.Context())
+	if err != nil || algo != "sha384" || hash != "abc" || p != nil {
+		t.Fatalf("got %s:%s pointer=%v err=%v", algo, hash, p, err)
+	}
+
+	l.opts.RequireSignedPointer = true
+	if _, _, _, err := l.FetchCurrentPointer(t.Context()); !errors.Is(err, ErrPointerRejected) {
+		t.Fatalf("required: err = %v, want ErrPointerRejected", err)
+	}
+}
+
+// Loader.Load
+
+func TestLoad_SignedPointer_RecordedOnMeta(t *testing.T) {
+	s3fake := newFakeS3()
+	data, hash := buildContentBundle(t)
+	putBundle(s3fake, hash, data)
+	putSigBundle(s3fake, hash, []byte(`{"mock":"sig"}`))
+	doc := signedPointer(s3fake, hash, time.Now().Add(-time.Minute))
+	l := newTestLoader(t, s3fake, ssmWithValue(doc))
+
+	snap, err := l.Load(t.Context())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if snap.Meta.Pointer == nil || snap.Meta.Pointer.Version != "1.2.3" {
+		t.Fatalf("Meta.Pointer = %+v", snap.Meta.Pointer)
+	}
+}
+
+func TestLoad_SignedPointer_Format(t *testing.T) {
+	s3fake := newFakeS3()
+	data := zipFiles(t, map[string]string{"index.html": "<html>zipped</html>"})
+	hash := cryptoutil.SHA384Hex(data)
+	key := fmt.Sprintf("%s/sha384/%s.zip", testS3Prefix, hash)
+	s3fake.put(key, data)
+	s3fake.put(key+kmsBundleSuffix, []byte(`{"mock":"sig"}`))
+	s3fake.put(key+keylessBundleSuffix, []byte(`{"mock":"sig"}`))
+	l := newTestLoader(t, s3fake, ssmWithValue(formatPointer(s3fake, hash, FormatZip)))
+
+	snap, err := l.Load(t.Context())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := readFileFromFS(t, snap.FS, "index.html"); got != "<html>zipped</html>" {
+		t.Fatalf("index.html = %q", got)
+	}
+
+	// the same bytes under the tar.gz key are not found by format guessing
+	if _, err := l.LoadHash(t.Context(), "sha384", hash); err == nil {
+		t.Fatal("LoadHash without a format should look for the tar.gz object")
+	}
+	if _, err := l.LoadHashFormat(t.Context(), "sha384", hash, "rar"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestCheckOnce_SignedPointer_Format(t *testing.T) {
+	t.Parallel()
+	dataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, dataA)
+
+	data := makeTarZst(t, map[string]string{"index.html": "<html>zstd</html>"})
+	hashB := cryptoutil.SHA384Hex(data)
+	key := f.loader.bundleKey("sha384", hashB, FormatTarZst)
+	f.s3.put(key, data)
+	f.s3.put(key+kmsBundleSuffix, []byte(`{"mock":"sig"}`))
+	f.s3.put(key+keylessBundleSuffix, []byte(`{"mock":"sig"}`))
+	f.ssm.setValue(formatPointer(f.s3, hashB, FormatTarZst))
+
+	w := f.newWatcher()
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", r)
+	}
+	snap, _ := f.mgr.Get()
+	if got := readFileFromFS(t, snap.FS, "index.html"); got != "<html>zstd</html>" {
+		t.Fatalf("index.html = %q", got)
+	}
+}
+
+func TestLoad_ExpiredPointer(t *testing.T) {
+	s3fake := newFakeS3()
+	data, hash := buildContentBundle(t)
+	putBundle(s3fake, hash, data)
+	putSigBundle(s3fake, hash, []byte(`{"mock":"sig"}`))
+	created := time.Now().Add(-48 * time.Hour)
+	doc := pointerDoc(hash, created, created.Add(time.Hour))
+	putPointerSigs(s3fake, doc)
+	l := newTestLoader(t, s3fake, ssmWithValue(doc))
+
+	if _, err := l.Load(t.Context()); !errors.Is(err, ErrPointerRejected) {
+		t.Fatalf("err = %v, want ErrPointerRejected", err)
+	}
+}
+
+// Watcher
+
+func TestCheckOnce_SignedPointer_Swaps(t *testing.T) {
+	t.Parallel()
+	dataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, dataA)
+
+	hashB := storeBundle(t, f, map[string]string{"index.html": "<html>b</html>"})
+	created := time.Now().Add(-time.Minute).Truncate(time.Second)
+	f.ssm.setValue(signedPointer(f.s3, hashB, created))
+
+	w := f.newWatcher()
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", r)
+	}
+	snap, _ := f.mgr.Get()
+	if snap.Meta.Pointer == nil || !snap.Meta.Pointer.CreatedAt.Equal(created) {
+		t.Fatalf("Meta.Pointer = %+v", snap.Meta.Pointer)
+	}
+	if !w.pointerFloor.Equal(created) {
+		t.Fatalf("pointerFloor = %v, want %v", w.pointerFloor, created)
+	}
+}
+
+func TestCheckOnce_PointerDowngradeRejected(t *testing.T) {
+	t.Parallel()
+	dataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, dataA)
+	hashB := storeBundle(t, f, map[string]string{"index.html": "<html>b</html>"})
+	hashC := storeBundle(t, f, map[string]string{"index.html": "<html>c</html>"})
+
+	m := newFakeWatcherMetrics()
+	w := f.newWatcher(func(o *WatcherOptions) { o.Metrics = m })
+
+	now := time.Now().Truncate(time.Second)
+	f.ssm.setValue(signedPointer(f.s3, hashC, now.Add(-time.Minute)))
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("newer pointer: result = %d, want pollSwapped", r)
+	}
+
+	// an older, validly signed pointer is a downgrade
+	f.ssm.setValue(signedPointer(f.s3, hashB, now.Add(-time.Hour)))
+	if r := w.checkOnce(t.Context()); r != pollPointerRejected {
+		t.Fatalf("older pointer: result = %d, want pollPointerRejected", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashC {
+		t.Fatalf("serving %s after downgrade attempt, want %s", truncHash(snap.Meta.Hash), truncHash(hashC))
+	}
+	if m.getErrors("pointer") != 1 {
+		t.Fatalf("pointer errors = %d, want 1", m.getErrors("pointer"))
+	}
+}
+
+func TestCheckOnce_FailedPointerKeepsFloor(t *testing.T) {
+	t.Parallel()
+	dataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, dataA)
+	hashB := storeBundle(t, f, map[string]string{"index.html": "<html>b</html>"})
+	// no index.html: fails validation
+	hashBad := storeBundle(t, f, map[string]string{"other.html": "<html>bad</html>"})
+
+	w := f.newWatcher()
+	now := time.Now().Truncate(time.Second)
+	good := signedPointer(f.s3, hashB, now.Add(-time.Hour))
+	f.ssm.setValue(good)
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("good pointer: result = %d, want pollSwapped", r)
+	}
+
+	// a newer pointer to a bundle that never goes live must not raise the floor
+	f.ssm.setValue(signedPointer(f.s3, hashBad, now.Add(-time.Minute)))
+	if r := w.checkOnce(t.Context()); r != pollValidationError {
+		t.Fatalf("bad publish: result = %d, want pollValidationError", r)
+	}
+	if !w.pointerFloor.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("pointerFloor = %v after failed publish, want %v", w.pointerFloor, now.Add(-time.Hour))
+	}
+
+	// republishing the previous pointer is accepted, not treated as a downgrade
+	f.ssm.setValue(good)
+	if r := w.checkOnce(t.Context()); r != pollNoChange {
+		t.Fatalf("republished pointer: result = %d, want pollNoChange", r)
+	}
+}
+
+func TestCheckOnce_PointerRefreshForActiveContent(t *testing.T) {
+	t.Parallel()
+	dataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, dataA)
+	hashB := storeBundle(t, f, map[string]string{"index.html": "<html>b</html>"})
+
+	var refreshed []time.Time
+	w := f.newWatcher(func(o *WatcherOptions) {
+		o.OnPointerRefresh = func(hash string, p *Pointer) {
+			if hash != hashB {
+				t.Errorf("refresh for %s, want %s", truncHash(hash), truncHash(hashB))
+			}
+			refreshed = append(refreshed, p.CreatedAt)
+		}
+	})
+
+	now := time.Now().Truncate(time.Second)
+	f.ssm.setValue(signedPointer(f.s3, hashB, now.Add(-time.Hour)))
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", r)
+	}
+	// the pointer the swap went live under is not a refresh
+	if r := w.checkOnce(t.Context()); r != pollNoChange || len(refreshed) != 0 {
+		t.Fatalf("same pointer: result = %d, refreshed = %v", r, refreshed)
+	}
+
+	// a re-signed pointer to the same bundle is reported once and moves the floor
+	f.ssm.setValue(signedPointer(f.s3, hashB, now.Add(-time.Minute)))
+	for range 2 {
+		if r := w.checkOnce(t.Context()); r != pollNoChange {
+			t.Fatalf("refreshed pointer: result = %d, want pollNoChange", r)
+		}
+	}
+	if len(refreshed) != 1 || !refreshed[0].Equal(now.Add(-time.Minute)) {
+		t.Fatalf("refreshed = %v, want one refresh", refreshed)
+	}
+	if !w.pointerFloor.Equal(now.Add(-time.Minute)) {
+		t.Fatalf("pointerFloor = %v, want the refreshed pointer", w.pointerFloor)
+	}
+}
+
+func TestCheckOnce_BareHashAfterSignedRejected(t *testing.T) {
+	t.Parallel()
+	dataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, dataA)
+	hashB := storeBundle(t, f, map[string]string{"index.html": "<html>b</html>"})
+
+	m := newFakeWatcherMetrics()
+	w := f.newWatcher(func(o *WatcherOptions) { o.Metrics = m })
+
+	f.ssm.setValue(signedPointer(f.s3, hashB, time.Now().Add(-time.Minute)))
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("signed pointer: result = %d, want pollSwapped", r)
+	}
+
+	// putting the old bare hash back must not skip the created_at floor
+	f.ssm.setValue(ssmValue(hashA))
+	if r := w.checkOnce(t.Context()); r != pollPointerRejected {
+		t.Fatalf("bare hash: result = %d, want pollPointerRejected", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashB {
+		t.Fatalf("serving %s after bare hash, want %s", truncHash(snap.Meta.Hash), truncHash(hashB))
+	}
+	if m.getErrors("pointer") != 1 {
+		t.Fatalf("pointer errors = %d, want 1", m.getErrors("pointer"))
+	}
+
+	// a watcher started on content loaded through a signed pointer refuses
+	// bare hashes from its first poll
+	w2 := f.newWatcher()
+	if r := w2.checkOnce(t.Context()); r != pollPointerRejected {
+		t.Fatalf("restarted watcher: result = %d, want pollPointerRejected", r)
+	}
+}
+
+func TestCheckOnce_ExpiredPointerRejected(t *testing.T) {
+	t.Parallel()
+	dataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, dataA)
+
+	created := time.Now().Add(-48 * time.Hour)
+	doc := pointerDoc(hashA, created, created.Add(time.Hour))
+	putPointerSigs(f.s3, doc)
+	f.ssm.setValue(doc)
+
+	m := newFakeWatcherMetrics()
+	w := f.newWatcher(func(o *WatcherOptions) { o.Metrics = m })
+	before := w.lastSuccessAt
+
+	if r := w.checkOnce(t.Context()); r != pollPointerRejected {
+		t.Fatalf("result = %d, want pollPointerRejected", r)
+	}
+	if !w.lastSuccessAt.Equal(before) {
+		t.Fatal("an expired pointer must not count as a fresh poll")
+	}
+	if m.getErrors("pointer") != 1 || m.getErrors("ssm") != 0 {
+		t.Fatalf("errors = %v", m.errors)
+	}
+}
+
+func TestCheckOnce_PointerBadSignatureRejected(t *testing.T) {
+	t.Parallel()
+	dataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, dataA)
+	hashB := storeBundle(t, f, map[string]string{"index.html": "<html>b</html>"})
+	f.ssm.setValue(signedPointer(f.s3, hashB, time.Now()))
+	f.loader.opts.Verifier = failVerifier("forged")
+
+	w := f.newWatcher()
+	if r := w.checkOnce(t.Context()); r != pollPointerRejected {
+		t.Fatalf("result = %d, want pollPointerRejected", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashA {
+		t.Fatal("forged pointer swapped content")
+	}
+}
+
+func TestNewWatcher_SeedsPointerFloor(t *testing.T) {
+	created := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	mgr := NewManager()
+	mgr.Set(Snapshot{
+		FS:   fstest.MapFS{"index.html": &fstest.MapFile{Data: []byte("a")}},
+		Meta: Meta{Hash: "a", Pointer: &Pointer{CreatedAt: created}},
+	})
+
+	w := NewWatcher(&WatcherOptions{Manager: mgr})
+	if !w.pointerFloor.Equal(created) {
+		t.Fatalf("pointerFloor = %v, want %v", w.pointerFloor, created)
+	}
+}
@@ -0,0 +1,205 @@
+// internal/content/precompress.go
+//
+// Snapshots are immutable once published, so compressible files are encoded
+// once when the snapshot is activated instead of on every request. The site
+// handler serves these variants directly; the compression middleware still
+// covers anything without one.
+package content
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content-Encoding tokens for precompressed variants.
+const (
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+const (
+	// DefaultPrecompressMinSize skips files too small to benefit; the
+	// encoding overhead and extra header outweigh the savings.
+	DefaultPrecompressMinSize = 1024
+
+	// DefaultPrecompressMaxBytes caps the variant bytes built for one
+	// snapshot. Files past the budget are served uncompressed by the handler
+	// (and compressed on the fly by the middleware).
+	DefaultPrecompressMaxBytes int64 = 64 << 20
+
+	// a variant must be at most this fraction of the original to be kept
+	precompressMaxRatio = 0.9
+)
+
+// compressibleExts are the file types worth precompressing. Images, fonts
+// and archives are already compressed.
+var compressibleExts = map[string]bool{
+	".html": true, ".htm": true, ".css": true, ".js": true, ".mjs": true,
+	".json": true, ".map": true, ".svg": true, ".xml": true, ".txt": true,
+	".ico": true, ".webmanifest": true, ".wasm": true, ".md": true,
+	".csv": true, ".rss": true, ".atom": true,
+}
+
+// PrecompressOptions configures variant generation. Zero values use the defaults.
+type PrecompressOptions struct {
+	// Encodings in server preference order. Default: zstd, gzip.
+	Encodings []string
+
+	// MinSize is the smallest file, in bytes, that gets variants.
+	MinSize int
+
+	// MaxBytes caps the total variant bytes for one snapshot.
+	MaxBytes int64
+}
+
+// Variants holds precompressed encodings of a snapshot's files.
+type Variants struct {
+	encodings []string
+	files     map[string]map[string][]byte // path -> encoding -> data
+	bytes     int64
+	skipped   int
+}
+
+// Encodings returns the encodings in server preference order.
+func (v *Variants) Encodings() []string {
+	if v == nil {
+		return nil
+	}
+	return v.encodings
+}
+
+// Get returns the encoded bytes of name, if a variant was built.
+func (v *Variants) Get(name, encoding string) ([]byte, bool) {
+	if v == nil {
+		return nil, false
+	}
+	b, ok := v.files[name][encoding]
+	return b, ok
+}
+
+// Has reports whether any variant exists for name.
+func (v *Variants) Has(name string) bool {
+	if v == nil {
+		return false
+	}
+	return len(v.files[name]) > 0
+}
+
+// Len returns the number of files with at least one variant.
+func (v *Variants) Len() int {
+	if v == nil {
+		return 0
+	}
+	return len(v.files)
+}
+
+// Bytes returns the total size of all variants.
+func (v *Variants) Bytes() int64 {
+	if v == nil {
+		return 0
+	}
+	return v.bytes
+}
+
+// Skipped returns how many eligible files were left uncompressed because the
+// byte budget was exhausted.
+func (v *Variants) Skipped() int {
+	if v == nil {
+		return 0
+	}
+	return v.skipped
+}
+
+// Precompress builds variants for every compressible file in fsys. It is
+// best-effort: unreadable files and encoder failures leave that file without
+// variants rather than failing the snapshot.
+func Precompress(fsys fs.FS, opts *PrecompressOptions) *Variants {
+	var o PrecompressOptions
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.Encodings) == 0 {
+		o.Encodings = []string{EncodingZstd, EncodingGzip}
+	}
+	if o.MinSize <= 0 {
+		o.MinSize = DefaultPrecompressMinSize
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = DefaultPrecompressMaxBytes
+	}
+
+	v := &Variants{files: make(map[string]map[string][]byte)}
+	encoders := make(map[string]func([]byte) ([]byte, error), len(o.Encodings))
+	for _, enc := range o.Encodings {
+		switch enc {
+		case EncodingGzip:
+			encoders[enc] = gzipBytes
+		case EncodingZstd:
+			zw, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderConcurrency(1))
+			if err != nil {
+				continue
+			}
+			defer zw.Close()
+			encoders[enc] = func(b []byte) ([]byte, error) { return zw.EncodeAll(b, nil), nil }
+		default:
+			continue
+		}
+		v.encodings = append(v.encodings, enc)
+	}
+	if fsys == nil || len(v.encodings) == 0 {
+		return v
+	}
+
+	// lexical walk order keeps budget cut-off deterministic across instances
+	_ = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !compressibleExts[strings.ToLower(path.Ext(name))] {
+			return nil //nolint:nilerr // best-effort
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil || len(data) < o.MinSize {
+			return nil //nolint:nilerr // best-effort
+		}
+
+		built := make(map[string][]byte, len(v.encodings))
+		var size int64
+		for _, enc := range v.encodings {
+			out, err := encoders[enc](data)
+			if err != nil || float64(len(out)) > float64(len(data))*precompressMaxRatio {
+				continue
+			}
+			built[enc] = out
+			size += int64(len(out))
+		}
+		if len(built) == 0 {
+			return nil
+		}
+		if v.bytes+size > o.MaxBytes {
+			v.skipped++
+			return nil
+		}
+		v.files[name] = built
+		v.bytes += size
+		return nil
+	})
+	return v
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,185 @@
+package content
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressible returns n bytes of highly repetitive markup.
+func compressible(n int) []byte {
+	return []byte(strings.Repeat("<p>hello precompressed world</p>\n", n/33+1)[:n])
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func gunzip(t *testing.T, b []byte) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	return out
+}
+
+func unzstd(t *testing.T, b []byte) []byte {
+	t.Helper()
+	zr, err := zstd.NewReader(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	out, err := zr.DecodeAll(b, nil)
+	if err != nil {
+		t.Fatalf("unzstd: %v", err)
+	}
+	return out
+}
+
+func TestPrecompress_BuildsVariants(t *testing.T) {
+	html := compressible(8192)
+	fsys := fstest.MapFS{
+		"index.html":     &fstest.MapFile{Data: html},
+		"css/site.css":   &fstest.MapFile{Data: compressible(4096)},
+		"tiny.html":      &fstest.MapFile{Data: []byte("<p>hi</p>")},
+		"img/photo.png":  &fstest.MapFile{Data: compressible(8192)},
+		"js/random.js":   &fstest.MapFile{Data: randomBytes(t, 8192)},
+		"UPPER/PAGE.HTM": &fstest.MapFile{Data: compressible(2048)},
+	}
+
+	v := Precompress(fsys, nil)
+
+	if got := v.Encodings(); len(got) != 2 || got[0] != EncodingZstd || got[1] != EncodingGzip {
+		t.Fatalf("Encodings = %v, want [zstd gzip]", got)
+	}
+	for _, name := range []string{"index.html", "css/site.css", "UPPER/PAGE.HTM"} {
+		if !v.Has(name) {
+			t.Errorf("%s: no variants", name)
+		}
+	}
+	// below MinSize, not a compressible type, or not worth it
+	for _, name := range []string{"tiny.html", "img/photo.png", "js/random.js"} {
+		if v.Has(name) {
+			t.Errorf("%s: unexpected variants", name)
+		}
+	}
+
+	gz, ok := v.Get("index.html", EncodingGzip)
+	if !ok || !bytes.Equal(gunzip(t, gz), html) {
+		t.Fatal("gzip variant does not round-trip")
+	}
+	zs, ok := v.Get("index.html", EncodingZstd)
+	if !ok || !bytes.Equal(unzstd(t, zs), html) {
+		t.Fatal("zstd variant does not round-trip")
+	}
+
+	if v.Len() != 3 {
+		t.Fatalf("Len = %d, want 3", v.Len())
+	}
+	var total int64
+	for _, name := range []string{"index.html", "css/site.css", "UPPER/PAGE.HTM"} {
+		for _, enc := range v.Encodings() {
+			b, _ := v.Get(name, enc)
+			total += int64(len(b))
+		}
+	}
+	if v.Bytes() != total {
+		t.Fatalf("Bytes = %d, want %d", v.Bytes(), total)
+	}
+}
+
+func TestPrecompress_Options(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.html": &fstest.MapFile{Data: compressible(200)},
+	}
+
+	v := Precompress(fsys, &PrecompressOptions{Encodings: []string{EncodingGzip, "br"}, MinSize: 100})
+	if got := v.Encodings(); len(got) != 1 || got[0] != EncodingGzip {
+		t.Fatalf("unknown encodings should be dropped, got %v", got)
+	}
+	if !v.Has("a.html") {
+		t.Fatal("MinSize override not applied")
+	}
+	if _, ok := v.Get("a.html", EncodingZstd); ok {
+		t.Fatal("zstd built although not requested")
+	}
+}
+
+func TestPrecompress_BudgetCap(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.html": &fstest.MapFile{Data: compressible(64 << 10)},
+		"b.html": &fstest.MapFile{Data: compressible(64 << 10)},
+		"c.html": &fstest.MapFile{Data: compressible(64 << 10)},
+	}
+	one := Precompress(fstest.MapFS{"a.html": fsys["a.html"]}, nil).Bytes()
+
+	// room for exactly two files
+	v := Precompress(fsys, &PrecompressOptions{MaxBytes: 2*one + 1})
+	if v.Len() != 2 || v.Skipped() != 1 {
+		t.Fatalf("Len = %d Skipped = %d, want 2/1", v.Len(), v.Skipped())
+	}
+	// lexical order decides which file misses out
+	if !v.Has("a.html") || !v.Has("b.html") || v.Has("c.html") {
+		t.Fatal("budget cut-off is not deterministic")
+	}
+	if v.Bytes() > 2*one+1 {
+		t.Fatalf("Bytes = %d exceeds budget", v.Bytes())
+	}
+}
+
+func TestVariants_NilSafe(t *testing.T) {
+	var v *Variants
+	if v.Has("x") || v.Len() != 0 || v.Bytes() != 0 || v.Skipped() != 0 || v.Encodings() != nil {
+		t.Fatal("nil Variants should report empty")
+	}
+	if _, ok := v.Get("x", EncodingGzip); ok {
+		t.Fatal("nil Variants Get should miss")
+	}
+}
+
+func TestManager_Set_Precompresses(t *testing.T) {
+	m := NewManagerWithOptions(&ManagerOptions{Precompress: &PrecompressOptions{}})
+	html := compressible(8192)
+	m.Set(Snapshot{
+		FS:   fstest.MapFS{"index.html": &fstest.MapFile{Data: html}},
+		Meta: Meta{Hash: "h1"},
+	})
+
+	snap, _ := m.Get()
+	if !snap.Variants.Has("index.html") {
+		t.Fatal("Set did not build variants")
+	}
+
+	e := m.List()[0]
+	if e.PrecompressedFiles != 1 || e.PrecompressedBytes != snap.Variants.Bytes() {
+		t.Fatalf("entry = %+v", e)
+	}
+	if e.Bytes != int64(len(html))+snap.Variants.Bytes() {
+		t.Fatalf("Bytes = %d, want file + variant bytes", e.Bytes)
+	}
+}
+
+func TestManager_Set_NoPrecompressByDefault(t *testing.T) {
+	m := NewManager()
+	m.Set(Snapshot{FS: fstest.MapFS{"index.html": &fstest.MapFile{Data: compressible(8192)}}})
+	if snap, _ := m.Get(); snap.Variants != nil {
+		t.Fatal("variants built without Precompress option")
+	}
+}
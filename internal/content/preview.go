@@ -0,0 +1,154 @@
+// internal/content/preview.go
+//
+// A preview is a candidate bundle, fully downloaded and verified, that can be
+// QA'd on production infrastructure before the pointer is flipped. It lives
+// beside the Manager rather than in it, so the active snapshot and its history
+// are untouched until the preview is promoted.
+package content
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// PreviewLoader loads a bundle by hash; every BundleFetcher implements it.
+type PreviewLoader interface {
+	LoadHash(ctx context.Context, algorithm, hash string) (*Snapshot, error)
+}
+
+// SnapshotInterner shares a snapshot's file data with snapshots already in
+// memory and returns a func dropping that share; *Manager implements it.
+type SnapshotInterner interface {
+	Intern(s *Snapshot) (release func())
+}
+
+// PreviewOptions configures a Preview.
+type PreviewOptions struct {
+	Logger log.Logger
+	Loader PreviewLoader
+
+	// Validation is applied before a candidate is served; nil uses
+	// DefaultValidationOptions.
+	Validation *ValidationOptions
+
+	// Precompress, when set, builds precompressed variants for the
+	// candidate so a promoted snapshot doesn't need them built again.
+	Precompress *PrecompressOptions
+
+	// Interner, when set, shares the candidate's unchanged files with the
+	// active snapshot instead of holding a second copy.
+	Interner SnapshotInterner
+}
+
+// previewEntry is the loaded candidate and its interned file share.
+type previewEntry struct {
+	snap    *Snapshot
+	release func()
+}
+
+// Preview holds at most one verified candidate snapshot. Safe for
+// concurrent use.
+type Preview struct {
+	logger      log.Logger
+	loader      PreviewLoader
+	validation  ValidationOptions
+	precompress *PrecompressOptions
+	interner    SnapshotInterner
+
+	// loadMu serializes Load so two operators can't interleave downloads
+	loadMu sync.Mutex
+	cur    atomic.Pointer[previewEntry]
+}
+
+// NewPreview creates an empty Preview.
+func NewPreview(opts *PreviewOptions) *Preview {
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.Nop()
+	}
+	validation := DefaultValidationOptions()
+	if opts.Validation != nil {
+		validation = *opts.Validation
+	}
+	return &Preview{
+		logger:      logger,
+		loader:      opts.Loader,
+		validation:  validation,
+		precompress: opts.Precompress,
+		interner:    opts.Interner,
+	}
+}
+
+// Load downloads, verifies and validates the bundle and makes it the preview,
+// replacing any earlier candidate. On error the previous candidate is kept.
+func (p *Preview) Load(ctx context.Context, algorithm, hash string) (*Snapshot, error) {
+	if p.loader == nil {
+		return nil, xerrors.New("content preview has no loader")
+	}
+	p.loadMu.Lock()
+	defer p.loadMu.Unlock()
+
+	snap, err := p.loader.LoadHash(ctx, algorithm, hash)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "load preview bundle")
+	}
+	if err := ValidateSnapshot(snap, p.validation); err != nil {
+		return nil, xerrors.Wrap(err, "validate preview bundle")
+	}
+	// the same per-snapshot work Manager.Set does, done once here so the
+	// preview serves strong validators and promotion reuses it
+	if p.precompress != nil && snap.Variants == nil && snap.FS != nil {
+		snap.Variants = Precompress(snap.FS, p.precompress)
+	}
+	if snap.Digests == nil && snap.FS != nil {
+		snap.Digests = DigestFiles(snap.FS, snap.Provenance, snap.Augmented)
+	}
+
+	e := &previewEntry{snap: snap, release: func() {}}
+	if p.interner != nil {
+		e.release = p.interner.Intern(snap)
+	}
+	if old := p.cur.Swap(e); old != nil {
+		old.release()
+	}
+	p.logger.Info(ctx, "content preview loaded",
+		"hash", truncHash(snap.Meta.Hash),
+		"version", snap.Meta.Version,
+	)
+	return snap, nil
+}
+
+// Get returns the preview snapshot, if one is loaded.
+func (p *Preview) Get() (*Snapshot, bool) {
+	e := p.cur.Load()
+	if e == nil {
+		return nil, false
+	}
+	return e.snap, true
+}
+
+// Clear discards the preview. Returns false if none was loaded.
+func (p *Preview) Clear() bool {
+	e := p.cur.Swap(nil)
+	if e == nil {
+		return false
+	}
+	e.release()
+	return true
+}
+
+// Take removes and returns the preview if it is the bundle named by hash, so
+// a promotion can't pick up a candidate another operator loaded meanwhile.
+// The snapshot's file share is dropped; Manager.Set takes its own.
+func (p *Preview) Take(hash string) (*Snapshot, bool) {
+	e := p.cur.Load()
+	if e == nil || e.snap.Meta.Hash != hash || !p.cur.CompareAndSwap(e, nil) {
+		return nil, false
+	}
+	e.release()
+	return e.snap, true
+}
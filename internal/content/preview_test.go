@@ -0,0 +1,190 @@
+package content
+
+import (
+	"errors"
+	"testing"
+)
+
+func previewFixture(t *testing.T) (f *watcherFixture, p *Preview, hashOld, hashNew string) {
+	t.Helper()
+	dataOld, hashOld := buildContentBundle(t)
+	f = newWatcherFixture(t, ssmValue(hashOld))
+	f.seedManager(t, hashOld, dataOld)
+	hashNew = storeBundle(t, f, map[string]string{"index.html": "<html>candidate</html>"})
+	p = NewPreview(&PreviewOptions{Loader: f.loader, Validation: &ValidationOptions{MinFiles: 1}})
+	return f, p, hashOld, hashNew
+}
+
+func TestPreview_Load(t *testing.T) {
+	t.Parallel()
+	f, p, hashOld, hashNew := previewFixture(t)
+
+	if _, ok := p.Get(); ok {
+		t.Fatal("new preview should be empty")
+	}
+	snap, err := p.Load(t.Context(), "sha384", hashNew)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if snap.Meta.Hash != hashNew || snap.Digests == nil {
+		t.Fatalf("snapshot = %+v", snap.Meta)
+	}
+	if got, ok := p.Get(); !ok || got != snap {
+		t.Fatal("Get should return the loaded candidate")
+	}
+	if active, _ := f.mgr.Get(); active.Meta.Hash != hashOld {
+		t.Fatal("loading a preview must not touch the manager")
+	}
+}
+
+func TestPreview_LoadErrorKeepsPrevious(t *testing.T) {
+	t.Parallel()
+	f, p, _, hashNew := previewFixture(t)
+	if _, err := p.Load(t.Context(), "sha384", hashNew); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.Load(t.Context(), "sha384", "deadbeef"); err == nil {
+		t.Fatal("expected error for missing bundle")
+	}
+	invalid := storeBundle(t, f, map[string]string{"about.html": "<html>no index</html>"})
+	if _, err := p.Load(t.Context(), "sha384", invalid); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if got, _ := p.Get(); got.Meta.Hash != hashNew {
+		t.Fatal("failed loads should keep the earlier candidate")
+	}
+}
+
+func TestPreview_ClearAndTake(t *testing.T) {
+	t.Parallel()
+	_, p, _, hashNew := previewFixture(t)
+	if p.Clear() {
+		t.Fatal("Clear on empty preview should report false")
+	}
+	if _, err := p.Load(t.Context(), "sha384", hashNew); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := p.Take("other"); ok {
+		t.Fatal("Take must not return a different candidate")
+	}
+	snap, ok := p.Take(hashNew)
+	if !ok || snap.Meta.Hash != hashNew {
+		t.Fatal("Take should return the matching candidate")
+	}
+	if _, ok := p.Get(); ok {
+		t.Fatal("Take should empty the preview")
+	}
+}
+
+func TestPreview_NoLoader(t *testing.T) {
+	p := NewPreview(&PreviewOptions{})
+	if _, err := p.Load(t.Context(), "sha384", "abc"); err == nil {
+		t.Fatal("expected error without a loader")
+	}
+}
+
+func TestWatcher_Promote(t *testing.T) {
+	t.Parallel()
+	f, p, hashOld, hashNew := previewFixture(t)
+	m := newFakeWatcherMetrics()
+	w := f.newWatcher(func(o *WatcherOptions) { o.Metrics = m })
+	snap, err := p.Load(t.Context(), "sha384", hashNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the watcher has seen the upstream pointer, which still names the old bundle
+	if r := w.checkOnce(t.Context()); r != pollNoChange {
+		t.Fatalf("result = %d, want pollNoChange", r)
+	}
+
+	if err := w.Promote(t.Context(), snap); err != nil {
+		t.Fatalf("Promote: %v", err)
+	}
+	if active, _ := f.mgr.Get(); active.Meta.Hash != hashNew {
+		t.Fatal("promoted snapshot not active")
+	}
+	if w.Pinned() != QuarantineKey("sha384", hashOld) {
+		t.Fatalf("Pinned = %q, want the upstream pointer", w.Pinned())
+	}
+	if len(f.swapCalls) != 1 || f.swapCalls[0].hash != hashNew || m.swaps != 1 {
+		t.Fatalf("swapCalls = %+v, swaps = %d", f.swapCalls, m.swaps)
+	}
+
+	// the unchanged pointer must not swap the old bundle back
+	if r := w.checkOnce(t.Context()); r != pollPinned {
+		t.Fatalf("result = %d, want pollPinned", r)
+	}
+
+	// publishing the promoted hash releases the pin without another swap
+	f.ssm.setValue(ssmValue(hashNew))
+	if r := w.checkOnce(t.Context()); r != pollNoChange {
+		t.Fatalf("result = %d, want pollNoChange", r)
+	}
+	if w.Pinned() != "" {
+		t.Fatalf("Pinned = %q, want released", w.Pinned())
+	}
+}
+
+func TestWatcher_Promote_ClearsQuarantine(t *testing.T) {
+	t.Parallel()
+	f, p, _, hashNew := previewFixture(t)
+	m := newFakeWatcherMetrics()
+	w := f.newWatcher(func(o *WatcherOptions) { o.Metrics = m })
+	snap, err := p.Load(t.Context(), "sha384", hashNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := QuarantineKey("sha384", hashNew)
+	w.Quarantine().Record(key, QuarantineReasonLoad, errors.New("transient"))
+
+	if err := w.Promote(t.Context(), snap); err != nil {
+		t.Fatalf("Promote: %v", err)
+	}
+	if _, blocked := w.Quarantine().Blocked(key); blocked {
+		t.Fatal("promoted bundle should leave quarantine")
+	}
+	if w.swapCount.Load() != 1 || m.quarantined != 0 {
+		t.Fatalf("swapCount = %d, quarantined = %d", w.swapCount.Load(), m.quarantined)
+	}
+}
+
+func TestWatcher_Promote_SelfCheckFails(t *testing.T) {
+	t.Parallel()
+	f, p, hashOld, hashNew := previewFixture(t)
+	m := newFakeWatcherMetrics()
+	// the candidate lacks the marker the self-check requires
+	w := selfCheckWatcher(t, f, m)
+	snap, err := p.Load(t.Context(), "sha384", hashNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := w.checkOnce(t.Context()); r != pollNoChange {
+		t.Fatalf("result = %d, want pollNoChange", r)
+	}
+
+	if err := w.Promote(t.Context(), snap); err == nil {
+		t.Fatal("expected self-check error")
+	}
+	if active, _ := f.mgr.Get(); active.Meta.Hash != hashOld {
+		t.Fatal("failed promote not reverted")
+	}
+	if w.Pinned() != "" {
+		t.Fatalf("Pinned = %q, want the pin released with the revert", w.Pinned())
+	}
+	if len(f.swapCalls) != 0 || m.swaps != 0 || w.swapCount.Load() != 0 {
+		t.Fatalf("swapCalls = %+v, swaps = %d, swapCount = %d", f.swapCalls, m.swaps, w.swapCount.Load())
+	}
+	if m.getErrors("selfcheck") != 1 {
+		t.Fatalf("selfcheck errors = %d, want 1", m.getErrors("selfcheck"))
+	}
+	if e, blocked := w.Quarantine().Blocked(QuarantineKey("sha384", hashNew)); !blocked || e.Reason != QuarantineReasonSelfCheck {
+		t.Fatalf("quarantine = %+v, %v", e, blocked)
+	}
+
+	// the upstream pointer still names the restored content
+	if r := w.checkOnce(t.Context()); r != pollNoChange {
+		t.Fatalf("result = %d, want pollNoChange", r)
+	}
+}
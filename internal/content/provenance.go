@@ -16,6 +16,7 @@ type Provenance struct {
 	ContentID   string            `json:"content_id"`
 	ContentHash string            `json:"content_hash"`
 	CreatedAt   time.Time         `json:"created_at"`
+	ActivateAt  time.Time         `json:"activate_at,omitzero"`
 	Source      ProvenanceSource  `json:"source"`
 	Build       ProvenanceBuild   `json:"build"`
 	Summary     ProvenanceSummary `json:"summary"`
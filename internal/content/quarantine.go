@@ -0,0 +1,194 @@
+// internal/content/quarantine.go
+//
+// Quarantine tracks bundles the watcher failed to load or validate so a bad
+// publish is retried on an exponential schedule instead of being re-downloaded
+// and re-verified on every poll.
+package content
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultQuarantineBaseBackoff is the retry delay after the first failure.
+	DefaultQuarantineBaseBackoff = time.Minute
+
+	// DefaultQuarantineMaxBackoff caps the retry delay for a quarantined bundle.
+	DefaultQuarantineMaxBackoff = time.Hour
+
+	// maxQuarantineEntries bounds the set; the least recently attempted entry
+	// is evicted first.
+	maxQuarantineEntries = 64
+)
+
+// Quarantine reasons, matching the watcher error metric labels.
+const (
+	QuarantineReasonLoad       = "load"
+	QuarantineReasonValidation = "validation"
+	QuarantineReasonDowngrade  = "downgrade"
+	QuarantineReasonSelfCheck  = "selfcheck"
+)
+
+// QuarantineEntry describes one rejected bundle.
+type QuarantineEntry struct {
+	Key         string    `json:"key"` // algo:hash
+	Reason      string    `json:"reason"`
+	Error       string    `json:"error"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastAttempt time.Time `json:"last_attempt"`
+	Attempts    int       `json:"attempts"`
+	NextRetry   time.Time `json:"next_retry"`
+}
+
+// QuarantineOptions configures a Quarantine. Zero values use the defaults.
+type QuarantineOptions struct {
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Quarantine is a concurrency-safe set of rejected bundles keyed by algo:hash.
+// The watcher records failures and consults it before loading; operators
+// list and clear entries through the ops endpoint.
+type Quarantine struct {
+	mu      sync.Mutex
+	entries map[string]*QuarantineEntry
+	base    time.Duration
+	max     time.Duration
+	now     func() time.Time
+}
+
+// NewQuarantine creates an empty quarantine set.
+func NewQuarantine(opts QuarantineOptions) *Quarantine {
+	base := opts.BaseBackoff
+	if base <= 0 {
+		base = DefaultQuarantineBaseBackoff
+	}
+	maxB := opts.MaxBackoff
+	if maxB <= 0 {
+		maxB = DefaultQuarantineMaxBackoff
+	}
+	if maxB < base {
+		maxB = base
+	}
+	return &Quarantine{
+		entries: make(map[string]*QuarantineEntry),
+		base:    base,
+		max:     maxB,
+		now:     time.Now,
+	}
+}
+
+// QuarantineKey formats the key used for a bundle.
+func QuarantineKey(algorithm, hash string) string {
+	return algorithm + ":" + hash
+}
+
+// Record notes a failed attempt for key and schedules the next retry:
+// base after the first failure, doubling per attempt up to max.
+func (q *Quarantine) Record(key, reason string, err error) QuarantineEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	e, ok := q.entries[key]
+	if !ok {
+		if len(q.entries) >= maxQuarantineEntries {
+			q.evictOldestLocked()
+		}
+		e = &QuarantineEntry{Key: key, FirstSeen: now}
+		q.entries[key] = e
+	}
+
+	e.Attempts++
+	e.Reason = reason
+	if err != nil {
+		e.Error = err.Error()
+	}
+	e.LastAttempt = now
+	e.NextRetry = now.Add(q.backoff(e.Attempts))
+	return *e
+}
+
+// Blocked reports whether key is quarantined and not yet due for a retry.
+func (q *Quarantine) Blocked(key string) (QuarantineEntry, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.entries[key]
+	if !ok {
+		return QuarantineEntry{}, false
+	}
+	return *e, q.now().Before(e.NextRetry)
+}
+
+// Clear removes key from quarantine so the next poll retries it immediately.
+// Reports whether the key was present.
+func (q *Quarantine) Clear(key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	_, ok := q.entries[key]
+	delete(q.entries, key)
+	return ok
+}
+
+// ClearAll empties the quarantine and returns how many entries were removed.
+func (q *Quarantine) ClearAll() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := len(q.entries)
+	clear(q.entries)
+	return n
+}
+
+// List returns a snapshot of all entries, oldest first.
+func (q *Quarantine) List() []QuarantineEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	out := make([]QuarantineEntry, 0, len(q.entries))
+	for _, e := range q.entries {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FirstSeen.Equal(out[j].FirstSeen) {
+			return out[i].Key < out[j].Key
+		}
+		return out[i].FirstSeen.Before(out[j].FirstSeen)
+	})
+	return out
+}
+
+// Len returns the number of quarantined bundles.
+func (q *Quarantine) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// backoff returns base * 2^(attempts-1), capped at max.
+func (q *Quarantine) backoff(attempts int) time.Duration {
+	d := q.base
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= q.max {
+			return q.max
+		}
+	}
+	return d
+}
+
+func (q *Quarantine) evictOldestLocked() {
+	var oldest *QuarantineEntry
+	for _, e := range q.entries {
+		if oldest == nil || e.LastAttempt.Before(oldest.LastAttempt) {
+			oldest = e
+		}
+	}
+	if oldest != nil {
+		delete(q.entries, oldest.Key)
+	}
+}
@@ -0,0 +1,134 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeClock drives Quarantine.now in tests.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestQuarantine(base, maxB time.Duration) (*Quarantine, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}
+	q := NewQuarantine(QuarantineOptions{BaseBackoff: base, MaxBackoff: maxB})
+	q.now = clock.now
+	return q, clock
+}
+
+func TestNewQuarantine_Defaults(t *testing.T) {
+	q := NewQuarantine(QuarantineOptions{})
+	if q.base != DefaultQuarantineBaseBackoff || q.max != DefaultQuarantineMaxBackoff {
+		t.Fatalf("base=%s max=%s", q.base, q.max)
+	}
+
+	q = NewQuarantine(QuarantineOptions{BaseBackoff: time.Hour, MaxBackoff: time.Minute})
+	if q.max != time.Hour {
+		t.Fatalf("max below base should be raised to base, got %s", q.max)
+	}
+}
+
+func TestQuarantine_RecordAndBlocked(t *testing.T) {
+	q, clock := newTestQuarantine(time.Minute, time.Hour)
+
+	if _, blocked := q.Blocked("sha384:aa"); blocked {
+		t.Fatal("unknown key should not be blocked")
+	}
+
+	e := q.Record("sha384:aa", QuarantineReasonLoad, errors.New("checksum mismatch"))
+	if e.Attempts != 1 || e.Reason != QuarantineReasonLoad || e.Error != "checksum mismatch" {
+		t.Fatalf("entry = %+v", e)
+	}
+	if !e.NextRetry.Equal(clock.t.Add(time.Minute)) {
+		t.Fatalf("NextRetry = %s, want +1m", e.NextRetry)
+	}
+
+	if _, blocked := q.Blocked("sha384:aa"); !blocked {
+		t.Fatal("key should be blocked before its retry time")
+	}
+	clock.advance(time.Minute)
+	if e, blocked := q.Blocked("sha384:aa"); blocked || e.Attempts != 1 {
+		t.Fatalf("key should be due for retry: blocked=%v entry=%+v", blocked, e)
+	}
+}
+
+func TestQuarantine_ExponentialBackoffCapped(t *testing.T) {
+	q, clock := newTestQuarantine(time.Minute, 10*time.Minute)
+	start := clock.t
+
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for i, d := range want {
+		e := q.Record("k", QuarantineReasonValidation, nil)
+		if got := e.NextRetry.Sub(clock.t); got != d {
+			t.Fatalf("attempt %d: backoff = %s, want %s", i+1, got, d)
+		}
+		if !e.FirstSeen.Equal(start) {
+			t.Fatalf("FirstSeen moved: %s", e.FirstSeen)
+		}
+		clock.advance(d)
+	}
+}
+
+func TestQuarantine_ClearAndClearAll(t *testing.T) {
+	q, _ := newTestQuarantine(time.Minute, time.Hour)
+	q.Record("a", QuarantineReasonLoad, nil)
+	q.Record("b", QuarantineReasonLoad, nil)
+	q.Record("c", QuarantineReasonLoad, nil)
+
+	if !q.Clear("a") {
+		t.Fatal("Clear(a) should report present")
+	}
+	if q.Clear("a") {
+		t.Fatal("second Clear(a) should report absent")
+	}
+	if _, blocked := q.Blocked("a"); blocked {
+		t.Fatal("cleared key should not be blocked")
+	}
+	if n := q.ClearAll(); n != 2 {
+		t.Fatalf("ClearAll = %d, want 2", n)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("Len = %d after ClearAll", q.Len())
+	}
+}
+
+func TestQuarantine_ListOldestFirst(t *testing.T) {
+	q, clock := newTestQuarantine(time.Minute, time.Hour)
+	q.Record("second", QuarantineReasonLoad, nil)
+	clock.advance(time.Second)
+	q.Record("third", QuarantineReasonLoad, nil)
+	q.Record("second", QuarantineReasonLoad, nil) // re-attempt doesn't reorder
+
+	list := q.List()
+	if len(list) != 2 || list[0].Key != "second" || list[1].Key != "third" {
+		t.Fatalf("List = %+v", list)
+	}
+	if list[0].Attempts != 2 {
+		t.Fatalf("second attempts = %d, want 2", list[0].Attempts)
+	}
+}
+
+func TestQuarantine_EvictsLeastRecentlyAttempted(t *testing.T) {
+	q, clock := newTestQuarantine(time.Minute, time.Hour)
+	for i := range maxQuarantineEntries {
+		q.Record(fmt.Sprintf("k%02d", i), QuarantineReasonLoad, nil)
+		clock.advance(time.Second)
+	}
+	q.Record("k00", QuarantineReasonLoad, nil) // refresh k00 so k01 is oldest
+	clock.advance(time.Second)
+	q.Record("new", QuarantineReasonLoad, nil)
+
+	if q.Len() != maxQuarantineEntries {
+		t.Fatalf("Len = %d, want %d", q.Len(), maxQuarantineEntries)
+	}
+	if _, ok := q.Blocked("k01"); ok {
+		t.Fatal("k01 should have been evicted")
+	}
+	if _, ok := q.Blocked("k00"); !ok {
+		t.Fatal("k00 was refreshed and should remain")
+	}
+}
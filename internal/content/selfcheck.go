@@ -0,0 +1,170 @@
+// internal/content/selfcheck.go
+//
+// ValidateSnapshot looks at the bundle's files; it can't tell whether the
+// site actually renders once the snapshot is live behind the real middleware
+// chain. The self-check requests a few critical paths through the in-process
+// handler right after a swap and, if any of them fails, the watcher rolls the
+// swap back and quarantines the bundle.
+package content
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+const (
+	// selfCheckBodyLimit caps how much of a response is searched for markers.
+	selfCheckBodyLimit = 4 << 20
+
+	defaultSelfCheckTimeout = 5 * time.Second
+)
+
+// SelfCheckPath is one request the self-check makes and what it expects.
+type SelfCheckPath struct {
+	Path string
+	// Status is the expected status code; zero means 200.
+	Status int
+	// ContentType, when set, must prefix the response Content-Type.
+	ContentType string
+	// Markers must all appear in the response body. Requests are sent
+	// without Accept-Encoding, so the body is never compressed.
+	Markers []string
+}
+
+// SelfCheckOptions configures a SelfCheck.
+type SelfCheckOptions struct {
+	// Handler is the full site handler chain, as built by httpserver.NewHandler.
+	Handler http.Handler
+	Paths   []SelfCheckPath
+	// Timeout bounds the whole check. Zero defaults to 5s.
+	Timeout time.Duration
+}
+
+// SelfCheck probes the in-process site handler after a swap.
+type SelfCheck struct {
+	handler http.Handler
+	paths   []SelfCheckPath
+	timeout time.Duration
+}
+
+// NewSelfCheck returns nil when there is no handler or nothing to check, so
+// callers can pass the result straight to WatcherOptions.
+func NewSelfCheck(opts *SelfCheckOptions) *SelfCheck {
+	if opts == nil || opts.Handler == nil || len(opts.Paths) == 0 {
+		return nil
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultSelfCheckTimeout
+	}
+	return &SelfCheck{handler: opts.Handler, paths: opts.Paths, timeout: timeout}
+}
+
+// SelfCheckError reports the first path that failed.
+type SelfCheckError struct {
+	Path   string
+	Reason string
+}
+
+func (e *SelfCheckError) Error() string {
+	return "self-check " + e.Path + ": " + e.Reason
+}
+
+// Run requests every path in order and returns a *SelfCheckError for the
+// first one that doesn't meet its expectations.
+func (c *SelfCheck) Run(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	for _, p := range c.paths {
+		if err := ctx.Err(); err != nil {
+			return &SelfCheckError{Path: p.Path, Reason: err.Error()}
+		}
+		if reason := c.check(ctx, p); reason != "" {
+			return &SelfCheckError{Path: p.Path, Reason: reason}
+		}
+	}
+	return nil
+}
+
+// check returns why p failed, or "" when it passed.
+func (c *SelfCheck) check(ctx context.Context, p SelfCheckPath) (reason string) {
+	// a panicking handler is a failed check, not a crashed watcher; the
+	// recover middleware normally catches it first
+	defer func() {
+		if r := recover(); r != nil {
+			reason = "handler panic"
+		}
+	}()
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, p.Path, http.NoBody)
+	req.RemoteAddr = "127.0.0.1:0"
+	req.Header.Set("User-Agent", "linnemanlabs-web-selfcheck")
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, req)
+
+	want := p.Status
+	if want == 0 {
+		want = http.StatusOK
+	}
+	if rec.Code != want {
+		return "status " + strconv.Itoa(rec.Code) + ", want " + strconv.Itoa(want)
+	}
+	if ct := rec.Header().Get("Content-Type"); p.ContentType != "" && !strings.HasPrefix(ct, p.ContentType) {
+		return "content type " + strconv.Quote(ct) + ", want " + strconv.Quote(p.ContentType)
+	}
+	body := rec.Body.Bytes()
+	if len(body) > selfCheckBodyLimit {
+		body = body[:selfCheckBodyLimit]
+	}
+	for _, m := range p.Markers {
+		if !bytes.Contains(body, []byte(m)) {
+			return "missing marker " + strconv.Quote(m)
+		}
+	}
+	return ""
+}
+
+// ParseSelfCheckPaths parses a comma-separated list of checks, each
+// path[|status[|content-type[|marker...]]], e.g.
+//
+//	/|200|text/html|id="provenance-content-data",/404-check|404
+//
+// Empty fields keep their defaults.
+func ParseSelfCheckPaths(s string) ([]SelfCheckPath, error) {
+	var out []SelfCheckPath
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, "|")
+		p := SelfCheckPath{Path: fields[0]}
+		if !strings.HasPrefix(p.Path, "/") {
+			return nil, xerrors.Newf("self-check path %q must start with /", p.Path)
+		}
+		if len(fields) > 1 && fields[1] != "" {
+			code, err := strconv.Atoi(fields[1])
+			if err != nil || code < 100 || code > 599 {
+				return nil, xerrors.Newf("self-check %q: invalid status %q", p.Path, fields[1])
+			}
+			p.Status = code
+		}
+		if len(fields) > 2 {
+			p.ContentType = fields[2]
+		}
+		for _, m := range fields[min(len(fields), 3):] {
+			if m != "" {
+				p.Markers = append(p.Markers, m)
+			}
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,198 @@
+package content
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseSelfCheckPaths(t *testing.T) {
+	got, err := ParseSelfCheckPaths(` /|200|text/html|id="provenance-content-data"|</html>, /missing|404 ,,/feed.xml||application/xml`)
+	if err != nil {
+		t.Fatalf("ParseSelfCheckPaths: %v", err)
+	}
+	want := []SelfCheckPath{
+		{Path: "/", Status: 200, ContentType: "text/html", Markers: []string{`id="provenance-content-data"`, "</html>"}},
+		{Path: "/missing", Status: 404},
+		{Path: "/feed.xml", ContentType: "application/xml"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+
+	for _, bad := range []string{"index.html", "/|ok", "/|99"} {
+		if _, err := ParseSelfCheckPaths(bad); err == nil {
+			t.Errorf("ParseSelfCheckPaths(%q) expected error", bad)
+		}
+	}
+	if got, err := ParseSelfCheckPaths(""); err != nil || len(got) != 0 {
+		t.Fatalf("empty = %v, %v", got, err)
+	}
+}
+
+func TestNewSelfCheck_NilWhenUnconfigured(t *testing.T) {
+	if NewSelfCheck(nil) != nil {
+		t.Fatal("nil options should give nil")
+	}
+	if NewSelfCheck(&SelfCheckOptions{Handler: http.NotFoundHandler()}) != nil {
+		t.Fatal("no paths should give nil")
+	}
+	if NewSelfCheck(&SelfCheckOptions{Paths: []SelfCheckPath{{Path: "/"}}}) != nil {
+		t.Fatal("no handler should give nil")
+	}
+}
+
+// managerHandler serves the manager's active snapshot, standing in for the
+// site handler chain.
+func managerHandler(m *Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		snap, ok := m.Get()
+		if !ok {
+			http.Error(w, "no content", http.StatusServiceUnavailable)
+			return
+		}
+		http.FileServerFS(snap.FS).ServeHTTP(w, r)
+	})
+}
+
+func TestSelfCheck_Run(t *testing.T) {
+	m := NewManager()
+	m.Set(Snapshot{FS: fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: []byte("<html><p>marker</p></html>")},
+	}})
+
+	cases := []struct {
+		name   string
+		path   SelfCheckPath
+		reason string
+	}{
+		{"pass", SelfCheckPath{Path: "/", ContentType: "text/html", Markers: []string{"marker"}}, ""},
+		{"status", SelfCheckPath{Path: "/nope.html"}, "status 404, want 200"},
+		{"content type", SelfCheckPath{Path: "/", ContentType: "application/json"}, `content type "text/html; charset=utf-8", want "application/json"`},
+		{"marker", SelfCheckPath{Path: "/", Markers: []string{"absent"}}, `missing marker "absent"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := NewSelfCheck(&SelfCheckOptions{Handler: managerHandler(m), Paths: []SelfCheckPath{tc.path}}).Run(t.Context())
+			if tc.reason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var scErr *SelfCheckError
+			if !errors.As(err, &scErr) || scErr.Reason != tc.reason || scErr.Path != tc.path.Path {
+				t.Fatalf("err = %v, want reason %q", err, tc.reason)
+			}
+		})
+	}
+}
+
+func TestSelfCheck_HandlerPanic(t *testing.T) {
+	c := NewSelfCheck(&SelfCheckOptions{
+		Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") }),
+		Paths:   []SelfCheckPath{{Path: "/"}},
+	})
+	var scErr *SelfCheckError
+	if err := c.Run(t.Context()); !errors.As(err, &scErr) || scErr.Reason != "handler panic" {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// watcher
+
+func selfCheckWatcher(t *testing.T, f *watcherFixture, m *fakeWatcherMetrics) *Watcher {
+	t.Helper()
+	check := NewSelfCheck(&SelfCheckOptions{
+		Handler: managerHandler(f.mgr),
+		Paths:   []SelfCheckPath{{Path: "/", Markers: []string{"healthy"}}},
+	})
+	return f.newWatcher(func(o *WatcherOptions) {
+		o.Metrics = m
+		o.SelfCheck = check
+	})
+}
+
+func TestCheckOnce_SelfCheck_Pass(t *testing.T) {
+	t.Parallel()
+	dataOld, hashOld := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashOld))
+	f.seedManager(t, hashOld, dataOld)
+	hashNew := storeBundle(t, f, map[string]string{"index.html": "<html>healthy</html>"})
+	m := newFakeWatcherMetrics()
+	w := selfCheckWatcher(t, f, m)
+	f.ssm.setValue(ssmValue(hashNew))
+
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", r)
+	}
+	if m.selfChecks["pass"] != 1 || len(f.swapCalls) != 1 {
+		t.Fatalf("selfChecks = %v, swapCalls = %d", m.selfChecks, len(f.swapCalls))
+	}
+}
+
+func TestCheckOnce_SelfCheck_FailRollsBack(t *testing.T) {
+	t.Parallel()
+	dataOld, hashOld := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashOld))
+	f.seedManager(t, hashOld, dataOld)
+	hashBad := storeBundle(t, f, map[string]string{"index.html": "<html>broken</html>"})
+	m := newFakeWatcherMetrics()
+	w := selfCheckWatcher(t, f, m)
+	f.ssm.setValue(ssmValue(hashBad))
+
+	if r := w.checkOnce(t.Context()); r != pollSelfCheckFailed {
+		t.Fatalf("result = %d, want pollSelfCheckFailed", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashOld {
+		t.Fatal("manager not rolled back")
+	}
+	if w.currentHash != hashOld {
+		t.Fatalf("currentHash = %q, want restored hash", truncHash(w.currentHash))
+	}
+	// the failed snapshot is dropped, not kept as a rollback target
+	for _, e := range f.mgr.List() {
+		if e.Hash == hashBad {
+			t.Fatalf("failed snapshot still in history: %+v", f.mgr.List())
+		}
+	}
+	if len(f.swapCalls) != 0 {
+		t.Fatal("OnSwap must not announce a rolled-back bundle")
+	}
+	if m.selfChecks["fail"] != 1 || m.getErrors("selfcheck") != 1 {
+		t.Fatalf("selfChecks = %v, errors = %d", m.selfChecks, m.getErrors("selfcheck"))
+	}
+	e, blocked := w.Quarantine().Blocked(QuarantineKey("sha384", hashBad))
+	if !blocked || e.Reason != QuarantineReasonSelfCheck {
+		t.Fatalf("quarantine = %+v, %v", e, blocked)
+	}
+
+	// the next poll skips the quarantined bundle instead of swapping again
+	if r := w.checkOnce(t.Context()); r != pollQuarantined {
+		t.Fatalf("second poll = %d, want pollQuarantined", r)
+	}
+}
+
+func TestCheckOnce_SelfCheck_FailWithoutHistory(t *testing.T) {
+	t.Parallel()
+	dataOld, hashOld := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashOld))
+	f.mgr = NewManagerWithOptions(&ManagerOptions{HistorySize: 1})
+	f.seedManager(t, hashOld, dataOld)
+	hashBad := storeBundle(t, f, map[string]string{"index.html": "<html>broken</html>"})
+	w := selfCheckWatcher(t, f, newFakeWatcherMetrics())
+	f.ssm.setValue(ssmValue(hashBad))
+
+	if r := w.checkOnce(t.Context()); r != pollSelfCheckFailed {
+		t.Fatalf("result = %d, want pollSelfCheckFailed", r)
+	}
+	// nothing to roll back to: the bundle stays live and is announced
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashBad {
+		t.Fatal("expected the only snapshot to remain active")
+	}
+	if len(f.swapCalls) != 1 || f.swapCalls[0].hash != hashBad {
+		t.Fatalf("swapCalls = %+v", f.swapCalls)
+	}
+}
@@ -10,4 +10,39 @@ type Snapshot struct {
 	Meta       Meta
 	Provenance *Provenance
 	LoadedAt   time.Time
+
+	// Augmented records the files rewritten by provenance island injection,
+	// keyed by path, with their pre- and post-injection digests. Manifest
+	// verification checks these against release.json instead of the served
+	// bytes, and the provenance API publishes them so the served pages can be
+	// reconciled with the signed manifest.
+	Augmented map[string]AugmentedFile
+
+	// Variants holds precompressed encodings of compressible files, built
+	// once when the Manager activates the snapshot. Nil when disabled.
+	Variants *Variants
+
+	// Digests indexes the served bytes of every file by path, for strong
+	// ETags and conditional requests. Built by the Manager on activation.
+	Digests Digests
+
+	// islandSources keeps the pre-injection bytes of the files in Augmented
+	// so the islands can be rendered again when the provenance they embed
+	// changes (see Manager.RerenderIslands).
+	islandSources map[string][]byte
+}
+
+// AugmentedFile describes a bundle file the server modified in memory.
+type AugmentedFile struct {
+	// OriginalSHA256 and OriginalSize describe the file as shipped in the
+	// signed bundle; they match its release.json entry.
+	OriginalSHA256 string `json:"original_sha256"`
+	OriginalSize   int64  `json:"original_size"`
+
+	// SHA256 and Size describe the bytes actually served.
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+
+	// Islands lists the data-island IDs injected into the file.
+	Islands []string `json:"islands"`
 }
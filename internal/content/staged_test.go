@@ -0,0 +1,352 @@
+package content
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// storeEmbargoedBundle stores a bundle whose release.json carries activateAt.
+func storeEmbargoedBundle(t *testing.T, f *watcherFixture, body string, activateAt time.Time) string {
+	t.Helper()
+	return storeBundle(t, f, map[string]string{
+		"index.html":   "<html>" + body + "</html>",
+		"release.json": fmt.Sprintf(`{"version":"2.0.0","activate_at":%q}`, activateAt.Format(time.RFC3339Nano)),
+	})
+}
+
+// stagedFixture serves a seed bundle and points SSM at an embargoed one.
+func stagedFixture(t *testing.T, activateAt time.Time) (f *watcherFixture, w *Watcher, m *fakeWatcherMetrics, hashOld, hashNew string) {
+	t.Helper()
+	dataOld, hashOld := buildContentBundle(t)
+	f = newWatcherFixture(t, ssmValue(hashOld))
+	f.seedManager(t, hashOld, dataOld)
+	hashNew = storeEmbargoedBundle(t, f, "embargoed", activateAt)
+
+	m = newFakeWatcherMetrics()
+	w = f.newWatcher(func(o *WatcherOptions) { o.Metrics = m })
+	f.ssm.setValue(ssmValue(hashNew))
+	return f, w, m, hashOld, hashNew
+}
+
+func TestActivationTime(t *testing.T) {
+	t0 := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	snap := &Snapshot{Provenance: &Provenance{ActivateAt: t0}}
+
+	if got := activationTime(nil, nil); !got.IsZero() {
+		t.Fatalf("nil inputs = %v, want zero", got)
+	}
+	if got := activationTime(nil, snap); !got.Equal(t0) {
+		t.Fatalf("provenance only = %v, want %v", got, t0)
+	}
+	if got := activationTime(&Pointer{}, snap); !got.Equal(t0) {
+		t.Fatalf("pointer without activate_at = %v, want %v", got, t0)
+	}
+	if got := activationTime(&Pointer{ActivateAt: t1}, snap); !got.Equal(t1) {
+		t.Fatalf("pointer should win: %v, want %v", got, t1)
+	}
+}
+
+func TestCheckOnce_Staged_HoldsUntilActivation(t *testing.T) {
+	t.Parallel()
+	at := time.Now().Add(time.Hour)
+	f, w, m, hashOld, hashNew := stagedFixture(t, at)
+
+	if r := w.checkOnce(t.Context()); r != pollStaged {
+		t.Fatalf("result = %d, want pollStaged", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashOld {
+		t.Fatal("embargoed bundle swapped in early")
+	}
+	st, ok := w.Staged()
+	if !ok || st.Key != QuarantineKey("sha384", hashNew) || st.Version != "2.0.0" || !st.ActivateAt.Equal(at) {
+		t.Fatalf("Staged = %+v, %v", st, ok)
+	}
+	if m.stagedHash != hashNew || m.stagedAt != float64(at.Unix()) {
+		t.Fatalf("metrics staged = %q @ %f", m.stagedHash, m.stagedAt)
+	}
+	if len(f.swapCalls) != 0 {
+		t.Fatal("OnSwap called for staged bundle")
+	}
+}
+
+func TestCheckOnce_Staged_NoRedownload(t *testing.T) {
+	t.Parallel()
+	f, w, m, _, hashNew := stagedFixture(t, time.Now().Add(time.Hour))
+
+	_ = w.checkOnce(t.Context())
+	// a repeat poll must reuse the staged snapshot, even if the object vanished
+	f.s3.failOn(fmt.Sprintf("%s/sha384/%s.tar.gz", testS3Prefix, hashNew), fmt.Errorf("gone"))
+	if r := w.checkOnce(t.Context()); r != pollStaged {
+		t.Fatalf("second poll = %d, want pollStaged", r)
+	}
+	if len(m.loadDurations) != 1 {
+		t.Fatalf("loads = %d, want 1", len(m.loadDurations))
+	}
+}
+
+func TestCheckOnce_Staged_ActivatesWhenDue(t *testing.T) {
+	t.Parallel()
+	f, w, m, _, hashNew := stagedFixture(t, time.Now().Add(50*time.Millisecond))
+
+	if r := w.checkOnce(t.Context()); r != pollStaged {
+		t.Fatalf("result = %d, want pollStaged", r)
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashNew {
+		t.Fatal("staged bundle not activated")
+	}
+	if _, ok := w.Staged(); ok {
+		t.Fatal("staged bundle should be cleared after activation")
+	}
+	if m.stagedHash != "" || m.stagedAt != 0 {
+		t.Fatalf("metrics not cleared: %q @ %f", m.stagedHash, m.stagedAt)
+	}
+	if len(f.swapCalls) != 1 || f.swapCalls[0].hash != hashNew {
+		t.Fatalf("swapCalls = %+v", f.swapCalls)
+	}
+}
+
+func TestCheckOnce_Staged_PastActivationSwapsImmediately(t *testing.T) {
+	t.Parallel()
+	f, w, _, _, hashNew := stagedFixture(t, time.Now().Add(-time.Minute))
+
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashNew {
+		t.Fatal("bundle past its activate_at should swap")
+	}
+}
+
+func TestCheckOnce_Staged_Superseded(t *testing.T) {
+	t.Parallel()
+	f, w, _, _, _ := stagedFixture(t, time.Now().Add(time.Hour))
+	_ = w.checkOnce(t.Context())
+
+	later := time.Now().Add(2 * time.Hour)
+	hashNewer := storeEmbargoedBundle(t, f, "newer", later)
+	f.ssm.setValue(ssmValue(hashNewer))
+
+	if r := w.checkOnce(t.Context()); r != pollStaged {
+		t.Fatalf("result = %d, want pollStaged", r)
+	}
+	st, _ := w.Staged()
+	if st.Key != QuarantineKey("sha384", hashNewer) || !st.ActivateAt.Equal(later) {
+		t.Fatalf("Staged = %+v, want newer bundle", st)
+	}
+}
+
+func TestCheckOnce_Staged_DroppedWhenPointerReverts(t *testing.T) {
+	t.Parallel()
+	f, w, m, hashOld, _ := stagedFixture(t, time.Now().Add(time.Hour))
+	_ = w.checkOnce(t.Context())
+
+	f.ssm.setValue(ssmValue(hashOld))
+	if r := w.checkOnce(t.Context()); r != pollNoChange {
+		t.Fatalf("result = %d, want pollNoChange", r)
+	}
+	if _, ok := w.Staged(); ok {
+		t.Fatal("staged bundle should be dropped")
+	}
+	if m.stagedHash != "" {
+		t.Fatalf("metrics staged = %q, want cleared", m.stagedHash)
+	}
+}
+
+func TestCheckOnce_Staged_PointerActivateAt(t *testing.T) {
+	t.Parallel()
+	dataOld, hashOld := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashOld))
+	f.seedManager(t, hashOld, dataOld)
+	hashNew := storeBundle(t, f, map[string]string{"index.html": "<html>new</html>"})
+	w := f.newWatcher()
+
+	created := time.Now().Add(-time.Minute)
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	doc := fmt.Sprintf(`{"schema":%q,"hash":"sha384:%s","created_at":%q,"expires_at":%q,"activate_at":%q}`,
+		PointerSchema, hashNew, created.Format(time.RFC3339), created.Add(24*time.Hour).Format(time.RFC3339),
+		at.Format(time.RFC3339))
+	putPointerSigs(f.s3, doc)
+	f.ssm.setValue(doc)
+
+	if r := w.checkOnce(t.Context()); r != pollStaged {
+		t.Fatalf("result = %d, want pollStaged", r)
+	}
+	if st, _ := w.Staged(); !st.ActivateAt.Equal(at) {
+		t.Fatalf("ActivateAt = %v, want %v", st.ActivateAt, at)
+	}
+
+	// a re-signed pointer for the same bundle can pull the time forward
+	created = created.Add(time.Second)
+	doc = fmt.Sprintf(`{"schema":%q,"hash":"sha384:%s","created_at":%q,"expires_at":%q,"activate_at":%q}`,
+		PointerSchema, hashNew, created.Format(time.RFC3339), created.Add(24*time.Hour).Format(time.RFC3339),
+		time.Now().Add(-time.Second).Format(time.RFC3339))
+	putPointerSigs(f.s3, doc)
+	f.ssm.setValue(doc)
+
+	if r := w.checkOnce(t.Context()); r != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", r)
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashNew {
+		t.Fatal("rescheduled bundle not activated")
+	}
+}
+
+func TestCheckOnce_Staged_ActivationAfterPointerExpiry(t *testing.T) {
+	t.Parallel()
+	created := time.Now().Add(-time.Minute).Truncate(time.Second)
+	expires := created.Add(24 * time.Hour)
+
+	cases := []struct {
+		name       string
+		activateAt time.Time // pointer activate_at; zero leaves it to release.json
+		release    time.Time // release.json activate_at
+	}{
+		{"pointer activate_at", expires.Add(time.Hour), time.Time{}},
+		{"pointer activate_at equals expiry", expires, time.Time{}},
+		{"release.json activate_at", time.Time{}, expires.Add(time.Hour)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dataOld, hashOld := buildContentBundle(t)
+			f := newWatcherFixture(t, ssmValue(hashOld))
+			f.seedManager(t, hashOld, dataOld)
+			hashNew := storeEmbargoedBundle(t, f, "late", tc.release)
+			m := newFakeWatcherMetrics()
+			w := f.newWatcher(func(o *WatcherOptions) { o.Metrics = m })
+
+			doc := fmt.Sprintf(`{"schema":%q,"hash":"sha384:%s","created_at":%q,"expires_at":%q`,
+				PointerSchema, hashNew, created.Format(time.RFC3339), expires.Format(time.RFC3339))
+			if !tc.activateAt.IsZero() {
+				doc += fmt.Sprintf(`,"activate_at":%q`, tc.activateAt.Format(time.RFC3339))
+			}
+			doc += "}"
+			putPointerSigs(f.s3, doc)
+			f.ssm.setValue(doc)
+
+			if r := w.checkOnce(t.Context()); r != pollPointerRejected {
+				t.Fatalf("result = %d, want pollPointerRejected", r)
+			}
+			if _, ok := w.Staged(); ok {
+				t.Fatal("bundle activating after pointer expiry should not be staged")
+			}
+			if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashOld {
+				t.Fatal("active content should be unchanged")
+			}
+			if m.getErrors("pointer") != 1 {
+				t.Fatalf("pointer errors = %d, want 1", m.getErrors("pointer"))
+			}
+		})
+	}
+}
+
+func TestActivateDue_RechecksPointer(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name   string
+		mutate func(w *Watcher, p *Pointer)
+	}{
+		{"expired while staged", func(_ *Watcher, p *Pointer) { p.ExpiresAt = time.Now().Add(-time.Second) }},
+		{"floor moved past pointer", func(w *Watcher, p *Pointer) { w.pointerFloor = p.CreatedAt.Add(time.Second) }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dataOld, hashOld := buildContentBundle(t)
+			f := newWatcherFixture(t, ssmValue(hashOld))
+			f.seedManager(t, hashOld, dataOld)
+			hashNew := storeBundle(t, f, map[string]string{"index.html": "<html>new</html>"})
+			m := newFakeWatcherMetrics()
+			w := f.newWatcher(func(o *WatcherOptions) { o.Metrics = m })
+
+			created := time.Now().Add(-time.Minute)
+			doc := fmt.Sprintf(`{"schema":%q,"hash":"sha384:%s","created_at":%q,"expires_at":%q,"activate_at":%q}`,
+				PointerSchema, hashNew, created.Format(time.RFC3339), created.Add(24*time.Hour).Format(time.RFC3339),
+				time.Now().Add(time.Hour).Format(time.RFC3339))
+			putPointerSigs(f.s3, doc)
+			f.ssm.setValue(doc)
+			if r := w.checkOnce(t.Context()); r != pollStaged {
+				t.Fatalf("result = %d, want pollStaged", r)
+			}
+
+			// bring the activation due without another poll, as the timer would
+			w.ctlMu.Lock()
+			w.staged.ActivateAt = time.Now().Add(-time.Second)
+			tc.mutate(w, w.staged.pointer)
+			w.ctlMu.Unlock()
+
+			if r := w.activateDue(t.Context()); r != pollPointerRejected {
+				t.Fatalf("result = %d, want pollPointerRejected", r)
+			}
+			if _, ok := w.Staged(); ok {
+				t.Fatal("staged bundle should be dropped")
+			}
+			if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashOld {
+				t.Fatal("staged bundle activated under an invalid pointer")
+			}
+			if m.getErrors("pointer") != 1 || m.stagedHash != "" {
+				t.Fatalf("pointer errors = %d, staged = %q", m.getErrors("pointer"), m.stagedHash)
+			}
+			if len(f.swapCalls) != 0 {
+				t.Fatalf("swapCalls = %+v", f.swapCalls)
+			}
+		})
+	}
+}
+
+func TestActivateDue_PausedKeepsStaged(t *testing.T) {
+	t.Parallel()
+	f, w, _, hashOld, _ := stagedFixture(t, time.Now().Add(20*time.Millisecond))
+	_ = w.checkOnce(t.Context())
+	w.Pause()
+	time.Sleep(30 * time.Millisecond)
+
+	if r := w.activateDue(t.Context()); r != pollPaused {
+		t.Fatalf("result = %d, want pollPaused", r)
+	}
+	if w.activationC() != nil {
+		t.Fatal("activation timer should be disarmed while paused")
+	}
+	if snap, _ := f.mgr.Get(); snap.Meta.Hash != hashOld {
+		t.Fatal("paused watcher activated staged bundle")
+	}
+	if _, ok := w.Staged(); !ok {
+		t.Fatal("bundle should remain staged while paused")
+	}
+
+	w.Resume()
+	if r := w.activateDue(t.Context()); r != pollSwapped {
+		t.Fatalf("after resume = %d, want pollSwapped", r)
+	}
+}
+
+func TestRun_ActivatesStagedOnTime(t *testing.T) {
+	f, _, _, _, hashNew := stagedFixture(t, time.Now().Add(300*time.Millisecond))
+	// long poll interval: activation must come from the staged timer
+	w := f.newWatcher(func(o *WatcherOptions) { o.PollInterval = time.Hour })
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go w.Run(ctx)
+
+	w.RequestReload()
+	deadline := time.After(3 * time.Second)
+	for {
+		if snap, _ := f.mgr.Get(); snap.Meta.Hash == hashNew {
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatal("staged bundle not activated by the timer")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,688 @@
+// internal/content/tuf.go
+//
+// TUFFetcher is a BundleFetcher that resolves the current content bundle
+// through signed TUF root/timestamp/snapshot/targets metadata rather than an
+// unsigned SSM string. The bundle hash comes from the trusted targets
+// metadata, so only the release currently published by the targets role can
+// be loaded, not any hash that was ever signed.
+//
+// Trusted metadata is persisted to a local directory and reloaded at startup,
+// so version monotonicity (rollback protection) holds across restarts.
+// Expiry is enforced on every refresh, which bounds freeze attacks to the
+// timestamp role's expiry window.
+package content
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+const (
+	// tufOpTimeout bounds individual metadata and target downloads.
+	tufOpTimeout = 60 * time.Second
+
+	// maxTUFMetadataSize caps root/snapshot/targets metadata downloads when
+	// the referencing metadata does not pin a length.
+	maxTUFMetadataSize int64 = 1 * 1024 * 1024 // 1MB
+
+	// maxTUFTimestampSize caps timestamp.json, which is never length-pinned.
+	maxTUFTimestampSize int64 = 16 * 1024 // 16KB
+
+	// maxTUFRootRotations bounds the number of root versions walked in a
+	// single refresh.
+	maxTUFRootRotations = 32
+)
+
+// errTUFNotFound is returned by get when the repository answers 404.
+var errTUFNotFound = errors.New("tuf: not found")
+
+// TUFFetcherOptions configures a TUFFetcher.
+type TUFFetcherOptions struct {
+	Logger log.Logger
+
+	// MetadataURL is the base URL of the TUF metadata (root.json,
+	// timestamp.json, ...).
+	MetadataURL string
+
+	// TargetsURL is the base URL of target files. Empty defaults to
+	// MetadataURL + "/targets".
+	TargetsURL string
+
+	// MetadataDir persists trusted metadata between runs. Created if missing.
+	MetadataDir string
+
+	// TrustedRoot is the root.json used to bootstrap trust when MetadataDir
+	// holds none. It must be distributed out of band, never fetched from the
+	// repository being verified.
+	TrustedRoot []byte
+
+	// TargetName is the target path of the content bundle, e.g.
+	// "content/site.tar.gz".
+	TargetName string
+
+	// Verifier and KeylessVerifier, when set, additionally verify the sigstore
+	// bundles published as the TargetName + ".kms.bundle.sigstore.json" and
+	// ".keyless.bundle.sigstore.json" targets. Each configured verifier makes
+	// its sidecar target mandatory.
+	Verifier        BlobVerifier
+	KeylessVerifier BlobVerifier
+
+	// Inliner fills provenance data islands, as LoaderOptions.Inliner.
+	Inliner ProvenanceInliner
+
+	// HTTPClient allows injecting a custom client. Nil uses a default client.
+	HTTPClient *http.Client
+}
+
+// TUFFetcher resolves and downloads content bundles via a TUF repository.
+type TUFFetcher struct {
+	opts       TUFFetcherOptions
+	logger     log.Logger
+	client     *http.Client
+	targetsURL string
+
+	// now is the clock used for expiry checks, overridable in tests.
+	now func() time.Time
+
+	// mu guards the trusted metadata below. Refreshes are serialized so the
+	// watcher and a concurrent LoadHash never observe a half-updated set.
+	mu        sync.Mutex
+	root      *tufRoot
+	timestamp *tufTimestamp
+	snapshot  *tufSnapshot
+	targets   *tufTargets
+}
+
+// NewTUFFetcher creates a TUFFetcher, loading persisted trusted metadata from
+// MetadataDir or bootstrapping from TrustedRoot.
+func NewTUFFetcher(opts *TUFFetcherOptions) (*TUFFetcher, error) {
+	if opts.MetadataURL == "" {
+		return nil, xerrors.New("content: MetadataURL is required")
+	}
+	if opts.MetadataDir == "" {
+		return nil, xerrors.New("content: MetadataDir is required")
+	}
+	if opts.TargetName == "" {
+		return nil, xerrors.New("content: TargetName is required")
+	}
+	if opts.Logger == nil {
+		opts.Logger = log.Nop()
+	}
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	metadataURL := strings.TrimRight(opts.MetadataURL, "/")
+	targetsURL := strings.TrimRight(opts.TargetsURL, "/")
+	if targetsURL == "" {
+		targetsURL = metadataURL + "/targets"
+	}
+
+	if err := os.MkdirAll(opts.MetadataDir, 0o700); err != nil {
+		return nil, xerrors.Wrapf(err, "create TUF metadata dir %s", opts.MetadataDir)
+	}
+
+	o := *opts
+	o.MetadataURL = metadataURL
+	f := &TUFFetcher{
+		opts:       o,
+		logger:     opts.Logger,
+		client:     client,
+		targetsURL: targetsURL,
+		now:        time.Now,
+	}
+	if err := f.loadTrusted(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// loadTrusted restores the trusted root and any persisted top-level metadata.
+// The root must verify against itself; persisted non-root metadata that no
+// longer verifies is discarded. Expiry is not checked here - persisted
+// metadata only serves as the rollback baseline for the next refresh.
+func (f *TUFFetcher) loadTrusted() error {
+	rootRaw, err := f.readPersisted(tufRoleRoot)
+	if errors.Is(err, fs.ErrNotExist) {
+		if len(f.opts.TrustedRoot) == 0 {
+			return xerrors.Newf("no trusted root in %s and TrustedRoot not set", f.opts.MetadataDir)
+		}
+		rootRaw = f.opts.TrustedRoot
+	} else if err != nil {
+		return xerrors.Wrap(err, "read trusted root")
+	}
+
+	var root tufRoot
+	env, err := parseTUFEnvelope(rootRaw, tufRoleRoot, &root)
+	if err != nil {
+		return err
+	}
+	if err := verifyTUFSignatures(env, &root, tufRoleRoot); err != nil {
+		return xerrors.Wrap(err, "trusted root")
+	}
+	f.root = &root
+	if err := f.persist(tufRoleRoot, rootRaw); err != nil {
+		return err
+	}
+
+	var ts tufTimestamp
+	if f.loadPersistedRole(tufRoleTimestamp, &ts) {
+		f.timestamp = &ts
+	}
+	var snap tufSnapshot
+	if f.loadPersistedRole(tufRoleSnapshot, &snap) {
+		f.snapshot = &snap
+	}
+	var tgt tufTargets
+	if f.loadPersistedRole(tufRoleTargets, &tgt) {
+		f.targets = &tgt
+	}
+	return nil
+}
+
+// loadPersistedRole reads and verifies a persisted role file into v.
+func (f *TUFFetcher) loadPersistedRole(role string, v any) bool {
+	raw, err := f.readPersisted(role)
+	if err != nil {
+		return false
+	}
+	env, err := parseTUFEnvelope(raw, role, v)
+	if err == nil {
+		err = verifyTUFSignatures(env, f.root, role)
+	}
+	if err != nil {
+		f.logger.Warn(context.Background(), "discarding invalid persisted TUF metadata", "role", role, "error", err)
+		_ = os.Remove(f.persistedPath(role))
+		return false
+	}
+	return true
+}
+
+func (f *TUFFetcher) persistedPath(role string) string {
+	return filepath.Join(f.opts.MetadataDir, role+".json")
+}
+
+func (f *TUFFetcher) readPersisted(role string) ([]byte, error) {
+	return readFileLimit(f.persistedPath(role), maxTUFMetadataSize)
+}
+
+// persist atomically writes trusted metadata for role.
+func (f *TUFFetcher) persist(role string, raw []byte) error {
+	tmp, err := os.CreateTemp(f.opts.MetadataDir, "."+role+"-*.json")
+	if err != nil {
+		return xerrors.Wrapf(err, "persist %s metadata", role)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return xerrors.Wrapf(err, "persist %s metadata", role)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return xerrors.Wrapf(err, "persist %s metadata", role)
+	}
+	if err := tmp.Close(); err != nil {
+		return xerrors.Wrapf(err, "persist %s metadata", role)
+	}
+	if err := os.Rename(tmp.Name(), f.persistedPath(role)); err != nil {
+		return xerrors.Wrapf(err, "persist %s metadata", role)
+	}
+	return nil
+}
+
+// get downloads url, failing if the body exceeds maxSize.
+func (f *TUFFetcher) get(ctx context.Context, url string, maxSize int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, tufOpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, xerrors.Wrapf(err, "build request %s", url)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, xerrors.Wrapf(err, "get %s", url)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errTUFNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, xerrors.Newf("get %s: unexpected status %d", url, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, xerrors.Wrapf(err, "read %s", url)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, xerrors.Newf("%s exceeds max size (%d bytes)", url, maxSize)
+	}
+	return data, nil
+}
+
+// refresh runs the TUF client workflow: walk root rotations, then update
+// timestamp, snapshot and targets with signature, version and expiry checks.
+// Caller must hold f.mu.
+func (f *TUFFetcher) refresh(ctx context.Context) error {
+	if err := f.updateRoot(ctx); err != nil {
+		return err
+	}
+	if err := f.updateTimestamp(ctx); err != nil {
+		return err
+	}
+	if err := f.updateSnapshot(ctx); err != nil {
+		return err
+	}
+	return f.updateTargets(ctx)
+}
+
+func (f *TUFFetcher) updateRoot(ctx context.Context) error {
+	for range maxTUFRootRotations {
+		next := f.root.Version + 1
+		raw, err := f.get(ctx, fmt.Sprintf("%s/%d.root.json", f.opts.MetadataURL, next), maxTUFMetadataSize)
+		if errors.Is(err, errTUFNotFound) {
+			break
+		}
+		if err != nil {
+			return xerrors.Wrap(err, "fetch root")
+		}
+
+		var root tufRoot
+		env, err := parseTUFEnvelope(raw, tufRoleRoot, &root)
+		if err != nil {
+			return err
+		}
+		// a new root must be signed by a threshold of both the old and new root keys
+		if err := verifyTUFSignatures(env, f.root, tufRoleRoot); err != nil {
+			return xerrors.Wrapf(err, "root v%d not signed by trusted root", next)
+		}
+		if err := verifyTUFSignatures(env, &root, tufRoleRoot); err != nil {
+			return xerrors.Wrapf(err, "root v%d not self-signed", next)
+		}
+		if root.Version != next {
+			return xerrors.Newf("root version %d, expected %d", root.Version, next)
+		}
+
+		if roleKeysChanged(f.root, &root, tufRoleTimestamp) || roleKeysChanged(f.root, &root, tufRoleSnapshot) {
+			f.logger.Warn(ctx, "TUF timestamp/snapshot keys rotated, discarding trusted metadata", "root_version", next)
+			f.timestamp, f.snapshot = nil, nil
+			_ = os.Remove(f.persistedPath(tufRoleTimestamp))
+			_ = os.Remove(f.persistedPath(tufRoleSnapshot))
+		}
+		if roleKeysChanged(f.root, &root, tufRoleTargets) {
+			f.targets = nil
+			_ = os.Remove(f.persistedPath(tufRoleTargets))
+		}
+
+		if err := f.persist(tufRoleRoot, raw); err != nil {
+			return err
+		}
+		f.root = &root
+		f.logger.Info(ctx, "updated TUF root", "version", root.Version)
+	}
+
+	return checkTUFExpiry(tufRoleRoot, f.root.tufCommon, f.now())
+}
+
+func (f *TUFFetcher) updateTimestamp(ctx context.Context) error {
+	raw, err := f.get(ctx, f.opts.MetadataURL+"/timestamp.json", maxTUFTimestampSize)
+	if err != nil {
+		return xerrors.Wrap(err, "fetch timestamp")
+	}
+
+	var ts tufTimestamp
+	env, err := parseTUFEnvelope(raw, tufRoleTimestamp, &ts)
+	if err != nil {
+		return err
+	}
+	if err := verifyTUFSignatures(env, f.root, tufRoleTimestamp); err != nil {
+		return err
+	}
+	snapMeta, ok := ts.Meta["snapshot.json"]
+	if !ok {
+		return xerrors.New("timestamp metadata has no snapshot.json entry")
+	}
+	if old := f.timestamp; old != nil {
+		if ts.Version < old.Version {
+			return xerrors.Newf("timestamp rollback: version %d < trusted %d", ts.Version, old.Version)
+		}
+		if snapMeta.Version < old.Meta["snapshot.json"].Version {
+			return xerrors.Newf("snapshot rollback: timestamp lists version %d < trusted %d",
+				snapMeta.Version, old.Meta["snapshot.json"].Version)
+		}
+	}
+	if err := checkTUFExpiry(tufRoleTimestamp, ts.tufCommon, f.now()); err != nil {
+		return err
+	}
+
+	if f.timestamp == nil || ts.Version != f.timestamp.Version {
+		if err := f.persist(tufRoleTimestamp, raw); err != nil {
+			return err
+		}
+	}
+	f.timestamp = &ts
+	return nil
+}
+
+func (f *TUFFetcher) updateSnapshot(ctx context.Context) error {
+	want := f.timestamp.Meta["snapshot.json"]
+	if f.snapshot == nil || f.snapshot.Version != want.Version {
+		raw, err := f.fetchMeta(ctx, "snapshot.json", want)
+		if err != nil {
+			return err
+		}
+
+		var snap tufSnapshot
+		env, err := parseTUFEnvelope(raw, tufRoleSnapshot, &snap)
+		if err != nil {
+			return err
+		}
+		if err := verifyTUFSignatures(env, f.root, tufRoleSnapshot); err != nil {
+			return err
+		}
+		if snap.Version != want.Version {
+			return xerrors.Newf("snapshot version %d, timestamp lists %d", snap.Version, want.Version)
+		}
+		if f.snapshot != nil {
+			for name, old := range f.snapshot.Meta {
+				cur, ok := snap.Meta[name]
+				if !ok {
+					return xerrors.Newf("snapshot v%d drops %s", snap.Version, name)
+				}
+				if cur.Version < old.Version {
+					return xerrors.Newf("%s rollback: version %d < trusted %d", name, cur.Version, old.Version)
+				}
+			}
+		}
+		if err := f.persist(tufRoleSnapshot, raw); err != nil {
+			return err
+		}
+		f.snapshot = &snap
+	}
+
+	if _, ok := f.snapshot.Meta["targets.json"]; !ok {
+		return xerrors.New("snapshot metadata has no targets.json entry")
+	}
+	return checkTUFExpiry(tufRoleSnapshot, f.snapshot.tufCommon, f.now())
+}
+
+func (f *TUFFetcher) updateTargets(ctx context.Context) error {
+	want := f.snapshot.Meta["targets.json"]
+	if f.targets == nil || f.targets.Version != want.Version {
+		raw, err := f.fetchMeta(ctx, "targets.json", want)
+		if err != nil {
+			return err
+		}
+
+		var tgt tufTargets
+		env, err := parseTUFEnvelope(raw, tufRoleTargets, &tgt)
+		if err != nil {
+			return err
+		}
+		if err := verifyTUFSignatures(env, f.root, tufRoleTargets); err != nil {
+			return err
+		}
+		if tgt.Version != want.Version {
+			return xerrors.Newf("targets version %d, snapshot lists %d", tgt.Version, want.Version)
+		}
+		if f.targets != nil && tgt.Version < f.targets.Version {
+			return xerrors.Newf("targets rollback: version %d < trusted %d", tgt.Version, f.targets.Version)
+		}
+		if err := f.persist(tufRoleTargets, raw); err != nil {
+			return err
+		}
+		f.targets = &tgt
+		f.logger.Info(ctx, "updated TUF targets", "version", tgt.Version)
+	}
+
+	return checkTUFExpiry(tufRoleTargets, f.targets.tufCommon, f.now())
+}
+
+// fetchMeta downloads snapshot.json or targets.json (version-prefixed under
+// consistent snapshots) and checks any pinned length and hashes.
+func (f *TUFFetcher) fetchMeta(ctx context.Context, name string, want tufMetaFile) ([]byte, error) {
+	file := name
+	if f.root.ConsistentSnapshot {
+		file = fmt.Sprintf("%d.%s", want.Version, name)
+	}
+	maxSize := maxTUFMetadataSize
+	if want.Length > 0 {
+		maxSize = want.Length
+	}
+
+	raw, err := f.get(ctx, f.opts.MetadataURL+"/"+file, maxSize)
+	if err != nil {
+		return nil, xerrors.Wrapf(err, "fetch %s", name)
+	}
+	if want.Length > 0 && int64(len(raw)) != want.Length {
+		return nil, xerrors.Newf("%s length %d, expected %d", name, len(raw), want.Length)
+	}
+	if err := checkTUFHashes(raw, want.Hashes); err != nil {
+		return nil, xerrors.Wrap(err, name)
+	}
+	return raw, nil
+}
+
+// checkTUFHashes verifies data against every supported hash in hashes.
+// Unsupported algorithms are ignored, but at least one supported hash must
+// be present and match. An empty map passes: metadata hashes are optional
+// and targets reject an empty map before calling this.
+func checkTUFHashes(data []byte, hashes map[string]string) error {
+	checked := 0
+	for algo, want := range hashes {
+		var got string
+		switch algo {
+		case "sha256":
+			sum := sha256.Sum256(data)
+			got = hex.EncodeToString(sum[:])
+		case "sha384":
+			sum := sha512.Sum384(data)
+			got = hex.EncodeToString(sum[:])
+		case "sha512":
+			sum := sha512.Sum512(data)
+			got = hex.EncodeToString(sum[:])
+		default:
+			continue
+		}
+		if !cryptoutil.HashEqual(got, want) {
+			return xerrors.Newf("%s mismatch: expected %s, got %s", algo, want, got)
+		}
+		checked++
+	}
+	if len(hashes) > 0 && checked == 0 {
+		return xerrors.Newf("no supported hash algorithm in %s", strings.Join(slices.Sorted(maps.Keys(hashes)), ", "))
+	}
+	return nil
+}
+
+// FetchCurrentBundleHash refreshes the trusted metadata and returns the
+// content target's hash, preferring SHA-384 over SHA-256.
+func (f *TUFFetcher) FetchCurrentBundleHash(ctx context.Context) (hashAlgorithm, contentHash string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.refresh(ctx); err != nil {
+		return "", "", xerrors.Wrap(err, "refresh TUF metadata")
+	}
+
+	target, ok := f.targets.Targets[f.opts.TargetName]
+	if !ok {
+		return "", "", xerrors.Newf("target %s not found in TUF targets v%d", f.opts.TargetName, f.targets.Version)
+	}
+	for _, algo := range []string{"sha384", "sha256"} {
+		if h, ok := target.Hashes[algo]; ok {
+			return algo, h, nil
+		}
+	}
+	return "", "", xerrors.Newf("target %s has no sha384 or sha256 hash", f.opts.TargetName)
+}
+
+// Load refreshes metadata and returns a Snapshot of the current bundle.
+func (f *TUFFetcher) Load(ctx context.Context) (*Snapshot, error) {
+	algorithm, hash, err := f.FetchCurrentBundleHash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return f.LoadHash(ctx, algorithm, hash)
+}
+
+// LoadHash downloads the content target and returns a Snapshot. The hash must
+// be the one the trusted targets metadata currently lists for TargetName;
+// anything else is refused even if it was validly signed in the past.
+func (f *TUFFetcher) LoadHash(ctx context.Context, algorithm, hash string) (*Snapshot, error) {
+	loadedAt := time.Now().UTC()
+
+	f.mu.Lock()
+	if f.targets == nil {
+		f.mu.Unlock()
+		return nil, xerrors.New("no trusted TUF targets metadata, refresh first")
+	}
+	targets := f.targets
+	consistent := f.root.ConsistentSnapshot
+	f.mu.Unlock()
+
+	target, ok := targets.Targets[f.opts.TargetName]
+	if !ok {
+		return nil, xerrors.Newf("target %s not found in TUF targets v%d", f.opts.TargetName, targets.Version)
+	}
+	if !cryptoutil.HashEqual(target.Hashes[algorithm], hash) {
+		return nil, xerrors.Newf("%s:%s is not the current TUF target for %s", algorithm, truncHash(hash), f.opts.TargetName)
+	}
+
+	data, err := f.fetchTarget(ctx, f.opts.TargetName, target, consistent, maxBundleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	kmsBundleJSON, err := f.verifySidecarTarget(ctx, targets, consistent, kmsBundleSuffix, "kms", f.opts.Verifier, data)
+	if err != nil {
+		return nil, err
+	}
+	keylessBundleJSON, err := f.verifySidecarTarget(ctx, targets, consistent, keylessBundleSuffix, "keyless", f.opts.KeylessVerifier, data)
+	if err != nil {
+		return nil, err
+	}
+	var signatures *cryptoutil.SignaturesInfo
+	if kmsBundleJSON != nil || keylessBundleJSON != nil {
+		signatures = signaturesInfo(ctx, f.logger, hash, kmsBundleJSON, keylessBundleJSON)
+	}
+
+	contentFS, err := extractTarGzToMem(data)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "extract bundle")
+	}
+
+	f.logger.Info(ctx, "loaded content bundle from TUF repository",
+		"target", f.opts.TargetName,
+		"targets_version", targets.Version,
+		"bytes", len(data),
+		"hash", hash,
+	)
+
+	snap := newSnapshot(ctx, f.logger, contentFS, Meta{
+		Hash:          hash,
+		HashAlgorithm: algorithm,
+		Source:        SourceTUF,
+		VerifiedAt:    time.Now().UTC(),
+		Signatures:    signatures,
+	}, loadedAt)
+
+	inlineProvenance(ctx, f.logger, f.opts.Inliner, snap)
+
+	return snap, nil
+}
+
+// fetchTarget downloads a target file and checks its length and hashes
+// against the trusted targets metadata.
+func (f *TUFFetcher) fetchTarget(ctx context.Context, name string, target tufTarget, consistent bool, maxSize int64) ([]byte, error) {
+	if target.Length <= 0 || target.Length > maxSize {
+		return nil, xerrors.Newf("target %s length %d out of range (max %d)", name, target.Length, maxSize)
+	}
+	if len(target.Hashes) == 0 {
+		return nil, xerrors.Newf("target %s has no hashes", name)
+	}
+
+	file := name
+	if consistent {
+		// consistent snapshots prefix the basename with one of its hashes
+		var prefix string
+		for _, algo := range []string{"sha256", "sha512", "sha384"} {
+			if h, ok := target.Hashes[algo]; ok {
+				prefix = h
+				break
+			}
+		}
+		if prefix == "" {
+			return nil, xerrors.Newf("target %s has no supported hash for consistent snapshot path", name)
+		}
+		dir, base := path.Split(name)
+		file = dir + prefix + "." + base
+	}
+
+	data, err := f.get(ctx, f.targetsURL+"/"+file, target.Length)
+	if err != nil {
+		return nil, xerrors.Wrapf(err, "fetch target %s", name)
+	}
+	if int64(len(data)) != target.Length {
+		return nil, xerrors.Newf("target %s length %d, expected %d", name, len(data), target.Length)
+	}
+	if err := checkTUFHashes(data, target.Hashes); err != nil {
+		return nil, xerrors.Wrapf(err, "target %s", name)
+	}
+	return data, nil
+}
+
+// verifySidecarTarget fetches a sigstore bundle published as a TUF target and
+// verifies it against data. It returns nil without fetching when no verifier
+// is configured for that signature kind.
+func (f *TUFFetcher) verifySidecarTarget(ctx context.Context, targets *tufTargets, consistent bool, suffix, kind string, v BlobVerifier, data []byte) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	name := f.opts.TargetName + suffix
+	target, ok := targets.Targets[name]
+	if !ok {
+		return nil, xerrors.Newf("%s sigstore bundle target %s not found", kind, name)
+	}
+	bundleJSON, err := f.fetchTarget(ctx, name, target, consistent, maxSigBundleSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.VerifyBlob(ctx, bundleJSON, data); err != nil {
+		return nil, xerrors.Wrapf(err, "content bundle %s signature verification failed", kind)
+	}
+	return bundleJSON, nil
+}
+
+// LoadIntoManager refreshes metadata and updates the content manager.
+func (f *TUFFetcher) LoadIntoManager(ctx context.Context, mgr *Manager) error {
+	snap, err := f.Load(ctx)
+	if err != nil {
+		return err
+	}
+	mgr.Set(*snap)
+	return nil
+}
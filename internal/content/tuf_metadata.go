@@ -0,0 +1,336 @@
+// internal/content/tuf_metadata.go
+//
+// Minimal TUF (The Update Framework) metadata model and signature checks used
+// by TUFFetcher. Only the four top-level roles are supported; delegated
+// targets are ignored. Signatures are verified over the OLPC canonical JSON
+// encoding of the "signed" object, matching the reference implementations.
+package content
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// TUF top-level role names.
+const (
+	tufRoleRoot      = "root"
+	tufRoleTimestamp = "timestamp"
+	tufRoleSnapshot  = "snapshot"
+	tufRoleTargets   = "targets"
+)
+
+// tufEnvelope is the signed wrapper every TUF metadata file uses.
+type tufEnvelope struct {
+	Signed     json.RawMessage `json:"signed"`
+	Signatures []tufSignature  `json:"signatures"`
+}
+
+type tufSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// tufCommon holds the fields shared by all role metadata.
+type tufCommon struct {
+	Type        string    `json:"_type"`
+	SpecVersion string    `json:"spec_version"`
+	Version     int64     `json:"version"`
+	Expires     time.Time `json:"expires"`
+}
+
+type tufKey struct {
+	KeyType string `json:"keytype"`
+	Scheme  string `json:"scheme"`
+	KeyVal  struct {
+		Public string `json:"public"`
+	} `json:"keyval"`
+}
+
+type tufRole struct {
+	KeyIDs    []string `json:"keyids"`
+	Threshold int      `json:"threshold"`
+}
+
+type tufRoot struct {
+	tufCommon
+	ConsistentSnapshot bool               `json:"consistent_snapshot"`
+	Keys               map[string]tufKey  `json:"keys"`
+	Roles              map[string]tufRole `json:"roles"`
+}
+
+// tufMetaFile describes another metadata file from timestamp or snapshot.
+type tufMetaFile struct {
+	Version int64             `json:"version"`
+	Length  int64             `json:"length,omitempty"`
+	Hashes  map[string]string `json:"hashes,omitempty"`
+}
+
+type tufTimestamp struct {
+	tufCommon
+	Meta map[string]tufMetaFile `json:"meta"`
+}
+
+type tufSnapshot struct {
+	tufCommon
+	Meta map[string]tufMetaFile `json:"meta"`
+}
+
+type tufTarget struct {
+	Length int64             `json:"length"`
+	Hashes map[string]string `json:"hashes"`
+}
+
+type tufTargets struct {
+	tufCommon
+	Targets map[string]tufTarget `json:"targets"`
+}
+
+// parseTUFEnvelope splits a metadata file into its signed body and signatures
+// and checks the body's _type matches the expected role.
+func parseTUFEnvelope(raw []byte, role string, into any) (*tufEnvelope, error) {
+	var env tufEnvelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, xerrors.Wrapf(err, "parse %s metadata", role)
+	}
+	if len(env.Signed) == 0 {
+		return nil, xerrors.Newf("%s metadata has no signed body", role)
+	}
+	if err := json.Unmarshal(env.Signed, into); err != nil {
+		return nil, xerrors.Wrapf(err, "parse %s signed body", role)
+	}
+	var common tufCommon
+	if err := json.Unmarshal(env.Signed, &common); err != nil {
+		return nil, xerrors.Wrapf(err, "parse %s signed body", role)
+	}
+	if common.Type != role {
+		return nil, xerrors.Newf("metadata type %q, expected %q", common.Type, role)
+	}
+	return &env, nil
+}
+
+// verifyTUFSignatures checks that env carries at least threshold valid
+// signatures from distinct keys authorized for role in root. Keys are told
+// apart by their public key material, not their key IDs, and a role that
+// lists one key under several IDs is rejected, so a single key can never be
+// counted twice towards the threshold.
+func verifyTUFSignatures(env *tufEnvelope, root *tufRoot, role string) error {
+	r, ok := root.Roles[role]
+	if !ok {
+		return xerrors.Newf("root has no %s role", role)
+	}
+	if r.Threshold < 1 {
+		return xerrors.Newf("%s role threshold %d is invalid", role, r.Threshold)
+	}
+
+	msg, err := canonicalJSON(env.Signed)
+	if err != nil {
+		return xerrors.Wrapf(err, "canonicalize %s metadata", role)
+	}
+
+	// key ID -> key material for every key the role authorizes
+	authorized := make(map[string]string, len(r.KeyIDs))
+	owner := make(map[string]string, len(r.KeyIDs))
+	for _, id := range r.KeyIDs {
+		key, ok := root.Keys[id]
+		if !ok {
+			continue
+		}
+		material := tufKeyMaterial(key)
+		if other, dup := owner[material]; dup && other != id {
+			return xerrors.Newf("%s role lists the same key under key IDs %s and %s", role, other, id)
+		}
+		owner[material] = id
+		authorized[id] = material
+	}
+
+	valid := make(map[string]bool)
+	for _, s := range env.Signatures {
+		material, ok := authorized[s.KeyID]
+		if !ok || valid[material] {
+			continue
+		}
+		if verifyTUFKeySignature(root.Keys[s.KeyID], msg, s.Sig) == nil {
+			valid[material] = true
+		}
+	}
+
+	if len(valid) < r.Threshold {
+		return xerrors.Newf("%s metadata has %d valid signatures, threshold is %d", role, len(valid), r.Threshold)
+	}
+	return nil
+}
+
+// tufKeyMaterial identifies a key by its scheme and public value, in a
+// normalized form so re-encoding a key (hex case, PEM layout) doesn't make
+// it look like a second key. Unparseable keys fall back to the raw value;
+// they can't verify a signature anyway.
+func tufKeyMaterial(key tufKey) string {
+	public := strings.TrimSpace(key.KeyVal.Public)
+	switch key.Scheme {
+	case "ed25519":
+		public = strings.ToLower(public)
+	case "ecdsa-sha2-nistp256":
+		if block, _ := pem.Decode([]byte(public)); block != nil {
+			if parsed, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+				if der, err := x509.MarshalPKIXPublicKey(parsed); err == nil {
+					public = hex.EncodeToString(der)
+				}
+			}
+		}
+	}
+	return key.Scheme + ":" + public
+}
+
+// verifyTUFKeySignature verifies one hex-encoded signature. Supported schemes
+// are ed25519 and ecdsa-sha2-nistp256.
+func verifyTUFKeySignature(key tufKey, msg []byte, sigHex string) error {
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return xerrors.Wrap(err, "decode signature")
+	}
+
+	switch key.Scheme {
+	case "ed25519":
+		pub, err := hex.DecodeString(key.KeyVal.Public)
+		if err != nil || len(pub) != ed25519.PublicKeySize {
+			return xerrors.New("invalid ed25519 public key")
+		}
+		if !ed25519.Verify(ed25519.PublicKey(pub), msg, sig) {
+			return xerrors.New("ed25519 signature invalid")
+		}
+		return nil
+
+	case "ecdsa-sha2-nistp256":
+		block, _ := pem.Decode([]byte(key.KeyVal.Public))
+		if block == nil {
+			return xerrors.New("invalid ecdsa public key PEM")
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return xerrors.Wrap(err, "parse ecdsa public key")
+		}
+		pub, ok := parsed.(*ecdsa.PublicKey)
+		if !ok {
+			return xerrors.New("public key is not ECDSA")
+		}
+		digest := sha256.Sum256(msg)
+		if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return xerrors.New("ecdsa signature invalid")
+		}
+		return nil
+
+	default:
+		return xerrors.Newf("unsupported key scheme %q", key.Scheme)
+	}
+}
+
+// checkTUFExpiry returns an error when metadata has expired at now.
+func checkTUFExpiry(role string, c tufCommon, now time.Time) error {
+	if !now.Before(c.Expires) {
+		return xerrors.Newf("%s metadata v%d expired at %s", role, c.Version, c.Expires.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// roleKeysChanged reports whether role's key set or threshold differs between
+// two roots. Used to discard trusted timestamp/snapshot metadata after a key
+// rotation so a compromised key's fast-forwarded versions can be recovered.
+func roleKeysChanged(prev, next *tufRoot, role string) bool {
+	a, b := prev.Roles[role], next.Roles[role]
+	if a.Threshold != b.Threshold || len(a.KeyIDs) != len(b.KeyIDs) {
+		return true
+	}
+	ids := make(map[string]bool, len(a.KeyIDs))
+	for _, id := range a.KeyIDs {
+		ids[id] = true
+	}
+	for _, id := range b.KeyIDs {
+		if !ids[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalJSON re-encodes raw JSON in OLPC canonical form: object keys
+// sorted, no insignificant whitespace, integers only, and strings escaping
+// only backslash and double quote.
+func canonicalJSON(raw []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := encodeCanonical(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeCanonical(buf *bytes.Buffer, v any) error {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case json.Number:
+		if _, err := strconv.ParseInt(t.String(), 10, 64); err != nil {
+			return xerrors.Newf("canonical json does not allow non-integer number %s", t)
+		}
+		buf.WriteString(t.String())
+	case string:
+		writeCanonicalString(buf, t)
+	case []any:
+		buf.WriteByte('[')
+		for i, e := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeCanonical(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			if err := encodeCanonical(buf, t[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return xerrors.Newf("canonical json: unsupported type %T", v)
+	}
+	return nil
+}
+
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	buf.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s))
+	buf.WriteByte('"')
+}
@@ -0,0 +1,583 @@
+package content
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+)
+
+// TUF repository fixture
+
+const testTUFTarget = "content/site.tar.gz"
+
+type tufTestKey struct {
+	id   string
+	priv ed25519.PrivateKey
+	pub  tufKey
+}
+
+func newTUFTestKey(t *testing.T) tufTestKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	k := tufKey{KeyType: "ed25519", Scheme: "ed25519"}
+	k.KeyVal.Public = hex.EncodeToString(pub)
+
+	raw, _ := json.Marshal(k)
+	canon, err := canonicalJSON(raw)
+	if err != nil {
+		t.Fatalf("canonicalize key: %v", err)
+	}
+	sum := sha256.Sum256(canon)
+	return tufTestKey{id: hex.EncodeToString(sum[:]), priv: priv, pub: k}
+}
+
+// tufTestRepo serves a TUF repository over httptest. Metadata lives under
+// /metadata and targets under /metadata/targets.
+type tufTestRepo struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu    sync.Mutex
+	files map[string][]byte
+
+	keys    map[string]tufTestKey
+	root    tufRoot
+	rootRaw []byte
+	expires time.Time
+
+	targets    map[string]tufTarget
+	tsVersion  int64
+	snVersion  int64
+	tgtVersion int64
+}
+
+func newTUFTestRepo(t *testing.T) *tufTestRepo {
+	t.Helper()
+	r := &tufTestRepo{
+		t:       t,
+		files:   make(map[string][]byte),
+		keys:    make(map[string]tufTestKey),
+		targets: make(map[string]tufTarget),
+		expires: time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second),
+	}
+	for _, role := range []string{tufRoleRoot, tufRoleTimestamp, tufRoleSnapshot, tufRoleTargets} {
+		r.keys[role] = newTUFTestKey(t)
+	}
+	r.root = r.buildRoot(1)
+	r.rootRaw = r.sign(r.root, r.keys[tufRoleRoot])
+	r.put("1.root.json", r.rootRaw)
+
+	r.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		data, ok := r.files[strings.TrimPrefix(req.URL.Path, "/metadata/")]
+		r.mu.Unlock()
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(r.srv.Close)
+	return r
+}
+
+func (r *tufTestRepo) buildRoot(version int64) tufRoot {
+	root := tufRoot{
+		tufCommon: tufCommon{Type: tufRoleRoot, SpecVersion: "1.0.31", Version: version, Expires: r.expires},
+		Keys:      make(map[string]tufKey),
+		Roles:     make(map[string]tufRole),
+	}
+	for role, k := range r.keys {
+		root.Keys[k.id] = k.pub
+		root.Roles[role] = tufRole{KeyIDs: []string{k.id}, Threshold: 1}
+	}
+	return root
+}
+
+func (r *tufTestRepo) sign(signed any, keys ...tufTestKey) []byte {
+	r.t.Helper()
+	body, err := json.Marshal(signed)
+	if err != nil {
+		r.t.Fatalf("marshal: %v", err)
+	}
+	canon, err := canonicalJSON(body)
+	if err != nil {
+		r.t.Fatalf("canonicalize: %v", err)
+	}
+	env := tufEnvelope{Signed: body}
+	for _, k := range keys {
+		env.Signatures = append(env.Signatures, tufSignature{
+			KeyID: k.id,
+			Sig:   hex.EncodeToString(ed25519.Sign(k.priv, canon)),
+		})
+	}
+	out, err := json.Marshal(env)
+	if err != nil {
+		r.t.Fatalf("marshal envelope: %v", err)
+	}
+	return out
+}
+
+func (r *tufTestRepo) put(name string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.files[name] = data
+}
+
+func (r *tufTestRepo) get(name string) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.files[name]
+}
+
+// addTarget stores a target file and records it for the next publish.
+func (r *tufTestRepo) addTarget(name string, data []byte) {
+	s256 := sha256.Sum256(data)
+	s384 := sha512.Sum384(data)
+	r.targets[name] = tufTarget{
+		Length: int64(len(data)),
+		Hashes: map[string]string{
+			"sha256": hex.EncodeToString(s256[:]),
+			"sha384": hex.EncodeToString(s384[:]),
+		},
+	}
+	r.put("targets/"+name, data)
+}
+
+// publish signs new targets, snapshot and timestamp metadata.
+func (r *tufTestRepo) publish() {
+	r.tgtVersion++
+	r.snVersion++
+	r.tsVersion++
+
+	tgt := tufTargets{
+		tufCommon: tufCommon{Type: tufRoleTargets, SpecVersion: "1.0.31", Version: r.tgtVersion, Expires: r.expires},
+		Targets:   make(map[string]tufTarget, len(r.targets)),
+	}
+	for k, v := range r.targets {
+		tgt.Targets[k] = v
+	}
+	r.put("targets.json", r.sign(tgt, r.keys[tufRoleTargets]))
+
+	snap := tufSnapshot{
+		tufCommon: tufCommon{Type: tufRoleSnapshot, SpecVersion: "1.0.31", Version: r.snVersion, Expires: r.expires},
+		Meta:      map[string]tufMetaFile{"targets.json": {Version: r.tgtVersion}},
+	}
+	snapRaw := r.sign(snap, r.keys[tufRoleSnapshot])
+	r.put("snapshot.json", snapRaw)
+
+	sum := sha256.Sum256(snapRaw)
+	ts := tufTimestamp{
+		tufCommon: tufCommon{Type: tufRoleTimestamp, SpecVersion: "1.0.31", Version: r.tsVersion, Expires: r.expires},
+		Meta: map[string]tufMetaFile{"snapshot.json": {
+			Version: r.snVersion,
+			Length:  int64(len(snapRaw)),
+			Hashes:  map[string]string{"sha256": hex.EncodeToString(sum[:])},
+		}},
+	}
+	r.put("timestamp.json", r.sign(ts, r.keys[tufRoleTimestamp]))
+}
+
+func (r *tufTestRepo) fetcher(t *testing.T, dir string) *TUFFetcher {
+	t.Helper()
+	f, err := NewTUFFetcher(&TUFFetcherOptions{
+		Logger:      log.Nop(),
+		MetadataURL: r.srv.URL + "/metadata",
+		MetadataDir: dir,
+		TrustedRoot: r.rootRaw,
+		TargetName:  testTUFTarget,
+	})
+	if err != nil {
+		t.Fatalf("NewTUFFetcher: %v", err)
+	}
+	return f
+}
+
+// publishBundle adds a content bundle target and publishes it.
+func (r *tufTestRepo) publishBundle(t *testing.T, body string) []byte {
+	t.Helper()
+	data := makeTarGz(t, map[string]string{"index.html": body})
+	r.addTarget(testTUFTarget, data)
+	r.publish()
+	return data
+}
+
+// NewTUFFetcher
+
+func TestNewTUFFetcher_RequiredOptions(t *testing.T) {
+	base := TUFFetcherOptions{MetadataURL: "http://x", MetadataDir: t.TempDir(), TargetName: "t"}
+	for name, mutate := range map[string]func(*TUFFetcherOptions){
+		"MetadataURL": func(o *TUFFetcherOptions) { o.MetadataURL = "" },
+		"MetadataDir": func(o *TUFFetcherOptions) { o.MetadataDir = "" },
+		"TargetName":  func(o *TUFFetcherOptions) { o.TargetName = "" },
+		"TrustedRoot": func(o *TUFFetcherOptions) {},
+	} {
+		o := base
+		mutate(&o)
+		if _, err := NewTUFFetcher(&o); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNewTUFFetcher_RejectsUnsignedRoot(t *testing.T) {
+	r := newTUFTestRepo(t)
+	other := newTUFTestKey(t)
+	_, err := NewTUFFetcher(&TUFFetcherOptions{
+		MetadataURL: r.srv.URL,
+		MetadataDir: t.TempDir(),
+		TrustedRoot: r.sign(r.root, other),
+		TargetName:  testTUFTarget,
+	})
+	if err == nil {
+		t.Fatal("expected error for root not signed by its root keys")
+	}
+}
+
+// Fetch + load
+
+func TestTUFFetcher_FetchAndLoad(t *testing.T) {
+	r := newTUFTestRepo(t)
+	r.publishBundle(t, "v1")
+	dir := t.TempDir()
+	f := r.fetcher(t, dir)
+
+	algo, hash, err := f.FetchCurrentBundleHash(t.Context())
+	if err != nil {
+		t.Fatalf("FetchCurrentBundleHash: %v", err)
+	}
+	if algo != "sha384" || hash != r.targets[testTUFTarget].Hashes["sha384"] {
+		t.Fatalf("got %s:%s", algo, hash)
+	}
+
+	snap, err := f.LoadHash(t.Context(), algo, hash)
+	if err != nil {
+		t.Fatalf("LoadHash: %v", err)
+	}
+	if snap.Meta.Source != SourceTUF {
+		t.Fatalf("Source = %q, want %q", snap.Meta.Source, SourceTUF)
+	}
+	if got := readFileFromFS(t, snap.FS, "index.html"); got != "v1" {
+		t.Fatalf("index.html = %q", got)
+	}
+
+	for _, role := range []string{"root", "timestamp", "snapshot", "targets"} {
+		if _, err := os.Stat(filepath.Join(dir, role+".json")); err != nil {
+			t.Errorf("%s.json not persisted: %v", role, err)
+		}
+	}
+}
+
+func TestTUFFetcher_LoadHash_RejectsNonCurrentHash(t *testing.T) {
+	r := newTUFTestRepo(t)
+	old := r.publishBundle(t, "v1")
+	f := r.fetcher(t, t.TempDir())
+	if _, _, err := f.FetchCurrentBundleHash(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	oldSum := sha512.Sum384(old)
+	oldHash := hex.EncodeToString(oldSum[:])
+
+	r.publishBundle(t, "v2")
+	if _, _, err := f.FetchCurrentBundleHash(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// v1 was validly signed in the past but is no longer the current target
+	_, err := f.LoadHash(t.Context(), "sha384", oldHash)
+	if err == nil || !strings.Contains(err.Error(), "not the current TUF target") {
+		t.Fatalf("expected rejection of superseded hash, got %v", err)
+	}
+}
+
+func TestTUFFetcher_LoadHash_RequiresRefresh(t *testing.T) {
+	r := newTUFTestRepo(t)
+	r.publishBundle(t, "v1")
+	f := r.fetcher(t, t.TempDir())
+	if _, err := f.LoadHash(t.Context(), "sha384", "abc"); err == nil {
+		t.Fatal("expected error before any refresh")
+	}
+}
+
+func TestTUFFetcher_LoadHash_TamperedTarget(t *testing.T) {
+	r := newTUFTestRepo(t)
+	data := r.publishBundle(t, "v1")
+	f := r.fetcher(t, t.TempDir())
+	algo, hash, err := f.FetchCurrentBundleHash(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 0xff
+	r.put("targets/"+testTUFTarget, tampered)
+
+	if _, err := f.LoadHash(t.Context(), algo, hash); err == nil {
+		t.Fatal("expected hash mismatch for tampered target")
+	}
+}
+
+func TestTUFFetcher_MissingTarget(t *testing.T) {
+	r := newTUFTestRepo(t)
+	r.publish()
+	f := r.fetcher(t, t.TempDir())
+	if _, _, err := f.FetchCurrentBundleHash(t.Context()); err == nil {
+		t.Fatal("expected error for missing target")
+	}
+}
+
+// Security properties
+
+func TestTUFFetcher_ExpiredTimestamp(t *testing.T) {
+	r := newTUFTestRepo(t)
+	r.publishBundle(t, "v1")
+	f := r.fetcher(t, t.TempDir())
+	f.now = func() time.Time { return r.expires.Add(time.Second) }
+
+	_, _, err := f.FetchCurrentBundleHash(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expected expiry error, got %v", err)
+	}
+}
+
+func TestTUFFetcher_TimestampRollback(t *testing.T) {
+	r := newTUFTestRepo(t)
+	r.publishBundle(t, "v1")
+	oldTS := r.get("timestamp.json")
+	r.publishBundle(t, "v2")
+
+	f := r.fetcher(t, t.TempDir())
+	if _, _, err := f.FetchCurrentBundleHash(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	r.put("timestamp.json", oldTS)
+	_, _, err := f.FetchCurrentBundleHash(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "rollback") {
+		t.Fatalf("expected rollback error, got %v", err)
+	}
+}
+
+func TestTUFFetcher_RollbackProtectionSurvivesRestart(t *testing.T) {
+	r := newTUFTestRepo(t)
+	r.publishBundle(t, "v1")
+	oldTS := r.get("timestamp.json")
+	r.publishBundle(t, "v2")
+
+	dir := t.TempDir()
+	if _, _, err := r.fetcher(t, dir).FetchCurrentBundleHash(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	r.put("timestamp.json", oldTS)
+	restarted := r.fetcher(t, dir)
+	if _, _, err := restarted.FetchCurrentBundleHash(t.Context()); err == nil {
+		t.Fatal("expected rollback error after restart with persisted metadata")
+	}
+}
+
+func TestTUFFetcher_WrongTimestampKey(t *testing.T) {
+	r := newTUFTestRepo(t)
+	r.publishBundle(t, "v1")
+	r.keys[tufRoleTimestamp] = newTUFTestKey(t) // not in root
+	r.publish()
+
+	f := r.fetcher(t, t.TempDir())
+	_, _, err := f.FetchCurrentBundleHash(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "threshold") {
+		t.Fatalf("expected signature threshold error, got %v", err)
+	}
+}
+
+func TestVerifyTUFSignatures_DuplicateKeyMaterial(t *testing.T) {
+	r := newTUFTestRepo(t)
+	k := r.keys[tufRoleTimestamp]
+
+	// the same key under a second ID, re-encoded so the IDs differ
+	alias := k
+	alias.id = strings.Repeat("ab", 32)
+	alias.pub.KeyVal.Public = strings.ToUpper(k.pub.KeyVal.Public)
+
+	root := r.buildRoot(1)
+	root.Keys[alias.id] = alias.pub
+	root.Roles[tufRoleTimestamp] = tufRole{KeyIDs: []string{k.id, alias.id}, Threshold: 2}
+
+	ts := tufTimestamp{tufCommon: tufCommon{Type: tufRoleTimestamp, SpecVersion: "1.0.31", Version: 1, Expires: r.expires}}
+	var env tufEnvelope
+	if err := json.Unmarshal(r.sign(ts, k, alias), &env); err != nil {
+		t.Fatal(err)
+	}
+
+	err := verifyTUFSignatures(&env, &root, tufRoleTimestamp)
+	if err == nil || !strings.Contains(err.Error(), "same key") {
+		t.Fatalf("expected duplicate key rejection, got %v", err)
+	}
+}
+
+func TestTUFFetcher_RootRotation(t *testing.T) {
+	r := newTUFTestRepo(t)
+	r.publishBundle(t, "v1")
+	dir := t.TempDir()
+	f := r.fetcher(t, dir)
+	if _, _, err := f.FetchCurrentBundleHash(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	// rotate root and timestamp keys; v2 root is signed by old and new root keys
+	oldRootKey := r.keys[tufRoleRoot]
+	r.keys[tufRoleRoot] = newTUFTestKey(t)
+	r.keys[tufRoleTimestamp] = newTUFTestKey(t)
+	r.root = r.buildRoot(2)
+	r.put("2.root.json", r.sign(r.root, oldRootKey, r.keys[tufRoleRoot]))
+	r.publish()
+
+	if _, _, err := f.FetchCurrentBundleHash(t.Context()); err != nil {
+		t.Fatalf("FetchCurrentBundleHash after rotation: %v", err)
+	}
+	if f.root.Version != 2 {
+		t.Fatalf("root version = %d, want 2", f.root.Version)
+	}
+
+	// a restart must trust the rotated root from disk, not the bootstrap root
+	restarted := r.fetcher(t, dir)
+	if restarted.root.Version != 2 {
+		t.Fatalf("persisted root version = %d, want 2", restarted.root.Version)
+	}
+}
+
+func TestTUFFetcher_RootRotationRequiresOldKeys(t *testing.T) {
+	r := newTUFTestRepo(t)
+	r.publishBundle(t, "v1")
+	f := r.fetcher(t, t.TempDir())
+
+	r.keys[tufRoleRoot] = newTUFTestKey(t)
+	r.root = r.buildRoot(2)
+	r.put("2.root.json", r.sign(r.root, r.keys[tufRoleRoot])) // missing old-key signature
+
+	_, _, err := f.FetchCurrentBundleHash(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "not signed by trusted root") {
+		t.Fatalf("expected rotation rejection, got %v", err)
+	}
+}
+
+func TestTUFFetcher_SidecarTargets(t *testing.T) {
+	r := newTUFTestRepo(t)
+	data := makeTarGz(t, map[string]string{"index.html": "signed"})
+	r.addTarget(testTUFTarget, data)
+	r.addTarget(testTUFTarget+kmsBundleSuffix, []byte(`{"mock":"kms"}`))
+	r.addTarget(testTUFTarget+keylessBundleSuffix, []byte(`{"mock":"keyless"}`))
+	r.publish()
+
+	kms, keyless := passVerifier(), passVerifier()
+	f, err := NewTUFFetcher(&TUFFetcherOptions{
+		MetadataURL:     r.srv.URL + "/metadata",
+		MetadataDir:     t.TempDir(),
+		TrustedRoot:     r.rootRaw,
+		TargetName:      testTUFTarget,
+		Verifier:        kms,
+		KeylessVerifier: keyless,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err := f.Load(t.Context())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if string(kms.gotBundle) != `{"mock":"kms"}` || string(keyless.gotBundle) != `{"mock":"keyless"}` {
+		t.Fatal("sidecar targets not passed to verifiers")
+	}
+	if snap.Meta.Signatures == nil {
+		t.Fatal("expected signatures info")
+	}
+}
+
+func TestTUFFetcher_WatcherSwap(t *testing.T) {
+	r := newTUFTestRepo(t)
+	r.publishBundle(t, "v1")
+	f := r.fetcher(t, t.TempDir())
+	mgr := NewManager()
+	if err := f.LoadIntoManager(t.Context(), mgr); err != nil {
+		t.Fatalf("LoadIntoManager: %v", err)
+	}
+
+	w := NewWatcher(&WatcherOptions{Loader: f, Manager: mgr, Validation: &ValidationOptions{MinFiles: 1}})
+	if got := w.checkOnce(t.Context()); got != pollNoChange {
+		t.Fatalf("result = %d, want pollNoChange", got)
+	}
+	r.publishBundle(t, "v2")
+	if got := w.checkOnce(t.Context()); got != pollSwapped {
+		t.Fatalf("result = %d, want pollSwapped", got)
+	}
+	snap, _ := mgr.Get()
+	if got := readFileFromFS(t, snap.FS, "index.html"); got != "v2" {
+		t.Fatalf("index.html = %q, want v2", got)
+	}
+}
+
+// canonical JSON
+
+func TestCheckTUFHashes(t *testing.T) {
+	data := []byte("payload")
+	sum := sha256.Sum256(data)
+	good := hex.EncodeToString(sum[:])
+
+	cases := []struct {
+		name    string
+		hashes  map[string]string
+		wantErr string
+	}{
+		{"empty", nil, ""},
+		{"match", map[string]string{"sha256": good}, ""},
+		{"match with unsupported", map[string]string{"sha256": good, "md5": "00"}, ""},
+		{"mismatch", map[string]string{"sha256": strings.Repeat("0", 64)}, "sha256 mismatch"},
+		{"only unsupported", map[string]string{"md5": "00", "crc32": "00"}, "no supported hash algorithm in crc32, md5"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkTUFHashes(data, tc.hashes)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected %q error, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	got, err := canonicalJSON([]byte(`{ "b": [1, true, null], "a": "q\"u\\o\te" }`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\"a\":\"q\\\"u\\\\o\te\",\"b\":[1,true,null]}"
+	if string(got) != want {
+		t.Fatalf("canonicalJSON = %s, want %s", got, want)
+	}
+}
+
+func TestCanonicalJSON_RejectsFloat(t *testing.T) {
+	if _, err := canonicalJSON([]byte(`{"a":1.5}`)); err == nil {
+		t.Fatal("expected error for float")
+	}
+}
@@ -4,8 +4,13 @@
 package content
 
 import (
+	"fmt"
 	"io/fs"
+	"path"
+	"sort"
+	"strings"
 
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
 	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
 )
 
@@ -20,6 +25,13 @@ type ValidationOptions struct {
 	// RequireProvenance fails validation if release.json is missing or
 	// unparseable. When false, missing provenance is a warning, not an error.
 	RequireProvenance bool
+
+	// VerifyManifest checks every extracted file against the per-file list in
+	// release.json: sizes and SHA-256 digests must match, every listed file
+	// must be present, and no unlisted file may be present (release.json
+	// itself excepted). Files rewritten by provenance island injection are
+	// checked by their pre-injection digest. Implies RequireProvenance.
+	VerifyManifest bool
 }
 
 // DefaultValidationOptions returns the recommended production defaults.
@@ -61,10 +73,16 @@ func ValidateSnapshot(snap *Snapshot, opts ValidationOptions) error {
 	}
 
 	// provenance checks
-	if snap.Provenance == nil && opts.RequireProvenance {
+	if snap.Provenance == nil && (opts.RequireProvenance || opts.VerifyManifest) {
 		return xerrors.New("validate: release.json is required but missing")
 	}
 
+	if opts.VerifyManifest {
+		if err := checkManifest(snap); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -102,3 +120,105 @@ func countFiles(fsys fs.FS) (int, error) {
 	})
 	return count, err
 }
+
+// maxManifestErrorPaths caps how many paths of each kind a manifest error names.
+const maxManifestErrorPaths = 5
+
+// checkManifest verifies the snapshot's files against release.json's file list.
+// All discrepancies are collected so a single rejection explains the whole diff.
+func checkManifest(snap *Snapshot) error {
+	if len(snap.Provenance.Files) == 0 {
+		return xerrors.New("validate: release.json lists no files")
+	}
+
+	listed := make(map[string]ProvenanceFile, len(snap.Provenance.Files))
+	for _, f := range snap.Provenance.Files {
+		name := manifestPath(f.Path)
+		if _, dup := listed[name]; dup {
+			return xerrors.Newf("validate: release.json lists %q more than once", f.Path)
+		}
+		listed[name] = f
+	}
+
+	var mismatched, unlisted []string
+	seen := make(map[string]bool, len(listed))
+
+	err := fs.WalkDir(snap.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		want, ok := listed[name]
+		if !ok {
+			if name != ProvenanceFilePath {
+				unlisted = append(unlisted, name)
+			}
+			return nil
+		}
+		seen[name] = true
+
+		var gotSHA string
+		var gotSize int64
+		if orig, ok := snap.Augmented[name]; ok {
+			gotSHA, gotSize = orig.OriginalSHA256, orig.OriginalSize
+		} else {
+			data, err := fs.ReadFile(snap.FS, name)
+			if err != nil {
+				return err
+			}
+			gotSHA, gotSize = cryptoutil.SHA256Hex(data), int64(len(data))
+		}
+
+		if gotSize != want.Size || !cryptoutil.HashEqual(gotSHA, strings.ToLower(want.SHA256)) {
+			mismatched = append(mismatched, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Wrap(err, "validate: walking bundle for manifest check")
+	}
+
+	var missing []string
+	for name := range listed {
+		if !seen[name] {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(mismatched) == 0 && len(missing) == 0 && len(unlisted) == 0 {
+		return nil
+	}
+
+	var parts []string
+	for _, group := range []struct {
+		label string
+		paths []string
+	}{
+		{"modified", mismatched},
+		{"missing", missing},
+		{"unlisted", unlisted},
+	} {
+		if len(group.paths) > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s (%s)", len(group.paths), group.label, summarizePaths(group.paths)))
+		}
+	}
+	return xerrors.Newf("validate: bundle does not match release.json: %s", strings.Join(parts, ", "))
+}
+
+// manifestPath normalizes a release.json path to the fs.FS form used by the
+// extracted bundle ("a/b.html", no leading "./" or "/").
+func manifestPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// summarizePaths renders a sorted, capped list of paths for error messages.
+func summarizePaths(paths []string) string {
+	sort.Strings(paths)
+	if len(paths) > maxManifestErrorPaths {
+		return strings.Join(paths[:maxManifestErrorPaths], ", ") + ", ..."
+	}
+	return strings.Join(paths, ", ")
+}
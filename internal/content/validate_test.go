@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 	"testing/fstest"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
 )
 
 // ValidateSnapshot - nil / empty guards
@@ -314,3 +316,138 @@ func TestCountFiles_NestedDirectories(t *testing.T) {
 		t.Fatalf("count = %d, want 4", count)
 	}
 }
+
+// VerifyManifest
+
+// manifestSnapshot builds a snapshot whose release.json lists every file in
+// files with its true digest and size.
+func manifestSnapshot(files map[string]string) *Snapshot {
+	mfs := fstest.MapFS{}
+	prov := &Provenance{Version: "1.0.0"}
+	for name, body := range files {
+		mfs[name] = &fstest.MapFile{Data: []byte(body)}
+		prov.Files = append(prov.Files, ProvenanceFile{Path: name, SHA256: sha256hex([]byte(body)), Size: int64(len(body))})
+	}
+	mfs[ProvenanceFilePath] = &fstest.MapFile{Data: []byte(`{}`)}
+	return &Snapshot{FS: mfs, Provenance: prov}
+}
+
+func TestValidateSnapshot_VerifyManifest_Match(t *testing.T) {
+	snap := manifestSnapshot(map[string]string{
+		"index.html":    "<html>hi</html>",
+		"css/style.css": "body{}",
+	})
+	if err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true}); err != nil {
+		t.Fatalf("expected manifest match: %v", err)
+	}
+}
+
+func TestValidateSnapshot_VerifyManifest_NormalizesPaths(t *testing.T) {
+	snap := manifestSnapshot(map[string]string{"index.html": "<html>hi</html>"})
+	snap.Provenance.Files[0].Path = "./index.html"
+	if err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true}); err != nil {
+		t.Fatalf("expected ./ prefix to be normalized: %v", err)
+	}
+}
+
+func TestValidateSnapshot_VerifyManifest_ModifiedFile(t *testing.T) {
+	snap := manifestSnapshot(map[string]string{
+		"index.html": "<html>hi</html>",
+		"app.js":     "ok()",
+	})
+	snap.FS.(fstest.MapFS)["app.js"].Data = []byte("bad()")
+
+	err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true})
+	if err == nil || !strings.Contains(err.Error(), "1 modified (app.js)") {
+		t.Fatalf("expected modified app.js, got %v", err)
+	}
+}
+
+func TestValidateSnapshot_VerifyManifest_SizeMismatch(t *testing.T) {
+	snap := manifestSnapshot(map[string]string{"index.html": "<html>hi</html>"})
+	snap.Provenance.Files[0].Size++
+
+	err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true})
+	if err == nil || !strings.Contains(err.Error(), "modified (index.html)") {
+		t.Fatalf("expected size mismatch, got %v", err)
+	}
+}
+
+func TestValidateSnapshot_VerifyManifest_MissingAndUnlisted(t *testing.T) {
+	snap := manifestSnapshot(map[string]string{
+		"index.html": "<html>hi</html>",
+		"gone.css":   "x",
+	})
+	mfs := snap.FS.(fstest.MapFS)
+	delete(mfs, "gone.css")
+	mfs["extra.js"] = &fstest.MapFile{Data: []byte("evil()")}
+
+	err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true})
+	if err == nil {
+		t.Fatal("expected manifest error")
+	}
+	for _, want := range []string{"1 missing (gone.css)", "1 unlisted (extra.js)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q should contain %q", err, want)
+		}
+	}
+}
+
+func TestValidateSnapshot_VerifyManifest_RequiresProvenance(t *testing.T) {
+	snap := &Snapshot{FS: fstest.MapFS{"index.html": &fstest.MapFile{Data: []byte("x")}}}
+	err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true})
+	if err == nil || !strings.Contains(err.Error(), "release.json is required") {
+		t.Fatalf("expected missing provenance error, got %v", err)
+	}
+}
+
+func TestValidateSnapshot_VerifyManifest_EmptyFileList(t *testing.T) {
+	snap := manifestSnapshot(map[string]string{"index.html": "<html>hi</html>"})
+	snap.Provenance.Files = nil
+	err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true})
+	if err == nil || !strings.Contains(err.Error(), "lists no files") {
+		t.Fatalf("expected empty file list error, got %v", err)
+	}
+}
+
+func TestValidateSnapshot_VerifyManifest_DuplicateEntry(t *testing.T) {
+	snap := manifestSnapshot(map[string]string{"index.html": "<html>hi</html>"})
+	snap.Provenance.Files = append(snap.Provenance.Files, snap.Provenance.Files[0])
+	err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true})
+	if err == nil || !strings.Contains(err.Error(), "more than once") {
+		t.Fatalf("expected duplicate entry error, got %v", err)
+	}
+}
+
+func TestValidateSnapshot_VerifyManifest_AugmentedFileUsesOriginalDigest(t *testing.T) {
+	page := "<html>" + contentIsland() + "</html>"
+	snap := manifestSnapshot(map[string]string{"index.html": page})
+
+	inlineProvenance(t.Context(), log.Nop(), &stubInliner{contentJSON: []byte(`{"c":1}`)}, snap)
+
+	if _, ok := snap.Augmented["index.html"]; !ok {
+		t.Fatalf("index.html not recorded as augmented: %v", snap.Augmented)
+	}
+	if err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true}); err != nil {
+		t.Fatalf("augmented page should verify against its original digest: %v", err)
+	}
+
+	// a wrong recorded original is still caught
+	snap.Augmented["index.html"] = AugmentedFile{OriginalSHA256: strings.Repeat("0", 64), OriginalSize: int64(len(page))}
+	if err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true}); err == nil {
+		t.Fatal("expected mismatch when the original digest is wrong")
+	}
+}
+
+func TestValidateSnapshot_VerifyManifest_TruncatesLongLists(t *testing.T) {
+	files := map[string]string{"index.html": "<html>hi</html>"}
+	snap := manifestSnapshot(files)
+	mfs := snap.FS.(fstest.MapFS)
+	for _, n := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		mfs[n+".js"] = &fstest.MapFile{Data: []byte(n)}
+	}
+	err := ValidateSnapshot(snap, ValidationOptions{VerifyManifest: true})
+	if err == nil || !strings.Contains(err.Error(), "7 unlisted (a.js, b.js, c.js, d.js, e.js, ...)") {
+		t.Fatalf("expected truncated unlisted list, got %v", err)
+	}
+}
@@ -10,18 +10,26 @@ package content
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
 	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
 )
 
 const (
 	// DefaultPollInterval is how often the watcher checks SSM for a new hash.
 	DefaultPollInterval = 30 * time.Second
 
+	// DefaultNotifyDebounce is how long Notify waits for further change
+	// notifications before polling.
+	DefaultNotifyDebounce = 2 * time.Second
+
 	// maxBackoff caps exponential backoff on consecutive SSM errors.
 	maxBackoff = 5 * time.Minute
 )
@@ -30,21 +38,34 @@ const (
 type pollResult int
 
 const (
-	pollNoChange        pollResult = iota // SSM hash matches current - nothing to do
-	pollSwapped                           // new hash detected, bundle loaded and swapped
-	pollSSMError                          // SSM fetch failed - caller should back off
-	pollLoadError                         // SSM succeeded but download/extract/swap failed
-	pollValidationError                   // bundle loaded but failed health checks
+	pollNoChange          pollResult = iota // SSM hash matches current - nothing to do
+	pollSwapped                             // new hash detected, bundle loaded and swapped
+	pollSSMError                            // SSM fetch failed - caller should back off
+	pollLoadError                           // SSM succeeded but download/extract/swap failed
+	pollValidationError                     // bundle loaded but failed health checks
+	pollQuarantined                         // new hash is quarantined and not yet due for retry
+	pollPaused                              // watcher paused by an operator, no swap attempted
+	pollPinned                              // pointer unchanged since an operator rollback
+	pollPointerRejected                     // signed pointer failed verification, expired, or is a downgrade
+	pollDowngradeRejected                   // bundle older than active content under the version policy
+	pollStaged                              // bundle verified and held until its activate_at
+	pollSelfCheckFailed                     // swapped bundle failed the post-swap self-check and was rolled back
 )
 
 // BundleFetcher is the interface the Watcher needs from a Loader. Extracted to
 // decouple the Watcher from the concrete *Loader type, enabling simpler test
-// doubles and future alternative implementations (e.g. OCI-based fetching).
+// doubles and alternative sources (DiskFetcher, TUFFetcher, OCIFetcher).
 type BundleFetcher interface {
 	FetchCurrentBundleHash(ctx context.Context) (algorithm string, hash string, err error)
 	LoadHash(ctx context.Context, algorithm, hash string) (*Snapshot, error)
 }
 
+// FormatLoader is implemented by fetchers that can load bundles in more than
+// one archive format. The watcher uses it when a signed pointer names one.
+type FormatLoader interface {
+	LoadHashFormat(ctx context.Context, algorithm, hash string, format BundleFormat) (*Snapshot, error)
+}
+
 // WatcherMetrics is implemented by the metrics package to observe watcher behavior.
 type WatcherMetrics interface {
 	IncWatcherPolls()
@@ -53,6 +74,11 @@ type WatcherMetrics interface {
 	ObserveBundleLoadDuration(seconds float64)
 	SetWatcherLastSuccess(unixSeconds float64)
 	SetWatcherStale(stale bool)
+	SetWatcherQuarantined(count int)
+	IncWatcherQuarantineSkips()
+	SetWatcherStaged(hash string, activateAtUnix float64)
+	IncWatcherSelfCheck(result string)
+	IncWatcherNotification(source string)
 }
 
 // WatcherOptions configures the content bundle watcher.
@@ -72,12 +98,37 @@ type WatcherOptions struct {
 	// Called synchronously on the poll goroutine.
 	OnSwap func(hash, version string)
 
+	// OnPointerRefresh is called when a newer signed pointer names the
+	// content already active, e.g. so the bundle cache keeps a pointer that
+	// hasn't expired. Called synchronously on the poll goroutine.
+	OnPointerRefresh func(hash string, p *Pointer)
+
 	// Metrics receives watcher observability signals (polls, swaps, errors, durations).
 	Metrics WatcherMetrics
 
 	// StaleThreshold is how long since the last successful SSM poll before
 	// the watcher logs a staleness warning. Zero defaults to 30 minutes.
 	StaleThreshold time.Duration
+
+	// Quarantine records bundles that failed to load or validate so they are
+	// retried with exponential backoff rather than on every poll. Nil creates
+	// a private set with default backoff; pass a shared one to expose it on
+	// the ops server.
+	Quarantine *Quarantine
+
+	// VersionPolicy, when set, rejects bundles whose release.json is older
+	// than the active snapshot's. Reverts pass with a signed rollback pointer
+	// or an operator AllowDowngrade.
+	VersionPolicy VersionPolicy
+
+	// SelfCheck, when set, probes the live handler after every swap; a
+	// failure rolls the manager back and quarantines the bundle.
+	SelfCheck *SelfCheck
+
+	// NotifyDebounce is how long a change notification waits for more before
+	// the watcher polls, so a burst of publish events costs one SSM read.
+	// Zero uses DefaultNotifyDebounce.
+	NotifyDebounce time.Duration
 }
 
 // Watcher polls for content changes and hot-swaps bundles into the manager.
@@ -88,11 +139,47 @@ type Watcher struct {
 	interval   time.Duration
 	validation ValidationOptions
 	onSwap     func(hash, version string)
+	onPointer  func(hash string, p *Pointer)
 	metrics    WatcherMetrics
+	quarantine *Quarantine
+	policy     VersionPolicy
+	selfCheck  *SelfCheck
 
 	// hash tracking for change detection
 	currentHash string
 
+	// pointerFloor is the created_at of the newest signed pointer whose
+	// content went live; older pointers are refused as downgrades
+	pointerFloor time.Time
+
+	// operator controls, set from other goroutines (admin API) and read by
+	// the poll loop. gen increments on every control change so a load that
+	// was in flight when an operator acted is discarded rather than swapped.
+	ctlMu  sync.Mutex
+	paused bool
+	pinned string // upstream algo:hash at rollback time; "" when not pinned
+	gen    uint64
+
+	// allowDowngrade is the algo:hash an operator has cleared to bypass the
+	// version policy once
+	allowDowngrade string
+
+	// staged is a verified bundle waiting for its activate_at
+	staged *stagedBundle
+
+	// last pointer value seen from the fetcher, as algo:hash
+	lastUpstream string
+
+	// reload wakes Run for an immediate poll; buffered so requests made
+	// while a poll is running coalesce into one
+	reload chan struct{}
+
+	// notify wakes Run to start the debounce timer; notified counts the
+	// notifications coalesced into the next poll
+	notify   chan struct{}
+	notified atomic.Int64
+	debounce time.Duration
+
 	// backoff state
 	consecutiveErrs int
 
@@ -103,7 +190,8 @@ type Watcher struct {
 
 	// stats for logging and future metrics
 	pollCount int64
-	swapCount int64
+	// swapCount is atomic: Promote bumps it from the admin goroutine
+	swapCount atomic.Int64
 }
 
 // NewWatcher creates a content watcher. Call Run to start the poll loop.
@@ -119,8 +207,12 @@ func NewWatcher(opts *WatcherOptions) *Watcher {
 	// seed current hash from manager so first poll doesn't re-download
 	// what was already loaded at startup
 	currentHash := ""
+	var pointerFloor time.Time
 	if snap, ok := opts.Manager.Get(); ok {
 		currentHash = snap.Meta.Hash
+		if snap.Meta.Pointer != nil {
+			pointerFloor = snap.Meta.Pointer.CreatedAt
+		}
 	}
 
 	validation := DefaultValidationOptions()
@@ -133,6 +225,16 @@ func NewWatcher(opts *WatcherOptions) *Watcher {
 		staleThreshold = 30 * time.Minute
 	}
 
+	quarantine := opts.Quarantine
+	if quarantine == nil {
+		quarantine = NewQuarantine(QuarantineOptions{})
+	}
+
+	debounce := opts.NotifyDebounce
+	if debounce <= 0 {
+		debounce = DefaultNotifyDebounce
+	}
+
 	return &Watcher{
 		loader:         opts.Loader,
 		manager:        opts.Manager,
@@ -140,10 +242,18 @@ func NewWatcher(opts *WatcherOptions) *Watcher {
 		interval:       interval,
 		validation:     validation,
 		onSwap:         opts.OnSwap,
+		onPointer:      opts.OnPointerRefresh,
 		metrics:        opts.Metrics,
+		quarantine:     quarantine,
+		policy:         opts.VersionPolicy,
+		selfCheck:      opts.SelfCheck,
 		currentHash:    currentHash,
+		pointerFloor:   pointerFloor,
 		staleThreshold: staleThreshold,
 		lastSuccessAt:  time.Now(),
+		reload:         make(chan struct{}, 1),
+		notify:         make(chan struct{}, 1),
+		debounce:       debounce,
 	}
 }
 
@@ -158,60 +268,101 @@ func (w *Watcher) Run(ctx context.Context) error {
 	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
 
+	// armed while notifications wait out the debounce; nil otherwise
+	var debounce *time.Timer
+	var debounceC <-chan time.Time
+	defer func() {
+		if debounce != nil {
+			debounce.Stop()
+		}
+	}()
+
 	for {
 		select {
+		case <-w.activationC():
+			w.activateDue(ctx)
+			continue
+		case <-w.notify:
+			if debounceC == nil {
+				debounce = time.NewTimer(w.debounce)
+				debounceC = debounce.C
+			}
+			continue
+		case <-debounceC:
+			w.logger.Info(ctx, "content watcher: change notified, polling now",
+				"notifications", w.notified.Load(),
+			)
 		case <-ctx.Done():
 			w.logger.Info(ctx, "content watcher stopping",
 				"reason", ctx.Err(),
 				"polls", w.pollCount,
-				"swaps", w.swapCount,
+				"swaps", w.swapCount.Load(),
 			)
 			return ctx.Err()
 		case <-ticker.C:
-			result := w.checkOnce(ctx)
-
-			if result == pollSSMError {
-				w.consecutiveErrs++
-				backoff := w.backoffDuration()
-				w.logger.Warn(ctx, "content watcher: backing off",
-					"consecutive_errors", w.consecutiveErrs,
-					"next_poll_in", backoff.String(),
-				)
-				ticker.Reset(backoff)
-			} else if w.consecutiveErrs > 0 {
-				// recovered from error streak - resume normal cadence
-				w.logger.Info(ctx, "content watcher: recovered, resuming normal interval",
-					"had_consecutive_errors", w.consecutiveErrs,
-				)
-				w.consecutiveErrs = 0
-				ticker.Reset(w.interval)
-			}
+		case <-w.reload:
+			w.logger.Info(ctx, "content watcher: reload requested, polling now")
+		}
+
+		// any poll answers the notifications received so far
+		if debounce != nil {
+			debounce.Stop()
+			debounce, debounceC = nil, nil
+		}
+		w.notified.Store(0)
+
+		result := w.checkOnce(ctx)
+
+		if result == pollSSMError {
+			w.consecutiveErrs++
+			backoff := w.backoffDuration()
+			w.logger.Warn(ctx, "content watcher: backing off",
+				"consecutive_errors", w.consecutiveErrs,
+				"next_poll_in", backoff.String(),
+			)
+			ticker.Reset(backoff)
+		} else if w.consecutiveErrs > 0 {
+			// recovered from error streak - resume normal cadence
+			w.logger.Info(ctx, "content watcher: recovered, resuming normal interval",
+				"had_consecutive_errors", w.consecutiveErrs,
+			)
+			w.consecutiveErrs = 0
+			ticker.Reset(w.interval)
+		}
 
-			// staleness detection: emit structured error once on transition into stale state
-			if result != pollSSMError {
-				// non-SSM-error means lastSuccessAt was updated
-				if w.staleLogged {
-					w.logger.Info(ctx, "content watcher: staleness recovered")
-					w.staleLogged = false
-					if w.metrics != nil {
-						w.metrics.SetWatcherStale(false)
-					}
+		// staleness detection: emit structured error once on transition into stale state
+		if result != pollSSMError && result != pollPointerRejected {
+			// lastSuccessAt was updated; a rejected pointer doesn't count
+			if w.staleLogged {
+				w.logger.Info(ctx, "content watcher: staleness recovered")
+				w.staleLogged = false
+				if w.metrics != nil {
+					w.metrics.SetWatcherStale(false)
 				}
-			} else if time.Since(w.lastSuccessAt) > w.staleThreshold {
-				if !w.staleLogged {
-					w.logger.Error(ctx, fmt.Errorf("last successful SSM poll was %s ago", time.Since(w.lastSuccessAt).Truncate(time.Second)),
-						"content watcher: content is stale, unable to verify freshness",
-					)
-					w.staleLogged = true
-					if w.metrics != nil {
-						w.metrics.SetWatcherStale(true)
-					}
+			}
+		} else if time.Since(w.lastSuccessAt) > w.staleThreshold {
+			if !w.staleLogged {
+				w.logger.Error(ctx, fmt.Errorf("last successful SSM poll was %s ago", time.Since(w.lastSuccessAt).Truncate(time.Second)),
+					"content watcher: content is stale, unable to verify freshness",
+				)
+				w.staleLogged = true
+				if w.metrics != nil {
+					w.metrics.SetWatcherStale(true)
 				}
 			}
 		}
 	}
 }
 
+// loadBundle downloads a bundle in the archive format its signed pointer
+// names, when the fetcher supports formats.
+func (w *Watcher) loadBundle(ctx context.Context, algorithm, hash string, p *Pointer) (*Snapshot, error) {
+	if fl, ok := w.loader.(FormatLoader); ok && p != nil && p.Format != "" {
+		return fl.LoadHashFormat(ctx, algorithm, hash, p.Format)
+	}
+	return w.loader.LoadHash(ctx, algorithm, hash)
+}
+
 // checkOnce performs a single poll-compare-swap cycle.
 // Returns what happened so Run can adjust timing.
 func (w *Watcher) checkOnce(ctx context.Context) pollResult {
@@ -221,7 +372,10 @@ func (w *Watcher) checkOnce(ctx context.Context) pollResult {
 	}
 
 	// poll SSM for the current bundle hash
-	algorithm, hash, err := w.loader.FetchCurrentBundleHash(ctx)
+	algorithm, hash, pointer, err := w.fetchPointer(ctx)
+	if errors.Is(err, ErrPointerRejected) {
+		return w.rejectPointer(ctx, err)
+	}
 	if err != nil {
 		w.logger.Error(ctx, err, "content watcher: SSM poll failed")
 		if w.metrics != nil {
@@ -230,27 +384,112 @@ func (w *Watcher) checkOnce(ctx context.Context) pollResult {
 		return pollSSMError
 	}
 
-	// SSM call succeeded - update last success time
+	// once signed pointers are in use, a bare hash would bypass the
+	// created_at floor; anyone able to write the parameter could restore an
+	// old release with it
+	if pointer == nil && w.signedPointerSeen() {
+		return w.rejectPointer(ctx, xerrors.Wrap(ErrPointerRejected, "bare hash after a signed pointer was accepted"))
+	}
+
+	// an expired pointer means the publisher stopped refreshing it (or it is
+	// being withheld); keep serving but don't count the poll as fresh
 	now := time.Now()
+	if pointer != nil {
+		if err := pointer.Check(now, w.pointerFloor); err != nil {
+			return w.rejectPointer(ctx, err)
+		}
+		if err := checkActivation(pointer, pointer.ActivateAt); err != nil {
+			return w.rejectPointer(ctx, err)
+		}
+	}
+
+	// SSM call succeeded - update last success time
 	w.lastSuccessAt = now
 	if w.metrics != nil {
 		w.metrics.SetWatcherLastSuccess(float64(now.Unix()))
 	}
 
+	key := QuarantineKey(algorithm, hash)
+
+	// operator controls: a pause holds everything, a rollback pin holds until
+	// the upstream pointer moves to something new
+	w.ctlMu.Lock()
+	w.lastUpstream = key
+	paused, pinned, gen := w.paused, w.pinned, w.gen
+	if pinned != "" && pinned != key {
+		w.pinned = ""
+	}
+	current := w.currentHash
+	w.ctlMu.Unlock()
+
+	if paused {
+		return pollPaused
+	}
+	if pinned != "" {
+		if pinned == key {
+			return pollPinned
+		}
+		w.logger.Info(ctx, "content watcher: upstream pointer moved, releasing rollback pin",
+			"pinned", pinned,
+			"new_hash", truncHash(hash),
+		)
+	}
+
+	if w.metrics != nil {
+		w.metrics.SetWatcherQuarantined(w.quarantine.Len())
+	}
+
 	// no change - most common path
-	if cryptoutil.HashEqual(hash, w.currentHash) {
+	if cryptoutil.HashEqual(hash, current) {
+		w.dropStaged(ctx, "pointer returned to active content")
+		if pointer != nil {
+			w.pointerRefreshed(hash, pointer)
+		}
 		return pollNoChange
 	}
 
+	// a staged bundle is already verified; wait for it or activate it
+	// without downloading it again
+	if st := w.stagedState(); st != nil {
+		if st.Key == key {
+			// a re-signed pointer may move the activation time
+			at := activationTime(pointer, st.snap)
+			if err := checkActivation(pointer, at); err != nil {
+				w.dropStaged(ctx, "activation is past pointer expiry")
+				return w.rejectPointer(ctx, err)
+			}
+			if !at.Equal(st.ActivateAt) {
+				w.stage(ctx, st.snap, st.hash, key, pointer, at)
+			}
+			return w.activateDue(ctx)
+		}
+		w.dropStaged(ctx, "superseded by a new pointer")
+	}
+
+	// a bundle that already failed waits out its backoff instead of being
+	// re-downloaded and re-verified every poll
+	if entry, blocked := w.quarantine.Blocked(key); blocked {
+		w.logger.Debug(ctx, "content watcher: bundle quarantined, skipping",
+			"hash", truncHash(hash),
+			"reason", entry.Reason,
+			"attempts", entry.Attempts,
+			"next_retry", entry.NextRetry,
+		)
+		if w.metrics != nil {
+			w.metrics.IncWatcherQuarantineSkips()
+		}
+		return pollQuarantined
+	}
+
 	// new hash detected
 	w.logger.Info(ctx, "content watcher: new bundle hash detected",
-		"old_hash", truncHash(w.currentHash),
+		"old_hash", truncHash(current),
 		"new_hash", truncHash(hash),
 	)
 
 	// download, verify, extract to memory
 	loadStart := time.Now()
-	snap, err := w.loader.LoadHash(ctx, algorithm, hash)
+	snap, err := w.loadBundle(ctx, algorithm, hash, pointer)
 	loadDur := time.Since(loadStart).Seconds()
 	if w.metrics != nil {
 		w.metrics.ObserveBundleLoadDuration(loadDur)
@@ -263,6 +502,7 @@ func (w *Watcher) checkOnce(ctx context.Context) pollResult {
 		if w.metrics != nil {
 			w.metrics.IncWatcherError("load")
 		}
+		w.quarantineBundle(ctx, key, QuarantineReasonLoad, err)
 		return pollLoadError
 	}
 
@@ -270,51 +510,434 @@ func (w *Watcher) checkOnce(ctx context.Context) pollResult {
 	if err := ValidateSnapshot(snap, w.validation); err != nil {
 		w.logger.Error(ctx, err, "content watcher: new bundle failed validation, keeping current content",
 			"rejected_hash", truncHash(hash),
-			"current_hash", truncHash(w.currentHash),
+			"current_hash", truncHash(current),
 		)
 		if w.metrics != nil {
 			w.metrics.IncWatcherError("validation")
 		}
+		w.quarantineBundle(ctx, key, QuarantineReasonValidation, err)
 		// no disk cleanup needed - MemFS is garbage collected
 		return pollValidationError
 	}
 
+	// refuse regressions unless the revert was explicitly authorized
+	if err := w.checkVersion(snap, key, pointer); err != nil {
+		w.logger.Error(ctx, err, "content watcher: new bundle is a downgrade, keeping current content",
+			"rejected_hash", truncHash(hash),
+			"current_hash", truncHash(current),
+			"policy", string(w.policy),
+		)
+		if w.metrics != nil {
+			w.metrics.IncWatcherError("downgrade")
+		}
+		w.quarantineBundle(ctx, key, QuarantineReasonDowngrade, err)
+		return pollDowngradeRejected
+	}
+
+	// embargoed releases are held, verified, until their activation time so
+	// every instance swaps at the same moment
+	if at := activationTime(pointer, snap); at.After(time.Now()) {
+		// release.json may schedule past what the pointer vouches for
+		if err := checkActivation(pointer, at); err != nil {
+			return w.rejectPointer(ctx, err)
+		}
+		w.stage(ctx, snap, hash, key, pointer, at)
+		return pollStaged
+	}
+
+	return w.swap(ctx, snap, hash, key, pointer, gen)
+}
+
+// swap publishes a verified bundle unless an operator acted since gen was
+// read, then notifies OnSwap.
+func (w *Watcher) swap(ctx context.Context, snap *Snapshot, hash, key string, pointer *Pointer, gen uint64) pollResult {
+	// precompression and digests take seconds on a small instance; doing
+	// them under ctlMu would stall every admin call for as long
+	w.manager.Prepare(snap)
+
+	// an operator paused or rolled back while this bundle was loading; their
+	// decision wins over the in-flight swap
+	w.ctlMu.Lock()
+	if w.gen != gen {
+		w.ctlMu.Unlock()
+		w.logger.Info(ctx, "content watcher: operator action during load, discarding bundle",
+			"hash", truncHash(hash),
+		)
+		return pollNoChange
+	}
+
 	// atomic swap into manager - old MemFS becomes garbage
 	oldHash := w.currentHash
+	if pointer != nil {
+		snap.Meta.Pointer = pointer
+	}
 	w.manager.Set(*snap)
-	w.swapCount++
+	w.currentHash = hash
+	if w.allowDowngrade == key {
+		w.allowDowngrade = ""
+	}
+	w.ctlMu.Unlock()
 
-	version := w.manager.ContentVersion()
+	// exercise the real handler chain before announcing the swap
+	if err := w.runSelfCheck(ctx); err != nil {
+		return w.revertSwap(ctx, hash, key, err)
+	}
 
+	// only content that actually went live moves the floor, so a bad publish
+	// can be fixed by republishing the previous pointer
+	if pointer != nil && pointer.CreatedAt.After(w.pointerFloor) {
+		w.pointerFloor = pointer.CreatedAt
+	}
+
+	version := w.manager.ContentVersion()
 	w.logger.Info(ctx, "content watcher: bundle swapped",
 		"old_hash", truncHash(oldHash),
 		"new_hash", truncHash(hash),
 		"version", version,
-		"total_swaps", w.swapCount,
+		"total_swaps", w.swapCount.Load()+1,
 	)
+	w.published(ctx, hash, key, version)
+	return pollSwapped
+}
 
-	w.currentHash = hash
+// published finishes a swap that passed its self-check: counts it, lifts
+// any quarantine on the bundle and notifies OnSwap.
+func (w *Watcher) published(ctx context.Context, hash, key, version string) {
+	w.swapCount.Add(1)
+
+	// a retried bundle that now loads is no longer quarantined
+	if w.quarantine.Clear(key) {
+		w.logger.Info(ctx, "content watcher: quarantined bundle loaded on retry", "hash", truncHash(hash))
+	}
 
 	if w.metrics != nil {
 		w.metrics.IncWatcherSwaps()
+		w.metrics.SetWatcherQuarantined(w.quarantine.Len())
 	}
 
 	// notify caller (metrics, etc.)
-	if w.onSwap != nil {
-		func() {
-			defer func() {
-				if r := recover(); r != nil {
-					w.logger.Error(ctx, fmt.Errorf("OnSwap panic: %v", r),
-						"content watcher: OnSwap callback panicked, continuing",
-						"hash", truncHash(hash),
-					)
-				}
-			}()
-			w.onSwap(hash, version)
-		}()
+	w.notifySwap(ctx, hash, version)
+}
+
+// runSelfCheck probes the newly active snapshot, if a self-check is configured.
+func (w *Watcher) runSelfCheck(ctx context.Context) error {
+	if w.selfCheck == nil {
+		return nil
 	}
+	err := w.selfCheck.Run(ctx)
+	if w.metrics != nil {
+		result := "pass"
+		if err != nil {
+			result = "fail"
+		}
+		w.metrics.IncWatcherSelfCheck(result)
+	}
+	return err
+}
 
-	return pollSwapped
+// revertSwap undoes a swap whose self-check failed: the manager rolls back to
+// the previous snapshot and the bundle is quarantined so the next poll doesn't
+// swap it straight back in.
+func (w *Watcher) revertSwap(ctx context.Context, hash, key string, cause error) pollResult {
+	w.ctlMu.Lock()
+	rolledBack := false
+	// an operator may have acted while the check ran; only undo our own swap
+	if cur, ok := w.manager.Get(); ok && cur.Meta.Hash == hash {
+		// drop it rather than Rollback so a failed bundle can't be
+		// reactivated from history
+		rolledBack = w.manager.Discard(hash)
+	}
+	restored := ""
+	if cur, ok := w.manager.Get(); ok {
+		restored = cur.Meta.Hash
+	}
+	w.currentHash = restored
+	w.ctlMu.Unlock()
+
+	attrs := []any{
+		"event", "content.selfcheck_failed",
+		"hash", truncHash(hash),
+		"restored_hash", truncHash(restored),
+		"rolled_back", rolledBack,
+	}
+	var scErr *SelfCheckError
+	if errors.As(cause, &scErr) {
+		attrs = append(attrs, "path", scErr.Path, "reason", scErr.Reason)
+	}
+	if rolledBack {
+		w.logger.Error(ctx, cause, "content watcher: post-swap self-check failed, rolled back", attrs...)
+	} else {
+		w.logger.Error(ctx, cause, "content watcher: post-swap self-check failed and no earlier snapshot is retained, serving it anyway", attrs...)
+		if restored == hash {
+			w.notifySwap(ctx, hash, w.manager.ContentVersion())
+		}
+	}
+	if w.metrics != nil {
+		w.metrics.IncWatcherError("selfcheck")
+	}
+	w.quarantineBundle(ctx, key, QuarantineReasonSelfCheck, cause)
+	return pollSelfCheckFailed
+}
+
+// notifySwap calls OnSwap, containing any panic so the watcher keeps running.
+func (w *Watcher) notifySwap(ctx context.Context, hash, version string) {
+	if w.onSwap == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			w.logger.Error(ctx, fmt.Errorf("OnSwap panic: %v", r),
+				"content watcher: OnSwap callback panicked, continuing",
+				"hash", truncHash(hash),
+			)
+		}
+	}()
+	w.onSwap(hash, version)
+}
+
+// checkVersion applies the version policy to a loaded bundle. A pointer
+// signed as a rollback, or an operator override for this key, bypasses it.
+func (w *Watcher) checkVersion(snap *Snapshot, key string, pointer *Pointer) error {
+	if w.policy == VersionPolicyOff || (pointer != nil && pointer.Rollback) {
+		return nil
+	}
+	w.ctlMu.Lock()
+	allowed := w.allowDowngrade == key
+	w.ctlMu.Unlock()
+	if allowed {
+		return nil
+	}
+	active, ok := w.manager.Get()
+	if !ok {
+		return nil
+	}
+	return CheckMonotonic(w.policy, snap, active)
+}
+
+// fetchPointer reads the current pointer, through the signed document when
+// the fetcher supports one.
+func (w *Watcher) fetchPointer(ctx context.Context) (algorithm, hash string, p *Pointer, err error) {
+	if pf, ok := w.loader.(PointerFetcher); ok {
+		return pf.FetchCurrentPointer(ctx)
+	}
+	algorithm, hash, err = w.loader.FetchCurrentBundleHash(ctx)
+	return algorithm, hash, nil, err
+}
+
+// pointerRefreshed handles a re-signed pointer to the active content. The
+// content it names is live, so it moves the floor just as a swap would, and
+// each newer pointer is reported once through OnPointerRefresh.
+func (w *Watcher) pointerRefreshed(hash string, p *Pointer) {
+	if !p.CreatedAt.After(w.pointerFloor) {
+		return
+	}
+	w.pointerFloor = p.CreatedAt
+	if w.onPointer != nil {
+		w.onPointer(hash, p)
+	}
+}
+
+// signedPointerSeen reports whether the watcher has acted on a signed
+// pointer, or the active content was loaded through one.
+func (w *Watcher) signedPointerSeen() bool {
+	if !w.pointerFloor.IsZero() {
+		return true
+	}
+	snap, ok := w.manager.Get()
+	return ok && snap.Meta.Pointer != nil
+}
+
+// rejectPointer logs and counts a pointer the watcher refuses to act on.
+func (w *Watcher) rejectPointer(ctx context.Context, err error) pollResult {
+	w.ctlMu.Lock()
+	current := w.currentHash
+	w.ctlMu.Unlock()
+	w.logger.Error(ctx, err, "content watcher: content pointer rejected, keeping current content",
+		"current_hash", truncHash(current),
+	)
+	if w.metrics != nil {
+		w.metrics.IncWatcherError("pointer")
+	}
+	return pollPointerRejected
+}
+
+// quarantineBundle records a failed load or validation and logs the retry schedule.
+func (w *Watcher) quarantineBundle(ctx context.Context, key, reason string, err error) {
+	entry := w.quarantine.Record(key, reason, err)
+	w.logger.Warn(ctx, "content watcher: bundle quarantined",
+		"key", key,
+		"reason", reason,
+		"attempts", entry.Attempts,
+		"next_retry", entry.NextRetry,
+	)
+	if w.metrics != nil {
+		w.metrics.SetWatcherQuarantined(w.quarantine.Len())
+	}
+}
+
+// RequestReload asks Run to poll immediately instead of waiting for the next
+// tick. Returns false if a reload is already pending.
+func (w *Watcher) RequestReload() bool {
+	select {
+	case w.reload <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Notify reports that content may have changed upstream, for example a
+// publish event from a queue or a signal. The watcher polls one debounce
+// interval after the first notification, answering every notification that
+// arrives in between with that single poll. Interval polling continues
+// regardless, so a lost notification only delays an update.
+func (w *Watcher) Notify(source string) {
+	w.notified.Add(1)
+	if w.metrics != nil {
+		w.metrics.IncWatcherNotification(source)
+	}
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// AllowDowngrade lets the bundle named by key (algo:hash) be swapped in once
+// despite the version policy, clears any quarantine entry for it and polls
+// immediately. The override is consumed by the swap.
+func (w *Watcher) AllowDowngrade(key string) {
+	w.ctlMu.Lock()
+	w.allowDowngrade = key
+	w.ctlMu.Unlock()
+	w.quarantine.Clear(key)
+	w.RequestReload()
+}
+
+// DowngradeAllowed returns the pending AllowDowngrade key, or "".
+func (w *Watcher) DowngradeAllowed() string {
+	w.ctlMu.Lock()
+	defer w.ctlMu.Unlock()
+	return w.allowDowngrade
+}
+
+// Pause stops the watcher from swapping in new bundles until Resume. The
+// pointer is still polled so freshness metrics stay accurate.
+func (w *Watcher) Pause() {
+	w.ctlMu.Lock()
+	defer w.ctlMu.Unlock()
+	w.paused = true
+	w.gen++
+}
+
+// Resume undoes Pause. A rollback pin, if any, stays in effect.
+func (w *Watcher) Resume() {
+	w.ctlMu.Lock()
+	defer w.ctlMu.Unlock()
+	w.paused = false
+	w.gen++
+}
+
+// Paused reports whether the watcher is paused.
+func (w *Watcher) Paused() bool {
+	w.ctlMu.Lock()
+	defer w.ctlMu.Unlock()
+	return w.paused
+}
+
+// Pinned returns the upstream algo:hash the watcher is holding back from
+// after a rollback, or "" when not pinned.
+func (w *Watcher) Pinned() string {
+	w.ctlMu.Lock()
+	defer w.ctlMu.Unlock()
+	return w.pinned
+}
+
+// RollbackTo reactivates a retained snapshot and pins the watcher to the
+// current upstream pointer, so the rolled-back-from bundle isn't swapped
+// straight back in. The pin releases itself once the pointer changes.
+func (w *Watcher) RollbackTo(ctx context.Context, hash string) (*Snapshot, error) {
+	w.ctlMu.Lock()
+	defer w.ctlMu.Unlock()
+
+	var fromAlgo string
+	if cur, ok := w.manager.Get(); ok {
+		fromAlgo = cur.Meta.HashAlgorithm
+	}
+
+	snap, err := w.manager.RollbackTo(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	from := w.currentHash
+	w.currentHash = snap.Meta.Hash
+	w.pinned = w.lastUpstream
+	if w.pinned == "" && from != "" {
+		// no poll yet; the pointer is assumed to still name the bundle we left
+		w.pinned = QuarantineKey(fromAlgo, from)
+	}
+	w.gen++
+
+	w.logger.Warn(ctx, "content watcher: rolled back content",
+		"from_hash", truncHash(from),
+		"to_hash", truncHash(snap.Meta.Hash),
+		"pinned_upstream", w.pinned,
+	)
+	return snap, nil
+}
+
+// Promote activates an already verified snapshot, typically a preview,
+// without downloading it again. Like RollbackTo it pins the current upstream
+// pointer so the next poll doesn't swap straight back; publishing the
+// promoted hash to the pointer releases the pin with no further swap.
+//
+// The snapshot goes through the same self-check as a polled swap; one that
+// fails is reverted and quarantined, the earlier pin is restored, and the
+// error is returned.
+func (w *Watcher) Promote(ctx context.Context, snap *Snapshot) error {
+	hash := snap.Meta.Hash
+	key := QuarantineKey(snap.Meta.HashAlgorithm, hash)
+	w.manager.Prepare(snap)
+
+	w.ctlMu.Lock()
+	from := w.currentHash
+	prevPinned := w.pinned
+	w.manager.Set(*snap)
+	w.currentHash = hash
+	w.pinned = ""
+	if w.lastUpstream != "" && w.lastUpstream != key {
+		w.pinned = w.lastUpstream
+	}
+	w.gen++
+	pinned := w.pinned
+	w.ctlMu.Unlock()
+
+	if err := w.runSelfCheck(ctx); err != nil {
+		w.ctlMu.Lock()
+		// unless an operator re-pinned while the check ran
+		if w.pinned == pinned {
+			w.pinned = prevPinned
+		}
+		w.ctlMu.Unlock()
+		w.revertSwap(ctx, hash, key, err)
+		return xerrors.Wrapf(err, "promoted snapshot %s failed self-check", truncHash(hash))
+	}
+
+	version := w.manager.ContentVersion()
+	w.logger.Warn(ctx, "content watcher: promoted verified snapshot",
+		"from_hash", truncHash(from),
+		"to_hash", truncHash(hash),
+		"version", version,
+		"pinned_upstream", pinned,
+		"total_swaps", w.swapCount.Load()+1,
+	)
+	w.published(ctx, hash, key, version)
+	return nil
+}
+
+// Quarantine returns the watcher's quarantine set.
+func (w *Watcher) Quarantine() *Quarantine {
+	return w.quarantine
 }
 
 // backoffDuration computes exponential backoff capped at maxBackoff.
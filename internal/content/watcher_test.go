@@ -3,6 +3,7 @@ package content
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -49,6 +50,12 @@ type fakeWatcherMetrics struct {
 	loadDurations []float64
 	lastSuccessTs float64
 	stale         bool
+	quarantined   int
+	qSkips        int
+	stagedHash    string
+	stagedAt      float64
+	selfChecks    map[string]int
+	notifications map[string]int
 }
 
 func newFakeWatcherMetrics() *fakeWatcherMetrics {
@@ -85,6 +92,41 @@ func (f *fakeWatcherMetrics) SetWatcherStale(stale bool) {
 	f.stale = stale
 	f.mu.Unlock()
 }
+func (f *fakeWatcherMetrics) SetWatcherQuarantined(count int) {
+	f.mu.Lock()
+	f.quarantined = count
+	f.mu.Unlock()
+}
+func (f *fakeWatcherMetrics) IncWatcherQuarantineSkips() {
+	f.mu.Lock()
+	f.qSkips++
+	f.mu.Unlock()
+}
+
+func (f *fakeWatcherMetrics) SetWatcherStaged(hash string, activateAtUnix float64) {
+	f.mu.Lock()
+	f.stagedHash = hash
+	f.stagedAt = activateAtUnix
+	f.mu.Unlock()
+}
+
+func (f *fakeWatcherMetrics) IncWatcherSelfCheck(result string) {
+	f.mu.Lock()
+	if f.selfChecks == nil {
+		f.selfChecks = make(map[string]int)
+	}
+	f.selfChecks[result]++
+	f.mu.Unlock()
+}
+
+func (f *fakeWatcherMetrics) IncWatcherNotification(source string) {
+	f.mu.Lock()
+	if f.notifications == nil {
+		f.notifications = make(map[string]int)
+	}
+	f.notifications[source]++
+	f.mu.Unlock()
+}
 
 // Thread-safe reader methods for TestRun_* tests.
 func (f *fakeWatcherMetrics) getPolls() int {
@@ -425,8 +467,8 @@ func TestCheckOnce_Swap(t *testing.T) {
 	if w.currentHash != hashB {
 		t.Fatalf("currentHash = %q, want %q", w.currentHash, hashB)
 	}
-	if w.swapCount != 1 {
-		t.Fatalf("swapCount = %d, want 1", w.swapCount)
+	if w.swapCount.Load() != 1 {
+		t.Fatalf("swapCount = %d, want 1", w.swapCount.Load())
 	}
 }
 
@@ -484,8 +526,8 @@ func TestCheckOnce_PollCount_Increments(t *testing.T) {
 	if w.pollCount != 5 {
 		t.Fatalf("pollCount = %d, want 5", w.pollCount)
 	}
-	if w.swapCount != 0 {
-		t.Fatalf("swapCount = %d, want 0 (no changes)", w.swapCount)
+	if w.swapCount.Load() != 0 {
+		t.Fatalf("swapCount = %d, want 0 (no changes)", w.swapCount.Load())
 	}
 }
 
@@ -519,8 +561,8 @@ func TestCheckOnce_MultipleSwaps(t *testing.T) {
 		t.Fatalf("second swap: result = %d, want pollSwapped", result)
 	}
 
-	if w.swapCount != 2 {
-		t.Fatalf("swapCount = %d, want 2", w.swapCount)
+	if w.swapCount.Load() != 2 {
+		t.Fatalf("swapCount = %d, want 2", w.swapCount.Load())
 	}
 	if w.currentHash != hashC {
 		t.Fatalf("currentHash = %q, want %q", w.currentHash, hashC)
@@ -789,6 +831,57 @@ func TestRun_DetectsChange(t *testing.T) {
 	}
 }
 
+func TestRun_RequestReload_PollsImmediately(t *testing.T) {
+	bundleDataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, bundleDataA)
+
+	hashB := storeBundle(t, f, map[string]string{
+		"index.html": "<html>reloaded</html>",
+	})
+
+	var swapped atomic.Int32
+	w := f.newWatcher(func(o *WatcherOptions) {
+		// long enough that only a reload can trigger the poll
+		o.PollInterval = time.Hour
+		o.OnSwap = func(hash, version string) { swapped.Add(1) }
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go w.Run(ctx)
+
+	f.ssm.setValue(ssmValue(hashB))
+	if !w.RequestReload() {
+		t.Fatal("first RequestReload should be queued")
+	}
+
+	deadline := time.After(2 * time.Second)
+	for swapped.Load() == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("reload did not trigger a swap")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	if got := f.mgr.ContentHash(); got != hashB {
+		t.Fatalf("hash = %q, want %q", got, hashB)
+	}
+}
+
+func TestRequestReload_Coalesces(t *testing.T) {
+	_, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	w := f.newWatcher()
+
+	if !w.RequestReload() {
+		t.Fatal("first request should be queued")
+	}
+	if w.RequestReload() {
+		t.Fatal("second request should coalesce with the pending one")
+	}
+}
+
 func TestRun_BacksOffOnSSMError_ThenRecovers(t *testing.T) {
 	bundleDataA, hashA := buildContentBundle(t)
 	f := newWatcherFixture(t, ssmValue(hashA))
@@ -939,3 +1032,252 @@ func TestCheckOnce_Metrics_ValidationError(t *testing.T) {
 		t.Fatalf("validation errors = %d, want 1", fm.errors["validation"])
 	}
 }
+
+// checkOnce - quarantine
+
+func TestCheckOnce_QuarantinesFailedBundle(t *testing.T) {
+	t.Parallel()
+	bundleDataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, bundleDataA)
+
+	hashB := storeBundle(t, f, map[string]string{"about.html": "<html>no index</html>"})
+	newSSM := ssmValue(hashB)
+	f.ssm.value = &newSSM
+
+	fm := newFakeWatcherMetrics()
+	w := f.newWatcher(func(o *WatcherOptions) { o.Metrics = fm })
+
+	if got := w.checkOnce(t.Context()); got != pollValidationError {
+		t.Fatalf("first poll = %d, want pollValidationError", got)
+	}
+	loadsAfterFirst := len(fm.loadDurations)
+
+	// subsequent polls skip the bundle without downloading it again
+	for range 3 {
+		if got := w.checkOnce(t.Context()); got != pollQuarantined {
+			t.Fatalf("poll = %d, want pollQuarantined", got)
+		}
+	}
+	if len(fm.loadDurations) != loadsAfterFirst {
+		t.Fatalf("bundle re-downloaded while quarantined: %d loads", len(fm.loadDurations))
+	}
+	if fm.qSkips != 3 || fm.quarantined != 1 {
+		t.Fatalf("quarantine skips = %d, gauge = %d; want 3 and 1", fm.qSkips, fm.quarantined)
+	}
+
+	entries := w.Quarantine().List()
+	if len(entries) != 1 || entries[0].Key != "sha384:"+hashB || entries[0].Reason != QuarantineReasonValidation {
+		t.Fatalf("quarantine = %+v", entries)
+	}
+}
+
+func TestCheckOnce_QuarantineRetriesAfterBackoff(t *testing.T) {
+	t.Parallel()
+	bundleDataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, bundleDataA)
+
+	// SSM points at a hash whose bundle hasn't been uploaded yet
+	dataB := makeTarGz(t, map[string]string{"index.html": "<html>v2</html>"})
+	hashB := cryptoutil.SHA384Hex(dataB)
+	newSSM := ssmValue(hashB)
+	f.ssm.value = &newSSM
+
+	q, clock := newTestQuarantine(time.Minute, time.Hour)
+	w := f.newWatcher(func(o *WatcherOptions) { o.Quarantine = q })
+
+	if got := w.checkOnce(t.Context()); got != pollLoadError {
+		t.Fatalf("first poll = %d, want pollLoadError", got)
+	}
+	if got := w.checkOnce(t.Context()); got != pollQuarantined {
+		t.Fatalf("second poll = %d, want pollQuarantined", got)
+	}
+
+	// the bundle shows up; once the backoff elapses the retry swaps it in
+	putBundle(f.s3, hashB, dataB)
+	putSigBundle(f.s3, hashB, []byte(`{"mock":"sig"}`))
+	clock.advance(time.Minute)
+
+	if got := w.checkOnce(t.Context()); got != pollSwapped {
+		t.Fatalf("retry poll = %d, want pollSwapped", got)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("quarantine should be cleared after a successful retry: %+v", q.List())
+	}
+}
+
+func TestCheckOnce_QuarantineClearedByOperator(t *testing.T) {
+	t.Parallel()
+	bundleDataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, bundleDataA)
+
+	newSSM := "sha384:" + strings.Repeat("0", 96)
+	f.ssm.value = &newSSM
+
+	w := f.newWatcher()
+	if got := w.checkOnce(t.Context()); got != pollLoadError {
+		t.Fatalf("first poll = %d, want pollLoadError", got)
+	}
+	if !w.Quarantine().Clear(newSSM) {
+		t.Fatal("expected quarantine entry for the failed bundle")
+	}
+
+	// cleared: retried immediately, and re-quarantined with attempts reset
+	if got := w.checkOnce(t.Context()); got != pollLoadError {
+		t.Fatalf("poll after clear = %d, want pollLoadError", got)
+	}
+	if e, _ := w.Quarantine().Blocked(newSSM); e.Attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 after clear", e.Attempts)
+	}
+}
+
+// checkOnce - operator rollback and pause
+
+// twoVersionFixture seeds hashA, then has the watcher swap to hashB.
+func twoVersionFixture(t *testing.T) (f *watcherFixture, w *Watcher, hashA, hashB string) {
+	t.Helper()
+	bundleDataA, hashA := buildContentBundle(t)
+	f = newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, bundleDataA)
+
+	hashB = storeBundle(t, f, map[string]string{"index.html": "<html>v2</html>"})
+	newSSM := ssmValue(hashB)
+	f.ssm.value = &newSSM
+
+	w = f.newWatcher()
+	if got := w.checkOnce(t.Context()); got != pollSwapped {
+		t.Fatalf("swap to B = %d, want pollSwapped", got)
+	}
+	return f, w, hashA, hashB
+}
+
+func TestWatcher_RollbackTo_PinsUntilPointerMoves(t *testing.T) {
+	t.Parallel()
+	f, w, hashA, hashB := twoVersionFixture(t)
+
+	if _, err := w.RollbackTo(t.Context(), hashA); err != nil {
+		t.Fatalf("RollbackTo: %v", err)
+	}
+	if f.mgr.ContentHash() != hashA {
+		t.Fatalf("active = %q, want A", truncHash(f.mgr.ContentHash()))
+	}
+	if w.Pinned() != "sha384:"+hashB {
+		t.Fatalf("Pinned = %q, want sha384:B", w.Pinned())
+	}
+
+	// SSM still says B: the watcher must not swap it straight back
+	for range 3 {
+		if got := w.checkOnce(t.Context()); got != pollPinned {
+			t.Fatalf("poll = %d, want pollPinned", got)
+		}
+	}
+	if f.mgr.ContentHash() != hashA {
+		t.Fatal("pinned watcher re-swapped the rolled-back bundle")
+	}
+
+	// a new publish releases the pin and is swapped in
+	hashC := storeBundle(t, f, map[string]string{"index.html": "<html>v3</html>"})
+	newSSM := ssmValue(hashC)
+	f.ssm.value = &newSSM
+	if got := w.checkOnce(t.Context()); got != pollSwapped {
+		t.Fatalf("poll after publish = %d, want pollSwapped", got)
+	}
+	if w.Pinned() != "" || f.mgr.ContentHash() != hashC {
+		t.Fatalf("pin=%q active=%q, want released and C", w.Pinned(), truncHash(f.mgr.ContentHash()))
+	}
+}
+
+func TestWatcher_RollbackTo_UnknownHash(t *testing.T) {
+	t.Parallel()
+	f, w, _, hashB := twoVersionFixture(t)
+
+	if _, err := w.RollbackTo(t.Context(), "nope"); err == nil {
+		t.Fatal("expected error for hash not in history")
+	}
+	if w.Pinned() != "" || f.mgr.ContentHash() != hashB {
+		t.Fatal("failed rollback must not pin or change content")
+	}
+}
+
+func TestWatcher_PauseResume(t *testing.T) {
+	t.Parallel()
+	_, w, _, _ := twoVersionFixture(t)
+
+	w.Pause()
+	if !w.Paused() {
+		t.Fatal("Paused should be true")
+	}
+	if got := w.checkOnce(t.Context()); got != pollPaused {
+		t.Fatalf("poll while paused = %d, want pollPaused", got)
+	}
+	w.Resume()
+	if got := w.checkOnce(t.Context()); got != pollNoChange {
+		t.Fatalf("poll after resume = %d, want pollNoChange", got)
+	}
+}
+
+func TestWatcher_PausedDoesNotSwap(t *testing.T) {
+	t.Parallel()
+	f, w, _, hashB := twoVersionFixture(t)
+
+	w.Pause()
+	hashC := storeBundle(t, f, map[string]string{"index.html": "<html>v3</html>"})
+	newSSM := ssmValue(hashC)
+	f.ssm.value = &newSSM
+
+	if got := w.checkOnce(t.Context()); got != pollPaused {
+		t.Fatalf("poll = %d, want pollPaused", got)
+	}
+	if f.mgr.ContentHash() != hashB {
+		t.Fatal("paused watcher swapped content")
+	}
+
+	w.Resume()
+	if got := w.checkOnce(t.Context()); got != pollSwapped {
+		t.Fatalf("poll after resume = %d, want pollSwapped", got)
+	}
+}
+
+// blockingFetcher holds LoadHash until released, to exercise operator
+// actions racing an in-flight load.
+type blockingFetcher struct {
+	BundleFetcher
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingFetcher) LoadHash(ctx context.Context, algorithm, hash string) (*Snapshot, error) {
+	close(b.started)
+	<-b.release
+	return b.BundleFetcher.LoadHash(ctx, algorithm, hash)
+}
+
+func TestWatcher_OperatorActionDiscardsInFlightLoad(t *testing.T) {
+	t.Parallel()
+	bundleDataA, hashA := buildContentBundle(t)
+	f := newWatcherFixture(t, ssmValue(hashA))
+	f.seedManager(t, hashA, bundleDataA)
+
+	hashB := storeBundle(t, f, map[string]string{"index.html": "<html>v2</html>"})
+	newSSM := ssmValue(hashB)
+	f.ssm.value = &newSSM
+
+	bf := &blockingFetcher{BundleFetcher: f.loader, started: make(chan struct{}), release: make(chan struct{})}
+	w := f.newWatcher(func(o *WatcherOptions) { o.Loader = bf })
+
+	done := make(chan pollResult, 1)
+	go func() { done <- w.checkOnce(t.Context()) }()
+
+	<-bf.started
+	w.Pause()
+	close(bf.release)
+
+	if got := <-done; got != pollNoChange {
+		t.Fatalf("result = %d, want pollNoChange (discarded)", got)
+	}
+	if f.mgr.ContentHash() != hashA {
+		t.Fatal("in-flight load swapped despite operator pause")
+	}
+}
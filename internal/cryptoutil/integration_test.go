@@ -422,6 +422,40 @@ func TestVerifyRekorInclusion_TamperedProofFails(t *testing.T) {
 	}
 }
 
+func TestVerifyRekorInclusion_KindMustMatchContent(t *testing.T) {
+	// the real bundle is a message signature logged as hashedrekord
+	b := loadRealBundle(t)
+	b.VerificationMaterial.TlogEntries[0].KindVersion.Kind = "dsse"
+	err := VerifyRekorInclusion(b)
+	if err == nil || !strings.Contains(err.Error(), "kind") {
+		t.Fatalf("dsse entry for a message signature: err = %v, want kind mismatch", err)
+	}
+
+	// a DSSE bundle can't lean on a hashedrekord entry either
+	b = loadRealBundle(t)
+	b.MessageSignature = nil
+	b.DSSEEnvelope = &DSSEEnvelope{
+		Payload:    base64.StdEncoding.EncodeToString([]byte("payload")),
+		Signatures: []DSSESignature{{Sig: "c2ln"}},
+	}
+	err = VerifyRekorInclusion(b)
+	if err == nil || !strings.Contains(err.Error(), "kind") {
+		t.Fatalf("hashedrekord entry for a DSSE bundle: err = %v, want kind mismatch", err)
+	}
+}
+
+func TestVerifyRekorInclusion_MixedBundleFails(t *testing.T) {
+	b := loadRealBundle(t)
+	b.DSSEEnvelope = &DSSEEnvelope{
+		Payload:    base64.StdEncoding.EncodeToString([]byte("payload")),
+		Signatures: []DSSESignature{{Sig: "c2ln"}},
+	}
+	err := VerifyRekorInclusion(b)
+	if err == nil || !strings.Contains(err.Error(), "both") {
+		t.Fatalf("err = %v, want rejection of a bundle with both content types", err)
+	}
+}
+
 func TestVerifyRFC3161_WrongImprintFails(t *testing.T) {
 	b := loadRealBundle(t)
 	tsRaw, _ := base64.StdEncoding.DecodeString(b.VerificationMaterial.TimestampVerificationData.RFC3161Timestamps[0].SignedTimestamp)
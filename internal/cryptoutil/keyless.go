@@ -77,6 +77,41 @@ func (v *KeylessVerifier) VerifyBlob(ctx context.Context, bundleJSON, artifact [
 		return err
 	}
 
+	return v.checkCert(bundle, cert)
+}
+
+// VerifyDSSE verifies a keyless DSSE attestation bundle - the envelope
+// signature against the leaf certificate, then the same trust-root and
+// identity checks as VerifyBlob - and returns its in-toto statement.
+func (v *KeylessVerifier) VerifyDSSE(ctx context.Context, bundleJSON []byte) (*InTotoStatement, error) {
+	_ = ctx // no network calls in the keyless path
+
+	bundle, err := ParseBundle(bundleJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := parseLeafCert(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	statement, err := verifyDSSEBundle(bundle, func(message, sig []byte) error {
+		return verifyWithPublicKey(cert.PublicKey, message, sig, v.AllowPKCS1v15)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.checkCert(bundle, cert); err != nil {
+		return nil, err
+	}
+	return statement, nil
+}
+
+// checkCert runs the checks on a keyless bundle's leaf certificate that follow
+// a good signature: trust roots, then the identity policy.
+func (v *KeylessVerifier) checkCert(bundle *SigstoreBundle, cert *x509.Certificate) error {
 	// Trust-root verification: chain to LinnemanLabs Fulcio CA at a trusted
 	// signing time, the cert was issued via the CT log (SCT), and the entry
 	// was publicly logged in Rekor with the same cert + signature + digest.
@@ -104,12 +139,9 @@ func (v *KeylessVerifier) VerifyBlob(ctx context.Context, bundleJSON, artifact [
 // logged with the CT log; and the Rekor inclusion proof + signed checkpoint
 // prove the cert+signature combination was publicly logged in Rekor.
 func (v *KeylessVerifier) verifyTrustRoot(bundle *SigstoreBundle, cert *x509.Certificate) error {
-	if bundle.MessageSignature == nil {
-		return xerrors.New("bundle has no messageSignature for TSA imprint")
-	}
-	sigBytes, err := base64.StdEncoding.DecodeString(bundle.MessageSignature.Signature)
+	sigBytes, err := bundleSignature(bundle)
 	if err != nil {
-		return xerrors.Wrap(err, "decode messageSignature")
+		return xerrors.Wrap(err, "TSA imprint")
 	}
 	imprint := sha256.Sum256(sigBytes)
 
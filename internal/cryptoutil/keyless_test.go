@@ -317,3 +317,119 @@ func TestCertInfoFromBundle_NoCertificate(t *testing.T) {
 		t.Fatal("expected error extracting cert info from a keyed bundle")
 	}
 }
+
+// buildKeylessDSSEBundle builds a keyless DSSE attestation bundle whose
+// in-toto statement names subject as its only subject.
+func buildKeylessDSSEBundle(t *testing.T, key *ecdsa.PrivateKey, cert *x509.Certificate, subject []byte) []byte {
+	t.Helper()
+	payload, err := json.Marshal(InTotoStatement{
+		Type:          "https://in-toto.io/Statement/v1",
+		PredicateType: "https://spdx.dev/Document",
+		Subject:       []InTotoSubject{{Name: "sbom.json", Digest: map[string]string{"sha256": SHA256Hex(subject)}}},
+	})
+	if err != nil {
+		t.Fatalf("marshal statement: %v", err)
+	}
+	sig := signBlobECDSA(t, key, PAE("application/vnd.in-toto+json", payload))
+
+	bundle := SigstoreBundle{
+		MediaType: "application/vnd.dev.sigstore.bundle.v0.3+json",
+		VerificationMaterial: VerificationMaterial{
+			Certificate: &CertificateRef{RawBytes: base64.StdEncoding.EncodeToString(cert.Raw)},
+		},
+		DSSEEnvelope: &DSSEEnvelope{
+			Payload:     base64.StdEncoding.EncodeToString(payload),
+			PayloadType: "application/vnd.in-toto+json",
+			Signatures:  []DSSESignature{{Sig: base64.StdEncoding.EncodeToString(sig)}},
+		},
+	}
+	raw, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatalf("marshal bundle: %v", err)
+	}
+	return raw
+}
+
+func TestKeylessVerifier_VerifyDSSE(t *testing.T) {
+	key := generateTestECKey(t, elliptic.P256())
+	cert := newTestLeafCert(t, key, &fulcioCertOptions{sanURI: "https://example.com/workflow"})
+	report := []byte(`{"spdxVersion":"SPDX-2.3"}`)
+
+	v := NewKeylessVerifier()
+	v.SkipTrustRootChecks = true // synthetic bundles - no TSA/Rekor/SCT
+	st, err := v.VerifyDSSE(t.Context(), buildKeylessDSSEBundle(t, key, cert, report))
+	if err != nil {
+		t.Fatalf("VerifyDSSE: %v", err)
+	}
+	if st.PredicateType != "https://spdx.dev/Document" {
+		t.Fatalf("PredicateType = %q", st.PredicateType)
+	}
+	if err := VerifySubjectDigest(st, report); err != nil {
+		t.Fatalf("subject: %v", err)
+	}
+}
+
+func TestKeylessVerifier_VerifyDSSE_WrongKey(t *testing.T) {
+	signingKey := generateTestECKey(t, elliptic.P256())
+	cert := newTestLeafCert(t, generateTestECKey(t, elliptic.P256()), &fulcioCertOptions{sanURI: "https://example.com/workflow"})
+
+	v := NewKeylessVerifier()
+	v.SkipTrustRootChecks = true // synthetic bundles - no TSA/Rekor/SCT
+	if _, err := v.VerifyDSSE(t.Context(), buildKeylessDSSEBundle(t, signingKey, cert, []byte("x"))); err == nil {
+		t.Fatal("expected failure when the envelope is not signed by the certificate key")
+	}
+}
+
+func TestKeylessVerifier_VerifyDSSE_RejectsBlobBundle(t *testing.T) {
+	key := generateTestECKey(t, elliptic.P256())
+	cert := newTestLeafCert(t, key, &fulcioCertOptions{sanURI: "https://example.com/workflow"})
+
+	v := NewKeylessVerifier()
+	v.SkipTrustRootChecks = true // synthetic bundles - no TSA/Rekor/SCT
+	if _, err := v.VerifyDSSE(t.Context(), buildKeylessBundle(t, key, cert, []byte("x"), false)); err == nil {
+		t.Fatal("expected failure for a messageSignature bundle")
+	}
+}
+
+func TestAssertRekorDSSEBodyMatchesBundle(t *testing.T) {
+	key := generateTestECKey(t, elliptic.P256())
+	cert := newTestLeafCert(t, key, &fulcioCertOptions{sanURI: "https://example.com/workflow"})
+	b, err := ParseBundle(buildKeylessDSSEBundle(t, key, cert, []byte("report")))
+	if err != nil {
+		t.Fatalf("ParseBundle: %v", err)
+	}
+	payload, _ := DecodeDSSEPayload(b.DSSEEnvelope)
+	digest := sha256.Sum256(payload)
+
+	body := func(digestB64, sig, certB64 string) []byte {
+		raw, _ := json.Marshal(map[string]any{
+			"apiVersion": "0.0.2",
+			"kind":       "dsse",
+			"spec": map[string]any{"dsseV002": map[string]any{
+				"payloadHash": map[string]string{"algorithm": "SHA2_256", "digest": digestB64},
+				"signatures": []map[string]any{{
+					"content":  sig,
+					"verifier": map[string]any{"x509Certificate": map[string]string{"rawBytes": certB64}},
+				}},
+			}},
+		})
+		return raw
+	}
+	goodDigest := base64.StdEncoding.EncodeToString(digest[:])
+	goodSig := b.DSSEEnvelope.Signatures[0].Sig
+	goodCert := b.VerificationMaterial.Certificate.RawBytes
+
+	if err := assertRekorDSSEBodyMatchesBundle(body(goodDigest, goodSig, goodCert), b); err != nil {
+		t.Fatalf("matching body: %v", err)
+	}
+	cases := map[string][]byte{
+		"payload":     body(base64.StdEncoding.EncodeToString(make([]byte, 32)), goodSig, goodCert),
+		"signature":   body(goodDigest, base64.StdEncoding.EncodeToString([]byte("other")), goodCert),
+		"certificate": body(goodDigest, goodSig, base64.StdEncoding.EncodeToString([]byte("other"))),
+	}
+	for name, raw := range cases {
+		if err := assertRekorDSSEBodyMatchesBundle(raw, b); err == nil {
+			t.Errorf("%s mismatch accepted", name)
+		}
+	}
+}
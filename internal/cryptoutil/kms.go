@@ -44,6 +44,18 @@ func (v *KMSVerifier) VerifyBlob(ctx context.Context, bundleJSON, artifact []byt
 	return err
 }
 
+// VerifyDSSE verifies a DSSE attestation bundle signed with the KMS key and
+// returns its in-toto statement.
+func (v *KMSVerifier) VerifyDSSE(ctx context.Context, bundleJSON []byte) (*InTotoStatement, error) {
+	bundle, err := ParseBundle(bundleJSON)
+	if err != nil {
+		return nil, err
+	}
+	return verifyDSSEBundle(bundle, func(message, sig []byte) error {
+		return v.VerifySignature(ctx, message, sig)
+	})
+}
+
 func NewKMSVerifier(client *kms.Client, keyARN string) *KMSVerifier {
 	return &KMSVerifier{client: client, keyARN: keyARN, AllowPKCS1v15: false}
 }
@@ -21,8 +21,9 @@ import (
 //  2. The signed checkpoint envelope is signed by the trusted Rekor key.
 //  3. The checkpoint's treeSize + rootHash match the InclusionProof.
 //  4. The RFC 6962 Merkle path from the leaf hash reaches the root.
-//  5. The leaf body (hashedrekord 0.0.2) reports the same cert/sig/digest as
-//     the bundle's verificationMaterial + messageSignature.
+//  5. The leaf body (hashedrekord or dsse 0.0.2) reports the same
+//     cert/sig/digest as the bundle's verificationMaterial + messageSignature
+//     or DSSE envelope.
 //
 // Returns nil on full success.
 func VerifyRekorInclusion(b *SigstoreBundle) error {
@@ -35,8 +36,15 @@ func VerifyRekorInclusion(b *SigstoreBundle) error {
 	if entry.LogID.KeyID != expectedLogID {
 		return xerrors.Newf("rekor: logId %q does not match trusted log %q", entry.LogID.KeyID, expectedLogID)
 	}
-	if entry.KindVersion.Kind != "hashedrekord" {
-		return xerrors.Newf("rekor: unsupported entry kind %q (want hashedrekord)", entry.KindVersion.Kind)
+	// the bundle's content decides which entry kind it needs; trusting the
+	// entry's own kind would let a dsse entry stand in for a message
+	// signature (or the reverse) and skip the matching body cross-check
+	wantKind, err := rekorKindFor(b)
+	if err != nil {
+		return err
+	}
+	if entry.KindVersion.Kind != wantKind {
+		return xerrors.Newf("rekor: entry kind %q does not match bundle content (want %s)", entry.KindVersion.Kind, wantKind)
 	}
 
 	if entry.InclusionProof == nil {
@@ -90,13 +98,32 @@ func VerifyRekorInclusion(b *SigstoreBundle) error {
 	// Body cross-check: the Rekor entry must reference the same cert + sig +
 	// artifact digest as the bundle, otherwise an attacker could replay a
 	// real inclusion proof for someone else's entry.
-	if err := assertRekorBodyMatchesBundle(bodyBytes, b); err != nil {
+	assertBody := assertRekorBodyMatchesBundle
+	if wantKind == "dsse" {
+		assertBody = assertRekorDSSEBodyMatchesBundle
+	}
+	if err := assertBody(bodyBytes, b); err != nil {
 		return xerrors.Wrap(err, "rekor: body cross-check")
 	}
 
 	return nil
 }
 
+// rekorKindFor returns the Rekor entry kind a bundle must be logged under:
+// hashedrekord for a message signature, dsse for a DSSE envelope.
+func rekorKindFor(b *SigstoreBundle) (string, error) {
+	switch {
+	case b.MessageSignature != nil && b.DSSEEnvelope != nil:
+		return "", xerrors.New("rekor: bundle has both a message signature and a DSSE envelope")
+	case b.MessageSignature != nil:
+		return "hashedrekord", nil
+	case b.DSSEEnvelope != nil:
+		return "dsse", nil
+	default:
+		return "", xerrors.New("rekor: bundle has neither a message signature nor a DSSE envelope")
+	}
+}
+
 // RFC 6962 §2.1: leaf hash = SHA-256(0x00 || leaf_data).
 func rfc6962LeafHash(leaf []byte) []byte {
 	h := sha256.New()
@@ -281,3 +308,69 @@ func assertRekorBodyMatchesBundle(bodyBytes []byte, b *SigstoreBundle) error {
 	}
 	return nil
 }
+
+// rekorDSSEBody mirrors enough of the dsse 0.0.2 spec to cross-check the
+// entry against a DSSE bundle: Rekor logs the payload digest and the
+// envelope signatures with their verifiers.
+type rekorDSSEBody struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Spec       struct {
+		DSSEV002 struct {
+			PayloadHash struct {
+				Algorithm string `json:"algorithm"`
+				Digest    string `json:"digest"`
+			} `json:"payloadHash"`
+			Signatures []struct {
+				Content  string `json:"content"`
+				Verifier struct {
+					KeyDetails      string `json:"keyDetails"`
+					X509Certificate struct {
+						RawBytes string `json:"rawBytes"`
+					} `json:"x509Certificate"`
+				} `json:"verifier"`
+			} `json:"signatures"`
+		} `json:"dsseV002"`
+	} `json:"spec"`
+}
+
+// assertRekorDSSEBodyMatchesBundle is assertRekorBodyMatchesBundle for dsse
+// entries: the body must commit to the envelope's payload digest and carry
+// its signature under the bundle's certificate.
+func assertRekorDSSEBodyMatchesBundle(bodyBytes []byte, b *SigstoreBundle) error {
+	var body rekorDSSEBody
+	if err := json.Unmarshal(bodyBytes, &body); err != nil {
+		return xerrors.Wrap(err, "parse dsse body")
+	}
+	spec := body.Spec.DSSEV002
+
+	if b.DSSEEnvelope == nil || len(b.DSSEEnvelope.Signatures) == 0 {
+		return xerrors.New("bundle has no DSSE envelope to cross-check")
+	}
+	payload, err := DecodeDSSEPayload(b.DSSEEnvelope)
+	if err != nil {
+		return err
+	}
+	digest, err := computeDigestForAlgorithm(spec.PayloadHash.Algorithm, payload)
+	if err != nil {
+		return err
+	}
+	if spec.PayloadHash.Digest != base64.StdEncoding.EncodeToString(digest) {
+		return xerrors.New("payload digest mismatch between Rekor body and bundle")
+	}
+	if b.VerificationMaterial.Certificate == nil {
+		return xerrors.New("bundle has no certificate to cross-check")
+	}
+
+	want := b.DSSEEnvelope.Signatures[0].Sig
+	for _, sig := range spec.Signatures {
+		if sig.Content != want {
+			continue
+		}
+		if sig.Verifier.X509Certificate.RawBytes != b.VerificationMaterial.Certificate.RawBytes {
+			return xerrors.New("certificate mismatch between Rekor body and bundle")
+		}
+		return nil
+	}
+	return xerrors.New("signature content mismatch between Rekor body and bundle")
+}
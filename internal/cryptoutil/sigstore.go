@@ -175,6 +175,8 @@ func ParseBundle(bundleJSON []byte) (*SigstoreBundle, error) {
 	}
 
 	switch {
+	case b.MessageSignature != nil && b.DSSEEnvelope != nil:
+		return nil, xerrors.New("sigstore bundle has both DSSE envelope and message signature")
 	case b.MessageSignature != nil:
 		if b.MessageSignature.Signature == "" {
 			return nil, xerrors.New("sigstore bundle has empty message signature")
@@ -239,6 +241,36 @@ func VerifyReleaseDSSE(ctx context.Context, v *KMSVerifier, bundleJSON, artifact
 		return nil, err
 	}
 
+	statement, err := verifyDSSEBundle(bundle, func(message, sig []byte) error {
+		return v.VerifySignature(ctx, message, sig)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := VerifySubjectDigest(statement, artifact); err != nil {
+		return nil, err
+	}
+
+	// build result
+	result := &DSSEVerifyResult{
+		KeyHint:       bundle.VerificationMaterial.PublicKey.Hint,
+		PredicateType: statement.PredicateType,
+	}
+	if len(statement.Subject) > 0 {
+		result.SubjectName = statement.Subject[0].Name
+		result.SubjectDigest = statement.Subject[0].Digest["sha256"]
+	}
+
+	return result, nil
+}
+
+// verifyDSSEBundle verifies a parsed DSSE bundle's envelope signature over the
+// PAE of its payload and returns the decoded in-toto statement. As with
+// verifyBlobBundle, the signature step is delegated to verifySig so KMS and
+// keyless bundles share it. Matching subjects to an artifact is up to the
+// caller.
+func verifyDSSEBundle(bundle *SigstoreBundle, verifySig func(message, sig []byte) error) (*InTotoStatement, error) {
 	if bundle.DSSEEnvelope == nil {
 		return nil, xerrors.New("bundle is not a DSSE attestation (no dsseEnvelope)")
 	}
@@ -257,31 +289,33 @@ func VerifyReleaseDSSE(ctx context.Context, v *KMSVerifier, bundleJSON, artifact
 
 	// compute PAE and verify signature
 	pae := PAE(bundle.DSSEEnvelope.PayloadType, payloadBytes)
-	if err := v.VerifySignature(ctx, pae, sig); err != nil {
+	if err := verifySig(pae, sig); err != nil {
 		return nil, xerrors.Wrap(err, "DSSE signature verification failed")
 	}
 
-	// parse in-toto statement and check subject digest
+	// parse in-toto statement
 	var statement InTotoStatement
 	if err := json.Unmarshal(payloadBytes, &statement); err != nil {
 		return nil, xerrors.Wrap(err, "parse in-toto statement")
 	}
+	return &statement, nil
+}
 
-	if err := VerifySubjectDigest(&statement, artifact); err != nil {
-		return nil, err
-	}
-
-	// build result
-	result := &DSSEVerifyResult{
-		KeyHint:       bundle.VerificationMaterial.PublicKey.Hint,
-		PredicateType: statement.PredicateType,
-	}
-	if len(statement.Subject) > 0 {
-		result.SubjectName = statement.Subject[0].Name
-		result.SubjectDigest = statement.Subject[0].Digest["sha256"]
+// bundleSignature returns the raw signature a bundle carries: its
+// messageSignature, or the first DSSE envelope signature.
+func bundleSignature(b *SigstoreBundle) ([]byte, error) {
+	switch {
+	case b.MessageSignature != nil:
+		sig, err := base64.StdEncoding.DecodeString(b.MessageSignature.Signature)
+		if err != nil {
+			return nil, xerrors.Wrap(err, "decode messageSignature")
+		}
+		return sig, nil
+	case b.DSSEEnvelope != nil:
+		return DecodeSignature(b.DSSEEnvelope)
+	default:
+		return nil, xerrors.New("bundle has no signature")
 	}
-
-	return result, nil
 }
 
 // VerifyBlobSignature verifies a cosign sign-blob bundle against
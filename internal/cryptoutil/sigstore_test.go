@@ -982,6 +982,30 @@ func TestParseBundle_NeitherDSSENorBlob(t *testing.T) {
 	}
 }
 
+// ParseBundle - both DSSE and message signature
+
+func TestParseBundle_BothDSSEAndBlob(t *testing.T) {
+	bundle := SigstoreBundle{
+		MessageSignature: &MessageSignature{
+			Signature: base64.StdEncoding.EncodeToString([]byte("sig")),
+		},
+		DSSEEnvelope: &DSSEEnvelope{
+			Payload:     base64.StdEncoding.EncodeToString([]byte("payload")),
+			PayloadType: "test",
+			Signatures:  []DSSESignature{{Sig: "c2ln"}},
+		},
+	}
+	raw, _ := json.Marshal(bundle)
+
+	_, err := ParseBundle(raw)
+	if err == nil {
+		t.Fatal("expected error for bundle with both DSSE and message signature")
+	}
+	if !strings.Contains(err.Error(), "both") {
+		t.Fatalf("error should mention 'both': %v", err)
+	}
+}
+
 func buildDSSEBundle(t *testing.T, key *rsa.PrivateKey, artifact []byte) []byte {
 	t.Helper()
 
@@ -1024,3 +1048,44 @@ func buildDSSEBundle(t *testing.T, key *rsa.PrivateKey, artifact []byte) []byte
 	}
 	return raw
 }
+
+func TestKMSVerifier_VerifyDSSE(t *testing.T) {
+	key := generateTestKey(t)
+	v := newTestVerifier(t, &key.PublicKey)
+	artifact := []byte(`{"matches":[]}`)
+
+	st, err := v.VerifyDSSE(t.Context(), buildDSSEBundle(t, key, artifact))
+	if err != nil {
+		t.Fatalf("VerifyDSSE: %v", err)
+	}
+	if len(st.Subject) != 1 || st.Subject[0].Digest["sha256"] != SHA256Hex(artifact) {
+		t.Fatalf("subjects = %+v", st.Subject)
+	}
+
+	other := newTestVerifier(t, &generateTestKey(t).PublicKey)
+	if _, err := other.VerifyDSSE(t.Context(), buildDSSEBundle(t, key, artifact)); err == nil {
+		t.Fatal("expected failure with the wrong key")
+	}
+	if _, err := v.VerifyDSSE(t.Context(), buildBlobBundle(t, key, artifact)); err == nil {
+		t.Fatal("expected failure for a blob bundle")
+	}
+}
+
+func TestBundleSignature(t *testing.T) {
+	key := generateTestKey(t)
+	for name, raw := range map[string][]byte{
+		"blob": buildBlobBundle(t, key, []byte("a")),
+		"dsse": buildDSSEBundle(t, key, []byte("a")),
+	} {
+		b, err := ParseBundle(raw)
+		if err != nil {
+			t.Fatalf("%s: ParseBundle: %v", name, err)
+		}
+		if sig, err := bundleSignature(b); err != nil || len(sig) == 0 {
+			t.Fatalf("%s: bundleSignature = %d bytes, %v", name, len(sig), err)
+		}
+	}
+	if _, err := bundleSignature(&SigstoreBundle{}); err == nil {
+		t.Fatal("expected error for a bundle without a signature")
+	}
+}
@@ -0,0 +1,104 @@
+package evidence
+
+import (
+	"context"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// AttestationVerifier verifies a DSSE attestation bundle's envelope signature
+// and returns its in-toto statement. cryptoutil.KMSVerifier and
+// cryptoutil.KeylessVerifier implement it alongside BlobVerifier, so the
+// loader uses its configured verifiers for attestations when they do.
+type AttestationVerifier interface {
+	VerifyDSSE(ctx context.Context, bundleJSON []byte) (*cryptoutil.InTotoStatement, error)
+}
+
+// verifyAttestations checks every fetched attestation file and records the
+// result on its ref. A failure is recorded and logged but does not fail the
+// load: the file's bytes are already pinned by the signed inventory, so a bad
+// attestation is a publishing problem to surface, not tampering in transit.
+// Nothing is recorded when neither configured verifier can check DSSE.
+func (l *Loader) verifyAttestations(ctx context.Context, index map[string]*EvidenceFileRef, files map[string]*EvidenceFile) {
+	kms, _ := l.opts.Verifier.(AttestationVerifier)
+	keyless, _ := l.opts.KeylessVerifier.(AttestationVerifier)
+	if kms == nil && keyless == nil {
+		return
+	}
+
+	var verified, failed int
+	for path, f := range files {
+		if f.Ref.Kind != "attestation" {
+			continue
+		}
+		res := verifyAttestation(ctx, kms, keyless, f.Data, f.Ref, index)
+		f.Ref.Attestation = res
+		if res.Verified {
+			verified++
+			continue
+		}
+		failed++
+		l.logger.Warn(ctx, "evidence attestation failed verification",
+			"path", path,
+			"covers", f.Ref.Covers,
+			"signer", res.Signer,
+			"error", res.Error,
+		)
+	}
+	if verified+failed > 0 {
+		l.logger.Info(ctx, "verified evidence attestations",
+			"verified", verified,
+			"failed", failed,
+		)
+	}
+}
+
+// verifyAttestation verifies one attestation with the verifier matching its
+// signing material, then requires an in-toto subject whose sha256 is that of
+// the report the attestation covers or of the target binary.
+func verifyAttestation(ctx context.Context, kms, keyless AttestationVerifier, data []byte, ref *EvidenceFileRef, index map[string]*EvidenceFileRef) *AttestationResult {
+	res := &AttestationResult{}
+	fail := func(err error) *AttestationResult {
+		res.Error = err.Error()
+		return res
+	}
+
+	bundle, err := cryptoutil.ParseBundle(data)
+	if err != nil {
+		return fail(err)
+	}
+	verifier := kms
+	res.Signer = "kms"
+	if vm := bundle.VerificationMaterial; vm.Certificate != nil || vm.X509CertificateChain != nil {
+		verifier = keyless
+		res.Signer = "keyless"
+	}
+	if verifier == nil {
+		return fail(xerrors.Newf("no %s attestation verifier configured", res.Signer))
+	}
+
+	statement, err := verifier.VerifyDSSE(ctx, data)
+	if err != nil {
+		return fail(err)
+	}
+	res.PredicateType = statement.PredicateType
+
+	candidates := make(map[string]string, 2) // path -> sha256
+	if covered, ok := index[ref.Covers]; ok && covered.SHA256 != "" {
+		candidates[covered.Path] = covered.SHA256
+	}
+	if ref.subjectPath != "" && ref.subjectSHA256 != "" {
+		candidates[ref.subjectPath] = ref.subjectSHA256
+	}
+	for _, subj := range statement.Subject {
+		for path, digest := range candidates {
+			if cryptoutil.HashEqual(subj.Digest["sha256"], digest) {
+				res.Verified = true
+				res.Subject = path
+				return res
+			}
+		}
+	}
+	return fail(xerrors.Newf("no in-toto subject matches %s or the target binary", ref.Covers))
+}
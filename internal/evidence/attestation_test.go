@@ -0,0 +1,229 @@
+package evidence
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+)
+
+// dsseVerifier is a BlobVerifier that also verifies attestations: any DSSE
+// bundle passes unless err is set, and its statement is decoded as-is.
+type dsseVerifier struct {
+	stubVerifier
+	dsseErr error
+	calls   int
+}
+
+func (v *dsseVerifier) VerifyDSSE(_ context.Context, bundleJSON []byte) (*cryptoutil.InTotoStatement, error) {
+	v.calls++
+	if v.dsseErr != nil {
+		return nil, v.dsseErr
+	}
+	b, err := cryptoutil.ParseBundle(bundleJSON)
+	if err != nil {
+		return nil, err
+	}
+	if b.DSSEEnvelope == nil {
+		return nil, errors.New("not a DSSE bundle")
+	}
+	payload, err := cryptoutil.DecodeDSSEPayload(b.DSSEEnvelope)
+	if err != nil {
+		return nil, err
+	}
+	var st cryptoutil.InTotoStatement
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// attestationJSON builds a DSSE sigstore bundle whose statement names
+// subjectSHA as its subject; keyless adds a certificate to the material.
+func attestationJSON(t *testing.T, subjectSHA string, keyless bool) []byte {
+	t.Helper()
+	payload, _ := json.Marshal(cryptoutil.InTotoStatement{
+		Type:          "https://in-toto.io/Statement/v1",
+		PredicateType: "https://spdx.dev/Document",
+		Subject:       []cryptoutil.InTotoSubject{{Name: "subject", Digest: map[string]string{"sha256": subjectSHA}}},
+	})
+	b := cryptoutil.SigstoreBundle{
+		DSSEEnvelope: &cryptoutil.DSSEEnvelope{
+			Payload:     base64.StdEncoding.EncodeToString(payload),
+			PayloadType: "application/vnd.in-toto+json",
+			Signatures:  []cryptoutil.DSSESignature{{Sig: base64.StdEncoding.EncodeToString([]byte("sig"))}},
+		},
+	}
+	if keyless {
+		b.VerificationMaterial.Certificate = &cryptoutil.CertificateRef{RawBytes: "Y2VydA=="}
+	} else {
+		b.VerificationMaterial.PublicKey.Hint = "kms-key"
+	}
+	raw, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("marshal bundle: %v", err)
+	}
+	return raw
+}
+
+const (
+	attReportPath = "artifacts/linux-amd64/sbom.json"
+	attPath       = "artifacts/linux-amd64/sbom.json.sigstore"
+	attBinaryPath = "artifacts/app-linux-amd64"
+)
+
+var attBinarySHA = cryptoutil.SHA256Hex([]byte("binary"))
+
+// populateWithAttestation publishes a target SBOM report with one
+// attestation (att) and returns the report's sha256.
+func populateWithAttestation(fake *fakeS3, att []byte) string {
+	prefix := testReleasePrefix()
+	report := []byte(`{"bomFormat":"CycloneDX"}`)
+	fake.put(prefix+attReportPath, report)
+	fake.put(prefix+attPath, att)
+
+	file := func(path string, data []byte) map[string]any {
+		return map[string]any{"path": path, "hashes": map[string]string{"sha256": cryptoutil.SHA256Hex(data)}, "size": len(data)}
+	}
+	inv, _ := json.Marshal(map[string]any{
+		"targets": []map[string]any{{
+			"platform": "linux/amd64",
+			"subject":  map[string]any{"path": attBinaryPath, "hashes": map[string]string{"sha256": attBinarySHA}},
+			"sbom": []map[string]any{{
+				"format":       "cyclonedx-json",
+				"report":       file(attReportPath, report),
+				"attestations": []map[string]any{file(attPath, att)},
+			}},
+		}},
+	})
+	fake.put(prefix+"inventory.json", inv)
+	fake.putJSON(prefix+"release.json", validReleaseManifest(cryptoutil.SHA256Hex(inv)))
+	putReleaseSigBundles(fake, prefix, []byte(`{"mock":"sigstore"}`))
+	return cryptoutil.SHA256Hex(report)
+}
+
+func loadAttestation(t *testing.T, fake *fakeS3, kms, keyless BlobVerifier) *EvidenceFileRef {
+	t.Helper()
+	l := newTestLoader(fake, kms)
+	l.opts.KeylessVerifier = keyless
+	b, err := l.Load(t.Context())
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	ref, ok := b.FileRef(attPath)
+	if !ok {
+		t.Fatal("attestation missing from index")
+	}
+	return ref
+}
+
+func TestLoad_AttestationCoversReport(t *testing.T) {
+	fake := newFakeS3()
+	reportSHA := cryptoutil.SHA256Hex([]byte(`{"bomFormat":"CycloneDX"}`))
+	populateWithAttestation(fake, attestationJSON(t, reportSHA, false))
+
+	ref := loadAttestation(t, fake, &dsseVerifier{}, &dsseVerifier{})
+	res := ref.Attestation
+	if res == nil || !res.Verified {
+		t.Fatalf("attestation = %+v, want verified", res)
+	}
+	if res.Signer != "kms" || res.Subject != attReportPath || res.PredicateType != "https://spdx.dev/Document" {
+		t.Fatalf("attestation = %+v", res)
+	}
+	if ref.Covers != attReportPath {
+		t.Fatalf("Covers = %q, want %q", ref.Covers, attReportPath)
+	}
+}
+
+func TestLoad_AttestationCoversBinary(t *testing.T) {
+	fake := newFakeS3()
+	populateWithAttestation(fake, attestationJSON(t, attBinarySHA, true))
+
+	keyless := &dsseVerifier{}
+	res := loadAttestation(t, fake, &dsseVerifier{}, keyless).Attestation
+	if res == nil || !res.Verified || res.Subject != attBinaryPath || res.Signer != "keyless" {
+		t.Fatalf("attestation = %+v, want verified against the binary by keyless", res)
+	}
+	if keyless.calls != 1 {
+		t.Fatalf("keyless verifier calls = %d, want 1", keyless.calls)
+	}
+}
+
+func TestLoad_AttestationFailuresAreRecorded(t *testing.T) {
+	cases := []struct {
+		name    string
+		att     func(t *testing.T) []byte
+		kms     BlobVerifier
+		keyless BlobVerifier
+		want    string
+	}{
+		{
+			name: "subject mismatch",
+			att:  func(t *testing.T) []byte { return attestationJSON(t, cryptoutil.SHA256Hex([]byte("other")), false) },
+			kms:  &dsseVerifier{},
+			want: "no in-toto subject matches",
+		},
+		{
+			name: "bad signature",
+			att:  func(t *testing.T) []byte { return attestationJSON(t, attBinarySHA, false) },
+			kms:  &dsseVerifier{dsseErr: errors.New("DSSE signature verification failed")},
+			want: "DSSE signature verification failed",
+		},
+		{
+			name:    "no keyless verifier",
+			att:     func(t *testing.T) []byte { return attestationJSON(t, attBinarySHA, true) },
+			kms:     &dsseVerifier{},
+			keyless: passVerifier(),
+			want:    "no keyless attestation verifier configured",
+		},
+		{
+			name: "not a sigstore bundle",
+			att:  func(*testing.T) []byte { return []byte(`{"not":"a bundle"}`) },
+			kms:  &dsseVerifier{},
+			want: "neither DSSE envelope nor message signature",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeS3()
+			populateWithAttestation(fake, tc.att(t))
+			keyless := tc.keyless
+			if keyless == nil {
+				keyless = &dsseVerifier{}
+			}
+			res := loadAttestation(t, fake, tc.kms, keyless).Attestation
+			if res == nil || res.Verified {
+				t.Fatalf("attestation = %+v, want a recorded failure", res)
+			}
+			if !strings.Contains(res.Error, tc.want) {
+				t.Fatalf("error = %q, want %q", res.Error, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoad_AttestationsSkippedWithoutDSSEVerifier(t *testing.T) {
+	fake := newFakeS3()
+	populateWithAttestation(fake, attestationJSON(t, attBinarySHA, false))
+
+	if res := loadAttestation(t, fake, passVerifier(), passVerifier()).Attestation; res != nil {
+		t.Fatalf("attestation = %+v, want nil when nothing can verify DSSE", res)
+	}
+}
+
+func TestAttestations_VerifiedCounts(t *testing.T) {
+	b := &Bundle{FileIndex: map[string]*EvidenceFileRef{
+		"a": {Kind: "attestation", Attestation: &AttestationResult{Verified: true}},
+		"b": {Kind: "attestation", Attestation: &AttestationResult{Error: "bad"}},
+		"c": {Kind: "attestation"},
+		"d": {Kind: "report"},
+	}}
+	c := b.Attestations()
+	if c.Total != 3 || c.Verified != 1 || c.Failed != 1 {
+		t.Fatalf("counts = %+v, want total 3, verified 1, failed 1", c)
+	}
+}
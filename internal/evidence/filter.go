@@ -63,6 +63,7 @@ func FilterBundleByPlatform(b *Bundle, platform string) *Bundle {
 		Signatures:           b.Signatures,
 		InventoryRaw:         newInventoryRaw,
 		InventoryHash:        b.InventoryHash,
+		ReleaseDigest:        b.ReleaseDigest,
 		FileIndex:            newIndex,
 		Files:                newFiles,
 		Tooling:              b.Tooling,
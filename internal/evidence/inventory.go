@@ -78,7 +78,7 @@ func BuildFileIndex(raw []byte) (map[string]*EvidenceFileRef, error) {
 	idx := make(map[string]*EvidenceFileRef, 64)
 
 	if se := inv.SourceEvidence; se != nil {
-		indexEvidence(idx, se.SBOM, se.Scans, se.License, "source", "")
+		indexEvidence(idx, se.SBOM, se.Scans, se.License, "source", "", inventoryFile{})
 	}
 
 	for i := range inv.Targets {
@@ -87,40 +87,51 @@ func BuildFileIndex(raw []byte) (map[string]*EvidenceFileRef, error) {
 		if platform == "" && t.OS != "" {
 			platform = t.OS + "/" + t.Arch
 		}
-		indexEvidence(idx, t.SBOM, t.Scans, t.License, "artifact", platform)
+		indexEvidence(idx, t.SBOM, t.Scans, t.License, "artifact", platform, t.Subject)
 	}
 
 	return idx, nil
 }
 
-func indexEvidence(idx map[string]*EvidenceFileRef, sboms []sbomEntry, scans []scanEntry, licenses []licenseEntry, category, platform string) {
+// indexEvidence adds each report and its attestations to idx. Attestations
+// are linked to the report they sit under and, for a target, to its binary
+// (subject), since an attestation may name either as its in-toto subject.
+func indexEvidence(idx map[string]*EvidenceFileRef, sboms []sbomEntry, scans []scanEntry, licenses []licenseEntry, category, platform string, subject inventoryFile) {
+	attest := func(report inventoryFile, atts []inventoryFile, kind string) {
+		for _, a := range atts {
+			ref := addFile(idx, a, category, kind, "attestation", platform)
+			if ref == nil {
+				continue
+			}
+			ref.Covers = report.Path
+			ref.subjectPath = subject.Path
+			ref.subjectSHA256 = subject.Hashes["sha256"]
+		}
+	}
 	for _, sb := range sboms {
 		addFile(idx, sb.Report, category, "sbom", "report", platform)
-		for _, a := range sb.Attestations {
-			addFile(idx, a, category, "sbom", "attestation", platform)
-		}
+		attest(sb.Report, sb.Attestations, "sbom")
 	}
 	for _, sc := range scans {
 		for _, rep := range sc.Reports {
-			addFile(idx, rep.Report, category, "scan", "report", platform)
-			for _, a := range rep.Attestations {
-				addFile(idx, a, category, "scan", "attestation", platform)
+			if ref := addFile(idx, rep.Report, category, "scan", "report", platform); ref != nil {
+				ref.Scanner = sc.Scanner
+				ref.Format = rep.Format
 			}
+			attest(rep.Report, rep.Attestations, "scan")
 		}
 	}
 	for _, lic := range licenses {
 		addFile(idx, lic.Report, category, "license", "report", platform)
-		for _, a := range lic.Attestations {
-			addFile(idx, a, category, "license", "attestation", platform)
-		}
+		attest(lic.Report, lic.Attestations, "license")
 	}
 }
 
-func addFile(idx map[string]*EvidenceFileRef, f inventoryFile, scope, category, kind, platform string) {
+func addFile(idx map[string]*EvidenceFileRef, f inventoryFile, scope, category, kind, platform string) *EvidenceFileRef {
 	if f.Path == "" {
-		return
+		return nil
 	}
-	idx[f.Path] = &EvidenceFileRef{
+	ref := &EvidenceFileRef{
 		Path:     f.Path,
 		SHA256:   f.Hashes["sha256"],
 		Size:     f.Size,
@@ -129,4 +140,6 @@ func addFile(idx map[string]*EvidenceFileRef, f inventoryFile, scope, category,
 		Kind:     kind,
 		Platform: platform,
 	}
+	idx[f.Path] = ref
+	return ref
 }
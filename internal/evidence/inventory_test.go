@@ -474,7 +474,7 @@ func TestBuildFileIndex_MultipleScanners(t *testing.T) {
 					{
 						Scanner: "trivy",
 						Reports: []scanReport{
-							{Report: inventoryFile{Path: "amd64/trivy.json", Hashes: map[string]string{"sha256": "t1"}, Size: 100}},
+							{Format: "json", Report: inventoryFile{Path: "amd64/trivy.json", Hashes: map[string]string{"sha256": "t1"}, Size: 100}},
 						},
 					},
 					{
@@ -498,6 +498,13 @@ func TestBuildFileIndex_MultipleScanners(t *testing.T) {
 
 	wantFileRef(t, idx, "amd64/trivy.json", "artifact", "scan", "report", "linux/amd64", "t1")
 	wantFileRef(t, idx, "amd64/grype.json", "artifact", "scan", "report", "linux/amd64", "g1")
+
+	if ref := idx["amd64/trivy.json"]; ref.Scanner != "trivy" || ref.Format != "json" {
+		t.Errorf("trivy report scanner/format = %q/%q, want trivy/json", ref.Scanner, ref.Format)
+	}
+	if ref := idx["amd64/grype.json"]; ref.Scanner != "grype" || ref.Format != "" {
+		t.Errorf("grype report scanner/format = %q/%q, want grype/\"\"", ref.Scanner, ref.Format)
+	}
 }
 
 // addFile tests
@@ -946,3 +953,38 @@ func TestParseInventoryTooling_InvalidJSON(t *testing.T) {
 		t.Fatal("expected error for invalid JSON")
 	}
 }
+
+func TestBuildFileIndex_AttestationLinks(t *testing.T) {
+	raw := []byte(`{
+		"source_evidence": {
+			"license": [{
+				"report": {"path": "source/license.json", "hashes": {"sha256": "lic"}},
+				"attestations": [{"path": "source/license.json.sigstore", "hashes": {"sha256": "licatt"}}]
+			}]
+		},
+		"targets": [{
+			"platform": "linux/amd64",
+			"subject": {"path": "artifacts/app", "hashes": {"sha256": "bin"}},
+			"scans": [{"scanner": "grype", "reports": [{
+				"report": {"path": "artifacts/grype.json", "hashes": {"sha256": "scan"}},
+				"attestations": [{"path": "artifacts/grype.json.sigstore", "hashes": {"sha256": "scanatt"}}]
+			}]}]
+		}]
+	}`)
+	idx, err := BuildFileIndex(raw)
+	if err != nil {
+		t.Fatalf("BuildFileIndex() error: %v", err)
+	}
+
+	src := idx["source/license.json.sigstore"]
+	if src.Covers != "source/license.json" || src.subjectPath != "" {
+		t.Fatalf("source attestation covers %q / subject %q", src.Covers, src.subjectPath)
+	}
+	art := idx["artifacts/grype.json.sigstore"]
+	if art.Covers != "artifacts/grype.json" || art.subjectPath != "artifacts/app" || art.subjectSHA256 != "bin" {
+		t.Fatalf("target attestation covers %q / subject %q %q", art.Covers, art.subjectPath, art.subjectSHA256)
+	}
+	if rep := idx["artifacts/grype.json"]; rep.Covers != "" {
+		t.Fatalf("report Covers = %q, want empty", rep.Covers)
+	}
+}
@@ -3,6 +3,7 @@ package evidence
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -93,6 +94,9 @@ type fetchResult struct {
 	path string
 	file *EvidenceFile
 	err  string // empty on success
+
+	// transient is set when err came from S3 rather than verification
+	transient bool
 }
 
 // NewLoader creates a new evidence loader that will fetch artifacts
@@ -269,6 +273,9 @@ func (l *Loader) Load(ctx context.Context) (*Bundle, error) {
 		return nil, xerrors.Wrap(err, "fetch evidence files")
 	}
 
+	// attestation signatures and subjects (recorded on the refs, not fatal)
+	l.verifyAttestations(ctx, fileIndex, files)
+
 	elapsed := time.Since(start)
 
 	l.logger.Info(ctx, "evidence loading complete",
@@ -285,6 +292,7 @@ func (l *Loader) Load(ctx context.Context) (*Bundle, error) {
 		Signatures:           signatures,
 		InventoryRaw:         inventoryRaw,
 		InventoryHash:        actualInvHash,
+		ReleaseDigest:        cryptoutil.SHA256Hex(releaseRaw),
 		FileIndex:            fileIndex,
 		Files:                files,
 		Tooling:              tooling,
@@ -365,9 +373,11 @@ func (l *Loader) fetchAllFiles(ctx context.Context, prefix string, index map[str
 		retryResults := l.fetchWorkerBatch(ctx, prefix, failed, sem)
 
 		var errs []string
+		allTransient := true
 		for _, r := range retryResults {
 			if r.err != "" {
 				errs = append(errs, fmt.Sprintf("%s: %s", r.path, r.err))
+				allTransient = allTransient && r.transient
 				continue
 			}
 			files[r.path] = r.file
@@ -376,9 +386,14 @@ func (l *Loader) fetchAllFiles(ctx context.Context, prefix string, index map[str
 		}
 
 		if len(errs) > 0 {
-			return nil, 0, 0, xerrors.Newf(
+			err := xerrors.Newf(
 				"failed to fetch %d evidence file(s) after retry: %s",
 				len(errs), strings.Join(errs, "; "))
+			// a single hash mismatch makes the whole load fatal
+			if allTransient {
+				err = transient(err)
+			}
+			return nil, 0, 0, err
 		}
 	}
 
@@ -404,7 +419,7 @@ func (l *Loader) fetchWorkerBatch(ctx context.Context, prefix string,
 
 			data, err := l.fetchS3(ctx, prefix+wi.path, MaxEvidenceFileSize)
 			if err != nil {
-				results <- fetchResult{path: wi.path, err: err.Error()}
+				results <- fetchResult{path: wi.path, err: err.Error(), transient: errors.Is(err, ErrTransient)}
 				return
 			}
 
@@ -447,6 +462,17 @@ func (l *Loader) fetchWorkerBatch(ctx context.Context, prefix string,
 	return out
 }
 
+// FetchRelease returns the current release.json for the configured release,
+// unverified. The Watcher compares its digest to the loaded bundle's to skip
+// a full Load when nothing was republished.
+func (l *Loader) FetchRelease(ctx context.Context) ([]byte, error) {
+	raw, err := l.fetchS3(ctx, l.releasePrefix()+"release.json", MaxManifestSize)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "evidence fetch release.json")
+	}
+	return raw, nil
+}
+
 // fetchS3 downloads an S3 object with a size limit
 func (l *Loader) fetchS3(ctx context.Context, key string, maxSize int64) ([]byte, error) {
 	out, err := l.s3Client.GetObject(ctx, &s3.GetObjectInput{
@@ -454,14 +480,14 @@ func (l *Loader) fetchS3(ctx context.Context, key string, maxSize int64) ([]byte
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return nil, xerrors.Wrapf(err, "get s3://%s/%s", l.opts.Bucket, key)
+		return nil, transient(xerrors.Wrapf(err, "get s3://%s/%s", l.opts.Bucket, key))
 	}
 	defer out.Body.Close()
 
 	lr := io.LimitReader(out.Body, maxSize+1)
 	data, err := io.ReadAll(lr)
 	if err != nil {
-		return nil, xerrors.Wrapf(err, "read s3://%s/%s", l.opts.Bucket, key)
+		return nil, transient(xerrors.Wrapf(err, "read s3://%s/%s", l.opts.Bucket, key))
 	}
 	if int64(len(data)) > maxSize {
 		return nil, xerrors.Newf("s3://%s/%s exceeds size limit (%d bytes, max %d)",
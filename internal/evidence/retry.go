@@ -0,0 +1,99 @@
+package evidence
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// ErrTransient matches (via errors.Is) load failures caused by the S3
+// requests themselves: throttling, timeouts, dropped connections, missing
+// objects. Verification failures (signatures, hashes, release_id, size
+// limits) never match, so they fail closed on the first attempt.
+var ErrTransient = errors.New("transient evidence fetch failure")
+
+// transientError marks err as retryable without changing its message.
+type transientError struct{ err error }
+
+func (e *transientError) Error() string        { return e.err.Error() }
+func (e *transientError) Unwrap() error        { return e.err }
+func (e *transientError) Is(target error) bool { return target == ErrTransient }
+
+func transient(err error) error { return &transientError{err: err} }
+
+// RetryOptions bounds Loader.LoadRetry.
+type RetryOptions struct {
+	// Deadline caps the total time spent loading, attempts and waits
+	// included. Zero means 5 minutes.
+	Deadline time.Duration
+
+	// InitialBackoff is the wait after the first failed attempt, doubled per
+	// attempt up to MaxBackoff. Each wait is jittered down by up to half so
+	// instances started together don't retry in lockstep. Zero means 1s and
+	// 30s.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// OnRetry, when set, is called after each failed attempt that will be
+	// retried, before waiting.
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+func (o *RetryOptions) withDefaults() RetryOptions {
+	out := *o
+	if out.Deadline <= 0 {
+		out.Deadline = 5 * time.Minute
+	}
+	if out.InitialBackoff <= 0 {
+		out.InitialBackoff = time.Second
+	}
+	if out.MaxBackoff <= 0 {
+		out.MaxBackoff = 30 * time.Second
+	}
+	return out
+}
+
+// LoadRetry calls Load until it succeeds, it fails with an error that isn't
+// ErrTransient, or the deadline passes. It returns the last error on
+// failure.
+func (l *Loader) LoadRetry(ctx context.Context, opts RetryOptions) (*Bundle, error) {
+	return retryLoad(ctx, opts, l.Load)
+}
+
+func retryLoad(ctx context.Context, opts RetryOptions, load func(context.Context) (*Bundle, error)) (*Bundle, error) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, opts.Deadline)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	backoff := opts.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		bundle, err := load(ctx)
+		if err == nil {
+			return bundle, nil
+		}
+		if !errors.Is(err, ErrTransient) {
+			return nil, err
+		}
+
+		wait := backoff/2 + rand.N(backoff/2+1) //nolint:gosec // jitter, not security
+		if time.Until(deadline) <= wait {
+			return nil, xerrors.Wrapf(err, "evidence still unavailable after %d attempts in %s", attempt, opts.Deadline)
+		}
+		if opts.OnRetry != nil {
+			opts.OnRetry(attempt, err, wait)
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, xerrors.Wrap(err, "evidence load cancelled while retrying")
+		case <-t.C:
+		}
+		backoff = min(backoff*2, opts.MaxBackoff)
+	}
+}
@@ -0,0 +1,183 @@
+package evidence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+)
+
+func fastRetry() RetryOptions {
+	return RetryOptions{Deadline: 5 * time.Second, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond}
+}
+
+func TestRetryLoad_RetriesTransientThenSucceeds(t *testing.T) {
+	var attempts int
+	var retries []int
+	opts := fastRetry()
+	opts.OnRetry = func(attempt int, err error, wait time.Duration) {
+		retries = append(retries, attempt)
+		if !errors.Is(err, ErrTransient) {
+			t.Errorf("OnRetry err = %v, want transient", err)
+		}
+		if wait <= 0 || wait > opts.MaxBackoff {
+			t.Errorf("wait = %v, want within (0, %v]", wait, opts.MaxBackoff)
+		}
+	}
+
+	want := &Bundle{}
+	got, err := retryLoad(t.Context(), opts, func(context.Context) (*Bundle, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, transient(errors.New("throttled"))
+		}
+		return want, nil
+	})
+	if err != nil {
+		t.Fatalf("retryLoad: %v", err)
+	}
+	if got != want || attempts != 3 {
+		t.Fatalf("bundle = %p after %d attempts, want %p after 3", got, attempts, want)
+	}
+	if fmt.Sprint(retries) != "[1 2]" {
+		t.Fatalf("OnRetry attempts = %v, want [1 2]", retries)
+	}
+}
+
+func TestRetryLoad_FatalErrorFailsImmediately(t *testing.T) {
+	var attempts int
+	_, err := retryLoad(t.Context(), fastRetry(), func(context.Context) (*Bundle, error) {
+		attempts++
+		return nil, errors.New("release.json signature verification failed")
+	})
+	if err == nil || attempts != 1 {
+		t.Fatalf("err = %v after %d attempts, want failure after 1", err, attempts)
+	}
+}
+
+func TestRetryLoad_Deadline(t *testing.T) {
+	opts := RetryOptions{Deadline: 50 * time.Millisecond, InitialBackoff: 5 * time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	var attempts int
+	start := time.Now()
+	_, err := retryLoad(t.Context(), opts, func(context.Context) (*Bundle, error) {
+		attempts++
+		return nil, transient(errors.New("connection reset"))
+	})
+	if err == nil || !strings.Contains(err.Error(), "still unavailable") {
+		t.Fatalf("err = %v, want deadline failure", err)
+	}
+	if !errors.Is(err, ErrTransient) || !strings.Contains(err.Error(), "connection reset") {
+		t.Fatalf("err = %v, should wrap the last attempt's error", err)
+	}
+	if attempts < 2 {
+		t.Fatalf("attempts = %d, want retries before the deadline", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("took %v, deadline not honored", elapsed)
+	}
+}
+
+func TestRetryLoad_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	opts := RetryOptions{InitialBackoff: time.Hour, MaxBackoff: time.Hour, Deadline: 2 * time.Hour}
+	opts.OnRetry = func(int, error, time.Duration) { cancel() }
+	_, err := retryLoad(ctx, opts, func(context.Context) (*Bundle, error) {
+		return nil, transient(errors.New("timeout"))
+	})
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("err = %v, want cancellation", err)
+	}
+}
+
+func TestRetryOptions_Defaults(t *testing.T) {
+	got := (&RetryOptions{}).withDefaults()
+	if got.Deadline != 5*time.Minute || got.InitialBackoff != time.Second || got.MaxBackoff != 30*time.Second {
+		t.Fatalf("defaults = %+v", got)
+	}
+}
+
+func TestLoad_ErrorClassification(t *testing.T) {
+	prefix := testReleasePrefix()
+
+	cases := []struct {
+		name      string
+		setup     func(*fakeS3)
+		verifier  BlobVerifier
+		transient bool
+	}{
+		{
+			name:      "release.json fetch",
+			setup:     func(f *fakeS3) { f.failOn(prefix+"release.json", errors.New("SlowDown")) },
+			transient: true,
+		},
+		{
+			name: "evidence file fetch",
+			setup: func(f *fakeS3) {
+				populateWithEvidence(f)
+				f.failOn(prefix+"source/sbom.json", errors.New("RequestTimeout"))
+			},
+			transient: true,
+		},
+		{
+			name:     "signature",
+			setup:    func(f *fakeS3) { populateFakeS3(f) },
+			verifier: failVerifier("wrong key"),
+		},
+		{
+			name: "inventory hash",
+			setup: func(f *fakeS3) {
+				f.put(prefix+"inventory.json", emptyInventoryJSON())
+				f.putJSON(prefix+"release.json", validReleaseManifest(strings.Repeat("0", 64)))
+				putReleaseSigBundles(f, prefix, []byte(`{}`))
+			},
+		},
+		{
+			name: "evidence file hash",
+			setup: func(f *fakeS3) {
+				invData := inventoryWithFile(strings.Repeat("0", 64), 100)
+				f.put(prefix+"inventory.json", invData)
+				f.putJSON(prefix+"release.json", validReleaseManifest(cryptoutil.SHA256Hex(invData)))
+				putReleaseSigBundles(f, prefix, []byte(`{}`))
+				f.put(prefix+"source/sbom.json", []byte(`{"tampered":"data"}`))
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := newFakeS3()
+			tc.setup(fake)
+			verifier := tc.verifier
+			if verifier == nil {
+				verifier = passVerifier()
+			}
+			_, err := newTestLoader(fake, verifier).Load(t.Context())
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := errors.Is(err, ErrTransient); got != tc.transient {
+				t.Fatalf("transient = %v, want %v (err: %v)", got, tc.transient, err)
+			}
+		})
+	}
+}
+
+func TestLoadRetry_RecoversFromThrottle(t *testing.T) {
+	fake := newFakeS3()
+	populateFakeS3(fake)
+	fake.failOnceOn(testReleasePrefix() + "release.json")
+
+	var retried int
+	opts := fastRetry()
+	opts.OnRetry = func(int, error, time.Duration) { retried++ }
+	bundle, err := newTestLoader(fake, passVerifier()).LoadRetry(t.Context(), opts)
+	if err != nil {
+		t.Fatalf("LoadRetry: %v", err)
+	}
+	if bundle == nil || retried != 1 {
+		t.Fatalf("bundle = %v, retries = %d, want a bundle after 1 retry", bundle, retried)
+	}
+}
@@ -0,0 +1,129 @@
+package evidence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// Self-attestation statuses
+const (
+	SelfAttestMatch    = "match"
+	SelfAttestMismatch = "mismatch"
+	SelfAttestUnknown  = "unknown"
+)
+
+// procSelfExe is the running image on Linux. Opening it reads the file the
+// process was started from even if the path has since been replaced or
+// removed by a deploy, which os.Executable's path would not.
+const procSelfExe = "/proc/self/exe"
+
+// Executable is the running binary's digest, hashed once at startup.
+type Executable struct {
+	Path     string
+	SHA256   string
+	HashedAt time.Time
+
+	// Err is set when the binary could not be hashed; every attestation
+	// then reports unknown.
+	Err error
+}
+
+// SelfAttestation compares the running binary to the release.json artifact
+// for its platform.
+type SelfAttestation struct {
+	Status     string    `json:"status"`
+	Platform   string    `json:"platform"`
+	Executable string    `json:"executable,omitempty"`
+	SHA256     string    `json:"sha256,omitempty"`
+	Expected   string    `json:"expected_sha256,omitempty"`
+	Artifact   string    `json:"artifact,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	HashedAt   time.Time `json:"hashed_at,omitzero"`
+}
+
+// HashExecutable hashes the running binary through /proc/self/exe, falling
+// back to os.Executable where procfs isn't available.
+func HashExecutable() *Executable {
+	path, perr := os.Executable()
+	if _, err := os.Stat(procSelfExe); err == nil {
+		e := hashExecutableAt(procSelfExe)
+		e.Path = path
+		return e
+	}
+	if perr != nil {
+		return &Executable{HashedAt: time.Now().UTC(), Err: xerrors.Wrap(perr, "locate running executable")}
+	}
+	return hashExecutableAt(path)
+}
+
+func hashExecutableAt(path string) *Executable {
+	e := &Executable{Path: path, HashedAt: time.Now().UTC()}
+	f, err := os.Open(path)
+	if err != nil {
+		e.Err = xerrors.Wrap(err, "open running executable")
+		return e
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		e.Err = xerrors.Wrap(err, "hash running executable")
+		return e
+	}
+	e.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return e
+}
+
+// Attest compares e to the artifact b's release lists for platform. The
+// result is unknown when the binary couldn't be hashed, no evidence is loaded
+// or release.json has no digest for the platform.
+func (e *Executable) Attest(b *Bundle, platform string) *SelfAttestation {
+	res := &SelfAttestation{
+		Status:     SelfAttestUnknown,
+		Platform:   platform,
+		Executable: e.Path,
+		SHA256:     e.SHA256,
+		HashedAt:   e.HashedAt,
+	}
+	if e.Err != nil {
+		res.Reason = e.Err.Error()
+		return res
+	}
+	if b == nil || b.Release == nil {
+		res.Reason = "no evidence loaded"
+		return res
+	}
+	art, ok := releaseArtifact(b.Release, platform)
+	if !ok {
+		res.Reason = "release.json has no artifact for " + platform
+		return res
+	}
+	res.Artifact = art.Binary.Path
+	res.Expected = art.Binary.SHA256
+	if res.Expected == "" {
+		res.Reason = "release.json artifact has no sha256"
+		return res
+	}
+	if cryptoutil.HashEqual(e.SHA256, res.Expected) {
+		res.Status = SelfAttestMatch
+		return res
+	}
+	res.Status = SelfAttestMismatch
+	res.Reason = "running executable does not match the released artifact"
+	return res
+}
+
+func releaseArtifact(rel *ReleaseManifest, platform string) (ReleaseArtifact, bool) {
+	for _, a := range rel.Artifacts {
+		if a.OS+"/"+a.Arch == platform {
+			return a, true
+		}
+	}
+	return ReleaseArtifact{}, false
+}
@@ -0,0 +1,137 @@
+package evidence
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+)
+
+// writeExecutable writes data to a temp file and hashes it like the running binary.
+func writeExecutable(t *testing.T, data []byte) *Executable {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "server")
+	if err := os.WriteFile(path, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return hashExecutableAt(path)
+}
+
+func bundleWithArtifact(platform, sha256 string) *Bundle {
+	osName, arch, _ := strings.Cut(platform, "/")
+	return &Bundle{Release: &ReleaseManifest{
+		Artifacts: []ReleaseArtifact{
+			{OS: "plan9", Arch: "386", Binary: BinaryRef{Path: "bin/plan9-386/server", SHA256: "ffff"}},
+			{OS: osName, Arch: arch, Binary: BinaryRef{Path: "bin/" + osName + "-" + arch + "/server", SHA256: sha256}},
+		},
+	}}
+}
+
+func TestHashExecutableAt(t *testing.T) {
+	data := []byte("\x7fELF not really")
+	e := writeExecutable(t, data)
+	if e.Err != nil {
+		t.Fatalf("Err = %v", e.Err)
+	}
+	if e.SHA256 != cryptoutil.SHA256Hex(data) {
+		t.Fatalf("SHA256 = %s, want %s", e.SHA256, cryptoutil.SHA256Hex(data))
+	}
+	if e.HashedAt.IsZero() {
+		t.Fatal("HashedAt not set")
+	}
+}
+
+func TestHashExecutableAt_Missing(t *testing.T) {
+	e := hashExecutableAt(filepath.Join(t.TempDir(), "gone"))
+	if e.Err == nil {
+		t.Fatal("expected error for a missing executable")
+	}
+	if e.SHA256 != "" {
+		t.Fatalf("SHA256 = %q, want empty", e.SHA256)
+	}
+}
+
+func TestHashExecutable_Self(t *testing.T) {
+	e := HashExecutable()
+	if e.Err != nil {
+		t.Fatalf("Err = %v", e.Err)
+	}
+	path, err := os.Executable()
+	if err != nil {
+		t.Skip("os.Executable unavailable")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.SHA256 != cryptoutil.SHA256Hex(data) {
+		t.Fatal("digest of the running test binary does not match its file")
+	}
+	if e.Path != path {
+		t.Fatalf("Path = %q, want %q", e.Path, path)
+	}
+}
+
+func TestAttest_Match(t *testing.T) {
+	e := writeExecutable(t, []byte("release build"))
+	res := e.Attest(bundleWithArtifact("linux/amd64", e.SHA256), "linux/amd64")
+	if res.Status != SelfAttestMatch {
+		t.Fatalf("Status = %q (%s), want match", res.Status, res.Reason)
+	}
+	if res.Artifact != "bin/linux-amd64/server" || res.Expected != e.SHA256 {
+		t.Fatalf("artifact = %q expected = %q", res.Artifact, res.Expected)
+	}
+	if res.Reason != "" {
+		t.Fatalf("Reason = %q, want empty", res.Reason)
+	}
+}
+
+func TestAttest_Mismatch(t *testing.T) {
+	e := writeExecutable(t, []byte("rebuilt locally"))
+	want := cryptoutil.SHA256Hex([]byte("release build"))
+	res := e.Attest(bundleWithArtifact("linux/arm64", want), "linux/arm64")
+	if res.Status != SelfAttestMismatch {
+		t.Fatalf("Status = %q, want mismatch", res.Status)
+	}
+	if res.Expected != want || res.SHA256 != e.SHA256 {
+		t.Fatalf("expected = %q sha256 = %q", res.Expected, res.SHA256)
+	}
+}
+
+func TestAttest_Unknown(t *testing.T) {
+	e := writeExecutable(t, []byte("release build"))
+	tests := []struct {
+		name   string
+		exe    *Executable
+		bundle *Bundle
+		reason string
+	}{
+		{"no evidence", e, nil, "no evidence loaded"},
+		{"no release", e, &Bundle{}, "no evidence loaded"},
+		{"other platform only", e, bundleWithArtifact("darwin/arm64", e.SHA256), "no artifact for linux/amd64"},
+		{"artifact without digest", e, bundleWithArtifact("linux/amd64", ""), "no sha256"},
+		{"hash failed", hashExecutableAt(filepath.Join(t.TempDir(), "gone")), bundleWithArtifact("linux/amd64", e.SHA256), "open running executable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.exe.Attest(tt.bundle, "linux/amd64")
+			if res.Status != SelfAttestUnknown {
+				t.Fatalf("Status = %q, want unknown", res.Status)
+			}
+			if !strings.Contains(res.Reason, tt.reason) {
+				t.Fatalf("Reason = %q, want it to contain %q", res.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestAttest_AfterPlatformFilter(t *testing.T) {
+	e := writeExecutable(t, []byte("release build"))
+	b := bundleWithArtifact(RuntimePlatform(), e.SHA256)
+	b = FilterBundleByPlatform(b, RuntimePlatform())
+	if res := e.Attest(b, RuntimePlatform()); res.Status != SelfAttestMatch {
+		t.Fatalf("Status = %q (%s), want match", res.Status, res.Reason)
+	}
+}
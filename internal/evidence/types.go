@@ -324,6 +324,11 @@ type VulnFinding struct {
 	Title            string   `json:"title,omitempty"`
 	SourceURL        string   `json:"source_url,omitempty"`
 	Scanners         []string `json:"scanners"`
+
+	// Aliases are the other IDs scanners reported this finding under
+	// (GHSA-*, GO-*); set on findings parsed from scan reports
+	Aliases []string `json:"aliases,omitempty"`
+	Scope   string   `json:"scope,omitempty"` // "source" or "artifact"; set on findings parsed from scan reports
 }
 
 // EvidenceFileRef is a flattened reference to any evidence file in the inventory
@@ -337,6 +342,36 @@ type EvidenceFileRef struct {
 	Category string `json:"category"`           // "sbom", "scan", "license"
 	Kind     string `json:"kind"`               // "report" or "attestation"
 	Platform string `json:"platform,omitempty"` // "linux/arm64" or "linux/amd64"
+
+	// Scanner and Format identify the tool and output format of a scan
+	// report ("grype", "json"); empty for other categories
+	Scanner string `json:"scanner,omitempty"`
+	Format  string `json:"format,omitempty"`
+
+	// Covers is the report an attestation was published with (attestations only)
+	Covers string `json:"covers,omitempty"`
+
+	// Attestation is the load-time DSSE verification of an attestation file.
+	// nil for reports, and when no attestation verifier is configured.
+	Attestation *AttestationResult `json:"attestation,omitempty"`
+
+	// the target binary an artifact attestation may name instead of its report
+	subjectPath   string
+	subjectSHA256 string
+}
+
+// AttestationResult records how an attestation's DSSE envelope and in-toto
+// subject checked out when the evidence was loaded.
+type AttestationResult struct {
+	Verified      bool   `json:"verified"`
+	Signer        string `json:"signer,omitempty"` // "kms" or "keyless"
+	PredicateType string `json:"predicate_type,omitempty"`
+
+	// Subject is the inventory path whose sha256 matched an in-toto subject:
+	// the covered report or the target binary
+	Subject string `json:"subject,omitempty"`
+
+	Error string `json:"error,omitempty"`
 }
 
 // EvidenceFile is an evidence file that has been fetched and hash-verified
@@ -368,6 +403,11 @@ type Bundle struct {
 	// verified hash of fetched inventory.json
 	InventoryHash string
 
+	// ReleaseDigest is the sha256 of release.json as fetched and verified,
+	// before any platform filtering of ReleaseRaw. The Watcher compares it
+	// to decide whether a refresh has anything new.
+	ReleaseDigest string
+
 	// flat index: inventory path -> file reference
 	FileIndex map[string]*EvidenceFileRef
 
@@ -445,6 +485,11 @@ type AttestationCounts struct {
 	SBOMAttested    bool
 	ScanAttested    bool
 	LicenseAttested bool
+
+	// Verified and Failed count attestations whose load-time DSSE check
+	// passed or failed; attestations never checked count in neither
+	Verified int
+	Failed   int
 }
 
 // Attestations counts attestation files in the index by scope and category
@@ -464,6 +509,13 @@ func (b *Bundle) Attestations() AttestationCounts {
 		case "artifact":
 			c.Artifact++
 		}
+		if r := ref.Attestation; r != nil {
+			if r.Verified {
+				c.Verified++
+			} else {
+				c.Failed++
+			}
+		}
 		switch ref.Category {
 		case "sbom":
 			c.SBOMAttested = true
@@ -0,0 +1,444 @@
+package evidence
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// severityRank orders normalized severities; higher is worse.
+var severityRank = map[string]int{
+	"critical":   5,
+	"high":       4,
+	"medium":     3,
+	"low":        2,
+	"negligible": 1,
+	"unknown":    0,
+}
+
+// NormalizeSeverity maps a scanner's severity ("High", "CRITICAL",
+// "moderate") onto the lower-case names used in VulnCounts. Anything
+// unrecognised is "unknown".
+func NormalizeSeverity(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "moderate" {
+		return "medium"
+	}
+	if _, ok := severityRank[s]; !ok {
+		return "unknown"
+	}
+	return s
+}
+
+// ValidSeverity reports whether s is a normalized severity name.
+func ValidSeverity(s string) bool {
+	_, ok := severityRank[s]
+	return ok
+}
+
+// VulnScanReport describes one scan report the findings were parsed from.
+type VulnScanReport struct {
+	Path     string `json:"path"`
+	Scanner  string `json:"scanner"`
+	Scope    string `json:"scope"`
+	Platform string `json:"platform,omitempty"`
+	Findings int    `json:"findings"`
+	Error    string `json:"error,omitempty"`
+}
+
+// VulnFindings parses every JSON scan report in the bundle and returns the
+// merged findings (see MergeVulnFindings) along with what was parsed from
+// each report. A report that fails to parse is listed with its error and
+// contributes no findings.
+func (b *Bundle) VulnFindings() ([]VulnFinding, []VulnScanReport) {
+	if b == nil {
+		return nil, nil
+	}
+	var refs []*EvidenceFileRef
+	for _, ref := range b.FileIndex {
+		if ref.Category != "scan" || ref.Kind != "report" {
+			continue
+		}
+		// the same results are often also published as SARIF or tables
+		if ref.Format != "" && ref.Format != "json" {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Path < refs[j].Path })
+
+	var all []VulnFinding
+	reports := make([]VulnScanReport, 0, len(refs))
+	for _, ref := range refs {
+		rep := VulnScanReport{
+			Path:     ref.Path,
+			Scanner:  ref.Scanner,
+			Scope:    ref.Scope,
+			Platform: ref.Platform,
+		}
+		f, ok := b.File(ref.Path)
+		if !ok {
+			rep.Error = "report not loaded"
+			reports = append(reports, rep)
+			continue
+		}
+		findings, err := ParseScanReport(ref.Scanner, f.Data)
+		if err != nil {
+			rep.Error = err.Error()
+			reports = append(reports, rep)
+			continue
+		}
+		for i := range findings {
+			findings[i].Scope = ref.Scope
+		}
+		rep.Findings = len(findings)
+		reports = append(reports, rep)
+		all = append(all, findings...)
+	}
+	return MergeVulnFindings(all), reports
+}
+
+// ParseScanReport parses a scanner's native JSON report.
+func ParseScanReport(scanner string, data []byte) ([]VulnFinding, error) {
+	switch scanner {
+	case "grype":
+		return ParseGrypeReport(data)
+	case "trivy":
+		return ParseTrivyReport(data)
+	case "govulncheck":
+		return ParseGovulncheckReport(data)
+	}
+	return nil, xerrors.Newf("unsupported scanner %q", scanner)
+}
+
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID         string `json:"id"`
+			Severity   string `json:"severity"`
+			DataSource string `json:"dataSource"`
+			Fix        struct {
+				Versions []string `json:"versions"`
+				State    string   `json:"state"`
+			} `json:"fix"`
+		} `json:"vulnerability"`
+		RelatedVulnerabilities []struct {
+			ID string `json:"id"`
+		} `json:"relatedVulnerabilities"`
+		Artifact struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+// ParseGrypeReport parses grype's `-o json` output.
+func ParseGrypeReport(data []byte) ([]VulnFinding, error) {
+	var r grypeReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, xerrors.Wrap(err, "parse grype report")
+	}
+	out := make([]VulnFinding, 0, len(r.Matches))
+	for _, m := range r.Matches {
+		v := m.Vulnerability
+		f := VulnFinding{
+			ID:               v.ID,
+			Severity:         NormalizeSeverity(v.Severity),
+			Package:          m.Artifact.Name,
+			InstalledVersion: m.Artifact.Version,
+			SourceURL:        v.DataSource,
+			Scanners:         []string{"grype"},
+		}
+		if v.Fix.State == "fixed" && len(v.Fix.Versions) > 0 {
+			f.FixedVersion = v.Fix.Versions[0]
+		}
+		for _, rel := range m.RelatedVulnerabilities {
+			f.Aliases = append(f.Aliases, rel.ID)
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+type trivyReport struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			FixedVersion     string `json:"FixedVersion"`
+			Severity         string `json:"Severity"`
+			Title            string `json:"Title"`
+			PrimaryURL       string `json:"PrimaryURL"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// ParseTrivyReport parses trivy's `--format json` output.
+func ParseTrivyReport(data []byte) ([]VulnFinding, error) {
+	var r trivyReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, xerrors.Wrap(err, "parse trivy report")
+	}
+	var out []VulnFinding
+	for _, res := range r.Results {
+		for _, v := range res.Vulnerabilities {
+			out = append(out, VulnFinding{
+				ID:               v.VulnerabilityID,
+				Severity:         NormalizeSeverity(v.Severity),
+				Package:          v.PkgName,
+				InstalledVersion: v.InstalledVersion,
+				FixedVersion:     v.FixedVersion,
+				Title:            v.Title,
+				SourceURL:        v.PrimaryURL,
+				Scanners:         []string{"trivy"},
+			})
+		}
+	}
+	return out, nil
+}
+
+// govulncheckMessage is one object of govulncheck's `-json` stream. Only the
+// osv and finding messages are used.
+type govulncheckMessage struct {
+	OSV *struct {
+		ID               string   `json:"id"`
+		Aliases          []string `json:"aliases"`
+		Summary          string   `json:"summary"`
+		DatabaseSpecific struct {
+			URL string `json:"url"`
+		} `json:"database_specific"`
+	} `json:"osv"`
+	Finding *struct {
+		OSV          string `json:"osv"`
+		FixedVersion string `json:"fixed_version"`
+		Trace        []struct {
+			Module  string `json:"module"`
+			Version string `json:"version"`
+		} `json:"trace"`
+	} `json:"finding"`
+}
+
+// ParseGovulncheckReport parses govulncheck's `-json` message stream. The
+// Go vulnerability database carries no severity, so findings are "unknown"
+// unless another scanner rates them when merged. govulncheck reports a
+// finding at module, package and symbol level; each becomes one finding per
+// OSV entry and module.
+func ParseGovulncheckReport(data []byte) ([]VulnFinding, error) {
+	type osvInfo struct {
+		aliases []string
+		summary string
+		url     string
+	}
+	osvs := make(map[string]osvInfo)
+	seen := make(map[string]bool)
+	var out []VulnFinding
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var msg govulncheckMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, xerrors.Wrap(err, "parse govulncheck report")
+		}
+		if o := msg.OSV; o != nil {
+			osvs[o.ID] = osvInfo{aliases: o.Aliases, summary: o.Summary, url: o.DatabaseSpecific.URL}
+		}
+		if f := msg.Finding; f != nil && len(f.Trace) > 0 {
+			// trace[0] is the vulnerable module
+			mod := f.Trace[0]
+			key := f.OSV + "\x00" + mod.Module
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, VulnFinding{
+				ID:               f.OSV,
+				Severity:         "unknown",
+				Package:          mod.Module,
+				InstalledVersion: mod.Version,
+				FixedVersion:     f.FixedVersion,
+				Scanners:         []string{"govulncheck"},
+			})
+		}
+	}
+	// osv entries normally precede their findings, but don't rely on it
+	for i := range out {
+		if info, ok := osvs[out[i].ID]; ok {
+			out[i].Aliases = info.aliases
+			out[i].Title = info.summary
+			out[i].SourceURL = info.url
+		}
+		if out[i].SourceURL == "" {
+			out[i].SourceURL = "https://pkg.go.dev/vuln/" + out[i].ID
+		}
+	}
+	return out, nil
+}
+
+// MergeVulnFindings deduplicates findings reported by several scanners, or
+// by one scanner in several reports. Findings in the same scope and package
+// merge when their IDs or aliases overlap, so grype's GHSA, trivy's CVE and
+// govulncheck's GO- entry for the same issue become one finding. Its ID is
+// the lexicographically smallest CVE among them, else the smallest GHSA,
+// else the first ID in input order, so it doesn't depend on which scanner
+// reported first; it keeps the highest severity and the union of scanners
+// and aliases. Results are ordered by severity, then ID and package.
+func MergeVulnFindings(findings []VulnFinding) []VulnFinding {
+	parent := make([]int, len(findings))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	owner := make(map[string]int, len(findings))
+	for i := range findings {
+		f := &findings[i]
+		for _, id := range append([]string{f.ID}, f.Aliases...) {
+			if id == "" {
+				continue
+			}
+			key := f.Scope + "\x00" + f.Package + "\x00" + id
+			if j, ok := owner[key]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				owner[key] = i
+			}
+		}
+	}
+
+	groups := make(map[int]*VulnFinding, len(findings))
+	var order []int
+	for i := range findings {
+		f := &findings[i]
+		root := find(i)
+		m, ok := groups[root]
+		if !ok {
+			m = &VulnFinding{Severity: "unknown", Package: f.Package, Scope: f.Scope}
+			groups[root] = m
+			order = append(order, root)
+		}
+		if sev := NormalizeSeverity(f.Severity); severityRank[sev] > severityRank[m.Severity] {
+			m.Severity = sev
+		}
+		if m.InstalledVersion == "" {
+			m.InstalledVersion = f.InstalledVersion
+		}
+		if m.FixedVersion == "" {
+			m.FixedVersion = f.FixedVersion
+		}
+		if m.Title == "" {
+			m.Title = f.Title
+		}
+		if m.SourceURL == "" {
+			m.SourceURL = f.SourceURL
+		}
+		m.Scanners = appendUnique(m.Scanners, f.Scanners...)
+		// collect every ID here; the canonical one is picked out below
+		m.Aliases = appendUnique(m.Aliases, f.ID)
+		m.Aliases = appendUnique(m.Aliases, f.Aliases...)
+	}
+
+	out := make([]VulnFinding, 0, len(order))
+	for _, root := range order {
+		m := groups[root]
+		ids := m.Aliases
+		m.ID = canonicalVulnID(ids)
+		m.Aliases = nil
+		for _, id := range ids {
+			if id != m.ID {
+				m.Aliases = append(m.Aliases, id)
+			}
+		}
+		sort.Strings(m.Aliases)
+		sort.Strings(m.Scanners)
+		out = append(out, *m)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if ra, rb := severityRank[a.Severity], severityRank[b.Severity]; ra != rb {
+			return ra > rb
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Scope < b.Scope
+	})
+	return out
+}
+
+// CountVulnFindings tallies findings by severity.
+func CountVulnFindings(findings []VulnFinding) VulnCounts {
+	var c VulnCounts
+	for i := range findings {
+		switch NormalizeSeverity(findings[i].Severity) {
+		case "critical":
+			c.Critical++
+		case "high":
+			c.High++
+		case "medium":
+			c.Medium++
+		case "low":
+			c.Low++
+		case "negligible":
+			c.Negligible++
+		default:
+			c.Unknown++
+		}
+	}
+	return c
+}
+
+// canonicalVulnID picks the ID a merged finding is reported under: the
+// smallest CVE in ids, else the smallest GHSA, else ids[0].
+func canonicalVulnID(ids []string) string {
+	for _, prefix := range []string{"CVE-", "GHSA-"} {
+		best := ""
+		for _, id := range ids {
+			if strings.HasPrefix(id, prefix) && (best == "" || id < best) {
+				best = id
+			}
+		}
+		if best != "" {
+			return best
+		}
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[0]
+}
+
+func appendUnique(dst []string, vals ...string) []string {
+	for _, v := range vals {
+		if v == "" {
+			continue
+		}
+		dup := false
+		for _, d := range dst {
+			if d == v {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,309 @@
+package evidence
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const grypeJSON = `{
+	"matches": [
+		{
+			"vulnerability": {
+				"id": "GHSA-qppj-fm5r-hxr3",
+				"severity": "High",
+				"dataSource": "https://github.com/advisories/GHSA-qppj-fm5r-hxr3",
+				"fix": {"versions": ["0.23.0"], "state": "fixed"}
+			},
+			"relatedVulnerabilities": [{"id": "CVE-2023-44487"}],
+			"artifact": {"name": "golang.org/x/net", "version": "v0.17.0", "type": "go-module"}
+		},
+		{
+			"vulnerability": {
+				"id": "CVE-2024-0001",
+				"severity": "Negligible",
+				"dataSource": "https://nvd.nist.gov/vuln/detail/CVE-2024-0001",
+				"fix": {"versions": [], "state": "not-fixed"}
+			},
+			"artifact": {"name": "example.com/lib", "version": "v1.0.0"}
+		}
+	]
+}`
+
+const trivyJSON = `{
+	"SchemaVersion": 2,
+	"Results": [
+		{
+			"Target": "server",
+			"Class": "lang-pkgs",
+			"Type": "gobinary",
+			"Vulnerabilities": [
+				{
+					"VulnerabilityID": "CVE-2023-44487",
+					"PkgName": "golang.org/x/net",
+					"InstalledVersion": "v0.17.0",
+					"FixedVersion": "0.17.0",
+					"Severity": "MEDIUM",
+					"Title": "HTTP/2 rapid reset can cause excessive work",
+					"PrimaryURL": "https://avd.aquasec.com/nvd/cve-2023-44487"
+				}
+			]
+		},
+		{"Target": "go.mod", "Class": "lang-pkgs"}
+	]
+}`
+
+const govulncheckJSON = `{"config": {"protocol_version": "v1.0.0", "scanner_name": "govulncheck"}}
+{"osv": {"id": "GO-2023-2102", "aliases": ["CVE-2023-44487", "GHSA-qppj-fm5r-hxr3"], "summary": "HTTP/2 rapid reset can cause excessive work in net/http", "database_specific": {"url": "https://pkg.go.dev/vuln/GO-2023-2102"}}}
+{"finding": {"osv": "GO-2023-2102", "fixed_version": "v0.17.0", "trace": [{"module": "golang.org/x/net", "version": "v0.16.0"}]}}
+{"finding": {"osv": "GO-2023-2102", "fixed_version": "v0.17.0", "trace": [{"module": "golang.org/x/net", "version": "v0.16.0", "package": "golang.org/x/net/http2", "function": "ServeConn"}]}}
+{"finding": {"osv": "GO-2024-9999", "trace": [{"module": "stdlib", "version": "v1.22.0"}]}}
+`
+
+func TestNormalizeSeverity(t *testing.T) {
+	tests := map[string]string{
+		"Critical":   "critical",
+		"HIGH":       "high",
+		" medium ":   "medium",
+		"moderate":   "medium",
+		"Low":        "low",
+		"Negligible": "negligible",
+		"":           "unknown",
+		"severe":     "unknown",
+	}
+	for in, want := range tests {
+		if got := NormalizeSeverity(in); got != want {
+			t.Errorf("NormalizeSeverity(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseGrypeReport(t *testing.T) {
+	got, err := ParseGrypeReport([]byte(grypeJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d findings, want 2", len(got))
+	}
+	f := got[0]
+	if f.ID != "GHSA-qppj-fm5r-hxr3" || f.Severity != "high" || f.Package != "golang.org/x/net" || f.InstalledVersion != "v0.17.0" {
+		t.Fatalf("finding = %+v", f)
+	}
+	if f.FixedVersion != "0.23.0" {
+		t.Errorf("FixedVersion = %q, want 0.23.0", f.FixedVersion)
+	}
+	if !reflect.DeepEqual(f.Aliases, []string{"CVE-2023-44487"}) {
+		t.Errorf("Aliases = %v", f.Aliases)
+	}
+	if !reflect.DeepEqual(f.Scanners, []string{"grype"}) {
+		t.Errorf("Scanners = %v", f.Scanners)
+	}
+	if got[1].FixedVersion != "" {
+		t.Errorf("not-fixed finding has FixedVersion %q", got[1].FixedVersion)
+	}
+	if got[1].Severity != "negligible" {
+		t.Errorf("Severity = %q, want negligible", got[1].Severity)
+	}
+}
+
+func TestParseTrivyReport(t *testing.T) {
+	got, err := ParseTrivyReport([]byte(trivyJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d findings, want 1", len(got))
+	}
+	f := got[0]
+	if f.ID != "CVE-2023-44487" || f.Severity != "medium" || f.FixedVersion != "0.17.0" {
+		t.Fatalf("finding = %+v", f)
+	}
+	if f.Title == "" || f.SourceURL == "" {
+		t.Errorf("title/url not parsed: %+v", f)
+	}
+}
+
+func TestParseGovulncheckReport(t *testing.T) {
+	got, err := ParseGovulncheckReport([]byte(govulncheckJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// module- and symbol-level findings for the same osv collapse to one
+	if len(got) != 2 {
+		t.Fatalf("got %d findings, want 2: %+v", len(got), got)
+	}
+	f := got[0]
+	if f.ID != "GO-2023-2102" || f.Package != "golang.org/x/net" || f.InstalledVersion != "v0.16.0" || f.FixedVersion != "v0.17.0" {
+		t.Fatalf("finding = %+v", f)
+	}
+	if f.Severity != "unknown" {
+		t.Errorf("Severity = %q, want unknown", f.Severity)
+	}
+	if !reflect.DeepEqual(f.Aliases, []string{"CVE-2023-44487", "GHSA-qppj-fm5r-hxr3"}) {
+		t.Errorf("Aliases = %v", f.Aliases)
+	}
+	if f.Title == "" || f.SourceURL != "https://pkg.go.dev/vuln/GO-2023-2102" {
+		t.Errorf("title/url = %q/%q", f.Title, f.SourceURL)
+	}
+	// no osv message: falls back to the pkg.go.dev URL
+	if got[1].SourceURL != "https://pkg.go.dev/vuln/GO-2024-9999" {
+		t.Errorf("fallback url = %q", got[1].SourceURL)
+	}
+}
+
+func TestParseScanReport_Errors(t *testing.T) {
+	if _, err := ParseScanReport("snyk", []byte(`{}`)); err == nil || !strings.Contains(err.Error(), "unsupported scanner") {
+		t.Fatalf("err = %v, want unsupported scanner", err)
+	}
+	for _, scanner := range []string{"grype", "trivy", "govulncheck"} {
+		if _, err := ParseScanReport(scanner, []byte(`{bad`)); err == nil {
+			t.Errorf("%s: expected error for invalid JSON", scanner)
+		}
+	}
+}
+
+func TestMergeVulnFindings_AcrossScanners(t *testing.T) {
+	var all []VulnFinding
+	for scanner, raw := range map[string]string{"grype": grypeJSON, "trivy": trivyJSON, "govulncheck": govulncheckJSON} {
+		f, err := ParseScanReport(scanner, []byte(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, f...)
+	}
+	got := MergeVulnFindings(all)
+	if len(got) != 3 {
+		t.Fatalf("got %d findings, want 3: %+v", len(got), got)
+	}
+
+	f := got[0]
+	if f.ID != "CVE-2023-44487" || f.Package != "golang.org/x/net" {
+		t.Fatalf("first finding = %+v, want CVE-2023-44487 in golang.org/x/net", f)
+	}
+	if f.Severity != "high" {
+		t.Errorf("Severity = %q, want the highest reported (high)", f.Severity)
+	}
+	if !reflect.DeepEqual(f.Scanners, []string{"govulncheck", "grype", "trivy"}) {
+		t.Errorf("Scanners = %v", f.Scanners)
+	}
+	if !reflect.DeepEqual(f.Aliases, []string{"GHSA-qppj-fm5r-hxr3", "GO-2023-2102"}) {
+		t.Errorf("Aliases = %v", f.Aliases)
+	}
+	if f.Title == "" {
+		t.Error("Title not carried over from trivy/govulncheck")
+	}
+
+	if got[1].Severity != "negligible" || got[2].Severity != "unknown" {
+		t.Errorf("order = %s, %s; want negligible then unknown", got[1].Severity, got[2].Severity)
+	}
+}
+
+func TestMergeVulnFindings_TransitiveAliases(t *testing.T) {
+	// grype only knows the GHSA; govulncheck links it to the CVE trivy uses
+	got := MergeVulnFindings([]VulnFinding{
+		{ID: "GHSA-aaaa", Package: "p", Severity: "low", Scanners: []string{"grype"}},
+		{ID: "CVE-2024-1", Package: "p", Severity: "medium", Scanners: []string{"trivy"}},
+		{ID: "GO-2024-1", Package: "p", Aliases: []string{"CVE-2024-1", "GHSA-aaaa"}, Scanners: []string{"govulncheck"}},
+	})
+	if len(got) != 1 {
+		t.Fatalf("got %d findings, want 1: %+v", len(got), got)
+	}
+	if got[0].ID != "CVE-2024-1" || got[0].Severity != "medium" || len(got[0].Scanners) != 3 {
+		t.Fatalf("merged = %+v", got[0])
+	}
+}
+
+func TestMergeVulnFindings_KeepsScopesAndPackagesApart(t *testing.T) {
+	got := MergeVulnFindings([]VulnFinding{
+		{ID: "CVE-1", Package: "a", Scope: "source", Scanners: []string{"grype"}},
+		{ID: "CVE-1", Package: "a", Scope: "artifact", Scanners: []string{"grype"}},
+		{ID: "CVE-1", Package: "b", Scope: "source", Scanners: []string{"grype"}},
+		{ID: "CVE-1", Package: "a", Scope: "source", Scanners: []string{"grype"}},
+	})
+	if len(got) != 3 {
+		t.Fatalf("got %d findings, want 3: %+v", len(got), got)
+	}
+	for _, f := range got {
+		if len(f.Scanners) != 1 || len(f.Aliases) != 0 {
+			t.Errorf("finding %+v: duplicate scanners or self-alias", f)
+		}
+	}
+}
+
+func TestCountVulnFindings(t *testing.T) {
+	c := CountVulnFindings([]VulnFinding{
+		{Severity: "critical"}, {Severity: "high"}, {Severity: "high"}, {Severity: "bogus"},
+	})
+	if c.Critical != 1 || c.High != 2 || c.Unknown != 1 || c.Medium != 0 {
+		t.Fatalf("counts = %+v", c)
+	}
+}
+
+func TestBundleVulnFindings(t *testing.T) {
+	b := &Bundle{FileIndex: map[string]*EvidenceFileRef{}, Files: map[string]*EvidenceFile{}}
+	add := func(path, scope, scanner, format string, data string) {
+		ref := &EvidenceFileRef{Path: path, Scope: scope, Category: "scan", Kind: "report", Scanner: scanner, Format: format}
+		b.FileIndex[path] = ref
+		if data != "" {
+			b.Files[path] = &EvidenceFile{Ref: ref, Data: []byte(data)}
+		}
+	}
+	add("source/grype.json", "source", "grype", "json", grypeJSON)
+	add("source/govulncheck.json", "source", "govulncheck", "", govulncheckJSON)
+	add("artifacts/linux-amd64/trivy.json", "artifact", "trivy", "json", trivyJSON)
+	add("artifacts/linux-amd64/trivy.sarif", "artifact", "trivy", "sarif", `{}`)
+	add("artifacts/linux-amd64/grype.json", "artifact", "grype", "json", "")
+	add("source/bad.json", "source", "trivy", "json", `{bad`)
+	b.FileIndex["source/grype.json.sigstore"] = &EvidenceFileRef{Path: "source/grype.json.sigstore", Scope: "source", Category: "scan", Kind: "attestation"}
+
+	findings, reports := b.VulnFindings()
+
+	wantPaths := []string{
+		"artifacts/linux-amd64/grype.json",
+		"artifacts/linux-amd64/trivy.json",
+		"source/bad.json",
+		"source/govulncheck.json",
+		"source/grype.json",
+	}
+	var gotPaths []string
+	for _, r := range reports {
+		gotPaths = append(gotPaths, r.Path)
+	}
+	if !reflect.DeepEqual(gotPaths, wantPaths) {
+		t.Fatalf("report paths = %v, want %v", gotPaths, wantPaths)
+	}
+	if reports[0].Error != "report not loaded" {
+		t.Errorf("missing report error = %q", reports[0].Error)
+	}
+	if reports[2].Error == "" {
+		t.Error("unparseable report has no error")
+	}
+	if reports[1].Findings != 1 || reports[4].Findings != 2 {
+		t.Errorf("per-report findings = %d, %d", reports[1].Findings, reports[4].Findings)
+	}
+
+	// source: x/net (grype+govulncheck), example.com/lib, stdlib; artifact: x/net (trivy)
+	if len(findings) != 4 {
+		t.Fatalf("got %d findings, want 4: %+v", len(findings), findings)
+	}
+	var source, artifact int
+	for _, f := range findings {
+		switch f.Scope {
+		case "source":
+			source++
+		case "artifact":
+			artifact++
+		}
+	}
+	if source != 3 || artifact != 1 {
+		t.Fatalf("source/artifact = %d/%d, want 3/1", source, artifact)
+	}
+}
+
+func TestBundleVulnFindings_Nil(t *testing.T) {
+	var b *Bundle
+	if f, r := b.VulnFindings(); f != nil || r != nil {
+		t.Fatal("nil bundle should have no findings")
+	}
+}
@@ -0,0 +1,192 @@
+package evidence
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// DefaultRefreshInterval is how often the Watcher re-checks release.json.
+const DefaultRefreshInterval = 15 * time.Minute
+
+// BundleSource is what the Watcher needs from a Loader.
+type BundleSource interface {
+	// FetchRelease returns the current, unverified release.json bytes.
+	FetchRelease(ctx context.Context) ([]byte, error)
+
+	// Load fetches and fully verifies the release's evidence.
+	Load(ctx context.Context) (*Bundle, error)
+}
+
+// WatcherMetrics is implemented by the metrics package to observe evidence
+// refreshes.
+type WatcherMetrics interface {
+	IncEvidencePolls()
+	IncEvidenceSwaps()
+	IncEvidenceError(errType string)
+	SetEvidenceLastSuccess(unixSeconds float64)
+}
+
+// WatcherOptions configures the evidence Watcher.
+type WatcherOptions struct {
+	Logger log.Logger
+	Source BundleSource
+	Store  *Store
+
+	// PollInterval is how often release.json is re-checked. Zero uses
+	// DefaultRefreshInterval.
+	PollInterval time.Duration
+
+	// Filter, when set, is applied to every refreshed bundle before it is
+	// stored, the same way the startup load is filtered (e.g. by platform).
+	Filter func(*Bundle) *Bundle
+
+	// OnSwap is called with the newly stored bundle after a refresh, on the
+	// poll goroutine. Use it to re-render anything built from the evidence.
+	OnSwap func(*Bundle)
+
+	// Metrics receives poll, swap and error signals.
+	Metrics WatcherMetrics
+}
+
+// Watcher keeps the Store current as the pipeline republishes evidence for
+// the running release (re-scans add reports and re-sign release.json). Each
+// poll fetches release.json; when its digest differs from the stored
+// bundle's, the release is loaded again with every signature and hash
+// re-verified, and only a bundle that passes replaces the stored one.
+type Watcher struct {
+	source   BundleSource
+	store    *Store
+	logger   log.Logger
+	interval time.Duration
+	filter   func(*Bundle) *Bundle
+	onSwap   func(*Bundle)
+	metrics  WatcherMetrics
+}
+
+// NewWatcher validates opts and returns a Watcher.
+func NewWatcher(opts *WatcherOptions) (*Watcher, error) {
+	if opts == nil || opts.Source == nil {
+		return nil, xerrors.New("evidence watcher requires a source")
+	}
+	if opts.Store == nil {
+		return nil, xerrors.New("evidence watcher requires a store")
+	}
+	w := &Watcher{
+		source:   opts.Source,
+		store:    opts.Store,
+		logger:   opts.Logger,
+		interval: opts.PollInterval,
+		filter:   opts.Filter,
+		onSwap:   opts.OnSwap,
+		metrics:  opts.Metrics,
+	}
+	if w.logger == nil {
+		w.logger = log.Nop()
+	}
+	if w.interval <= 0 {
+		w.interval = DefaultRefreshInterval
+	}
+	return w, nil
+}
+
+// Run polls until ctx is cancelled. The startup load has already filled the
+// Store, so the first poll waits a full interval.
+func (w *Watcher) Run(ctx context.Context) {
+	w.logger.Info(ctx, "evidence watcher started", "interval", w.interval.String())
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info(ctx, "evidence watcher stopped")
+			return
+		case <-t.C:
+			_, _ = w.checkOnce(ctx)
+		}
+	}
+}
+
+// checkOnce runs one poll, reporting whether a new bundle was stored. On
+// error the stored bundle is left untouched.
+func (w *Watcher) checkOnce(ctx context.Context) (bool, error) {
+	if w.metrics != nil {
+		w.metrics.IncEvidencePolls()
+	}
+
+	cur, _ := w.store.Get()
+	raw, err := w.source.FetchRelease(ctx)
+	if err != nil {
+		w.fail(ctx, err)
+		return false, err
+	}
+	if cur != nil && cur.ReleaseDigest != "" && cryptoutil.SHA256Hex(raw) == cur.ReleaseDigest {
+		w.succeed()
+		return false, nil
+	}
+
+	next, err := w.source.Load(ctx)
+	if err != nil {
+		w.fail(ctx, err)
+		return false, err
+	}
+	if w.filter != nil {
+		next = w.filter(next)
+	}
+	w.store.Set(next)
+	w.succeed()
+	if w.metrics != nil {
+		w.metrics.IncEvidenceSwaps()
+	}
+
+	added := addedPaths(cur, next)
+	w.logger.Info(ctx, "refreshed build evidence",
+		"summary", next.LoadSummary(),
+		"inventory_hash", next.InventoryHash,
+		"new_files", added,
+	)
+	if w.onSwap != nil {
+		w.onSwap(next)
+	}
+	return true, nil
+}
+
+func (w *Watcher) succeed() {
+	if w.metrics != nil {
+		w.metrics.SetEvidenceLastSuccess(float64(time.Now().Unix()))
+	}
+}
+
+// fail records a poll error: "fetch" for S3 trouble that a later poll may
+// clear, "verify" for evidence that was published but didn't check out.
+func (w *Watcher) fail(ctx context.Context, err error) {
+	errType := "verify"
+	if errors.Is(err, ErrTransient) {
+		errType = "fetch"
+	}
+	if w.metrics != nil {
+		w.metrics.IncEvidenceError(errType)
+	}
+	w.logger.Warn(ctx, "evidence refresh failed, keeping current evidence",
+		"type", errType,
+		"error", err,
+	)
+}
+
+// addedPaths lists the inventory paths in next that prev didn't have; after
+// a re-scan these are the new reports.
+func addedPaths(prev, next *Bundle) []string {
+	var out []string
+	for p := range next.FileIndex {
+		if _, ok := prev.FileRef(p); !ok {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,224 @@
+package evidence
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeWatcherMetrics struct {
+	mu          sync.Mutex
+	polls       int
+	swaps       int
+	errors      map[string]int
+	lastSuccess float64
+}
+
+func newFakeWatcherMetrics() *fakeWatcherMetrics {
+	return &fakeWatcherMetrics{errors: make(map[string]int)}
+}
+
+func (m *fakeWatcherMetrics) IncEvidencePolls() { m.mu.Lock(); m.polls++; m.mu.Unlock() }
+func (m *fakeWatcherMetrics) IncEvidenceSwaps() { m.mu.Lock(); m.swaps++; m.mu.Unlock() }
+func (m *fakeWatcherMetrics) IncEvidenceError(t string) {
+	m.mu.Lock()
+	m.errors[t]++
+	m.mu.Unlock()
+}
+func (m *fakeWatcherMetrics) SetEvidenceLastSuccess(v float64) {
+	m.mu.Lock()
+	m.lastSuccess = v
+	m.mu.Unlock()
+}
+
+func (m *fakeWatcherMetrics) getSwaps() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.swaps
+}
+
+// watcherFixture is a store loaded from a valid release, as at startup.
+type watcherFixture struct {
+	fake     *fakeS3
+	verifier *stubVerifier
+	loader   *Loader
+	store    *Store
+	metrics  *fakeWatcherMetrics
+}
+
+func newWatcherFixture(t *testing.T) *watcherFixture {
+	t.Helper()
+	f := &watcherFixture{
+		fake:     newFakeS3(),
+		verifier: passVerifier(),
+		store:    NewStore(),
+		metrics:  newFakeWatcherMetrics(),
+	}
+	populateFakeS3(f.fake)
+	f.loader = newTestLoader(f.fake, f.verifier)
+	b, err := f.loader.Load(t.Context())
+	if err != nil {
+		t.Fatalf("initial Load: %v", err)
+	}
+	f.store.Set(b)
+	return f
+}
+
+func (f *watcherFixture) newWatcher(t *testing.T, mut ...func(*WatcherOptions)) *Watcher {
+	t.Helper()
+	opts := &WatcherOptions{Source: f.loader, Store: f.store, Metrics: f.metrics}
+	for _, fn := range mut {
+		fn(opts)
+	}
+	w, err := NewWatcher(opts)
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	return w
+}
+
+func TestWatcher_UnchangedReleaseSkipsLoad(t *testing.T) {
+	f := newWatcherFixture(t)
+	before, _ := f.store.Get()
+	// a full Load would fail on this, so reaching it would show up as an error
+	f.fake.failOn(testReleasePrefix()+"inventory.json", errors.New("should not be fetched"))
+
+	swapped, err := f.newWatcher(t).checkOnce(t.Context())
+	if err != nil || swapped {
+		t.Fatalf("checkOnce = %v, %v; want no swap", swapped, err)
+	}
+	if after, _ := f.store.Get(); after != before {
+		t.Fatal("store replaced for an unchanged release")
+	}
+	if f.metrics.polls != 1 || f.metrics.lastSuccess == 0 {
+		t.Fatalf("polls = %d, lastSuccess = %v", f.metrics.polls, f.metrics.lastSuccess)
+	}
+}
+
+func TestWatcher_RescanSwapsStore(t *testing.T) {
+	f := newWatcherFixture(t)
+	var swappedTo *Bundle
+	w := f.newWatcher(t, func(o *WatcherOptions) {
+		o.OnSwap = func(b *Bundle) { swappedTo = b }
+	})
+
+	// the pipeline re-scans: a new report lands and release.json is re-signed
+	populateWithEvidence(f.fake)
+
+	swapped, err := w.checkOnce(t.Context())
+	if err != nil || !swapped {
+		t.Fatalf("checkOnce = %v, %v; want a swap", swapped, err)
+	}
+	cur, _ := f.store.Get()
+	if _, ok := cur.File("source/sbom.json"); !ok {
+		t.Fatal("new report missing from the store")
+	}
+	if swappedTo != cur {
+		t.Fatal("OnSwap not called with the stored bundle")
+	}
+	if f.metrics.swaps != 1 {
+		t.Fatalf("swaps = %d, want 1", f.metrics.swaps)
+	}
+
+	// and the next poll sees nothing new
+	if swapped, _ := w.checkOnce(t.Context()); swapped {
+		t.Fatal("swapped again for the same release")
+	}
+}
+
+func TestWatcher_VerifyFailureKeepsStore(t *testing.T) {
+	f := newWatcherFixture(t)
+	before, _ := f.store.Get()
+	populateWithEvidence(f.fake)
+	f.verifier.err = errors.New("signature mismatch")
+
+	swapped, err := f.newWatcher(t).checkOnce(t.Context())
+	if err == nil || swapped {
+		t.Fatalf("checkOnce = %v, %v; want a failure", swapped, err)
+	}
+	if after, _ := f.store.Get(); after != before {
+		t.Fatal("store replaced by unverified evidence")
+	}
+	if f.metrics.errors["verify"] != 1 {
+		t.Fatalf("errors = %v, want verify=1", f.metrics.errors)
+	}
+}
+
+func TestWatcher_FetchFailure(t *testing.T) {
+	f := newWatcherFixture(t)
+	f.fake.failOn(testReleasePrefix()+"release.json", errors.New("SlowDown"))
+
+	if _, err := f.newWatcher(t).checkOnce(t.Context()); err == nil {
+		t.Fatal("expected error")
+	}
+	if f.metrics.errors["fetch"] != 1 || f.metrics.lastSuccess != 0 {
+		t.Fatalf("errors = %v, lastSuccess = %v; want fetch=1 and no success", f.metrics.errors, f.metrics.lastSuccess)
+	}
+}
+
+func TestWatcher_FilterApplied(t *testing.T) {
+	f := newWatcherFixture(t)
+	populateWithEvidence(f.fake)
+	w := f.newWatcher(t, func(o *WatcherOptions) {
+		o.Filter = func(b *Bundle) *Bundle { return FilterBundleByPlatform(b, "linux/amd64") }
+	})
+
+	if _, err := w.checkOnce(t.Context()); err != nil {
+		t.Fatalf("checkOnce: %v", err)
+	}
+	cur, _ := f.store.Get()
+	if cur.ReleaseDigest == "" {
+		t.Fatal("filtered bundle lost its release digest")
+	}
+	// the filtered copy still matches the published release, so no reload
+	if swapped, _ := w.checkOnce(t.Context()); swapped {
+		t.Fatal("filtered bundle compared as changed")
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	f := newWatcherFixture(t)
+	populateWithEvidence(f.fake)
+	w := f.newWatcher(t, func(o *WatcherOptions) { o.PollInterval = 10 * time.Millisecond })
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() { w.Run(ctx); close(done) }()
+
+	deadline := time.After(2 * time.Second)
+	for f.metrics.getSwaps() == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for a refresh")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+}
+
+func TestNewWatcher_Requires(t *testing.T) {
+	if _, err := NewWatcher(&WatcherOptions{Store: NewStore()}); err == nil {
+		t.Fatal("expected error without Source")
+	}
+	if _, err := NewWatcher(&WatcherOptions{Source: newTestLoader(newFakeS3(), nil)}); err == nil {
+		t.Fatal("expected error without Store")
+	}
+	w, err := NewWatcher(&WatcherOptions{Source: newTestLoader(newFakeS3(), nil), Store: NewStore()})
+	if err != nil || w.interval != DefaultRefreshInterval {
+		t.Fatalf("NewWatcher = %v, %v; want default interval", w, err)
+	}
+}
+
+func TestAddedPaths(t *testing.T) {
+	prev := &Bundle{FileIndex: map[string]*EvidenceFileRef{"a": {}}}
+	next := &Bundle{FileIndex: map[string]*EvidenceFileRef{"a": {}, "c": {}, "b": {}}}
+	if got := addedPaths(prev, next); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Fatalf("addedPaths = %v, want [b c]", got)
+	}
+	if got := addedPaths(nil, next); len(got) != 3 {
+		t.Fatalf("addedPaths(nil) = %v, want all", got)
+	}
+}
@@ -25,7 +25,8 @@ func NewHandler(opts *Options) http.Handler {
 	// chi router
 	r := chi.NewRouter()
 
-	// Compress text responses (HTML/CSS/JS/JSON/SVG)
+	// Compress text responses (HTML/CSS/JS/JSON/SVG). Site files with
+	// precompressed variants arrive with Content-Encoding set and pass through.
 	r.Use(middleware.Compress(5,
 		"text/html",
 		"text/css",
@@ -629,6 +629,39 @@ func TestNewHandler_NoCompressionWithoutAcceptEncoding(t *testing.T) {
 	}
 }
 
+func TestNewHandler_PassesThroughPreEncodedBody(t *testing.T) {
+	// handlers serving precompressed variants set Content-Encoding themselves;
+	// the compression middleware must not encode them again
+	body := "already-zstd-bytes"
+	opts := defaultOpts()
+	opts.APIRoutes = func(r chi.Router) {
+		r.Get("/api/pre", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Content-Encoding", "zstd")
+			w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(body))
+		})
+	}
+
+	h := NewHandler(&opts)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/pre", http.NoBody)
+	req.Header.Set("Accept-Encoding", "gzip, zstd")
+	h.ServeHTTP(rec, req)
+
+	if ce := rec.Header().Get("Content-Encoding"); ce != "zstd" {
+		t.Fatalf("Content-Encoding = %q, want zstd", ce)
+	}
+	if rec.Body.String() != body {
+		t.Fatalf("body re-encoded: %q", rec.Body.String())
+	}
+	if rec.Header().Get("Content-Length") != fmt.Sprint(len(body)) {
+		t.Fatalf("Content-Length = %q", rec.Header().Get("Content-Length"))
+	}
+}
+
 // NewHandler - no options
 
 func TestNewHandler_NoOptions(t *testing.T) {
@@ -20,11 +20,14 @@ type Logger interface {
 }
 
 type Options struct {
-	App               string
-	Version           string
-	Commit            string
-	BuildId           string
-	Level             slog.Level
+	App     string
+	Version string
+	Commit  string
+	BuildId string
+	Level   slog.Level
+	// LevelVar, when set, takes precedence over Level so the level can be
+	// changed at runtime (ops admin API).
+	LevelVar          *slog.LevelVar
 	StacktraceLevel   slog.Level
 	JsonFormat        bool
 	MaxErrorLinks     int
@@ -40,12 +40,17 @@ func newSlog(opts *Options) (Logger, error) {
 		opts.StacktraceLevel = slog.LevelError
 	}
 
+	var level slog.Leveler = opts.Level
+	if opts.LevelVar != nil {
+		level = opts.LevelVar
+	}
+
 	// json or logfmt
 	var h slog.Handler
 	if opts.JsonFormat {
-		h = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: opts.Level, AddSource: true})
+		h = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level, AddSource: true})
 	} else {
-		h = slog.NewTextHandler(w, &slog.HandlerOptions{Level: opts.Level, AddSource: true})
+		h = slog.NewTextHandler(w, &slog.HandlerOptions{Level: level, AddSource: true})
 	}
 
 	// enrich with otel data
@@ -161,6 +161,26 @@ func TestSlogLogger_DebugLevel_AllPass(t *testing.T) {
 	}
 }
 
+func TestSlogLogger_LevelVar_RuntimeChange(t *testing.T) {
+	var buf bytes.Buffer
+	lv := new(slog.LevelVar)
+	lv.Set(slog.LevelWarn)
+	// LevelVar wins over Level
+	l := newTestLogger(t, &buf, &Options{App: "test", JsonFormat: true, Level: slog.LevelDebug, LevelVar: lv})
+
+	ctx := context.Background()
+	l.Info(ctx, "before")
+	if buf.Len() != 0 {
+		t.Fatalf("info should be filtered at warn, got: %s", buf.String())
+	}
+
+	lv.Set(slog.LevelDebug)
+	l.With("k", "v").Debug(ctx, "after")
+	if !strings.Contains(buf.String(), `"msg":"after"`) {
+		t.Fatalf("debug should pass after level change, got: %s", buf.String())
+	}
+}
+
 // With - copy-on-write
 
 func TestSlogLogger_With_AddsAttrs(t *testing.T) {
@@ -38,6 +38,24 @@ type ServerMetrics struct {
 	bundleLoadDuration   prometheus.Histogram
 	watcherLastSuccessTs prometheus.Gauge
 	watcherStale         prometheus.Gauge
+	watcherQuarantined   prometheus.Gauge
+	watcherQSkipsTotal   prometheus.Counter
+	watcherStagedInfo    *prometheus.GaugeVec
+	watcherStagedAt      prometheus.Gauge
+	watcherSelfChecks    *prometheus.CounterVec
+	watcherNotifications *prometheus.CounterVec
+
+	// evidence watcher metrics
+	evidencePollsTotal    prometheus.Counter
+	evidenceSwapsTotal    prometheus.Counter
+	evidenceErrorsTotal   *prometheus.CounterVec
+	evidenceLastSuccessTs prometheus.Gauge
+
+	// running binary vs release.json
+	selfAttestation *prometheus.GaugeVec
+
+	// ops admin API
+	adminActionsTotal *prometheus.CounterVec
 }
 
 // New returns a fresh registry + standard collectors + HTTP metrics
@@ -129,6 +147,54 @@ func New() *ServerMetrics {
 			Name: "content_watcher_stale",
 			Help: "Whether the content watcher is stale (1) or healthy (0)",
 		}),
+		watcherQuarantined: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "content_watcher_quarantined_bundles",
+			Help: "Number of content bundles quarantined after failing to load or validate",
+		}),
+		watcherQSkipsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "content_watcher_quarantine_skips_total",
+			Help: "Total polls that skipped a quarantined bundle awaiting its retry backoff",
+		}),
+		watcherStagedInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "content_watcher_staged_bundle_info",
+			Help: "Bundle verified and staged for scheduled activation (label carries identity, value is always 1)",
+		}, []string{"sha256"}),
+		watcherStagedAt: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "content_watcher_staged_activate_timestamp_seconds",
+			Help: "Unix timestamp the staged bundle is scheduled to activate (0 when nothing is staged)",
+		}),
+		watcherSelfChecks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "content_watcher_selfchecks_total",
+			Help: "Post-swap self-checks by result (pass, fail); a failure rolls the swap back",
+		}, []string{"result"}),
+		watcherNotifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "content_watcher_notifications_total",
+			Help: "Change notifications received by source (signal, admin, queue); bursts are debounced into one poll",
+		}, []string{"source"}),
+		evidencePollsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "evidence_watcher_polls_total",
+			Help: "Total number of build evidence refresh polls",
+		}),
+		evidenceSwapsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "evidence_watcher_swaps_total",
+			Help: "Total number of refreshed build evidence bundles swapped in",
+		}),
+		evidenceErrorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "evidence_watcher_errors_total",
+			Help: "Build evidence refresh failures by type (fetch, verify); the previous evidence keeps serving",
+		}, []string{"type"}),
+		evidenceLastSuccessTs: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "evidence_watcher_last_success_timestamp_seconds",
+			Help: "Unix timestamp of the last successful build evidence refresh poll",
+		}),
+		selfAttestation: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "self_attestation_status",
+			Help: "Whether the running executable's sha256 matches this platform's artifact in release.json; 1 on the current status (match, mismatch, unknown)",
+		}, []string{"status"}),
+		adminActionsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ops_admin_actions_total",
+			Help: "Total ops admin API requests by action and outcome (ok, error, denied)",
+		}, []string{"action", "outcome"}),
 	}
 	reg.MustRegister(
 		m.inflight,
@@ -150,6 +216,18 @@ func New() *ServerMetrics {
 		m.bundleLoadDuration,
 		m.watcherLastSuccessTs,
 		m.watcherStale,
+		m.watcherQuarantined,
+		m.watcherQSkipsTotal,
+		m.watcherStagedInfo,
+		m.watcherStagedAt,
+		m.watcherSelfChecks,
+		m.watcherNotifications,
+		m.evidencePollsTotal,
+		m.evidenceSwapsTotal,
+		m.evidenceErrorsTotal,
+		m.evidenceLastSuccessTs,
+		m.selfAttestation,
+		m.adminActionsTotal,
 	)
 
 	m.handler = promhttp.HandlerFor(reg, promhttp.HandlerOpts{
@@ -243,3 +321,86 @@ func (m *ServerMetrics) SetWatcherStale(stale bool) {
 		m.watcherStale.Set(0)
 	}
 }
+
+func (m *ServerMetrics) SetWatcherQuarantined(count int) {
+	m.watcherQuarantined.Set(float64(count))
+}
+
+func (m *ServerMetrics) IncWatcherQuarantineSkips() {
+	m.watcherQSkipsTotal.Inc()
+}
+
+// SetWatcherStaged records the bundle waiting for activation; an empty hash
+// clears it.
+func (m *ServerMetrics) SetWatcherStaged(hash string, activateAtUnix float64) {
+	m.watcherStagedInfo.Reset()
+	if hash != "" {
+		m.watcherStagedInfo.WithLabelValues(hash).Set(1)
+	}
+	m.watcherStagedAt.Set(activateAtUnix)
+}
+
+func (m *ServerMetrics) IncWatcherSelfCheck(result string) {
+	m.watcherSelfChecks.WithLabelValues(result).Inc()
+}
+
+func (m *ServerMetrics) IncWatcherNotification(source string) {
+	m.watcherNotifications.WithLabelValues(source).Inc()
+}
+
+func (m *ServerMetrics) IncEvidencePolls() {
+	m.evidencePollsTotal.Inc()
+}
+
+func (m *ServerMetrics) IncEvidenceSwaps() {
+	m.evidenceSwapsTotal.Inc()
+}
+
+func (m *ServerMetrics) IncEvidenceError(errType string) {
+	m.evidenceErrorsTotal.WithLabelValues(errType).Inc()
+}
+
+func (m *ServerMetrics) SetEvidenceLastSuccess(unixSeconds float64) {
+	m.evidenceLastSuccessTs.Set(unixSeconds)
+}
+
+func (m *ServerMetrics) SetSelfAttestation(status string) {
+	m.selfAttestation.Reset()
+	m.selfAttestation.WithLabelValues(status).Set(1)
+}
+
+// ObserveContentBlobs exports the content blob store's usage, read from
+// stats at scrape time. Logical bytes count every snapshot's files as if
+// held separately; physical bytes count each distinct file once. Call it
+// once.
+func (m *ServerMetrics) ObserveContentBlobs(stats func() (blobs int, logical, physical int64)) {
+	m.reg.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "content_blobs",
+			Help: "Distinct file contents held by the content blob store",
+		}, func() float64 {
+			n, _, _ := stats()
+			return float64(n)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "content_blob_logical_bytes",
+			Help: "File bytes referenced by active, retained, staged and preview snapshots, counting shared files once per snapshot",
+		}, func() float64 {
+			_, logical, _ := stats()
+			return float64(logical)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "content_blob_physical_bytes",
+			Help: "File bytes actually held by the content blob store after deduplication",
+		}, func() float64 {
+			_, _, physical := stats()
+			return float64(physical)
+		}),
+	)
+}
+
+// IncAdminAction counts an ops admin API request. Action names are a fixed
+// set defined by the admin routes, so cardinality is bounded.
+func (m *ServerMetrics) IncAdminAction(action, outcome string) {
+	m.adminActionsTotal.WithLabelValues(action, outcome).Inc()
+}
@@ -548,8 +548,192 @@ func TestSetWatcherStale_False(t *testing.T) {
 	}
 }
 
+// Watcher quarantine
+
+func TestSetWatcherQuarantined(t *testing.T) {
+	m := New()
+	m.SetWatcherQuarantined(3)
+
+	f := gatherMetric(t, m.reg, "content_watcher_quarantined_bundles")
+	if f == nil {
+		t.Fatal("content_watcher_quarantined_bundles metric not found")
+	}
+	if val := f.GetMetric()[0].GetGauge().GetValue(); val != 3 {
+		t.Fatalf("content_watcher_quarantined_bundles = %f, want 3", val)
+	}
+}
+
+func TestIncWatcherQuarantineSkips(t *testing.T) {
+	m := New()
+	m.IncWatcherQuarantineSkips()
+	m.IncWatcherQuarantineSkips()
+
+	f := gatherMetric(t, m.reg, "content_watcher_quarantine_skips_total")
+	if f == nil {
+		t.Fatal("content_watcher_quarantine_skips_total metric not found")
+	}
+	if val := f.GetMetric()[0].GetCounter().GetValue(); val != 2 {
+		t.Fatalf("content_watcher_quarantine_skips_total = %f, want 2", val)
+	}
+}
+
+// Ops admin API
+
+func TestIncAdminAction(t *testing.T) {
+	m := New()
+	m.IncAdminAction("content.rollback", "ok")
+	m.IncAdminAction("content.rollback", "ok")
+	m.IncAdminAction("content.rollback", "denied")
+
+	f := gatherMetric(t, m.reg, "ops_admin_actions_total")
+	if f == nil {
+		t.Fatal("ops_admin_actions_total not found")
+	}
+	if len(f.GetMetric()) != 2 {
+		t.Fatalf("expected 2 action/outcome combos, got %d", len(f.GetMetric()))
+	}
+	var ok float64
+	for _, mt := range f.GetMetric() {
+		for _, lp := range mt.GetLabel() {
+			if lp.GetName() == "outcome" && lp.GetValue() == "ok" {
+				ok = mt.GetCounter().GetValue()
+			}
+		}
+	}
+	if ok != 2 {
+		t.Fatalf("ok count = %f, want 2", ok)
+	}
+}
+
 func TestSetContentBundle(t *testing.T) {
 	m := New()
 	m.SetContentBundle("abc123")
 	m.SetContentBundle("def456") // verify Reset doesn't panic on second call
 }
+
+func TestSetWatcherStaged(t *testing.T) {
+	m := New()
+	m.SetWatcherStaged("abc123", 1700000000)
+
+	f := gatherMetric(t, m.reg, "content_watcher_staged_bundle_info")
+	if f == nil || len(f.GetMetric()) != 1 {
+		t.Fatal("content_watcher_staged_bundle_info missing staged bundle")
+	}
+	if v := f.GetMetric()[0].GetLabel()[0].GetValue(); v != "abc123" {
+		t.Fatalf("sha256 label = %q, want abc123", v)
+	}
+	at := gatherMetric(t, m.reg, "content_watcher_staged_activate_timestamp_seconds")
+	if at == nil || at.GetMetric()[0].GetGauge().GetValue() != 1700000000 {
+		t.Fatal("content_watcher_staged_activate_timestamp_seconds not set")
+	}
+
+	m.SetWatcherStaged("", 0)
+	if f := gatherMetric(t, m.reg, "content_watcher_staged_bundle_info"); f != nil && len(f.GetMetric()) != 0 {
+		t.Fatal("clearing should remove the staged bundle series")
+	}
+}
+
+func TestIncWatcherSelfCheck(t *testing.T) {
+	m := New()
+	m.IncWatcherSelfCheck("pass")
+	m.IncWatcherSelfCheck("fail")
+	m.IncWatcherSelfCheck("fail")
+
+	f := gatherMetric(t, m.reg, "content_watcher_selfchecks_total")
+	if f == nil {
+		t.Fatal("content_watcher_selfchecks_total metric not found")
+	}
+	got := map[string]float64{}
+	for _, mm := range f.GetMetric() {
+		got[mm.GetLabel()[0].GetValue()] = mm.GetCounter().GetValue()
+	}
+	if got["pass"] != 1 || got["fail"] != 2 {
+		t.Fatalf("selfchecks = %v", got)
+	}
+}
+
+func TestIncWatcherNotification(t *testing.T) {
+	m := New()
+	m.IncWatcherNotification("signal")
+	m.IncWatcherNotification("queue")
+	m.IncWatcherNotification("queue")
+
+	f := gatherMetric(t, m.reg, "content_watcher_notifications_total")
+	if f == nil {
+		t.Fatal("content_watcher_notifications_total metric not found")
+	}
+	got := map[string]float64{}
+	for _, mm := range f.GetMetric() {
+		got[mm.GetLabel()[0].GetValue()] = mm.GetCounter().GetValue()
+	}
+	if got["signal"] != 1 || got["queue"] != 2 {
+		t.Fatalf("notifications = %v, want signal=1 queue=2", got)
+	}
+}
+
+func TestEvidenceWatcherMetrics(t *testing.T) {
+	m := New()
+	m.IncEvidencePolls()
+	m.IncEvidencePolls()
+	m.IncEvidenceSwaps()
+	m.IncEvidenceError("verify")
+	m.SetEvidenceLastSuccess(1700000000)
+
+	if f := gatherMetric(t, m.reg, "evidence_watcher_polls_total"); f == nil || f.GetMetric()[0].GetCounter().GetValue() != 2 {
+		t.Fatalf("evidence_watcher_polls_total = %v, want 2", f)
+	}
+	if f := gatherMetric(t, m.reg, "evidence_watcher_swaps_total"); f == nil || f.GetMetric()[0].GetCounter().GetValue() != 1 {
+		t.Fatalf("evidence_watcher_swaps_total = %v, want 1", f)
+	}
+	f := gatherMetric(t, m.reg, "evidence_watcher_errors_total")
+	if f == nil || f.GetMetric()[0].GetLabel()[0].GetValue() != "verify" || f.GetMetric()[0].GetCounter().GetValue() != 1 {
+		t.Fatalf("evidence_watcher_errors_total = %v, want verify=1", f)
+	}
+	if f := gatherMetric(t, m.reg, "evidence_watcher_last_success_timestamp_seconds"); f == nil || f.GetMetric()[0].GetGauge().GetValue() != 1700000000 {
+		t.Fatalf("evidence_watcher_last_success_timestamp_seconds = %v", f)
+	}
+}
+
+func TestSetSelfAttestation(t *testing.T) {
+	m := New()
+	m.SetSelfAttestation("unknown")
+	m.SetSelfAttestation("match")
+
+	f := gatherMetric(t, m.reg, "self_attestation_status")
+	if f == nil {
+		t.Fatal("self_attestation_status metric not found")
+	}
+	if len(f.GetMetric()) != 1 {
+		t.Fatalf("self_attestation_status has %d series, want 1 (previous status cleared)", len(f.GetMetric()))
+	}
+	if got := f.GetMetric()[0].GetLabel()[0].GetValue(); got != "match" {
+		t.Fatalf("status = %q, want match", got)
+	}
+}
+
+func TestObserveContentBlobs(t *testing.T) {
+	m := New()
+	blobs, logical, physical := 3, int64(3000), int64(1200)
+	m.ObserveContentBlobs(func() (int, int64, int64) { return blobs, logical, physical })
+
+	for name, want := range map[string]float64{
+		"content_blobs":               3,
+		"content_blob_logical_bytes":  3000,
+		"content_blob_physical_bytes": 1200,
+	} {
+		f := gatherMetric(t, m.reg, name)
+		if f == nil {
+			t.Fatalf("%s metric not found", name)
+		}
+		if got := f.GetMetric()[0].GetGauge().GetValue(); got != want {
+			t.Fatalf("%s = %v, want %v", name, got, want)
+		}
+	}
+
+	// values are read at scrape time
+	physical = 900
+	f := gatherMetric(t, m.reg, "content_blob_physical_bytes")
+	if got := f.GetMetric()[0].GetGauge().GetValue(); got != 900 {
+		t.Fatalf("content_blob_physical_bytes after change = %v, want 900", got)
+	}
+}
@@ -0,0 +1,581 @@
+package opshttp
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+	"github.com/keithlinneman/linnemanlabs-web/internal/sitehandler"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// maxAdminBody caps admin request bodies; every payload is a small JSON object.
+const maxAdminBody = 64 << 10
+
+// AdminWatcher is the content watcher surface the admin API drives;
+// *content.Watcher implements it.
+type AdminWatcher interface {
+	RequestReload() bool
+	Notify(source string)
+	Pause()
+	Resume()
+	Paused() bool
+	Pinned() string
+	RollbackTo(ctx context.Context, hash string) (*content.Snapshot, error)
+	AllowDowngrade(key string)
+	DowngradeAllowed() string
+	Promote(ctx context.Context, snap *content.Snapshot) error
+}
+
+// AdminPreview loads and discards the candidate served on the preview
+// handler; *content.Preview implements it.
+type AdminPreview interface {
+	Load(ctx context.Context, algorithm, hash string) (*content.Snapshot, error)
+	Get() (*content.Snapshot, bool)
+	Clear() bool
+	Take(hash string) (*content.Snapshot, bool)
+}
+
+// AdminQuarantine clears content watcher quarantine entries so the next
+// poll retries them; *content.Quarantine implements it.
+type AdminQuarantine interface {
+	Clear(key string) bool
+	ClearAll() int
+}
+
+// AdminContentHistory lists retained snapshots; *content.Manager implements it.
+type AdminContentHistory interface {
+	List() []content.HistoryEntry
+}
+
+// AdminMaintenance toggles forced maintenance mode; *sitehandler.Maintenance
+// implements it.
+type AdminMaintenance interface {
+	State() sitehandler.MaintenanceState
+	Set(enabled bool, reason string)
+}
+
+// AdminLogLevel reads and changes the process log level; *slog.LevelVar
+// implements it.
+type AdminLogLevel interface {
+	Level() slog.Level
+	Set(slog.Level)
+}
+
+// AdminRateLimit clears per-IP rate limiter state; *ratelimit.IPLimiter
+// implements it.
+type AdminRateLimit interface {
+	Clear(ip string) bool
+	ClearAll() int
+}
+
+// AdminOptions configures the mutating admin API mounted under /admin/ on the
+// ops listener. At least one authentication method must be configured or
+// Start refuses to mount it.
+//
+// Any nil action dependency leaves its routes unregistered (404).
+type AdminOptions struct {
+	// TokenSHA256 is the hex SHA-256 of the bearer token. The token itself is
+	// never held in config; presented tokens are hashed and compared in
+	// constant time.
+	TokenSHA256 string
+
+	// ClientCertAuth accepts requests that presented a client certificate
+	// verified by the listener's TLS config (see TLSConfig). ClientCertNames,
+	// when non-empty, restricts accepted certificates to those whose CN or a
+	// DNS SAN matches.
+	ClientCertAuth  bool
+	ClientCertNames []string
+
+	Watcher     AdminWatcher
+	History     AdminContentHistory
+	Preview     AdminPreview
+	Quarantine  AdminQuarantine
+	Maintenance AdminMaintenance
+	LogLevel    AdminLogLevel
+	RateLimit   AdminRateLimit
+
+	// OnAction is called after every admin request with the action name and
+	// outcome (ok, error, denied), e.g. to increment a prometheus counter.
+	OnAction func(action, outcome string)
+}
+
+// admin audit outcomes
+const (
+	adminOutcomeOK     = "ok"
+	adminOutcomeError  = "error"
+	adminOutcomeDenied = "denied"
+)
+
+// adminResult is what an action handler hands back to the audit wrapper.
+type adminResult struct {
+	status int
+	body   any
+	err    error
+	// header is copied onto the response before it is written
+	header http.Header
+	// attrs are extra key/value pairs for the audit record (target hash,
+	// new level, ...)
+	attrs []any
+}
+
+type adminActionFunc func(r *http.Request) adminResult
+
+type adminAPI struct {
+	opts  *AdminOptions
+	audit log.Logger
+	auth  *adminAuth
+}
+
+// adminHandler builds the /admin/ subtree:
+//
+//	GET    /admin/content                 watcher state and snapshot history
+//	POST   /admin/content/reload          poll for a new bundle now
+//	POST   /admin/content/notify          content changed upstream; poll after the debounce
+//	POST   /admin/content/rollback        {"hash": "..."} reactivate a retained snapshot
+//	POST   /admin/content/allow-downgrade {"hash": "algo:hex"} let one older bundle past the version policy
+//	POST   /admin/content/preview         {"hash": "algo:hex"} load a candidate for the preview handler
+//	DELETE /admin/content/preview         discard the candidate
+//	POST   /admin/content/promote         {"hash": "algo:hex"} activate the verified candidate
+//	DELETE /admin/content/quarantine      ?key=algo:hash (or ?all=true) clear quarantined bundles
+//	POST   /admin/content/pause           stop swapping in new bundles
+//	POST   /admin/content/resume          undo pause
+//	GET    /admin/maintenance             current maintenance state
+//	PUT    /admin/maintenance             {"enabled": true, "reason": "..."}
+//	GET    /admin/log-level               current log level
+//	PUT    /admin/log-level               {"level": "debug"}
+//	DELETE /admin/ratelimit?ip=1.2.3.4    clear one IP's limiter state
+//	DELETE /admin/ratelimit?all=true      clear every IP
+//
+// Every request, including rejected ones and those matching no route,
+// produces one audit log record.
+func adminHandler(l log.Logger, opts *AdminOptions) (http.Handler, error) {
+	auth, err := newAdminAuth(opts)
+	if err != nil {
+		return nil, err
+	}
+	a := &adminAPI{
+		opts:  opts,
+		audit: l.With("audit", "ops_admin"),
+		auth:  auth,
+	}
+
+	mux := http.NewServeMux()
+	if opts.Watcher != nil || opts.History != nil || opts.Preview != nil {
+		mux.Handle("GET /admin/content", a.handle("content.status", a.contentStatus))
+	}
+	if opts.Watcher != nil {
+		mux.Handle("POST /admin/content/reload", a.handle("content.reload", a.contentReload))
+		mux.Handle("POST /admin/content/notify", a.handle("content.notify", a.contentNotify))
+		mux.Handle("POST /admin/content/rollback", a.handle("content.rollback", a.contentRollback))
+		mux.Handle("POST /admin/content/allow-downgrade", a.handle("content.allow_downgrade", a.contentAllowDowngrade))
+		mux.Handle("POST /admin/content/pause", a.handle("content.pause", a.contentPause))
+		mux.Handle("POST /admin/content/resume", a.handle("content.resume", a.contentResume))
+	}
+	if opts.Preview != nil {
+		mux.Handle("POST /admin/content/preview", a.handle("content.preview", a.contentPreviewLoad))
+		mux.Handle("DELETE /admin/content/preview", a.handle("content.preview_clear", a.contentPreviewClear))
+		if opts.Watcher != nil {
+			mux.Handle("POST /admin/content/promote", a.handle("content.promote", a.contentPromote))
+		}
+	}
+	if opts.Quarantine != nil {
+		mux.Handle("DELETE /admin/content/quarantine", a.handle("content.quarantine_clear", a.contentQuarantineClear))
+	}
+	if opts.Maintenance != nil {
+		mux.Handle("GET /admin/maintenance", a.handle("maintenance.get", a.maintenanceGet))
+		mux.Handle("PUT /admin/maintenance", a.handle("maintenance.set", a.maintenanceSet))
+	}
+	if opts.LogLevel != nil {
+		mux.Handle("GET /admin/log-level", a.handle("log_level.get", a.logLevelGet))
+		mux.Handle("PUT /admin/log-level", a.handle("log_level.set", a.logLevelSet))
+	}
+	if opts.RateLimit != nil {
+		mux.Handle("DELETE /admin/ratelimit", a.handle("ratelimit.clear", a.rateLimitClear))
+	}
+
+	// requests no route matches go through handle too, so probing for
+	// actions is authenticated and audited rather than answered by the mux
+	unknown := a.handle("unknown", unknownAdminRoute(mux))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern == "" {
+			unknown.ServeHTTP(w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}), nil
+}
+
+// unknownAdminRoute answers a request no admin route matched: 405 when the
+// path is routed for other methods, 404 otherwise.
+func unknownAdminRoute(mux *http.ServeMux) adminActionFunc {
+	return func(r *http.Request) adminResult {
+		var allow []string
+		for _, m := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+			probe := r.Clone(r.Context())
+			probe.Method = m
+			if _, pattern := mux.Handler(probe); pattern != "" {
+				allow = append(allow, m)
+			}
+		}
+		if len(allow) > 0 {
+			return adminResult{
+				status: http.StatusMethodNotAllowed,
+				err:    xerrors.New("method not allowed"),
+				header: http.Header{"Allow": {strings.Join(allow, ", ")}},
+			}
+		}
+		return adminResult{status: http.StatusNotFound, err: xerrors.New("unknown admin action")}
+	}
+}
+
+// handle authenticates the request, runs fn, writes its JSON result and emits
+// the audit record.
+func (a *adminAPI) handle(action string, fn adminActionFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		principal, method, err := a.auth.authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ops-admin"`)
+			writeOpsJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			a.record(r.Context(), action, adminOutcomeDenied, r, http.StatusUnauthorized, start,
+				"auth_error", err.Error())
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxAdminBody)
+		res := fn(r)
+
+		outcome := adminOutcomeOK
+		body := res.body
+		if res.err != nil {
+			outcome = adminOutcomeError
+			if body == nil {
+				body = map[string]string{"error": res.err.Error()}
+			}
+		}
+		for k, v := range res.header {
+			w.Header()[k] = v
+		}
+		writeOpsJSON(w, res.status, body)
+
+		kv := append([]any{"principal", principal, "auth_method", method}, res.attrs...)
+		if res.err != nil {
+			kv = append(kv, "error", res.err.Error())
+		}
+		a.record(r.Context(), action, outcome, r, res.status, start, kv...)
+	})
+}
+
+func (a *adminAPI) record(ctx context.Context, action, outcome string, r *http.Request, status int, start time.Time, kv ...any) {
+	base := []any{
+		"action", action,
+		"outcome", outcome,
+		"status", status,
+		"method", r.Method,
+		"path", r.URL.Path,
+		"remote_addr", r.RemoteAddr,
+		"duration_ms", time.Since(start).Milliseconds(),
+	}
+	base = append(base, kv...)
+	if outcome == adminOutcomeOK {
+		a.audit.Info(ctx, "admin action", base...)
+	} else {
+		a.audit.Warn(ctx, "admin action", base...)
+	}
+	if a.opts.OnAction != nil {
+		a.opts.OnAction(action, outcome)
+	}
+}
+
+// content
+
+type adminContentStatus struct {
+	Paused bool   `json:"paused"`
+	Pinned string `json:"pinned,omitempty"`
+	// AllowedDowngrade is a pending allow-downgrade override (algo:hash).
+	AllowedDowngrade string                 `json:"allowed_downgrade,omitempty"`
+	History          []content.HistoryEntry `json:"history,omitempty"`
+	// Preview is the candidate loaded for the preview handler, if any.
+	Preview *content.Meta `json:"preview,omitempty"`
+}
+
+func (a *adminAPI) contentStatus(_ *http.Request) adminResult {
+	var st adminContentStatus
+	if a.opts.Watcher != nil {
+		st.Paused = a.opts.Watcher.Paused()
+		st.Pinned = a.opts.Watcher.Pinned()
+		st.AllowedDowngrade = a.opts.Watcher.DowngradeAllowed()
+	}
+	if a.opts.History != nil {
+		st.History = a.opts.History.List()
+	}
+	if a.opts.Preview != nil {
+		if snap, ok := a.opts.Preview.Get(); ok {
+			st.Preview = &snap.Meta
+		}
+	}
+	return adminResult{status: http.StatusOK, body: st}
+}
+
+func (a *adminAPI) contentReload(_ *http.Request) adminResult {
+	queued := a.opts.Watcher.RequestReload()
+	return adminResult{
+		status: http.StatusAccepted,
+		body:   map[string]any{"queued": queued},
+		attrs:  []any{"queued", queued},
+	}
+}
+
+// contentNotify is for publish pipelines: unlike reload, bursts of calls are
+// debounced by the watcher into one poll.
+func (a *adminAPI) contentNotify(_ *http.Request) adminResult {
+	a.opts.Watcher.Notify(content.NotifySourceAdmin)
+	return adminResult{status: http.StatusAccepted, body: map[string]any{"notified": true}}
+}
+
+func (a *adminAPI) contentRollback(r *http.Request) adminResult {
+	var req struct {
+		Hash string `json:"hash"`
+	}
+	if err := decodeAdminJSON(r, &req); err != nil {
+		return adminResult{status: http.StatusBadRequest, err: err}
+	}
+	if req.Hash == "" {
+		return adminResult{status: http.StatusBadRequest, err: errAdminField("hash")}
+	}
+
+	snap, err := a.opts.Watcher.RollbackTo(r.Context(), req.Hash)
+	if err != nil {
+		return adminResult{status: http.StatusNotFound, err: err, attrs: []any{"target_hash", req.Hash}}
+	}
+	return adminResult{
+		status: http.StatusOK,
+		body: map[string]any{
+			"hash":   snap.Meta.Hash,
+			"pinned": a.opts.Watcher.Pinned(),
+		},
+		attrs: []any{"target_hash", req.Hash},
+	}
+}
+
+func (a *adminAPI) contentAllowDowngrade(r *http.Request) adminResult {
+	// the override is matched against the pointer, which always carries the algorithm
+	algo, hash, res, ok := decodeAdminHash(r)
+	if !ok {
+		return res
+	}
+	key := algo + ":" + hash
+
+	a.opts.Watcher.AllowDowngrade(key)
+	return adminResult{
+		status: http.StatusAccepted,
+		body:   map[string]any{"allowed_downgrade": key},
+		attrs:  []any{"target_hash", key},
+	}
+}
+
+func (a *adminAPI) contentPreviewLoad(r *http.Request) adminResult {
+	algo, hash, res, ok := decodeAdminHash(r)
+	if !ok {
+		return res
+	}
+	snap, err := a.opts.Preview.Load(r.Context(), algo, hash)
+	if err != nil {
+		return adminResult{status: http.StatusUnprocessableEntity, err: err, attrs: []any{"target_hash", algo + ":" + hash}}
+	}
+	return adminResult{
+		status: http.StatusOK,
+		body:   map[string]any{"preview": snap.Meta},
+		attrs:  []any{"target_hash", algo + ":" + hash},
+	}
+}
+
+func (a *adminAPI) contentPreviewClear(_ *http.Request) adminResult {
+	cleared := a.opts.Preview.Clear()
+	return adminResult{status: http.StatusOK, body: map[string]any{"cleared": cleared}}
+}
+
+// contentPromote requires the hash of the candidate being promoted so an
+// operator can't activate a preview someone else replaced after their QA.
+func (a *adminAPI) contentPromote(r *http.Request) adminResult {
+	algo, hash, res, ok := decodeAdminHash(r)
+	if !ok {
+		return res
+	}
+	attrs := []any{"target_hash", algo + ":" + hash}
+	if cur, ok := a.opts.Preview.Get(); !ok || cur.Meta.HashAlgorithm != algo {
+		return adminResult{status: http.StatusConflict, err: xerrors.New("hash is not the loaded preview"), attrs: attrs}
+	}
+	snap, ok := a.opts.Preview.Take(hash)
+	if !ok {
+		return adminResult{status: http.StatusConflict, err: xerrors.New("hash is not the loaded preview"), attrs: attrs}
+	}
+	if err := a.opts.Watcher.Promote(r.Context(), snap); err != nil {
+		return adminResult{status: http.StatusUnprocessableEntity, err: err, attrs: attrs}
+	}
+	return adminResult{
+		status: http.StatusOK,
+		body: map[string]any{
+			"hash":   snap.Meta.Hash,
+			"pinned": a.opts.Watcher.Pinned(),
+		},
+		attrs: attrs,
+	}
+}
+
+func (a *adminAPI) contentPause(_ *http.Request) adminResult {
+	a.opts.Watcher.Pause()
+	return adminResult{status: http.StatusOK, body: map[string]any{"paused": true}}
+}
+
+func (a *adminAPI) contentResume(_ *http.Request) adminResult {
+	a.opts.Watcher.Resume()
+	return adminResult{status: http.StatusOK, body: map[string]any{"paused": false}}
+}
+
+func (a *adminAPI) contentQuarantineClear(r *http.Request) adminResult {
+	if key := r.URL.Query().Get("key"); key != "" {
+		if !a.opts.Quarantine.Clear(key) {
+			return adminResult{
+				status: http.StatusNotFound,
+				body:   map[string]string{"error": "not quarantined"},
+				attrs:  []any{"key", key},
+			}
+		}
+		return adminResult{status: http.StatusOK, body: map[string]any{"cleared": 1}, attrs: []any{"key", key}}
+	}
+	if r.URL.Query().Get("all") == "true" {
+		n := a.opts.Quarantine.ClearAll()
+		return adminResult{status: http.StatusOK, body: map[string]any{"cleared": n}, attrs: []any{"cleared", n}}
+	}
+	return adminResult{status: http.StatusBadRequest, err: errAdminField("key or all=true")}
+}
+
+// maintenance
+
+func (a *adminAPI) maintenanceGet(_ *http.Request) adminResult {
+	return adminResult{status: http.StatusOK, body: a.opts.Maintenance.State()}
+}
+
+func (a *adminAPI) maintenanceSet(r *http.Request) adminResult {
+	var req struct {
+		Enabled *bool  `json:"enabled"`
+		Reason  string `json:"reason"`
+	}
+	if err := decodeAdminJSON(r, &req); err != nil {
+		return adminResult{status: http.StatusBadRequest, err: err}
+	}
+	if req.Enabled == nil {
+		return adminResult{status: http.StatusBadRequest, err: errAdminField("enabled")}
+	}
+
+	prev := a.opts.Maintenance.State().Enabled
+	a.opts.Maintenance.Set(*req.Enabled, req.Reason)
+	return adminResult{
+		status: http.StatusOK,
+		body:   a.opts.Maintenance.State(),
+		attrs:  []any{"previous", prev, "enabled", *req.Enabled, "reason", req.Reason},
+	}
+}
+
+// log level
+
+func (a *adminAPI) logLevelGet(_ *http.Request) adminResult {
+	return adminResult{status: http.StatusOK, body: map[string]string{"level": levelName(a.opts.LogLevel.Level())}}
+}
+
+func (a *adminAPI) logLevelSet(r *http.Request) adminResult {
+	var req struct {
+		Level string `json:"level"`
+	}
+	if err := decodeAdminJSON(r, &req); err != nil {
+		return adminResult{status: http.StatusBadRequest, err: err}
+	}
+	lvl, err := log.ParseLevel(req.Level)
+	if err != nil {
+		return adminResult{status: http.StatusBadRequest, err: err}
+	}
+
+	prev := levelName(a.opts.LogLevel.Level())
+	a.opts.LogLevel.Set(lvl)
+	return adminResult{
+		status: http.StatusOK,
+		body:   map[string]string{"level": levelName(lvl), "previous": prev},
+		attrs:  []any{"previous", prev, "level", levelName(lvl)},
+	}
+}
+
+// levelName renders a level in the lowercase form ParseLevel accepts.
+func levelName(l slog.Level) string {
+	switch l {
+	case slog.LevelDebug:
+		return "debug"
+	case slog.LevelInfo:
+		return "info"
+	case slog.LevelWarn:
+		return "warn"
+	case slog.LevelError:
+		return "error"
+	default:
+		return l.String()
+	}
+}
+
+// rate limit
+
+func (a *adminAPI) rateLimitClear(r *http.Request) adminResult {
+	if ip := r.URL.Query().Get("ip"); ip != "" {
+		if !a.opts.RateLimit.Clear(ip) {
+			return adminResult{
+				status: http.StatusNotFound,
+				body:   map[string]string{"error": "ip not tracked"},
+				attrs:  []any{"ip", ip},
+			}
+		}
+		return adminResult{status: http.StatusOK, body: map[string]any{"cleared": 1}, attrs: []any{"ip", ip}}
+	}
+	if r.URL.Query().Get("all") == "true" {
+		n := a.opts.RateLimit.ClearAll()
+		return adminResult{status: http.StatusOK, body: map[string]any{"cleared": n}, attrs: []any{"cleared", n}}
+	}
+	return adminResult{status: http.StatusBadRequest, err: errAdminField("ip or all=true")}
+}
+
+func decodeAdminJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return xerrors.Wrap(err, "invalid request body")
+	}
+	return nil
+}
+
+// decodeAdminHash reads a {"hash": "algo:hex"} body. When ok is false, res
+// is the 400 to return.
+func decodeAdminHash(r *http.Request) (algo, hash string, res adminResult, ok bool) {
+	var req struct {
+		Hash string `json:"hash"`
+	}
+	if err := decodeAdminJSON(r, &req); err != nil {
+		return "", "", adminResult{status: http.StatusBadRequest, err: err}, false
+	}
+	if req.Hash == "" {
+		return "", "", adminResult{status: http.StatusBadRequest, err: errAdminField("hash")}, false
+	}
+	algo, hash, found := strings.Cut(req.Hash, ":")
+	if !found || algo == "" || hash == "" {
+		return "", "", adminResult{status: http.StatusBadRequest, err: xerrors.Newf("hash %q must be algo:hex", req.Hash)}, false
+	}
+	return algo, hash, adminResult{}, true
+}
+
+func errAdminField(name string) error {
+	return xerrors.Newf("%s required", name)
+}
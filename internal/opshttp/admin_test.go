@@ -0,0 +1,932 @@
+package opshttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+	"github.com/keithlinneman/linnemanlabs-web/internal/sitehandler"
+)
+
+const testAdminToken = "s3cret-admin-token"
+
+func testTokenDigest() string {
+	sum := sha256.Sum256([]byte(testAdminToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// fakeAdminWatcher records calls from the admin API.
+type fakeAdminWatcher struct {
+	mu       sync.Mutex
+	reloads  int
+	notified []string
+	paused   bool
+	pinned   string
+	allowed  string
+	promoted string
+	retained map[string]bool
+
+	promoteErr error
+}
+
+func (f *fakeAdminWatcher) RequestReload() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reloads++
+	return f.reloads == 1
+}
+func (f *fakeAdminWatcher) Notify(source string) {
+	f.mu.Lock()
+	f.notified = append(f.notified, source)
+	f.mu.Unlock()
+}
+func (f *fakeAdminWatcher) Pause()         { f.mu.Lock(); f.paused = true; f.mu.Unlock() }
+func (f *fakeAdminWatcher) Resume()        { f.mu.Lock(); f.paused = false; f.mu.Unlock() }
+func (f *fakeAdminWatcher) Paused() bool   { f.mu.Lock(); defer f.mu.Unlock(); return f.paused }
+func (f *fakeAdminWatcher) Pinned() string { f.mu.Lock(); defer f.mu.Unlock(); return f.pinned }
+func (f *fakeAdminWatcher) RollbackTo(_ context.Context, hash string) (*content.Snapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.retained[hash] {
+		return nil, errors.New("content: snapshot is not in history")
+	}
+	f.pinned = "sha384:upstream"
+	return &content.Snapshot{Meta: content.Meta{Hash: hash}}, nil
+}
+
+func (f *fakeAdminWatcher) AllowDowngrade(key string) { f.mu.Lock(); f.allowed = key; f.mu.Unlock() }
+func (f *fakeAdminWatcher) DowngradeAllowed() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.allowed
+}
+
+func (f *fakeAdminWatcher) Promote(_ context.Context, snap *content.Snapshot) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.promoteErr != nil {
+		return f.promoteErr
+	}
+	f.promoted = snap.Meta.Hash
+	f.pinned = "sha384:upstream"
+	return nil
+}
+
+// fakeAdminPreview loads any hash except "bad".
+type fakeAdminPreview struct {
+	mu   sync.Mutex
+	snap *content.Snapshot
+}
+
+func (f *fakeAdminPreview) Load(_ context.Context, algorithm, hash string) (*content.Snapshot, error) {
+	if hash == "bad" {
+		return nil, errors.New("signature verification failed")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.snap = &content.Snapshot{Meta: content.Meta{Hash: hash, HashAlgorithm: algorithm, Version: "2.0.0"}}
+	return f.snap, nil
+}
+func (f *fakeAdminPreview) Get() (*content.Snapshot, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.snap, f.snap != nil
+}
+func (f *fakeAdminPreview) Clear() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	had := f.snap != nil
+	f.snap = nil
+	return had
+}
+func (f *fakeAdminPreview) Take(hash string) (*content.Snapshot, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.snap == nil || f.snap.Meta.Hash != hash {
+		return nil, false
+	}
+	s := f.snap
+	f.snap = nil
+	return s, true
+}
+
+type fakeAdminHistory []content.HistoryEntry
+
+func (f fakeAdminHistory) List() []content.HistoryEntry { return f }
+
+type fakeAdminRateLimit struct{ ips map[string]bool }
+
+func (f *fakeAdminRateLimit) Clear(ip string) bool {
+	ok := f.ips[ip]
+	delete(f.ips, ip)
+	return ok
+}
+func (f *fakeAdminRateLimit) ClearAll() int {
+	n := len(f.ips)
+	clear(f.ips)
+	return n
+}
+
+type adminFixture struct {
+	watcher  *fakeAdminWatcher
+	maint    *sitehandler.Maintenance
+	level    *slog.LevelVar
+	limiter  *fakeAdminRateLimit
+	handler  http.Handler
+	auditBuf *bytes.Buffer
+	actions  []string
+}
+
+func newAdminFixture(t *testing.T, mutate ...func(*AdminOptions)) *adminFixture {
+	t.Helper()
+	f := &adminFixture{
+		watcher:  &fakeAdminWatcher{retained: map[string]bool{"aaa": true}},
+		maint:    &sitehandler.Maintenance{},
+		level:    new(slog.LevelVar),
+		limiter:  &fakeAdminRateLimit{ips: map[string]bool{"203.0.113.7": true, "203.0.113.8": true}},
+		auditBuf: &bytes.Buffer{},
+	}
+	opts := &AdminOptions{
+		TokenSHA256: testTokenDigest(),
+		Watcher:     f.watcher,
+		History:     fakeAdminHistory{{Hash: "aaa", Active: true}},
+		Maintenance: f.maint,
+		LogLevel:    f.level,
+		RateLimit:   f.limiter,
+		OnAction: func(action, outcome string) {
+			f.actions = append(f.actions, action+":"+outcome)
+		},
+	}
+	for _, m := range mutate {
+		m(opts)
+	}
+
+	lg, err := log.New(&log.Options{App: "test", JsonFormat: true, Writer: f.auditBuf})
+	if err != nil {
+		t.Fatalf("log.New: %v", err)
+	}
+	h, err := adminHandler(lg, opts)
+	if err != nil {
+		t.Fatalf("adminHandler: %v", err)
+	}
+	f.handler = h
+	return f
+}
+
+func (f *adminFixture) do(method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// lastAudit returns the last audit record written.
+func (f *adminFixture) lastAudit(t *testing.T) map[string]any {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(f.auditBuf.String()), "\n")
+	var m map[string]any
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &m); err != nil {
+		t.Fatalf("parse audit record: %v\n%s", err, f.auditBuf.String())
+	}
+	return m
+}
+
+// construction
+
+func TestAdminHandler_RequiresAuthMethod(t *testing.T) {
+	_, err := adminHandler(log.Nop(), &AdminOptions{})
+	if err == nil || !strings.Contains(err.Error(), "requires a token digest or client certificate") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestAdminHandler_RejectsBadDigest(t *testing.T) {
+	for _, d := range []string{"not-hex", "abcd", strings.Repeat("z", 64)} {
+		if _, err := adminHandler(log.Nop(), &AdminOptions{TokenSHA256: d}); err == nil {
+			t.Errorf("digest %q accepted", d)
+		}
+	}
+}
+
+func TestStart_AdminMisconfigured_Error(t *testing.T) {
+	_, err := Start(context.Background(), log.Nop(), &Options{Port: getFreePort(t), Admin: &AdminOptions{}})
+	if err == nil {
+		t.Fatal("expected Start to fail without admin auth")
+	}
+}
+
+// token auth
+
+func TestAdmin_TokenAuth(t *testing.T) {
+	f := newAdminFixture(t)
+
+	cases := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic " + testAdminToken, http.StatusUnauthorized},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"valid", "Bearer " + testAdminToken, http.StatusOK},
+		{"valid lowercase scheme", "bearer " + testAdminToken, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/log-level", http.NoBody)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			f.handler.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.want)
+			}
+			if tc.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("missing WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestAdmin_DeniedIsAudited(t *testing.T) {
+	f := newAdminFixture(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/content/pause", http.NoBody)
+	req.Header.Set("Authorization", "Bearer wrong")
+	f.handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if f.watcher.Paused() {
+		t.Fatal("unauthenticated request must not reach the action")
+	}
+	rec := f.lastAudit(t)
+	if rec["action"] != "content.pause" || rec["outcome"] != "denied" || rec["level"] != "WARN" {
+		t.Fatalf("audit = %v", rec)
+	}
+	if rec["auth_error"] != "invalid bearer token" {
+		t.Fatalf("auth_error = %v", rec["auth_error"])
+	}
+	if got := f.actions; len(got) != 1 || got[0] != "content.pause:denied" {
+		t.Fatalf("OnAction = %v", got)
+	}
+}
+
+// client certificate auth
+
+func verifiedRequest(cn string, dnsNames ...string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/admin/log-level", http.NoBody)
+	leaf := &x509.Certificate{Subject: pkix.Name{CommonName: cn}, DNSNames: dnsNames}
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf}}}
+	return req
+}
+
+func TestAdmin_ClientCertAuth(t *testing.T) {
+	f := newAdminFixture(t, func(o *AdminOptions) {
+		o.TokenSHA256 = ""
+		o.ClientCertAuth = true
+		o.ClientCertNames = []string{"ops-cli", "deploy.internal"}
+	})
+
+	cases := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"cn allowed", verifiedRequest("ops-cli"), http.StatusOK},
+		{"dns san allowed", verifiedRequest("someone", "deploy.internal"), http.StatusOK},
+		{"name not allowed", verifiedRequest("intruder"), http.StatusUnauthorized},
+		{"no certificate", httptest.NewRequest(http.MethodGet, "/admin/log-level", http.NoBody), http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// a bearer token is not an alternative when token auth is off
+			tc.req.Header.Set("Authorization", "Bearer "+testAdminToken)
+			rec := httptest.NewRecorder()
+			f.handler.ServeHTTP(rec, tc.req)
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+
+	f.handler.ServeHTTP(httptest.NewRecorder(), verifiedRequest("someone", "deploy.internal"))
+	rec := f.lastAudit(t)
+	if rec["principal"] != "deploy.internal" || rec["auth_method"] != "mtls" {
+		t.Fatalf("audit = %v", rec)
+	}
+}
+
+func TestAdmin_ClientCertFallsBackToToken(t *testing.T) {
+	f := newAdminFixture(t, func(o *AdminOptions) {
+		o.ClientCertAuth = true
+		o.ClientCertNames = []string{"ops-cli"}
+	})
+
+	req := verifiedRequest("intruder")
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 via token", rec.Code)
+	}
+	if a := f.lastAudit(t); a["auth_method"] != "token" || !strings.HasPrefix(a["principal"].(string), "token:") {
+		t.Fatalf("audit = %v", a)
+	}
+}
+
+// content actions
+
+func TestAdmin_ContentStatus(t *testing.T) {
+	f := newAdminFixture(t)
+	f.watcher.Pause()
+
+	rec := f.do(http.MethodGet, "/admin/content", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var body adminContentStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !body.Paused || len(body.History) != 1 || !body.History[0].Active {
+		t.Fatalf("body = %+v", body)
+	}
+}
+
+func TestAdmin_ContentReload(t *testing.T) {
+	f := newAdminFixture(t)
+
+	rec := f.do(http.MethodPost, "/admin/content/reload", "")
+	if rec.Code != http.StatusAccepted || !strings.Contains(rec.Body.String(), `"queued":true`) {
+		t.Fatalf("status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	rec = f.do(http.MethodPost, "/admin/content/reload", "")
+	if !strings.Contains(rec.Body.String(), `"queued":false`) {
+		t.Fatalf("second reload body = %s", rec.Body.String())
+	}
+	if f.lastAudit(t)["queued"] != false {
+		t.Fatalf("audit = %v", f.lastAudit(t))
+	}
+}
+
+func TestAdmin_ContentNotify(t *testing.T) {
+	f := newAdminFixture(t)
+	for range 2 {
+		rec := f.do(http.MethodPost, "/admin/content/notify", "")
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("status = %d body = %s", rec.Code, rec.Body.String())
+		}
+	}
+	f.watcher.mu.Lock()
+	got := f.watcher.notified
+	f.watcher.mu.Unlock()
+	if len(got) != 2 || got[0] != content.NotifySourceAdmin {
+		t.Fatalf("notifications = %v, want two from %q", got, content.NotifySourceAdmin)
+	}
+	if a := f.lastAudit(t); a["action"] != "content.notify" || a["outcome"] != "ok" {
+		t.Fatalf("audit = %v", a)
+	}
+}
+
+func TestAdmin_ContentReload_MethodNotAllowed(t *testing.T) {
+	f := newAdminFixture(t)
+	rec := f.do(http.MethodGet, "/admin/content/reload", "")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", rec.Code)
+	}
+}
+
+func TestAdmin_UnknownRouteIsAudited(t *testing.T) {
+	f := newAdminFixture(t)
+
+	rec := f.do(http.MethodPost, "/admin/nope", "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown path status = %d, want 404", rec.Code)
+	}
+	if a := f.lastAudit(t); a["action"] != "unknown" || a["outcome"] != "error" || a["path"] != "/admin/nope" {
+		t.Fatalf("audit = %v", a)
+	}
+
+	rec = f.do(http.MethodDelete, "/admin/log-level", "")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("wrong method status = %d, want 405", rec.Code)
+	}
+	if got := rec.Header().Get("Allow"); got != "GET, PUT" {
+		t.Fatalf("Allow = %q, want GET, PUT", got)
+	}
+	if a := f.lastAudit(t); a["action"] != "unknown" || a["method"] != "DELETE" {
+		t.Fatalf("audit = %v", a)
+	}
+
+	// unauthenticated probes are denied before anything is revealed
+	req := httptest.NewRequest(http.MethodGet, "/admin/nope", http.NoBody)
+	rec = httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated status = %d, want 401", rec.Code)
+	}
+	if a := f.lastAudit(t); a["action"] != "unknown" || a["outcome"] != "denied" {
+		t.Fatalf("audit = %v", a)
+	}
+
+	want := []string{"unknown:error", "unknown:error", "unknown:denied"}
+	if len(f.actions) != len(want) {
+		t.Fatalf("OnAction = %v, want %v", f.actions, want)
+	}
+	for i := range want {
+		if f.actions[i] != want[i] {
+			t.Fatalf("OnAction = %v, want %v", f.actions, want)
+		}
+	}
+}
+
+func TestAdmin_ContentRollback(t *testing.T) {
+	f := newAdminFixture(t)
+
+	rec := f.do(http.MethodPost, "/admin/content/rollback", `{"hash":"aaa"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"pinned":"sha384:upstream"`) {
+		t.Fatalf("body = %s", rec.Body.String())
+	}
+	a := f.lastAudit(t)
+	if a["action"] != "content.rollback" || a["outcome"] != "ok" || a["target_hash"] != "aaa" {
+		t.Fatalf("audit = %v", a)
+	}
+}
+
+func TestAdmin_ContentRollback_Errors(t *testing.T) {
+	f := newAdminFixture(t)
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"unknown hash", `{"hash":"zzz"}`, http.StatusNotFound},
+		{"missing hash", `{}`, http.StatusBadRequest},
+		{"unknown field", `{"hash":"aaa","force":true}`, http.StatusBadRequest},
+		{"not json", `hash=aaa`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := f.do(http.MethodPost, "/admin/content/rollback", tc.body)
+			if rec.Code != tc.want {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tc.want, rec.Body.String())
+			}
+			if a := f.lastAudit(t); a["outcome"] != "error" || a["error"] == nil {
+				t.Fatalf("audit = %v", a)
+			}
+		})
+	}
+}
+
+func TestAdmin_ContentAllowDowngrade(t *testing.T) {
+	f := newAdminFixture(t)
+
+	rec := f.do(http.MethodPost, "/admin/content/allow-downgrade", `{"hash":"sha384:abc"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if f.watcher.DowngradeAllowed() != "sha384:abc" {
+		t.Fatalf("allowed = %q", f.watcher.DowngradeAllowed())
+	}
+	if a := f.lastAudit(t); a["action"] != "content.allow_downgrade" || a["target_hash"] != "sha384:abc" {
+		t.Fatalf("audit = %v", a)
+	}
+
+	rec = f.do(http.MethodGet, "/admin/content", "")
+	if !strings.Contains(rec.Body.String(), `"allowed_downgrade":"sha384:abc"`) {
+		t.Fatalf("status body = %s", rec.Body.String())
+	}
+
+	for _, body := range []string{`{}`, `{"hash":"abc"}`, `{"hash":":abc"}`} {
+		if rec := f.do(http.MethodPost, "/admin/content/allow-downgrade", body); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestAdmin_ContentPreviewAndPromote(t *testing.T) {
+	preview := &fakeAdminPreview{}
+	f := newAdminFixture(t, func(o *AdminOptions) { o.Preview = preview })
+
+	rec := f.do(http.MethodPost, "/admin/content/preview", `{"hash":"sha384:ccc"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("preview status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if a := f.lastAudit(t); a["action"] != "content.preview" || a["target_hash"] != "sha384:ccc" {
+		t.Fatalf("audit = %v", a)
+	}
+	if rec := f.do(http.MethodGet, "/admin/content", ""); !strings.Contains(rec.Body.String(), `"preview":{"version":"2.0.0","hash":"ccc"`) {
+		t.Fatalf("status body = %s", rec.Body.String())
+	}
+
+	// promoting anything but the loaded candidate is refused and keeps it
+	for _, body := range []string{`{"hash":"sha384:ddd"}`, `{"hash":"sha256:ccc"}`} {
+		if rec := f.do(http.MethodPost, "/admin/content/promote", body); rec.Code != http.StatusConflict {
+			t.Fatalf("%s: status = %d, want 409", body, rec.Code)
+		}
+	}
+	if _, ok := preview.Get(); !ok {
+		t.Fatal("refused promote discarded the candidate")
+	}
+
+	rec = f.do(http.MethodPost, "/admin/content/promote", `{"hash":"sha384:ccc"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("promote status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if f.watcher.promoted != "ccc" {
+		t.Fatalf("promoted = %q", f.watcher.promoted)
+	}
+	if _, ok := preview.Get(); ok {
+		t.Fatal("promoted candidate should leave the preview")
+	}
+	if !strings.Contains(rec.Body.String(), `"pinned":"sha384:upstream"`) {
+		t.Fatalf("promote body = %s", rec.Body.String())
+	}
+}
+
+func TestAdmin_ContentPreviewErrors(t *testing.T) {
+	preview := &fakeAdminPreview{}
+	f := newAdminFixture(t, func(o *AdminOptions) { o.Preview = preview })
+
+	if rec := f.do(http.MethodPost, "/admin/content/preview", `{"hash":"sha384:bad"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", rec.Code)
+	}
+	if rec := f.do(http.MethodPost, "/admin/content/preview", `{"hash":"ccc"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	if rec := f.do(http.MethodPost, "/admin/content/promote", `{"hash":"sha384:ccc"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("promote without preview = %d, want 409", rec.Code)
+	}
+
+	// a promote that fails its self-check is reported, not answered 200
+	f.watcher.promoteErr = errors.New("self-check failed")
+	_ = f.do(http.MethodPost, "/admin/content/preview", `{"hash":"sha384:ccc"}`)
+	if rec := f.do(http.MethodPost, "/admin/content/promote", `{"hash":"sha384:ccc"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("failed promote = %d, want 422", rec.Code)
+	}
+	if f.watcher.promoted != "" {
+		t.Fatalf("promoted = %q", f.watcher.promoted)
+	}
+
+	_ = f.do(http.MethodPost, "/admin/content/preview", `{"hash":"sha384:ccc"}`)
+	rec := f.do(http.MethodDelete, "/admin/content/preview", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"cleared":true`) {
+		t.Fatalf("clear = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAdmin_ContentPreview_NotConfigured(t *testing.T) {
+	f := newAdminFixture(t)
+	if rec := f.do(http.MethodPost, "/admin/content/preview", `{"hash":"sha384:ccc"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+	if rec := f.do(http.MethodPost, "/admin/content/promote", `{"hash":"sha384:ccc"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", rec.Code)
+	}
+}
+
+func TestAdmin_ContentPauseResume(t *testing.T) {
+	f := newAdminFixture(t)
+
+	if rec := f.do(http.MethodPost, "/admin/content/pause", ""); rec.Code != http.StatusOK {
+		t.Fatalf("pause status = %d", rec.Code)
+	}
+	if !f.watcher.Paused() {
+		t.Fatal("watcher not paused")
+	}
+	if rec := f.do(http.MethodPost, "/admin/content/resume", ""); rec.Code != http.StatusOK {
+		t.Fatalf("resume status = %d", rec.Code)
+	}
+	if f.watcher.Paused() {
+		t.Fatal("watcher still paused")
+	}
+}
+
+func TestAdmin_NilDependencies_NotRouted(t *testing.T) {
+	f := newAdminFixture(t, func(o *AdminOptions) {
+		o.Watcher = nil
+		o.History = nil
+		o.Maintenance = nil
+		o.LogLevel = nil
+		o.RateLimit = nil
+	})
+	for _, p := range []string{"/admin/content", "/admin/maintenance", "/admin/log-level"} {
+		if rec := f.do(http.MethodGet, p, ""); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", p, rec.Code)
+		}
+	}
+}
+
+// maintenance
+
+func TestAdmin_Maintenance(t *testing.T) {
+	f := newAdminFixture(t)
+
+	rec := f.do(http.MethodPut, "/admin/maintenance", `{"enabled":true,"reason":"storage migration"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if st := f.maint.State(); !st.Enabled || st.Reason != "storage migration" {
+		t.Fatalf("state = %+v", st)
+	}
+	if a := f.lastAudit(t); a["previous"] != false || a["enabled"] != true || a["reason"] != "storage migration" {
+		t.Fatalf("audit = %v", a)
+	}
+
+	rec = f.do(http.MethodGet, "/admin/maintenance", "")
+	var st sitehandler.MaintenanceState
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil || !st.Enabled {
+		t.Fatalf("get = %s (%v)", rec.Body.String(), err)
+	}
+
+	if rec := f.do(http.MethodPut, "/admin/maintenance", `{"reason":"x"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing enabled: status = %d, want 400", rec.Code)
+	}
+
+	f.do(http.MethodPut, "/admin/maintenance", `{"enabled":false}`)
+	if f.maint.Enabled() {
+		t.Fatal("maintenance still enabled")
+	}
+}
+
+// log level
+
+func TestAdmin_LogLevel(t *testing.T) {
+	f := newAdminFixture(t)
+
+	rec := f.do(http.MethodGet, "/admin/log-level", "")
+	if !strings.Contains(rec.Body.String(), `"level":"info"`) {
+		t.Fatalf("get body = %s", rec.Body.String())
+	}
+
+	rec = f.do(http.MethodPut, "/admin/log-level", `{"level":"DEBUG"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if f.level.Level() != slog.LevelDebug {
+		t.Fatalf("level = %v, want debug", f.level.Level())
+	}
+	if a := f.lastAudit(t); a["previous"] != "info" || a["level"] != "debug" {
+		t.Fatalf("audit = %v", a)
+	}
+
+	if rec := f.do(http.MethodPut, "/admin/log-level", `{"level":"verbose"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("bad level: status = %d, want 400", rec.Code)
+	}
+	if f.level.Level() != slog.LevelDebug {
+		t.Fatal("bad level request changed the level")
+	}
+}
+
+// quarantine
+
+func TestAdmin_ContentQuarantineClear(t *testing.T) {
+	q := newQuarantineWithEntries("sha384:aaa", "sha384:bbb", "sha384:ccc")
+	f := newAdminFixture(t, func(o *AdminOptions) { o.Quarantine = q })
+
+	if rec := f.do(http.MethodDelete, "/admin/content/quarantine?key=sha384:aaa", ""); rec.Code != http.StatusOK {
+		t.Fatalf("clear key status = %d", rec.Code)
+	}
+	if q.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", q.Len())
+	}
+	if a := f.lastAudit(t); a["action"] != "content.quarantine_clear" || a["key"] != "sha384:aaa" {
+		t.Fatalf("audit = %v", a)
+	}
+	if rec := f.do(http.MethodDelete, "/admin/content/quarantine?key=sha384:aaa", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("second clear status = %d, want 404", rec.Code)
+	}
+	if rec := f.do(http.MethodDelete, "/admin/content/quarantine", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("no target status = %d, want 400", rec.Code)
+	}
+	if q.Len() != 2 {
+		t.Fatal("bare DELETE must not clear anything")
+	}
+	rec := f.do(http.MethodDelete, "/admin/content/quarantine?all=true", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"cleared":2`) {
+		t.Fatalf("clear all: status = %d body = %s", rec.Code, rec.Body.String())
+	}
+	if q.Len() != 0 {
+		t.Fatalf("Len = %d, want 0", q.Len())
+	}
+}
+
+func TestAdmin_ContentQuarantineClear_RequiresAuth(t *testing.T) {
+	q := newQuarantineWithEntries("sha384:aaa")
+	f := newAdminFixture(t, func(o *AdminOptions) { o.Quarantine = q })
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/content/quarantine?all=true", http.NoBody)
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rec.Code)
+	}
+	if q.Len() != 1 {
+		t.Fatal("unauthenticated request cleared the quarantine")
+	}
+}
+
+// rate limit
+
+func TestAdmin_RateLimitClear(t *testing.T) {
+	f := newAdminFixture(t)
+
+	if rec := f.do(http.MethodDelete, "/admin/ratelimit?ip=203.0.113.7", ""); rec.Code != http.StatusOK {
+		t.Fatalf("clear ip status = %d", rec.Code)
+	}
+	if rec := f.do(http.MethodDelete, "/admin/ratelimit?ip=203.0.113.7", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("second clear status = %d, want 404", rec.Code)
+	}
+	if rec := f.do(http.MethodDelete, "/admin/ratelimit", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("no target status = %d, want 400", rec.Code)
+	}
+	rec := f.do(http.MethodDelete, "/admin/ratelimit?all=true", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"cleared":1`) {
+		t.Fatalf("clear all: status = %d body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+// end-to-end mTLS through Start
+
+type testPKI struct {
+	dir        string
+	caFile     string
+	serverCert string
+	serverKey  string
+	client     tls.Certificate
+	roots      *x509.CertPool
+}
+
+func newTestPKI(t *testing.T, clientCN string) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ops CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	issue := func(serial int64, cn string, usage x509.ExtKeyUsage, ips []net.IP) ([]byte, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  ips,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, key.Public(), caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der, key
+	}
+
+	writePEM := func(name, typ string, b []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	keyDER := func(k *ecdsa.PrivateKey) []byte {
+		b, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	pki := &testPKI{dir: dir, roots: x509.NewCertPool()}
+	pki.roots.AddCert(caCert)
+	pki.caFile = writePEM("ca.pem", "CERTIFICATE", caDER)
+
+	srvDER, srvKey := issue(2, "ops-server", x509.ExtKeyUsageServerAuth, []net.IP{net.ParseIP("127.0.0.1")})
+	pki.serverCert = writePEM("server.pem", "CERTIFICATE", srvDER)
+	pki.serverKey = writePEM("server-key.pem", "EC PRIVATE KEY", keyDER(srvKey))
+
+	cliDER, cliKey := issue(3, clientCN, x509.ExtKeyUsageClientAuth, nil)
+	pki.client = tls.Certificate{Certificate: [][]byte{cliDER}, PrivateKey: cliKey}
+	return pki
+}
+
+func TestStart_AdminOverMTLS(t *testing.T) {
+	pki := newTestPKI(t, "ops-cli")
+	tlsCfg, err := TLSConfig(pki.serverCert, pki.serverKey, pki.caFile)
+	if err != nil {
+		t.Fatalf("TLSConfig: %v", err)
+	}
+
+	maint := &sitehandler.Maintenance{}
+	port, _ := startOps(t, &Options{
+		TLSConfig: tlsCfg,
+		Admin: &AdminOptions{
+			ClientCertAuth:  true,
+			ClientCertNames: []string{"ops-cli"},
+			Maintenance:     maint,
+		},
+	})
+
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			RootCAs:      pki.roots,
+			Certificates: certs,
+		}}}
+	}
+	put := func(c *http.Client) int {
+		req, _ := http.NewRequestWithContext(t.Context(), http.MethodPut,
+			fmt.Sprintf("https://127.0.0.1:%d/admin/maintenance", port), strings.NewReader(`{"enabled":true}`))
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("PUT: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// without a client certificate the listener still serves, but admin refuses
+	if code := put(newClient()); code != http.StatusUnauthorized {
+		t.Fatalf("no cert: status = %d, want 401", code)
+	}
+	if maint.Enabled() {
+		t.Fatal("maintenance enabled without auth")
+	}
+
+	if code := put(newClient(pki.client)); code != http.StatusOK {
+		t.Fatalf("with cert: status = %d, want 200", code)
+	}
+	if !maint.Enabled() {
+		t.Fatal("maintenance not enabled")
+	}
+}
+
+func TestTLSConfig_Errors(t *testing.T) {
+	pki := newTestPKI(t, "ops-cli")
+
+	if _, err := TLSConfig(filepath.Join(pki.dir, "missing.pem"), pki.serverKey, ""); err == nil {
+		t.Fatal("expected error for missing cert")
+	}
+	if _, err := TLSConfig(pki.serverCert, pki.serverKey, filepath.Join(pki.dir, "missing-ca.pem")); err == nil {
+		t.Fatal("expected error for missing CA")
+	}
+	if _, err := TLSConfig(pki.serverCert, pki.serverKey, pki.serverKey); err == nil || !strings.Contains(err.Error(), "no certificates") {
+		t.Fatalf("expected no-certificates error, got %v", err)
+	}
+
+	cfg, err := TLSConfig(pki.serverCert, pki.serverKey, "")
+	if err != nil {
+		t.Fatalf("TLSConfig without CA: %v", err)
+	}
+	if cfg.ClientAuth != tls.NoClientCert {
+		t.Fatalf("ClientAuth = %v, want NoClientCert", cfg.ClientAuth)
+	}
+}
@@ -1,6 +1,7 @@
 package opshttp
 
 import (
+	"crypto/tls"
 	"net/http"
 
 	"github.com/keithlinneman/linnemanlabs-web/internal/health"
@@ -14,4 +15,27 @@ type Options struct {
 	Readiness    health.Probe
 	UseRecoverMW bool
 	OnPanic      func() // Optional callback for when panics are recovered, e.g. to trigger alerts or increment prometheus counters, etc.
+
+	// ContentQuarantine, when set, is exposed read-only at
+	// /content/quarantine so an operator can list bundles the content
+	// watcher rejected.
+	ContentQuarantine ContentQuarantine
+
+	// ContentStaged, when set, is exposed at /content/staged to show a bundle
+	// held for scheduled activation.
+	ContentStaged ContentStaged
+
+	// Preview, when set, serves the content preview site on this listener:
+	// for requests to PreviewHost when that is set, otherwise for any path
+	// not claimed by an ops endpoint. Unreleased content stays off the
+	// public listener.
+	Preview     http.Handler
+	PreviewHost string
+
+	// TLSConfig, when set, serves the ops listener over TLS. Build it with
+	// TLSConfig(); a client CA there enables mTLS for the admin API.
+	TLSConfig *tls.Config
+
+	// Admin, when set, mounts the authenticated admin API under /admin/.
+	Admin *AdminOptions
 }
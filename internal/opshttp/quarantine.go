@@ -0,0 +1,35 @@
+package opshttp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+)
+
+// ContentQuarantine is the read-only view of the content watcher's
+// quarantine set the ops server needs; *content.Quarantine implements it.
+// Clearing entries is an admin action (DELETE /admin/content/quarantine).
+type ContentQuarantine interface {
+	List() []content.QuarantineEntry
+}
+
+// quarantineHandler serves GET /content/quarantine, listing quarantined
+// bundles.
+func quarantineHandler(q ContentQuarantine) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeOpsJSON(w, http.StatusOK, map[string]any{"entries": q.List()})
+	})
+}
+
+func writeOpsJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,85 @@
+package opshttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+)
+
+func newQuarantineWithEntries(keys ...string) *content.Quarantine {
+	q := content.NewQuarantine(content.QuarantineOptions{BaseBackoff: time.Hour})
+	for _, k := range keys {
+		q.Record(k, content.QuarantineReasonValidation, errors.New("index.html not found"))
+	}
+	return q
+}
+
+func serveQuarantine(q ContentQuarantine, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	quarantineHandler(q).ServeHTTP(rec, httptest.NewRequest(method, target, http.NoBody))
+	return rec
+}
+
+func TestQuarantineHandler_List(t *testing.T) {
+	q := newQuarantineWithEntries("sha384:aaa", "sha384:bbb")
+
+	rec := serveQuarantine(q, http.MethodGet, "/content/quarantine")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var body struct {
+		Entries []content.QuarantineEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Entries) != 2 {
+		t.Fatalf("entries = %+v", body.Entries)
+	}
+	if body.Entries[0].Reason != "validation" || body.Entries[0].Attempts != 1 || body.Entries[0].Error == "" {
+		t.Fatalf("entry = %+v", body.Entries[0])
+	}
+}
+
+func TestQuarantineHandler_MethodNotAllowed(t *testing.T) {
+	rec := serveQuarantine(newQuarantineWithEntries(), http.MethodPost, "/content/quarantine")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", rec.Code)
+	}
+}
+
+func TestQuarantineHandler_DeleteNotAllowed(t *testing.T) {
+	// clearing is an authenticated admin action, not an ops route
+	q := newQuarantineWithEntries("sha384:aaa")
+
+	rec := serveQuarantine(q, http.MethodDelete, "/content/quarantine?all=true")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", rec.Code)
+	}
+	if q.Len() != 1 {
+		t.Fatal("DELETE on the ops route cleared the quarantine")
+	}
+}
+
+func TestStart_QuarantineEndpoint(t *testing.T) {
+	port, _ := startOps(t, &Options{ContentQuarantine: newQuarantineWithEntries("sha384:aaa")})
+	resp := opsGet(t, port, "/content/quarantine")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+}
+
+func TestStart_QuarantineEndpoint_NotConfigured(t *testing.T) {
+	port, _ := startOps(t, &Options{})
+	resp := opsGet(t, port, "/content/quarantine")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", resp.StatusCode)
+	}
+	resp.Body.Close()
+}
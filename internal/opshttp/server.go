@@ -2,6 +2,7 @@ package opshttp
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -14,7 +15,8 @@ import (
 	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
 )
 
-// Start admin HTTP server with /metrics, /healthz, /readyz, pprof debug endpoints
+// Start admin HTTP server with /metrics, /healthz, /readyz, pprof debug endpoints and,
+// when configured, the authenticated /admin/ API
 // Returns stop(ctx) for graceful shutdown
 func Start(ctx context.Context, l log.Logger, opts *Options) (func(context.Context) error, error) {
 	port := opts.Port
@@ -35,6 +37,33 @@ func Start(ctx context.Context, l log.Logger, opts *Options) (func(context.Conte
 		mux.Handle("/metrics", opts.Metrics)
 	}
 
+	// Content watcher quarantine
+	if opts.ContentQuarantine != nil {
+		mux.Handle("/content/quarantine", quarantineHandler(opts.ContentQuarantine))
+	}
+	if opts.ContentStaged != nil {
+		mux.Handle("/content/staged", stagedHandler(opts.ContentStaged))
+	}
+
+	// content preview site; a host pattern outranks the ops paths for that
+	// host, "/" only catches what they leave
+	if opts.Preview != nil {
+		if opts.PreviewHost != "" {
+			mux.Handle(opts.PreviewHost+"/", opts.Preview)
+		} else {
+			mux.Handle("/", opts.Preview)
+		}
+	}
+
+	// authenticated admin API for runtime actions
+	if opts.Admin != nil {
+		admin, err := adminHandler(l, opts.Admin)
+		if err != nil {
+			return nil, err
+		}
+		mux.Handle("/admin/", admin)
+	}
+
 	// pprof (or shadow with 404s)
 	if opts.EnablePprof {
 		RegisterPprof(mux)
@@ -60,9 +89,12 @@ func Start(ctx context.Context, l log.Logger, opts *Options) (func(context.Conte
 	if err != nil {
 		return nil, xerrors.Wrapf(err, "could not listen for admin port on addr=%v", addr)
 	}
+	if opts.TLSConfig != nil {
+		ln = tls.NewListener(ln, opts.TLSConfig)
+	}
 
 	go func() {
-		l.Info(ctx, "ops http server listening", "addr", addr)
+		l.Info(ctx, "ops http server listening", "addr", addr, "tls", opts.TLSConfig != nil, "admin_api", opts.Admin != nil)
 		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
 			l.Error(ctx, err, "ops http server error")
 		}
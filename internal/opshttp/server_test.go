@@ -519,3 +519,56 @@ func TestRequireNonPublicNetwork_IPv4MappedIPv6_PrivateAllowed(t *testing.T) {
 		t.Fatalf("IPv4-mapped private IP: status = %d, want 200", rec.Code)
 	}
 }
+
+func previewStub() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("preview site"))
+	})
+}
+
+func opsGetHost(t *testing.T, port int, host, path string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d%s", port, path), http.NoBody)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	req.Host = host
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	return resp
+}
+
+func TestStart_Preview_CatchAll(t *testing.T) {
+	port, _ := startOps(t, &Options{
+		Health:    health.Fixed(true, ""),
+		Readiness: health.Fixed(true, ""),
+		Preview:   previewStub(),
+	})
+
+	if body := readBody(t, opsGet(t, port, "/posts/hello/")); body != "preview site" {
+		t.Fatalf("body = %q, want preview site", body)
+	}
+	if body := readBody(t, opsGet(t, port, "/healthz")); body == "preview site" {
+		t.Fatal("ops endpoints must win over the preview catch-all")
+	}
+}
+
+func TestStart_Preview_Host(t *testing.T) {
+	port, _ := startOps(t, &Options{
+		Health:      health.Fixed(true, ""),
+		Readiness:   health.Fixed(true, ""),
+		Preview:     previewStub(),
+		PreviewHost: "preview.internal",
+	})
+
+	if body := readBody(t, opsGetHost(t, port, "preview.internal", "/healthz")); body != "preview site" {
+		t.Fatalf("preview host body = %q", body)
+	}
+	resp := opsGet(t, port, "/posts/hello/")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("other hosts status = %d, want 404", resp.StatusCode)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,31 @@
+package opshttp
+
+import (
+	"net/http"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+)
+
+// ContentStaged is the view of the content watcher's scheduled activation
+// the ops server needs; *content.Watcher implements it.
+type ContentStaged interface {
+	Staged() (content.StagedBundle, bool)
+}
+
+// stagedHandler serves GET /content/staged: the verified bundle waiting for
+// its activate_at time, so a fleet can be checked as primed before it flips.
+func stagedHandler(s ContentStaged) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		st, ok := s.Staged()
+		if !ok {
+			writeOpsJSON(w, http.StatusOK, map[string]any{"staged": false})
+			return
+		}
+		writeOpsJSON(w, http.StatusOK, map[string]any{"staged": true, "bundle": st})
+	})
+}
@@ -0,0 +1,71 @@
+package opshttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+)
+
+type fakeStaged struct {
+	bundle content.StagedBundle
+	ok     bool
+}
+
+func (f fakeStaged) Staged() (content.StagedBundle, bool) { return f.bundle, f.ok }
+
+func serveStaged(s ContentStaged, method string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	stagedHandler(s).ServeHTTP(rec, httptest.NewRequest(method, "/content/staged", http.NoBody))
+	return rec
+}
+
+func TestStagedHandler_Staged(t *testing.T) {
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+	rec := serveStaged(fakeStaged{ok: true, bundle: content.StagedBundle{Key: "sha384:aaa", Version: "1.2.0", ActivateAt: at}}, http.MethodGet)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var body struct {
+		Staged bool                 `json:"staged"`
+		Bundle content.StagedBundle `json:"bundle"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !body.Staged || body.Bundle.Key != "sha384:aaa" || !body.Bundle.ActivateAt.Equal(at) {
+		t.Fatalf("body = %+v", body)
+	}
+}
+
+func TestStagedHandler_NothingStaged(t *testing.T) {
+	rec := serveStaged(fakeStaged{}, http.MethodGet)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body["staged"] != false || body["bundle"] != nil {
+		t.Fatalf("body = %v", body)
+	}
+}
+
+func TestStagedHandler_MethodNotAllowed(t *testing.T) {
+	if rec := serveStaged(fakeStaged{}, http.MethodPost); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", rec.Code)
+	}
+}
+
+func TestStart_StagedEndpoint(t *testing.T) {
+	port, _ := startOps(t, &Options{ContentStaged: fakeStaged{}})
+	resp := opsGet(t, port, "/content/staged")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+}
@@ -3,6 +3,7 @@ package provenancehttp
 import (
 	"context"
 	"encoding/json"
+	"io/fs"
 	"net/http"
 	"strings"
 	"time"
@@ -17,6 +18,11 @@ import (
 	v "github.com/keithlinneman/linnemanlabs-web/internal/version"
 )
 
+// evidenceCacheControl is sent with raw evidence. The evidence watcher can
+// replace release.json, inventory.json and reports at the same paths when the
+// release is re-scanned, so caches revalidate within minutes.
+const evidenceCacheControl = "public, max-age=300"
+
 // NewAPI creates a new provenance API handler
 // evidenceStore may be nil for local builds without provenance
 func NewAPI(contentProvider SnapshotProvider, evidenceStore *evidence.Store, logger log.Logger) *API {
@@ -30,6 +36,21 @@ func NewAPI(contentProvider SnapshotProvider, evidenceStore *evidence.Store, log
 	}
 }
 
+// SetExecutable sets the running binary's digest for self-attestation on the
+// app summary. Call it before serving.
+func (api *API) SetExecutable(e *evidence.Executable) {
+	api.executable = e
+}
+
+// selfAttestation compares the running binary to b's artifact for this
+// platform, or returns nil when no executable was set.
+func (api *API) selfAttestation(b *evidence.Bundle) *evidence.SelfAttestation {
+	if api.executable == nil {
+		return nil
+	}
+	return api.executable.Attest(b, evidence.RuntimePlatform())
+}
+
 // RegisterRoutes attaches provenance endpoints to the router
 func (api *API) RegisterRoutes(r chi.Router) {
 	// App build provenance (full)
@@ -41,12 +62,16 @@ func (api *API) RegisterRoutes(r chi.Router) {
 	// Content bundle provenance
 	r.Get("/api/provenance/content", api.HandleContentProvenance)
 	r.Get("/api/provenance/content/summary", api.HandleContentSummary)
+	r.Get("/api/provenance/content/files/*", api.HandleContentFile)
 
 	// Build evidence
 	r.Get("/api/provenance/evidence", api.HandleEvidenceManifest)
 	r.Get("/api/provenance/evidence/release.json", api.HandleReleaseJSON)
 	r.Get("/api/provenance/evidence/inventory.json", api.HandleInventoryJSON)
 	r.Get("/api/provenance/evidence/files/*", api.HandleEvidenceFile)
+
+	// Vulnerability findings parsed from the scan reports
+	r.Get("/api/provenance/vulnerabilities", api.HandleVulnerabilities)
 }
 
 func (api *API) HandleAppProvenance(w http.ResponseWriter, r *http.Request) {
@@ -62,11 +87,12 @@ func (api *API) buildAppProvenance(ctx context.Context) AppProvenanceResponse {
 	resp := AppProvenanceResponse{
 		Build: v.Get(),
 		Links: map[string]string{
-			"summary":   "/api/provenance/app/summary",
-			"evidence":  "/api/provenance/evidence",
-			"release":   "/api/provenance/evidence/release.json",
-			"inventory": "/api/provenance/evidence/inventory.json",
-			"content":   "/api/provenance/content",
+			"summary":         "/api/provenance/app/summary",
+			"evidence":        "/api/provenance/evidence",
+			"release":         "/api/provenance/evidence/release.json",
+			"inventory":       "/api/provenance/evidence/inventory.json",
+			"content":         "/api/provenance/content",
+			"vulnerabilities": "/api/provenance/vulnerabilities",
 		},
 	}
 
@@ -110,6 +136,8 @@ func (api *API) buildAppProvenance(ctx context.Context) AppProvenanceResponse {
 			SBOMAttested:    ac.SBOMAttested,
 			ScanAttested:    ac.ScanAttested,
 			LicenseAttested: ac.LicenseAttested,
+			Verified:        ac.Verified,
+			Failed:          ac.Failed,
 			Files:           attestationFiles,
 		}
 	}
@@ -204,9 +232,10 @@ func (api *API) HandleAppSummary(w http.ResponseWriter, r *http.Request) {
 	bundle, ok := api.evidence.Get()
 	if !ok {
 		api.writeJSON(ctx, w, http.StatusOK, AppSummaryResponse{
-			HasEvidence: false,
-			Error:       "no evidence loaded",
-			Links:       appSummaryLinks(),
+			HasEvidence:     false,
+			Error:           "no evidence loaded",
+			Links:           appSummaryLinks(),
+			SelfAttestation: api.selfAttestation(nil),
 		})
 		return
 	}
@@ -224,6 +253,8 @@ func (api *API) HandleAppSummary(w http.ResponseWriter, r *http.Request) {
 		FetchedAt:   bundle.FetchedAt,
 		Links:       appSummaryLinks(),
 		GoVersion:   bi.GoVersion,
+
+		SelfAttestation: api.selfAttestation(bundle),
 	}
 
 	// source
@@ -382,6 +413,8 @@ func (api *API) HandleAppSummary(w http.ResponseWriter, r *http.Request) {
 			SBOMAttested:         ac.SBOMAttested,
 			ScanAttested:         ac.ScanAttested,
 			LicenseAttested:      ac.LicenseAttested,
+			Verified:             ac.Verified,
+			Failed:               ac.Failed,
 		}
 	}
 
@@ -476,11 +509,12 @@ func buildAppSummaryComponents(rel *evidence.ReleaseManifest) []AppSummaryCompon
 
 func appSummaryLinks() map[string]string {
 	return map[string]string{
-		"full":      "/api/provenance/app",
-		"evidence":  "/api/provenance/evidence",
-		"release":   "/api/provenance/evidence/release.json",
-		"inventory": "/api/provenance/evidence/inventory.json",
-		"content":   "/api/provenance/content/summary",
+		"full":            "/api/provenance/app",
+		"evidence":        "/api/provenance/evidence",
+		"release":         "/api/provenance/evidence/release.json",
+		"inventory":       "/api/provenance/evidence/inventory.json",
+		"content":         "/api/provenance/content/summary",
+		"vulnerabilities": "/api/provenance/vulnerabilities",
 	}
 }
 
@@ -520,6 +554,7 @@ func buildContentResponse(snap *content.Snapshot, serverTime time.Time) ContentP
 		},
 		Signatures:     snap.Meta.Signatures,
 		TrustedRootURL: cryptoutil.TrustedRootURL(),
+		AugmentedFiles: snap.Augmented,
 	}
 
 	if snap.Provenance == nil {
@@ -529,6 +564,74 @@ func buildContentResponse(snap *content.Snapshot, serverTime time.Time) ContentP
 	return resp
 }
 
+// HandleContentFile reconciles a single served content file with the signed
+// release.json: its served digest, its manifest entry and, for pages rewritten
+// by island injection, the original digest and injected islands.
+func (api *API) HandleContentFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filePath := chi.URLParam(r, "*")
+	if filePath == "" {
+		http.Error(w, `{"error":"file path required"}`, http.StatusBadRequest)
+		return
+	}
+	if strings.Contains(filePath, "\x00") || strings.Contains(filePath, "\\") || pathutil.HasDotSegments(filePath) {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+
+	snap, ok := api.content.Get()
+	if !ok {
+		http.Error(w, `{"error":"no content loaded"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	data, err := fs.ReadFile(snap.FS, filePath)
+	if err != nil {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+
+	api.writeJSON(ctx, w, http.StatusOK, buildContentFileResponse(snap, filePath, data))
+}
+
+// buildContentFileResponse classifies a served file against release.json.
+func buildContentFileResponse(snap *content.Snapshot, filePath string, data []byte) ContentFileResponse {
+	resp := ContentFileResponse{
+		Path:       filePath,
+		SHA256:     cryptoutil.SHA256Hex(data),
+		Size:       int64(len(data)),
+		BundleHash: snap.Meta.Hash,
+	}
+
+	if snap.Provenance != nil {
+		for i := range snap.Provenance.Files {
+			f := &snap.Provenance.Files[i]
+			if strings.TrimPrefix(f.Path, "./") == filePath {
+				resp.Manifest = f
+				break
+			}
+		}
+	}
+	if aug, ok := snap.Augmented[filePath]; ok {
+		resp.Augmented = &aug
+	}
+
+	switch {
+	case resp.Manifest == nil:
+		resp.Status = ContentFileUnlisted
+	case resp.Augmented != nil &&
+		strings.EqualFold(resp.Augmented.OriginalSHA256, resp.Manifest.SHA256) &&
+		resp.Augmented.SHA256 == resp.SHA256:
+		resp.Status = ContentFileAugmented
+	case strings.EqualFold(resp.SHA256, resp.Manifest.SHA256) && resp.Size == resp.Manifest.Size:
+		resp.Status = ContentFileMatch
+	default:
+		resp.Status = ContentFileMismatch
+	}
+	return resp
+}
+
 // HandleContentSummary serves a lightweight summary for UI display
 func (api *API) HandleContentSummary(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -630,8 +733,7 @@ func (api *API) HandleReleaseJSON(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	// immutable for a given release (for now at least, will do re-scans etc soon)
-	w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+	w.Header().Set("Cache-Control", evidenceCacheControl)
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(bundle.ReleaseRaw) //nolint:gosec // G705: Content-Type set to application/json above
 }
@@ -650,7 +752,7 @@ func (api *API) HandleInventoryJSON(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+	w.Header().Set("Cache-Control", evidenceCacheControl)
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(bundle.InventoryRaw) //nolint:gosec // G705: Content-Type set to application/json above
 }
@@ -700,8 +802,7 @@ func (api *API) HandleEvidenceFile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", contentType)
-	// evidence is immutable and hash-addressed via inventory (will do periodic re-scans soon)
-	w.Header().Set("Cache-Control", "public, max-age=86400, immutable")
+	w.Header().Set("Cache-Control", evidenceCacheControl)
 	w.WriteHeader(http.StatusOK)
 	_, err := w.Write(file.Data) //nolint:gosec // G705: Content-Type set to application/json or in-toto+json above
 	if err != nil {
@@ -6,11 +6,13 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+	"github.com/keithlinneman/linnemanlabs-web/internal/cryptoutil"
 	"github.com/keithlinneman/linnemanlabs-web/internal/evidence"
 	"github.com/keithlinneman/linnemanlabs-web/internal/log"
 	"github.com/keithlinneman/linnemanlabs-web/internal/pathutil"
@@ -258,6 +260,7 @@ func TestRegisterRoutes_AllEndpoints(t *testing.T) {
 		{http.MethodGet, "/api/provenance/evidence/release.json"},
 		{http.MethodGet, "/api/provenance/evidence/inventory.json"},
 		{http.MethodGet, "/api/provenance/evidence/files/source/sbom/report.json"},
+		{http.MethodGet, "/api/provenance/vulnerabilities"},
 	}
 
 	for _, ep := range endpoints {
@@ -372,7 +375,7 @@ func TestHandleAppProvenance_AlwaysHasLinks(t *testing.T) {
 		t.Fatal("_links should be a map")
 	}
 
-	required := []string{"summary", "evidence", "release", "inventory", "content"}
+	required := []string{"summary", "evidence", "release", "inventory", "content", "vulnerabilities"}
 	for _, key := range required {
 		if links[key] == nil {
 			t.Errorf("_links missing %q", key)
@@ -451,6 +454,77 @@ func TestHandleAppSummary_WithEvidence(t *testing.T) {
 	}
 }
 
+func TestHandleAppSummary_SelfAttestation(t *testing.T) {
+	runningSHA := cryptoutil.SHA256Hex([]byte("server binary"))
+	osName, arch, _ := strings.Cut(evidence.RuntimePlatform(), "/")
+
+	tests := []struct {
+		name     string
+		artifact string
+		want     string
+	}{
+		{"match", runningSHA, evidence.SelfAttestMatch},
+		{"mismatch", cryptoutil.SHA256Hex([]byte("other binary")), evidence.SelfAttestMismatch},
+		{"no digest", "", evidence.SelfAttestUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := testBundle()
+			b.Release.Artifacts = []evidence.ReleaseArtifact{
+				{OS: osName, Arch: arch, Binary: evidence.BinaryRef{Path: "bin/server", SHA256: tt.artifact}},
+			}
+			store := evidence.NewStore()
+			store.Set(b)
+			api := NewAPI(noContentProvider(), store, log.Nop())
+			api.SetExecutable(&evidence.Executable{Path: "/usr/local/bin/server", SHA256: runningSHA})
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/provenance/app/summary", http.NoBody)
+			api.HandleAppSummary(rec, req)
+
+			sa, ok := parseJSON(t, rec)["self_attestation"].(map[string]any)
+			if !ok {
+				t.Fatal("self_attestation missing")
+			}
+			if sa["status"] != tt.want {
+				t.Fatalf("status = %v, want %s", sa["status"], tt.want)
+			}
+			if sa["sha256"] != runningSHA {
+				t.Fatalf("sha256 = %v", sa["sha256"])
+			}
+			if sa["platform"] != evidence.RuntimePlatform() {
+				t.Fatalf("platform = %v", sa["platform"])
+			}
+		})
+	}
+}
+
+func TestHandleAppSummary_SelfAttestationWithoutEvidence(t *testing.T) {
+	api := NewAPI(noContentProvider(), emptyEvidenceStore(), log.Nop())
+	api.SetExecutable(&evidence.Executable{SHA256: "abc"})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/provenance/app/summary", http.NoBody)
+	api.HandleAppSummary(rec, req)
+
+	sa, ok := parseJSON(t, rec)["self_attestation"].(map[string]any)
+	if !ok || sa["status"] != evidence.SelfAttestUnknown {
+		t.Fatalf("self_attestation = %v, want unknown", sa)
+	}
+}
+
+func TestHandleAppSummary_NoExecutableOmitsSelfAttestation(t *testing.T) {
+	api := NewAPI(noContentProvider(), evidenceStore(), log.Nop())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/provenance/app/summary", http.NoBody)
+	api.HandleAppSummary(rec, req)
+
+	if _, ok := parseJSON(t, rec)["self_attestation"]; ok {
+		t.Fatal("self_attestation should be omitted when no executable was set")
+	}
+}
+
 func TestHandleAppSummary_BuilderHasBuildAttribution(t *testing.T) {
 	api := NewAPI(noContentProvider(), evidenceStore(), log.Nop())
 
@@ -639,6 +713,164 @@ func TestHandleContentProvenance_NoProvenance(t *testing.T) {
 	}
 }
 
+// augmentedContentProvider serves a bundle with one island-augmented page,
+// one untouched file matching release.json and one unlisted file.
+func augmentedContentProvider() *stubSnapshotProvider {
+	original := []byte(`<html>"__PROVENANCE_CONTENT_DATA__"</html>`)
+	served := []byte(`<html>{"c":1}</html>`)
+	css := []byte("body{}")
+	return &stubSnapshotProvider{
+		snap: &content.Snapshot{
+			FS: fstest.MapFS{
+				"index.html":    &fstest.MapFile{Data: served},
+				"css/style.css": &fstest.MapFile{Data: css},
+				"extra.txt":     &fstest.MapFile{Data: []byte("x")},
+			},
+			Meta: content.Meta{Hash: "abc123def456", Source: content.SourceS3},
+			Provenance: &content.Provenance{
+				Version: "v1.0.0",
+				Files: []content.ProvenanceFile{
+					{Path: "index.html", SHA256: cryptoutil.SHA256Hex(original), Size: int64(len(original))},
+					{Path: "css/style.css", SHA256: cryptoutil.SHA256Hex(css), Size: int64(len(css))},
+				},
+			},
+			Augmented: map[string]content.AugmentedFile{
+				"index.html": {
+					OriginalSHA256: cryptoutil.SHA256Hex(original),
+					OriginalSize:   int64(len(original)),
+					SHA256:         cryptoutil.SHA256Hex(served),
+					Size:           int64(len(served)),
+					Islands:        []string{"provenance-content-data"},
+				},
+			},
+		},
+		ok: true,
+	}
+}
+
+func TestHandleContentProvenance_AugmentedFiles(t *testing.T) {
+	api := NewAPI(augmentedContentProvider(), nil, log.Nop())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/provenance/content", http.NoBody)
+	api.HandleContentProvenance(rec, req)
+
+	var resp ContentProvenanceResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	aug, ok := resp.AugmentedFiles["index.html"]
+	if !ok {
+		t.Fatalf("augmented_files missing index.html: %v", resp.AugmentedFiles)
+	}
+	if aug.OriginalSHA256 != resp.Bundle.Files[0].SHA256 {
+		t.Fatalf("original_sha256 = %q, want release.json digest %q", aug.OriginalSHA256, resp.Bundle.Files[0].SHA256)
+	}
+	if len(aug.Islands) != 1 || aug.Islands[0] != "provenance-content-data" {
+		t.Fatalf("islands = %v", aug.Islands)
+	}
+}
+
+func TestHandleContentProvenance_NoAugmentedFilesOmitted(t *testing.T) {
+	api := NewAPI(contentProvider(), nil, log.Nop())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/provenance/content", http.NoBody)
+	api.HandleContentProvenance(rec, req)
+
+	if _, ok := parseJSON(t, rec)["augmented_files"]; ok {
+		t.Fatal("augmented_files should be omitted when nothing was augmented")
+	}
+}
+
+// HandleContentFile
+
+func TestHandleContentFile_Statuses(t *testing.T) {
+	api := NewAPI(augmentedContentProvider(), nil, log.Nop())
+
+	for path, want := range map[string]string{
+		"index.html":    ContentFileAugmented,
+		"css/style.css": ContentFileMatch,
+		"extra.txt":     ContentFileUnlisted,
+	} {
+		rec := serveWithChi("/api/provenance/content/files/*", http.MethodGet,
+			"/api/provenance/content/files/"+path, api.HandleContentFile)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d", path, rec.Code)
+		}
+		var resp ContentFileResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode: %v", path, err)
+		}
+		if resp.Status != want {
+			t.Errorf("%s: status = %q, want %q", path, resp.Status, want)
+		}
+		if resp.BundleHash != "abc123def456" {
+			t.Errorf("%s: bundle_hash = %q", path, resp.BundleHash)
+		}
+	}
+}
+
+func TestHandleContentFile_AugmentedDetails(t *testing.T) {
+	api := NewAPI(augmentedContentProvider(), nil, log.Nop())
+
+	rec := serveWithChi("/api/provenance/content/files/*", http.MethodGet,
+		"/api/provenance/content/files/index.html", api.HandleContentFile)
+
+	var resp ContentFileResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Augmented == nil || resp.Manifest == nil {
+		t.Fatalf("augmented and manifest should both be set: %+v", resp)
+	}
+	if resp.SHA256 != resp.Augmented.SHA256 {
+		t.Fatalf("served sha256 %q != augmented sha256 %q", resp.SHA256, resp.Augmented.SHA256)
+	}
+	if resp.SHA256 == resp.Manifest.SHA256 {
+		t.Fatal("served digest should differ from the manifest for an augmented page")
+	}
+}
+
+func TestHandleContentFile_Mismatch(t *testing.T) {
+	p := augmentedContentProvider()
+	p.snap.FS.(fstest.MapFS)["css/style.css"].Data = []byte("body{color:red}")
+	api := NewAPI(p, nil, log.Nop())
+
+	rec := serveWithChi("/api/provenance/content/files/*", http.MethodGet,
+		"/api/provenance/content/files/css/style.css", api.HandleContentFile)
+
+	var resp ContentFileResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Status != ContentFileMismatch {
+		t.Fatalf("status = %q, want mismatch", resp.Status)
+	}
+}
+
+func TestHandleContentFile_NotFound(t *testing.T) {
+	api := NewAPI(augmentedContentProvider(), nil, log.Nop())
+
+	for _, path := range []string{"missing.html", "css/../index.html"} {
+		rec := serveWithChi("/api/provenance/content/files/*", http.MethodGet,
+			"/api/provenance/content/files/"+path, api.HandleContentFile)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", path, rec.Code)
+		}
+	}
+}
+
+func TestHandleContentFile_NoContent(t *testing.T) {
+	api := NewAPI(noContentProvider(), nil, log.Nop())
+
+	rec := serveWithChi("/api/provenance/content/files/*", http.MethodGet,
+		"/api/provenance/content/files/index.html", api.HandleContentFile)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+}
+
 // HandleContentSummary
 
 func TestHandleContentSummary_NoContent(t *testing.T) {
@@ -817,6 +1049,48 @@ func TestHandleEvidenceManifest_WithEvidence(t *testing.T) {
 	}
 }
 
+func TestHandleEvidenceManifest_AttestationResults(t *testing.T) {
+	b := testBundle()
+	b.FileIndex["artifact/scan/attestation.json"].Attestation = &evidence.AttestationResult{
+		Verified: true,
+		Signer:   "kms",
+		Subject:  "artifact/scan/report.json",
+	}
+	store := evidence.NewStore()
+	store.Set(b)
+	api := NewAPI(noContentProvider(), store, log.Nop())
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/provenance/evidence", http.NoBody)
+	api.HandleEvidenceManifest(rec, req)
+
+	var resp EvidenceManifestResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var found bool
+	for _, f := range resp.Files {
+		if f.Kind != "attestation" {
+			if f.Attestation != nil {
+				t.Fatalf("%s: report carries an attestation result", f.Path)
+			}
+			continue
+		}
+		found = true
+		if f.Attestation == nil || !f.Attestation.Verified || f.Attestation.Signer != "kms" {
+			t.Fatalf("%s: attestation = %+v", f.Path, f.Attestation)
+		}
+	}
+	if !found {
+		t.Fatal("no attestation in manifest files")
+	}
+
+	summary := api.buildAppProvenance(t.Context())
+	if summary.Attestations == nil || summary.Attestations.Verified != 1 || summary.Attestations.Failed != 0 {
+		t.Fatalf("attestation counts = %+v", summary.Attestations)
+	}
+}
+
 // HandleReleaseJSON
 
 func TestHandleReleaseJSON_NoEvidence(t *testing.T) {
@@ -867,8 +1141,8 @@ func TestHandleReleaseJSON_CacheHeaders(t *testing.T) {
 	api.HandleReleaseJSON(rec, req)
 
 	cc := rec.Header().Get("Cache-Control")
-	if !strings.Contains(cc, "immutable") {
-		t.Fatalf("Cache-Control = %q, want immutable", cc)
+	if cc != evidenceCacheControl || strings.Contains(cc, "immutable") {
+		t.Fatalf("Cache-Control = %q, want %q", cc, evidenceCacheControl)
 	}
 	ct := rec.Header().Get("Content-Type")
 	if !strings.Contains(ct, "application/json") {
@@ -914,8 +1188,8 @@ func TestHandleInventoryJSON_CacheHeaders(t *testing.T) {
 	api.HandleInventoryJSON(rec, req)
 
 	cc := rec.Header().Get("Cache-Control")
-	if !strings.Contains(cc, "immutable") {
-		t.Fatalf("Cache-Control = %q, want immutable", cc)
+	if cc != evidenceCacheControl || strings.Contains(cc, "immutable") {
+		t.Fatalf("Cache-Control = %q, want %q", cc, evidenceCacheControl)
 	}
 }
 
@@ -976,8 +1250,8 @@ func TestHandleEvidenceFile_CacheHeaders(t *testing.T) {
 	)
 
 	cc := rec.Header().Get("Cache-Control")
-	if !strings.Contains(cc, "immutable") {
-		t.Fatalf("Cache-Control = %q, want immutable", cc)
+	if cc != evidenceCacheControl || strings.Contains(cc, "immutable") {
+		t.Fatalf("Cache-Control = %q, want %q", cc, evidenceCacheControl)
 	}
 }
 
@@ -1210,7 +1484,7 @@ func TestBuildAppSummarySource_BranchBuild(t *testing.T) {
 func TestAppSummaryLinks(t *testing.T) {
 	links := appSummaryLinks()
 
-	required := []string{"full", "evidence", "release", "inventory", "content"}
+	required := []string{"full", "evidence", "release", "inventory", "content", "vulnerabilities"}
 	for _, key := range required {
 		if links[key] == "" {
 			t.Errorf("appSummaryLinks missing %q", key)
@@ -2,6 +2,7 @@ package provenancehttp
 
 import (
 	"encoding/json"
+	"sync/atomic"
 	"time"
 
 	"github.com/keithlinneman/linnemanlabs-web/internal/content"
@@ -46,6 +47,13 @@ type API struct {
 	content  SnapshotProvider
 	evidence *evidence.Store
 	logger   log.Logger
+
+	// executable is the running binary's digest, compared against the
+	// release's artifact on the summary. Nil for local builds.
+	executable *evidence.Executable
+
+	// vulns caches the findings parsed from the current bundle's scan reports
+	vulns atomic.Pointer[vulnCache]
 }
 
 // AppProvenanceResponse is the comprehensive app provenance endpoint.
@@ -107,6 +115,10 @@ type AppProvenanceAttestations struct {
 	ScanAttested    bool `json:"scan_attested"`
 	LicenseAttested bool `json:"license_attested"`
 
+	// load-time DSSE verification outcomes; per-file detail is on Files
+	Verified int `json:"verified"`
+	Failed   int `json:"failed"`
+
 	// Full list of attestation files with metadata
 	Files []*evidence.EvidenceFileRef `json:"files,omitempty"`
 }
@@ -132,9 +144,42 @@ type ContentProvenanceResponse struct {
 	// TrustedRootURL to the core trusted_root.json for LinnemanLabs trust stack.
 	TrustedRootURL string `json:"trusted_root_url,omitempty"`
 
+	// AugmentedFiles lists, by path, the pages the server rewrote to inline
+	// provenance data islands, with the original (release.json) and served
+	// digests. Absent from the inlined island itself, which is built before
+	// injection.
+	AugmentedFiles map[string]content.AugmentedFile `json:"augmented_files,omitempty"`
+
 	Error string `json:"error,omitempty"`
 }
 
+// Content file reconciliation statuses reported by ContentFileResponse.
+const (
+	ContentFileMatch     = "match"     // served bytes match release.json
+	ContentFileAugmented = "augmented" // rewritten by island injection; original matches release.json
+	ContentFileMismatch  = "mismatch"  // served bytes differ from release.json
+	ContentFileUnlisted  = "unlisted"  // served but not in release.json
+)
+
+// ContentFileResponse reconciles one served content file with its entry in
+// the bundle's signed release.json.
+type ContentFileResponse struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+
+	// SHA256 and Size describe the bytes currently served for Path.
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+
+	// Manifest is the release.json entry for Path, if any.
+	Manifest *content.ProvenanceFile `json:"manifest,omitempty"`
+
+	// Augmented is set when the server rewrote the file to inline provenance.
+	Augmented *content.AugmentedFile `json:"augmented,omitempty"`
+
+	BundleHash string `json:"bundle_hash,omitempty"`
+}
+
 // RuntimeInfo contains server-side runtime information
 type RuntimeInfo struct {
 	LoadedAt   time.Time      `json:"loaded_at"`
@@ -232,6 +277,10 @@ type AppSummaryResponse struct {
 	Evidence         *AppSummaryEvidence         `json:"evidence,omitempty"`
 	Components       []AppSummaryComponent       `json:"components,omitempty"`
 
+	// SelfAttestation reports whether the binary answering this request is
+	// the artifact release.json lists for its platform.
+	SelfAttestation *evidence.SelfAttestation `json:"self_attestation,omitempty"`
+
 	// Signatures aggregates the keyless + KMS signature evidence for
 	// release.json. Either half may be nil.
 	Signatures *cryptoutil.SignaturesInfo `json:"signatures,omitempty"`
@@ -248,6 +297,33 @@ type AppSummaryResponse struct {
 	Links map[string]string `json:"_links,omitempty"`
 }
 
+// VulnerabilitiesResponse lists the findings parsed from the evidence
+// bundle's scan reports, after any filters.
+type VulnerabilitiesResponse struct {
+	HasEvidence bool   `json:"has_evidence"`
+	Error       string `json:"error,omitempty"`
+
+	ReleaseID string `json:"release_id,omitempty"`
+	Version   string `json:"version,omitempty"`
+
+	// Total and Counts describe the findings that match the filters
+	Total    int                       `json:"total"`
+	Counts   evidence.VulnCounts       `json:"counts"`
+	Findings []evidence.VulnFinding    `json:"findings"`
+	Filters  *VulnFilters              `json:"filters,omitempty"`
+	Reports  []evidence.VulnScanReport `json:"reports,omitempty"`
+
+	Links map[string]string `json:"_links,omitempty"`
+}
+
+// VulnFilters are the query filters applied to /api/provenance/vulnerabilities.
+type VulnFilters struct {
+	Severity []string `json:"severity,omitempty"` // ?severity=critical,high
+	Scope    string   `json:"scope,omitempty"`    // ?scope=source or artifact
+	Package  string   `json:"package,omitempty"`  // ?package=golang.org/x/net
+	Fixed    *bool    `json:"fixed,omitempty"`    // ?fixed=true: a fixed version is available
+}
+
 type AppSummarySource struct {
 	Repository  string    `json:"repository"`
 	Commit      string    `json:"commit"`
@@ -411,6 +487,10 @@ type AppSummaryAttestations struct {
 	SBOMAttested    bool `json:"sbom_attested"`
 	ScanAttested    bool `json:"scan_attested"`
 	LicenseAttested bool `json:"license_attested"`
+
+	// attestations whose signature and subject checked out at load time
+	Verified int `json:"verified"`
+	Failed   int `json:"failed"`
 }
 
 // AppProvenanceLicenses is the enriched license section on the full endpoint.
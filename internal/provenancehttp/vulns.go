@@ -0,0 +1,159 @@
+package provenancehttp
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/evidence"
+	"github.com/keithlinneman/linnemanlabs-web/internal/xerrors"
+)
+
+// vulnCache holds the findings parsed from one evidence bundle. Reports are
+// parsed on the first request and again only after the evidence watcher
+// stores a new bundle.
+type vulnCache struct {
+	bundle   *evidence.Bundle
+	findings []evidence.VulnFinding
+	reports  []evidence.VulnScanReport
+}
+
+// HandleVulnerabilities serves the deduplicated findings from the grype,
+// trivy and govulncheck reports in the evidence bundle, filtered by the
+// severity, scope, package and fixed query parameters.
+func (api *API) HandleVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filters, err := parseVulnFilters(r)
+	if err != nil {
+		api.writeJSON(ctx, w, http.StatusBadRequest, VulnerabilitiesResponse{
+			Error:    err.Error(),
+			Findings: []evidence.VulnFinding{},
+		})
+		return
+	}
+
+	if api.evidence == nil {
+		api.writeJSON(ctx, w, http.StatusOK, VulnerabilitiesResponse{
+			HasEvidence: false,
+			Error:       "evidence not configured (local build)",
+			Findings:    []evidence.VulnFinding{},
+		})
+		return
+	}
+
+	bundle, ok := api.evidence.Get()
+	if !ok {
+		api.writeJSON(ctx, w, http.StatusOK, VulnerabilitiesResponse{
+			HasEvidence: false,
+			Error:       "no evidence loaded",
+			Findings:    []evidence.VulnFinding{},
+		})
+		return
+	}
+
+	vc := api.vulnFindings(bundle)
+	findings := make([]evidence.VulnFinding, 0, len(vc.findings))
+	for i := range vc.findings {
+		if filters.match(&vc.findings[i]) {
+			findings = append(findings, vc.findings[i])
+		}
+	}
+
+	resp := VulnerabilitiesResponse{
+		HasEvidence: true,
+		Total:       len(findings),
+		Counts:      evidence.CountVulnFindings(findings),
+		Findings:    findings,
+		Reports:     vc.reports,
+		Links: map[string]string{
+			"summary":  "/api/provenance/app/summary",
+			"evidence": "/api/provenance/evidence",
+		},
+	}
+	if filters.active() {
+		resp.Filters = filters
+	}
+	if rel := bundle.Release; rel != nil {
+		resp.ReleaseID = rel.ReleaseID
+		resp.Version = rel.Version
+	}
+
+	api.logger.Debug(ctx, "served vulnerability findings",
+		"total", len(vc.findings),
+		"matched", len(findings),
+	)
+
+	api.writeJSON(ctx, w, http.StatusOK, resp)
+}
+
+// vulnFindings returns the parsed findings for bundle, parsing its scan
+// reports only when bundle isn't the one already cached.
+func (api *API) vulnFindings(bundle *evidence.Bundle) *vulnCache {
+	if vc := api.vulns.Load(); vc != nil && vc.bundle == bundle {
+		return vc
+	}
+	findings, reports := bundle.VulnFindings()
+	vc := &vulnCache{bundle: bundle, findings: findings, reports: reports}
+	api.vulns.Store(vc)
+	return vc
+}
+
+// parseVulnFilters reads the query filters, rejecting values that could
+// never match so a typo doesn't read as "no findings".
+func parseVulnFilters(r *http.Request) (*VulnFilters, error) {
+	q := r.URL.Query()
+	f := &VulnFilters{
+		Scope:   q.Get("scope"),
+		Package: q.Get("package"),
+	}
+	if raw := q.Get("severity"); raw != "" {
+		for _, s := range strings.Split(raw, ",") {
+			s = strings.ToLower(strings.TrimSpace(s))
+			if !evidence.ValidSeverity(s) {
+				return nil, xerrors.Newf("invalid severity %q (want critical, high, medium, low, negligible or unknown)", s)
+			}
+			f.Severity = append(f.Severity, s)
+		}
+	}
+	if f.Scope != "" && f.Scope != "source" && f.Scope != "artifact" {
+		return nil, xerrors.Newf("invalid scope %q (want source or artifact)", f.Scope)
+	}
+	if raw := q.Get("fixed"); raw != "" {
+		fixed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, xerrors.Newf("invalid fixed %q (want true or false)", raw)
+		}
+		f.Fixed = &fixed
+	}
+	return f, nil
+}
+
+func (f *VulnFilters) active() bool {
+	return len(f.Severity) > 0 || f.Scope != "" || f.Package != "" || f.Fixed != nil
+}
+
+func (f *VulnFilters) match(v *evidence.VulnFinding) bool {
+	if len(f.Severity) > 0 {
+		ok := false
+		for _, s := range f.Severity {
+			if v.Severity == s {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if f.Scope != "" && v.Scope != f.Scope {
+		return false
+	}
+	if f.Package != "" && v.Package != f.Package {
+		return false
+	}
+	if f.Fixed != nil && (v.FixedVersion != "") != *f.Fixed {
+		return false
+	}
+	return true
+}
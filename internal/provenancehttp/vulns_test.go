@@ -0,0 +1,201 @@
+package provenancehttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/evidence"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+)
+
+// vulnBundle returns testBundle with a source grype report and an artifact
+// trivy report that share one finding.
+func vulnBundle() *evidence.Bundle {
+	b := testBundle()
+	b.FileIndex = map[string]*evidence.EvidenceFileRef{}
+	b.Files = map[string]*evidence.EvidenceFile{}
+	add := func(path, scope, scanner, data string) {
+		ref := &evidence.EvidenceFileRef{Path: path, Scope: scope, Category: "scan", Kind: "report", Scanner: scanner, Format: "json"}
+		b.FileIndex[path] = ref
+		b.Files[path] = &evidence.EvidenceFile{Ref: ref, Data: []byte(data)}
+	}
+	add("source/scans/grype.json", "source", "grype", `{"matches": [
+		{"vulnerability": {"id": "GHSA-qppj-fm5r-hxr3", "severity": "High", "fix": {"versions": ["0.17.0"], "state": "fixed"}},
+		 "relatedVulnerabilities": [{"id": "CVE-2023-44487"}],
+		 "artifact": {"name": "golang.org/x/net", "version": "v0.16.0"}},
+		{"vulnerability": {"id": "CVE-2024-0001", "severity": "Low", "fix": {"state": "not-fixed"}},
+		 "artifact": {"name": "example.com/lib", "version": "v1.0.0"}}
+	]}`)
+	add("artifacts/linux-amd64/trivy.json", "artifact", "trivy", `{"Results": [{"Vulnerabilities": [
+		{"VulnerabilityID": "CVE-2023-44487", "PkgName": "golang.org/x/net", "InstalledVersion": "v0.16.0", "FixedVersion": "0.17.0", "Severity": "CRITICAL", "Title": "HTTP/2 rapid reset"}
+	]}]}`)
+	return b
+}
+
+func getVulns(t *testing.T, api *API, query string) (*httptest.ResponseRecorder, VulnerabilitiesResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/provenance/vulnerabilities"+query, http.NoBody)
+	api.HandleVulnerabilities(rec, req)
+	var resp VulnerabilitiesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("parse JSON: %v\nbody: %s", err, rec.Body.String())
+	}
+	return rec, resp
+}
+
+func vulnAPI() *API {
+	store := evidence.NewStore()
+	store.Set(vulnBundle())
+	return NewAPI(noContentProvider(), store, log.Nop())
+}
+
+func TestHandleVulnerabilities_NoEvidence(t *testing.T) {
+	rec, resp := getVulns(t, NewAPI(noContentProvider(), nil, log.Nop()), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if resp.HasEvidence || resp.Error == "" {
+		t.Fatalf("resp = %+v, want has_evidence false with an error", resp)
+	}
+
+	_, resp = getVulns(t, NewAPI(noContentProvider(), emptyEvidenceStore(), log.Nop()), "")
+	if resp.HasEvidence || resp.Error != "no evidence loaded" {
+		t.Fatalf("empty store resp = %+v", resp)
+	}
+}
+
+func TestHandleVulnerabilities_All(t *testing.T) {
+	rec, resp := getVulns(t, vulnAPI(), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if !resp.HasEvidence || resp.ReleaseID != "rel-20250115-abc123" {
+		t.Fatalf("resp = %+v", resp)
+	}
+	// the shared CVE stays separate per scope: source x/net, source lib, artifact x/net
+	if resp.Total != 3 || len(resp.Findings) != 3 {
+		t.Fatalf("total = %d, findings = %d, want 3", resp.Total, len(resp.Findings))
+	}
+	if resp.Counts.Critical != 1 || resp.Counts.High != 1 || resp.Counts.Low != 1 {
+		t.Fatalf("counts = %+v", resp.Counts)
+	}
+	first := resp.Findings[0]
+	if first.ID != "CVE-2023-44487" || first.Severity != "critical" || first.Scope != "artifact" {
+		t.Fatalf("first finding = %+v", first)
+	}
+	if len(resp.Reports) != 2 {
+		t.Fatalf("reports = %+v", resp.Reports)
+	}
+	if resp.Filters != nil {
+		t.Fatalf("filters = %+v, want omitted without a query", resp.Filters)
+	}
+	if rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("Cache-Control = %q", rec.Header().Get("Cache-Control"))
+	}
+}
+
+func TestHandleVulnerabilities_Filters(t *testing.T) {
+	api := vulnAPI()
+	tests := []struct {
+		query string
+		want  []string // ID@scope
+	}{
+		{"?severity=critical,HIGH", []string{"CVE-2023-44487@artifact", "CVE-2023-44487@source"}},
+		{"?severity=low", []string{"CVE-2024-0001@source"}},
+		{"?scope=source", []string{"CVE-2023-44487@source", "CVE-2024-0001@source"}},
+		{"?scope=artifact", []string{"CVE-2023-44487@artifact"}},
+		{"?package=example.com/lib", []string{"CVE-2024-0001@source"}},
+		{"?fixed=true", []string{"CVE-2023-44487@artifact", "CVE-2023-44487@source"}},
+		{"?fixed=false", []string{"CVE-2024-0001@source"}},
+		{"?scope=source&package=golang.org/x/net&fixed=true&severity=high", []string{"CVE-2023-44487@source"}},
+		{"?package=nope", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec, resp := getVulns(t, api, tt.query)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d", rec.Code)
+			}
+			var got []string
+			for _, f := range resp.Findings {
+				got = append(got, f.ID+"@"+f.Scope)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("findings = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("findings = %v, want %v", got, tt.want)
+				}
+			}
+			if resp.Total != len(tt.want) {
+				t.Fatalf("total = %d, want %d", resp.Total, len(tt.want))
+			}
+			if resp.Filters == nil {
+				t.Fatal("filters should be echoed")
+			}
+			// reports describe everything parsed, not just the matches
+			if len(resp.Reports) != 2 {
+				t.Fatalf("reports = %d, want 2", len(resp.Reports))
+			}
+		})
+	}
+}
+
+func TestHandleVulnerabilities_InvalidFilters(t *testing.T) {
+	for _, query := range []string{"?severity=severe", "?scope=binary", "?fixed=maybe"} {
+		rec, resp := getVulns(t, vulnAPI(), query)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rec.Code)
+		}
+		if resp.Error == "" {
+			t.Errorf("%s: error should be set", query)
+		}
+	}
+}
+
+func TestHandleVulnerabilities_CachedPerBundle(t *testing.T) {
+	store := evidence.NewStore()
+	b := vulnBundle()
+	store.Set(b)
+	api := NewAPI(noContentProvider(), store, log.Nop())
+
+	getVulns(t, api, "")
+	first := api.vulns.Load()
+	getVulns(t, api, "?scope=source")
+	if api.vulns.Load() != first {
+		t.Fatal("findings re-parsed for the same bundle")
+	}
+
+	// a refreshed bundle is parsed again
+	next := vulnBundle()
+	delete(next.FileIndex, "artifacts/linux-amd64/trivy.json")
+	store.Set(next)
+	_, resp := getVulns(t, api, "")
+	if api.vulns.Load() == first || resp.Total != 2 {
+		t.Fatalf("refreshed bundle not re-parsed: total = %d", resp.Total)
+	}
+}
+
+func TestHandleVulnerabilities_Route(t *testing.T) {
+	r := chi.NewRouter()
+	vulnAPI().RegisterRoutes(r)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/provenance/vulnerabilities?severity=critical", http.NoBody))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var resp VulnerabilitiesResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 {
+		t.Fatalf("total = %d, want 1", resp.Total)
+	}
+}
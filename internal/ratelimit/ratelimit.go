@@ -191,6 +191,27 @@ func (l *IPLimiter) cleanup(ctx context.Context) {
 	}
 }
 
+// Clear drops the tracked state for ip so its next request starts with a full
+// bucket. Reports whether the ip was tracked. Used by the ops admin API to
+// lift a ban without waiting for TTL eviction.
+func (l *IPLimiter) Clear(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.visitors[ip]
+	delete(l.visitors, ip)
+	return ok
+}
+
+// ClearAll drops every tracked visitor and returns how many were removed.
+func (l *IPLimiter) ClearAll() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := len(l.visitors)
+	clear(l.visitors)
+	l.atCapacity = false
+	return n
+}
+
 // Middleware returns middleware that rejects requests over the per-ip rate limit with 429
 func (l *IPLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
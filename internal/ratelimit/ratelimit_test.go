@@ -713,3 +713,48 @@ func TestMaxVisitors_ConcurrentAccess(t *testing.T) {
 		t.Fatalf("map size = %d, want 50", mapSize)
 	}
 }
+
+func TestClear_ResetsBucket(t *testing.T) {
+	l, cancel := newTestLimiter(WithRate(0.001, 2), WithTTL(time.Hour))
+	defer cancel()
+
+	ip := "10.0.0.1"
+	l.allow(ip)
+	l.allow(ip)
+	if l.allow(ip) {
+		t.Fatal("third request should be denied")
+	}
+
+	if !l.Clear(ip) {
+		t.Fatal("Clear should report the ip was tracked")
+	}
+	if !l.allow(ip) {
+		t.Fatal("request after Clear should be allowed")
+	}
+	if l.Clear("10.9.9.9") {
+		t.Fatal("Clear of unknown ip should report false")
+	}
+}
+
+func TestClearAll_ResetsCapacity(t *testing.T) {
+	var capCalls int
+	l, cancel := newTestLimiter(WithMaxVisitors(1), WithTTL(time.Hour), WithOnCapacity(func() { capCalls++ }))
+	defer cancel()
+
+	l.allow("10.0.0.1")
+	if l.allow("10.0.0.2") {
+		t.Fatal("second ip should be rejected at capacity")
+	}
+
+	if n := l.ClearAll(); n != 1 {
+		t.Fatalf("ClearAll = %d, want 1", n)
+	}
+	if !l.allow("10.0.0.2") {
+		t.Fatal("new ip should be allowed after ClearAll")
+	}
+	// capacity notification re-arms after a clear
+	l.allow("10.0.0.3")
+	if capCalls != 2 {
+		t.Fatalf("OnCapacity calls = %d, want 2", capCalls)
+	}
+}
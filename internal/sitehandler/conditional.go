@@ -0,0 +1,44 @@
+package sitehandler
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+)
+
+// etagFor returns the strong ETag for a representation of a file: the hash of
+// its served bytes, suffixed with the content coding for encoded variants so
+// each representation gets its own validator. Empty when no digest is known.
+func etagFor(d content.FileDigest, encoding string) string {
+	if d.SHA256 == "" {
+		return ""
+	}
+	if encoding == "" {
+		return `"` + d.SHA256 + `"`
+	}
+	return `"` + d.SHA256 + "-" + encoding + `"`
+}
+
+// serveIdentity serves file unencoded with validators from its digest.
+// http.ServeContent answers If-None-Match and If-Modified-Since against the
+// ETag header and modtime, and omits Last-Modified when modtime is zero.
+func serveIdentity(w http.ResponseWriter, r *http.Request, fsys fs.FS, file string, d content.FileDigest) {
+	if etag := etagFor(d, ""); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	f, err := fsys.Open(file)
+	if err == nil {
+		defer f.Close()
+		if rs, ok := f.(io.ReadSeeker); ok {
+			http.ServeContent(w, r, file, d.Modified, rs)
+			return
+		}
+	}
+
+	// not seekable (or vanished): ServeFileFS still honors the ETag set above
+	//nolint:gosec // G703: FS, path cannot escape root
+	http.ServeFileFS(w, r, fsys, file)
+}
@@ -0,0 +1,165 @@
+package sitehandler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+)
+
+var testModified = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// digestHandler serves a snapshot with digests built as the Manager would:
+// index.html is listed in the manifest, rewritten.html was island-injected.
+func digestHandler(t *testing.T) (*Handler, *content.Snapshot) {
+	t.Helper()
+	siteFS := fstest.MapFS{
+		"index.html":     &fstest.MapFile{Data: []byte(strings.Repeat("<p>etag</p>\n", 200))},
+		"rewritten.html": &fstest.MapFile{Data: []byte("<p>islands</p>")},
+	}
+	prov := &content.Provenance{Files: []content.ProvenanceFile{
+		{Path: "index.html", Modified: testModified},
+		{Path: "rewritten.html", Modified: testModified},
+	}}
+	augmented := map[string]content.AugmentedFile{"rewritten.html": {}}
+	snap := &content.Snapshot{
+		FS:         siteFS,
+		Provenance: prov,
+		Augmented:  augmented,
+		Variants:   content.Precompress(siteFS, nil),
+		Digests:    content.DigestFiles(siteFS, prov, augmented),
+	}
+	return newTestHandler(&stubProvider{snap: snap, ok: true}, testFallbackFS()), snap
+}
+
+func TestEtagFor(t *testing.T) {
+	d := content.FileDigest{SHA256: "abc"}
+	if got := etagFor(d, ""); got != `"abc"` {
+		t.Fatalf("identity = %s", got)
+	}
+	if got := etagFor(d, "gzip"); got != `"abc-gzip"` {
+		t.Fatalf("gzip = %s", got)
+	}
+	if got := etagFor(content.FileDigest{}, ""); got != "" {
+		t.Fatalf("no digest = %s", got)
+	}
+}
+
+func TestServeHTTP_ETag_Identity(t *testing.T) {
+	h, snap := digestHandler(t)
+	d, _ := snap.Digests.Get("index.html")
+
+	rec := getWithHeaders(h, http.MethodGet, "/", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got := rec.Header().Get("ETag"); got != `"`+d.SHA256+`"` {
+		t.Fatalf("ETag = %q", got)
+	}
+	if got := rec.Header().Get("Last-Modified"); got != testModified.Format(http.TimeFormat) {
+		t.Fatalf("Last-Modified = %q", got)
+	}
+}
+
+func TestServeHTTP_IfNoneMatch_NotModified(t *testing.T) {
+	h, _ := digestHandler(t)
+
+	first := getWithHeaders(h, http.MethodGet, "/", nil)
+	etag := first.Header().Get("ETag")
+
+	rec := getWithHeaders(h, http.MethodGet, "/", map[string]string{"If-None-Match": etag})
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", rec.Code)
+	}
+	if rec.Body.Len() != 0 {
+		t.Fatalf("304 carried a %d byte body", rec.Body.Len())
+	}
+	if rec.Header().Get("ETag") != etag {
+		t.Fatal("304 should repeat the ETag")
+	}
+	if rec.Header().Get("Cache-Control") == "" {
+		t.Fatal("304 should keep Cache-Control")
+	}
+
+	rec = getWithHeaders(h, http.MethodGet, "/", map[string]string{"If-None-Match": `"stale"`})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("stale validator status = %d, want 200", rec.Code)
+	}
+}
+
+func TestServeHTTP_IfModifiedSince(t *testing.T) {
+	h, _ := digestHandler(t)
+
+	rec := getWithHeaders(h, http.MethodGet, "/", map[string]string{
+		"If-Modified-Since": testModified.Format(http.TimeFormat),
+	})
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", rec.Code)
+	}
+
+	rec = getWithHeaders(h, http.MethodGet, "/", map[string]string{
+		"If-Modified-Since": testModified.Add(-time.Hour).Format(http.TimeFormat),
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("older If-Modified-Since status = %d, want 200", rec.Code)
+	}
+}
+
+func TestServeHTTP_ETag_PerEncoding(t *testing.T) {
+	h, snap := digestHandler(t)
+	d, _ := snap.Digests.Get("index.html")
+
+	rec := getWithHeaders(h, http.MethodGet, "/", map[string]string{"Accept-Encoding": "gzip"})
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q", rec.Header().Get("Content-Encoding"))
+	}
+	etag := rec.Header().Get("ETag")
+	if etag != `"`+d.SHA256+`-gzip"` {
+		t.Fatalf("ETag = %q", etag)
+	}
+
+	rec = getWithHeaders(h, http.MethodGet, "/", map[string]string{
+		"Accept-Encoding": "gzip",
+		"If-None-Match":   etag,
+	})
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", rec.Code)
+	}
+	if rec.Header().Get("Content-Length") != "" {
+		t.Fatal("304 should not carry the variant Content-Length")
+	}
+
+	// the gzip validator does not match the identity representation
+	rec = getWithHeaders(h, http.MethodGet, "/", map[string]string{"If-None-Match": etag})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("identity with gzip ETag status = %d, want 200", rec.Code)
+	}
+}
+
+func TestServeHTTP_RewrittenFile_NoLastModified(t *testing.T) {
+	h, snap := digestHandler(t)
+	d, _ := snap.Digests.Get("rewritten.html")
+
+	rec := getWithHeaders(h, http.MethodGet, "/rewritten.html", nil)
+	if rec.Header().Get("ETag") != `"`+d.SHA256+`"` {
+		t.Fatalf("ETag = %q", rec.Header().Get("ETag"))
+	}
+	if got := rec.Header().Get("Last-Modified"); got != "" {
+		t.Fatalf("Last-Modified = %q, want none for rewritten file", got)
+	}
+}
+
+func TestServeHTTP_NoDigests_NoValidators(t *testing.T) {
+	h := newTestHandler(activeProvider(testSiteFS()), testFallbackFS())
+
+	rec := getWithHeaders(h, http.MethodGet, "/", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if rec.Header().Get("ETag") != "" || rec.Header().Get("Last-Modified") != "" {
+		t.Fatalf("unexpected validators: %v", rec.Header())
+	}
+}
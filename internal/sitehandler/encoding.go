@@ -0,0 +1,111 @@
+package sitehandler
+
+import (
+	"bytes"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+)
+
+// servePrecompressed serves a precompressed variant of file when the snapshot
+// has one the client accepts. Returns false to fall back to the identity body.
+func servePrecompressed(w http.ResponseWriter, r *http.Request, snap *content.Snapshot, file string, digest content.FileDigest) bool {
+	if !snap.Variants.Has(file) {
+		return false
+	}
+	// the representation depends on Accept-Encoding whichever body we send
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	// byte ranges are served from the identity body; ranges over an encoded
+	// representation are legal but nothing we serve benefits from them
+	if r.Header.Get("Range") != "" {
+		return false
+	}
+
+	enc := negotiateEncoding(r.Header.Get("Accept-Encoding"), snap.Variants.Encodings(), func(e string) bool {
+		_, ok := snap.Variants.Get(file, e)
+		return ok
+	})
+	if enc == "" {
+		return false
+	}
+	data, _ := snap.Variants.Get(file, enc)
+
+	ctype := mime.TypeByExtension(path.Ext(file))
+	if ctype == "" {
+		ctype = sniffContentType(snap.FS, file)
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Content-Encoding", enc)
+	// ServeContent leaves Content-Length unset for encoded bodies; the length
+	// is known here, so clients and the CDN don't fall back to chunking
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if etag := etagFor(digest, enc); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	// ServeContent handles HEAD and conditional headers
+	http.ServeContent(w, r, file, digest.Modified, bytes.NewReader(data))
+	return true
+}
+
+// negotiateEncoding picks the first encoding in server preference order that
+// the Accept-Encoding header allows (q > 0) and available reports present.
+// An explicit entry wins over "*"; an absent header accepts nothing.
+func negotiateEncoding(header string, preference []string, available func(string) bool) string {
+	if header == "" {
+		return ""
+	}
+	q := make(map[string]float64)
+	wildcard := -1.0
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		weight := 1.0
+		for p := range strings.SplitSeq(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					weight = f
+				}
+			}
+		}
+		if name == "*" {
+			wildcard = weight
+			continue
+		}
+		q[name] = weight
+	}
+
+	for _, enc := range preference {
+		weight, ok := q[enc]
+		if !ok {
+			weight = wildcard
+		}
+		if weight > 0 && available(enc) {
+			return enc
+		}
+	}
+	return ""
+}
+
+// sniffContentType detects the type of file from its identity bytes, as
+// http.ServeContent would, since the encoded body can't be sniffed.
+func sniffContentType(fsys fs.FS, file string) string {
+	f, err := fsys.Open(file)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	return http.DetectContentType(buf[:n])
+}
@@ -0,0 +1,180 @@
+package sitehandler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	pref := []string{"zstd", "gzip"}
+	all := func(string) bool { return true }
+	onlyGzip := func(e string) bool { return e == "gzip" }
+
+	cases := []struct {
+		header string
+		avail  func(string) bool
+		want   string
+	}{
+		{"", all, ""},
+		{"gzip", all, "gzip"},
+		{"gzip, zstd", all, "zstd"},
+		{"gzip, deflate, br, zstd", onlyGzip, "gzip"},
+		{"zstd;q=0, gzip", all, "gzip"},
+		{"ZSTD;Q=0.5", all, "zstd"},
+		{"*", all, "zstd"},
+		{"*;q=0, gzip", all, "gzip"},
+		{"zstd;q=0, *", all, "gzip"},
+		{"identity", all, ""},
+		{"br", all, ""},
+		{"gzip;q=bogus", all, "gzip"},
+		{" , gzip ;q=1 ", all, "gzip"},
+	}
+	for _, tc := range cases {
+		if got := negotiateEncoding(tc.header, pref, tc.avail); got != tc.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+// precompressedHandler serves a snapshot whose index.html has variants built
+// by content.Precompress; style.css is below the size threshold.
+func precompressedHandler(t *testing.T) (*Handler, []byte) {
+	t.Helper()
+	html := []byte(strings.Repeat("<p>precompressed</p>\n", 200))
+	siteFS := fstest.MapFS{
+		"index.html": &fstest.MapFile{Data: html},
+		"style.css":  &fstest.MapFile{Data: []byte("body{}")},
+	}
+	snap := &content.Snapshot{
+		FS:       siteFS,
+		Variants: content.Precompress(siteFS, nil),
+	}
+	return newTestHandler(&stubProvider{snap: snap, ok: true}, testFallbackFS()), html
+}
+
+func getWithHeaders(h http.Handler, method, target string, hdr map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, http.NoBody)
+	for k, v := range hdr {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServeHTTP_Precompressed_Gzip(t *testing.T) {
+	h, html := precompressedHandler(t)
+
+	rec := getWithHeaders(h, http.MethodGet, "/", map[string]string{"Accept-Encoding": "gzip"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if ce := rec.Header().Get("Content-Encoding"); ce != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", ce)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if v := rec.Header().Get("Vary"); v != "Accept-Encoding" {
+		t.Fatalf("Vary = %q", v)
+	}
+	if cl := rec.Header().Get("Content-Length"); cl != strconv.Itoa(rec.Body.Len()) {
+		t.Fatalf("Content-Length = %q, body = %d", cl, rec.Body.Len())
+	}
+	if rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("Cache-Control = %q, policy must still apply", rec.Header().Get("Cache-Control"))
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	body, _ := io.ReadAll(zr)
+	if !bytes.Equal(body, html) {
+		t.Fatal("decoded body mismatch")
+	}
+}
+
+func TestServeHTTP_Precompressed_PrefersZstd(t *testing.T) {
+	h, _ := precompressedHandler(t)
+
+	rec := getWithHeaders(h, http.MethodGet, "/", map[string]string{"Accept-Encoding": "gzip, br, zstd"})
+	if ce := rec.Header().Get("Content-Encoding"); ce != "zstd" {
+		t.Fatalf("Content-Encoding = %q, want zstd", ce)
+	}
+}
+
+func TestServeHTTP_Precompressed_IdentityFallbacks(t *testing.T) {
+	h, html := precompressedHandler(t)
+
+	cases := map[string]map[string]string{
+		"no accept-encoding": nil,
+		"unsupported":        {"Accept-Encoding": "br"},
+		"range request":      {"Accept-Encoding": "gzip", "Range": "bytes=0-9"},
+	}
+	for name, hdr := range cases {
+		t.Run(name, func(t *testing.T) {
+			rec := getWithHeaders(h, http.MethodGet, "/", hdr)
+			if ce := rec.Header().Get("Content-Encoding"); ce != "" {
+				t.Fatalf("Content-Encoding = %q, want identity", ce)
+			}
+			if v := rec.Header().Get("Vary"); v != "Accept-Encoding" {
+				t.Fatalf("Vary = %q, identity body must still vary", v)
+			}
+			if hdr["Range"] != "" {
+				if rec.Code != http.StatusPartialContent || rec.Body.String() != string(html[:10]) {
+					t.Fatalf("range: status = %d body = %q", rec.Code, rec.Body.String())
+				}
+				return
+			}
+			if !bytes.Equal(rec.Body.Bytes(), html) {
+				t.Fatal("identity body mismatch")
+			}
+		})
+	}
+}
+
+func TestServeHTTP_Precompressed_Head(t *testing.T) {
+	h, _ := precompressedHandler(t)
+
+	get := getWithHeaders(h, http.MethodGet, "/", map[string]string{"Accept-Encoding": "gzip"})
+	head := getWithHeaders(h, http.MethodHead, "/", map[string]string{"Accept-Encoding": "gzip"})
+	if head.Body.Len() != 0 {
+		t.Fatal("HEAD returned a body")
+	}
+	if head.Header().Get("Content-Length") != get.Header().Get("Content-Length") {
+		t.Fatalf("HEAD Content-Length = %q, GET = %q", head.Header().Get("Content-Length"), get.Header().Get("Content-Length"))
+	}
+}
+
+func TestServeHTTP_Precompressed_NoVariantNoVary(t *testing.T) {
+	h, _ := precompressedHandler(t)
+
+	// style.css is below the size threshold
+	rec := getWithHeaders(h, http.MethodGet, "/style.css", map[string]string{"Accept-Encoding": "gzip"})
+	if rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("Vary") != "" {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+	if rec.Body.String() != "body{}" {
+		t.Fatalf("body = %q", rec.Body.String())
+	}
+}
+
+func TestSniffContentType(t *testing.T) {
+	fsys := fstest.MapFS{"noext": &fstest.MapFile{Data: []byte("<!DOCTYPE html><html></html>")}}
+	if ct := sniffContentType(fsys, "noext"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("sniff = %q", ct)
+	}
+	if ct := sniffContentType(fsys, "missing"); ct != "application/octet-stream" {
+		t.Fatalf("missing = %q", ct)
+	}
+}
@@ -28,9 +28,23 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// operator-forced maintenance takes precedence over loaded content
+	if h.opts.Maintenance != nil && h.opts.Maintenance.Enabled() {
+		h.serveMaintenance(w, r)
+		return
+	}
+
 	// get active content snapshot
 	snap, ok := h.opts.Content.Get()
 
+	if h.opts.Preview {
+		if !ok {
+			servePreviewEmpty(w)
+			return
+		}
+		setPreviewHeaders(w, snap)
+	}
+
 	// serve maintenance page if no active content snapshot
 	if !ok {
 		h.serveMaintenance(w, r)
@@ -53,13 +67,26 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// apply cache-control policy (basic version based on file extension for now, will expand to cache posts and not homepage/tags/categories/etc)
-	if cc := cacheControlForFile(file, &h.opts); cc != "" {
+	if cc := cacheControlForFile(file, &h.opts); cc != "" && !h.opts.Preview {
 		w.Header().Set("Cache-Control", cc)
 	}
 
+	// preview pages carry a banner, so they are rewritten per request
+	if h.opts.Preview && isHTMLPath(file) {
+		servePreviewHTML(w, r, snap, file)
+		return
+	}
+
+	// strong validators from the served bytes; the in-memory FS has no mtimes
+	digest, _ := snap.Digests.Get(file)
+
+	// prefer a variant precompressed at snapshot load over on-the-fly compression
+	if servePrecompressed(w, r, snap, file, digest) {
+		return
+	}
+
 	// serve the actual file from the active content FS
-	//nolint:gosec // G703: FS, path cannot escape root
-	http.ServeFileFS(w, r, snap.FS, file)
+	serveIdentity(w, r, snap.FS, file, digest)
 }
 
 func (h *Handler) serveMaintenance(w http.ResponseWriter, r *http.Request) {
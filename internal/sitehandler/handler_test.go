@@ -441,6 +441,65 @@ func TestServeHTTP_Maintenance_AnyPath(t *testing.T) {
 	}
 }
 
+func TestServeHTTP_MaintenanceToggle_OverridesContent(t *testing.T) {
+	maint := &Maintenance{}
+	h, err := New(&Options{
+		Logger:      log.Nop(),
+		Content:     activeProvider(testSiteFS()),
+		FallbackFS:  testFallbackFS(),
+		Maintenance: maint,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+		return rec
+	}
+
+	if rec := serve(); rec.Code != http.StatusOK {
+		t.Fatalf("disabled: status = %d, want 200", rec.Code)
+	}
+
+	maint.Set(true, "db migration")
+	rec := serve()
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "Maintenance") {
+		t.Fatalf("enabled: status = %d body = %q", rec.Code, rec.Body.String())
+	}
+
+	maint.Set(false, "")
+	if rec := serve(); rec.Code != http.StatusOK {
+		t.Fatalf("re-disabled: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestMaintenance_State(t *testing.T) {
+	var m Maintenance
+	if m.Enabled() {
+		t.Fatal("zero value should be disabled")
+	}
+
+	m.Set(true, "first")
+	since := m.State().Since
+	if since.IsZero() {
+		t.Fatal("Since not set when enabled")
+	}
+
+	// re-enabling updates the reason but keeps the original start time
+	m.Set(true, "second")
+	st := m.State()
+	if !st.Enabled || st.Reason != "second" || !st.Since.Equal(since) {
+		t.Fatalf("state = %+v", st)
+	}
+
+	m.Set(false, "ignored")
+	if st := m.State(); st.Enabled || st.Reason != "" || !st.Since.IsZero() {
+		t.Fatalf("disabled state = %+v", st)
+	}
+}
+
 // ServeHTTP - cache-control policy
 
 func TestServeHTTP_CacheControl_HTML(t *testing.T) {
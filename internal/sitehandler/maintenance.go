@@ -0,0 +1,50 @@
+package sitehandler
+
+import (
+	"sync"
+	"time"
+)
+
+// MaintenanceState is the operator-controlled maintenance mode.
+type MaintenanceState struct {
+	Enabled bool      `json:"enabled"`
+	Reason  string    `json:"reason,omitempty"`
+	Since   time.Time `json:"since,omitzero"`
+}
+
+// Maintenance is a runtime switch that makes the handler serve the
+// maintenance page even while content is loaded. Safe for concurrent use;
+// the zero value is disabled.
+type Maintenance struct {
+	mu    sync.RWMutex
+	state MaintenanceState
+}
+
+// Set enables or disables maintenance mode. Reason is kept only while enabled.
+func (m *Maintenance) Set(enabled bool, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !enabled {
+		m.state = MaintenanceState{}
+		return
+	}
+	if !m.state.Enabled {
+		m.state.Since = time.Now().UTC()
+	}
+	m.state.Enabled = true
+	m.state.Reason = reason
+}
+
+// State returns the current maintenance state.
+func (m *Maintenance) State() MaintenanceState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// Enabled reports whether maintenance mode is on.
+func (m *Maintenance) Enabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.Enabled
+}
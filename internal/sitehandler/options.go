@@ -18,6 +18,15 @@ type Options struct {
 	Content SnapshotProvider
 	// fallback FS (maintenance page, maybe fallback 404)
 	FallbackFS fs.FS
+	// Maintenance, when set and enabled, serves the maintenance page for every
+	// request regardless of content (toggled from the ops admin API)
+	Maintenance *Maintenance
+
+	// Preview serves Content as an unreleased candidate: noindex and no-store
+	// on every response, a visible banner on HTML pages, and a plain 404
+	// instead of the maintenance page while no candidate is loaded. Mount a
+	// preview handler only where the public can't reach it.
+	Preview bool
 
 	// file names inside the FS roots (relative path)
 	// - MaintenanceFile and Fallback404File are read from FallbackFS
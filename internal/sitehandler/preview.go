@@ -0,0 +1,82 @@
+package sitehandler
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+)
+
+// previewRobots keeps crawlers that reach a preview host from indexing
+// unreleased content.
+const previewRobots = "noindex, nofollow, noarchive"
+
+// setPreviewHeaders marks every preview response: not indexable, not
+// cacheable (the candidate can be replaced at any time) and labelled with the
+// bundle it came from.
+func setPreviewHeaders(w http.ResponseWriter, snap *content.Snapshot) {
+	h := w.Header()
+	h.Set("X-Robots-Tag", previewRobots)
+	h.Set("Cache-Control", "no-store")
+	if snap != nil {
+		h.Set("X-Content-Preview", snap.Meta.Hash)
+	}
+}
+
+// servePreviewEmpty answers when no candidate is loaded.
+func servePreviewEmpty(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write([]byte("no preview bundle loaded"))
+}
+
+// isHTMLPath reports whether file is served as HTML; extensionless files are
+// treated as HTML, matching cacheControlForFile.
+func isHTMLPath(file string) bool {
+	ext := strings.ToLower(path.Ext(file))
+	return ext == ".html" || ext == ""
+}
+
+// servePreviewHTML serves an HTML page with a fixed banner injected before
+// </body>, so nobody mistakes the preview for the live site. The rewritten
+// page has no stored digest and is always sent uncompressed.
+func servePreviewHTML(w http.ResponseWriter, r *http.Request, snap *content.Snapshot, file string) {
+	data, err := fs.ReadFile(snap.FS, file)
+	if err != nil {
+		//nolint:gosec // G703: FS, path cannot escape root
+		http.ServeFileFS(w, r, snap.FS, file)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.ServeContent(w, r, file, time.Time{}, bytes.NewReader(injectPreviewBanner(data, snap.Meta)))
+}
+
+// injectPreviewBanner inserts the banner before the last </body>, or appends
+// it when the page has none.
+func injectPreviewBanner(page []byte, meta content.Meta) []byte {
+	label := meta.Version
+	if label == "" {
+		label = "unversioned"
+	}
+	short := meta.Hash
+	if len(short) > 12 {
+		short = short[:12]
+	}
+	banner := fmt.Sprintf(`<div id="content-preview-banner" style="position:fixed;bottom:0;left:0;right:0;z-index:2147483647;padding:6px 12px;background:#b00020;color:#fff;font:14px/1.4 sans-serif;text-align:center">PREVIEW %s (%s) &mdash; not live</div>`,
+		html.EscapeString(label), html.EscapeString(short))
+
+	i := bytes.LastIndex(bytes.ToLower(page), []byte("</body>"))
+	if i < 0 {
+		return append(append([]byte{}, page...), banner...)
+	}
+	out := make([]byte, 0, len(page)+len(banner))
+	out = append(out, page[:i]...)
+	out = append(out, banner...)
+	return append(out, page[i:]...)
+}
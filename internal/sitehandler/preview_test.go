@@ -0,0 +1,121 @@
+package sitehandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/keithlinneman/linnemanlabs-web/internal/content"
+	"github.com/keithlinneman/linnemanlabs-web/internal/log"
+)
+
+func newPreviewHandler(t *testing.T, cp SnapshotProvider) *Handler {
+	t.Helper()
+	h, err := New(&Options{
+		Logger:     log.Nop(),
+		Content:    cp,
+		FallbackFS: testFallbackFS(),
+		Preview:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func previewProvider() *stubProvider {
+	return &stubProvider{ok: true, snap: &content.Snapshot{
+		FS: fstest.MapFS{
+			"index.html": &fstest.MapFile{Data: []byte("<html><body><h1>Next</h1></BODY></html>")},
+			"style.css":  &fstest.MapFile{Data: []byte("body{}")},
+		},
+		Meta: content.Meta{Hash: "abcdef0123456789", Version: "2.0.0<x>"},
+	}}
+}
+
+func TestPreview_HTMLBannerAndHeaders(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newPreviewHandler(t, previewProvider()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-Robots-Tag"); got != previewRobots {
+		t.Fatalf("X-Robots-Tag = %q", got)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Fatalf("Cache-Control = %q, want no-store", got)
+	}
+	if got := rec.Header().Get("X-Content-Preview"); got != "abcdef0123456789" {
+		t.Fatalf("X-Content-Preview = %q", got)
+	}
+	if rec.Header().Get("ETag") != "" {
+		t.Fatal("rewritten preview page must not carry the bundle ETag")
+	}
+	body := rec.Body.String()
+	i := strings.Index(body, `id="content-preview-banner"`)
+	if i < 0 || i > strings.Index(body, "</BODY>") {
+		t.Fatalf("banner not injected before </body>: %s", body)
+	}
+	if !strings.Contains(body, "PREVIEW 2.0.0&lt;x&gt; (abcdef012345)") {
+		t.Fatalf("banner label not escaped/truncated: %s", body)
+	}
+}
+
+func TestPreview_AssetsUnmodified(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newPreviewHandler(t, previewProvider()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/style.css", http.NoBody))
+
+	if rec.Body.String() != "body{}" {
+		t.Fatalf("body = %q", rec.Body.String())
+	}
+	if rec.Header().Get("X-Robots-Tag") != previewRobots || rec.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+}
+
+func TestPreview_NotFoundStillMarked(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newPreviewHandler(t, previewProvider()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", http.NoBody))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if rec.Header().Get("X-Robots-Tag") != previewRobots {
+		t.Fatal("404 from preview must be noindex")
+	}
+}
+
+func TestPreview_NothingLoaded(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newPreviewHandler(t, noProvider()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 rather than maintenance", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "no preview") {
+		t.Fatalf("body = %q", rec.Body.String())
+	}
+}
+
+func TestInjectPreviewBanner_NoBody(t *testing.T) {
+	page := []byte("<p>fragment</p>")
+	out := injectPreviewBanner(page, content.Meta{})
+	if !strings.HasPrefix(string(out), "<p>fragment</p><div") || !strings.Contains(string(out), "unversioned") {
+		t.Fatalf("out = %s", out)
+	}
+	if string(page) != "<p>fragment</p>" {
+		t.Fatal("input page modified")
+	}
+}
+
+func TestHandler_NonPreviewHasNoMarker(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHandler(previewProvider(), testFallbackFS()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	if rec.Header().Get("X-Robots-Tag") != "" || strings.Contains(rec.Body.String(), "content-preview-banner") {
+		t.Fatal("live handler must not mark pages as preview")
+	}
+}